@@ -0,0 +1,160 @@
+// Package headeraccess trusts an identity header set by an authenticating
+// reverse proxy such as oauth2-proxy or an SSO gateway.
+package headeraccess
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	internalaccess "github.com/nghyane/llm-mux/internal/access"
+	"github.com/nghyane/llm-mux/internal/config"
+)
+
+var doRegister = sync.OnceFunc(func() {
+	internalaccess.RegisterProvider(config.AccessProviderTypeTrustedHeader, newProvider)
+})
+
+// Register ensures the trusted-header provider is available to the access manager.
+func Register() {
+	doRegister()
+}
+
+var (
+	defaultHeaders        = []string{"X-Forwarded-Email", "X-Forwarded-User", "X-Auth-Request-Email", "X-Auth-Request-User", "Remote-User"}
+	defaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+)
+
+type provider struct {
+	name           string
+	headers        []string
+	trusted        []netip.Prefix
+	secretHeader   string
+	secret         string
+	groupsHeader   string
+	allowedGroups  []string
+	identityPrefix string
+}
+
+func newProvider(cfg *config.AccessProvider, _ *config.SDKConfig) (internalaccess.Provider, error) {
+	opts := cfg.Config
+	headers := internalaccess.OptionStrings(opts, "headers")
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+	cidrs := internalaccess.OptionStrings(opts, "trusted-proxies")
+	if len(cidrs) == 0 {
+		cidrs = defaultTrustedProxies
+	}
+	trusted := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("trusted-header provider %q: invalid trusted proxy %q: %w", cfg.Name, cidr, err)
+		}
+		trusted = append(trusted, prefix)
+	}
+	p := &provider{
+		name:           cfg.Name,
+		headers:        headers,
+		trusted:        trusted,
+		secretHeader:   internalaccess.OptionString(opts, "shared-secret-header"),
+		secret:         internalaccess.OptionString(opts, "shared-secret"),
+		groupsHeader:   internalaccess.OptionString(opts, "groups-header"),
+		allowedGroups:  internalaccess.OptionStrings(opts, "allowed-groups"),
+		identityPrefix: internalaccess.OptionString(opts, "identity-prefix"),
+	}
+	if p.name == "" {
+		p.name = config.AccessProviderTypeTrustedHeader
+	}
+	if (p.secretHeader == "") != (p.secret == "") {
+		return nil, fmt.Errorf("trusted-header provider %q: shared-secret-header and shared-secret must be set together", cfg.Name)
+	}
+	if len(p.allowedGroups) > 0 && p.groupsHeader == "" {
+		return nil, fmt.Errorf("trusted-header provider %q: allowed-groups requires groups-header", cfg.Name)
+	}
+	return p, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (p *provider) Identifier() string {
+	return p.name
+}
+
+// Authenticate accepts the identity header only on connections from a trusted
+// proxy; requests from anywhere else are left to the other providers.
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*internalaccess.Result, error) {
+	if !p.fromTrustedProxy(r.RemoteAddr) {
+		return nil, internalaccess.ErrNotHandled
+	}
+	identity, source := "", ""
+	for _, h := range p.headers {
+		if v := strings.TrimSpace(r.Header.Get(h)); v != "" {
+			identity, source = v, h
+			break
+		}
+	}
+	if identity == "" {
+		return nil, internalaccess.ErrNoCredentials
+	}
+	if p.secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(p.secretHeader)), []byte(p.secret)) != 1 {
+		return nil, internalaccess.ErrInvalidCredential
+	}
+	meta := map[string]string{"source": strings.ToLower(source)}
+	if p.groupsHeader != "" {
+		groups := splitGroups(r.Header.Get(p.groupsHeader))
+		if len(p.allowedGroups) > 0 && !slices.ContainsFunc(groups, func(g string) bool { return slices.Contains(p.allowedGroups, g) }) {
+			return nil, internalaccess.ErrInvalidCredential
+		}
+		if len(groups) > 0 {
+			meta["groups"] = strings.Join(groups, ",")
+		}
+	}
+	return &internalaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: p.identityPrefix + identity,
+		Metadata:  meta,
+	}, nil
+}
+
+func (p *provider) fromTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitGroups(v string) []string {
+	var out []string
+	for _, g := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		out = append(out, g)
+	}
+	return out
+}
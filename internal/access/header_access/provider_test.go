@@ -0,0 +1,65 @@
+package headeraccess
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	internalaccess "github.com/nghyane/llm-mux/internal/access"
+	"github.com/nghyane/llm-mux/internal/config"
+)
+
+func TestAuthenticate(t *testing.T) {
+	p, err := newProvider(&config.AccessProvider{Name: "proxy", Config: map[string]any{
+		"trusted-proxies":      []any{"10.0.0.0/8", "127.0.0.1"},
+		"shared-secret-header": "X-Proxy-Secret",
+		"shared-secret":        "s3cret",
+		"groups-header":        "X-Forwarded-Groups",
+		"allowed-groups":       "llm-users",
+		"identity-prefix":      "sso:",
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := func(remote string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		r.RemoteAddr = remote
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+	good := map[string]string{
+		"X-Forwarded-Email":  "dev@example.com",
+		"X-Proxy-Secret":     "s3cret",
+		"X-Forwarded-Groups": "staff,llm-users",
+	}
+
+	res, err := p.Authenticate(context.Background(), req("10.1.2.3:5555", good))
+	if err != nil {
+		t.Fatalf("trusted request rejected: %v", err)
+	}
+	if res.Principal != "sso:dev@example.com" || res.Metadata["groups"] != "staff,llm-users" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    error
+	}{
+		{"untrusted peer", "203.0.113.9:443", good, internalaccess.ErrNotHandled},
+		{"no identity", "127.0.0.1:1", map[string]string{"X-Proxy-Secret": "s3cret"}, internalaccess.ErrNoCredentials},
+		{"bad secret", "127.0.0.1:1", map[string]string{"X-Forwarded-Email": "a@b", "X-Proxy-Secret": "nope", "X-Forwarded-Groups": "llm-users"}, internalaccess.ErrInvalidCredential},
+		{"group denied", "127.0.0.1:1", map[string]string{"X-Forwarded-Email": "a@b", "X-Proxy-Secret": "s3cret", "X-Forwarded-Groups": "staff"}, internalaccess.ErrInvalidCredential},
+	}
+	for _, tc := range cases {
+		if _, err := p.Authenticate(context.Background(), req(tc.remote, tc.headers)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
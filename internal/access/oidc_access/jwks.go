@@ -0,0 +1,147 @@
+package oidcaccess
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+const (
+	// jwksMinRefetch limits how often an unknown kid can trigger a fetch.
+	jwksMinRefetch  = 30 * time.Second
+	jwksMaxBodySize = 1 << 20
+)
+
+type cachedKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet caches the issuer's signing keys and refreshes them on expiry or when
+// a token names an unknown kid (key rotation).
+type keySet struct {
+	issuer  string
+	url     string
+	ttl     time.Duration
+	client  *http.Client
+	mu      sync.Mutex
+	keys    []cachedKey
+	fetched time.Time
+	tried   time.Time
+}
+
+// candidates returns the keys that may have signed a token with kid.
+func (s *keySet) candidates(ctx context.Context, kid string) ([]cachedKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stale := s.fetched.IsZero() || now.Sub(s.fetched) > s.ttl
+	if !stale && kid != "" && !s.hasKidLocked(kid) && now.Sub(s.tried) > jwksMinRefetch {
+		stale = true
+	}
+	if stale && now.Sub(s.tried) > time.Second {
+		s.tried = now
+		if err := s.refreshLocked(ctx); err != nil {
+			if len(s.keys) == 0 {
+				return nil, err
+			}
+			log.Warnf("oidc-jwt: JWKS refresh failed, using cached keys: %v", err)
+		}
+	}
+
+	var out []cachedKey
+	for _, k := range s.keys {
+		if kid == "" || k.kid == kid {
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no signing key for kid %q", kid)
+	}
+	return out, nil
+}
+
+func (s *keySet) hasKidLocked(kid string) bool {
+	for _, k := range s.keys {
+		if k.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *keySet) refreshLocked(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if s.url == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		url := strings.TrimSuffix(s.issuer, "/") + "/.well-known/openid-configuration"
+		if err := s.getJSON(ctx, url, &discovery); err != nil {
+			return fmt.Errorf("OIDC discovery: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC discovery at %s has no jwks_uri", url)
+		}
+		s.url = discovery.JWKSURI
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := s.getJSON(ctx, s.url, &doc); err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys := make([]cachedKey, 0, len(doc.Keys))
+	for i := range doc.Keys {
+		k := &doc.Keys[i]
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			log.Debugf("oidc-jwt: skipping JWKS key %q: %v", k.Kid, err)
+			continue
+		}
+		keys = append(keys, cachedKey{kid: k.Kid, alg: k.Alg, key: pub})
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s contains no usable signing keys", s.url)
+	}
+	s.keys = keys
+	s.fetched = time.Now()
+	return nil
+}
+
+func (s *keySet) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxBodySize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
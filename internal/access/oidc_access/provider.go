@@ -0,0 +1,253 @@
+// Package oidcaccess authenticates clients with JWTs issued by an OIDC provider.
+package oidcaccess
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	internalaccess "github.com/nghyane/llm-mux/internal/access"
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+var doRegister = sync.OnceFunc(func() {
+	internalaccess.RegisterProvider(config.AccessProviderTypeOIDCJWT, newProvider)
+})
+
+// Register ensures the oidc-jwt provider is available to the access manager.
+func Register() {
+	doRegister()
+}
+
+const (
+	defaultLeeway   = 60 * time.Second
+	defaultCacheTTL = time.Hour
+)
+
+var defaultIdentityClaims = []string{"email", "sub"}
+
+type provider struct {
+	name           string
+	issuer         string
+	audiences      []string
+	algorithms     []string
+	identityClaims []string
+	requiredClaims map[string]string
+	identityPrefix string
+	leeway         time.Duration
+	keys           *keySet
+	now            func() time.Time
+}
+
+func newProvider(cfg *config.AccessProvider, _ *config.SDKConfig) (internalaccess.Provider, error) {
+	opts := cfg.Config
+	issuer := internalaccess.OptionString(opts, "issuer")
+	if issuer == "" {
+		return nil, fmt.Errorf("oidc-jwt provider %q: issuer is required", cfg.Name)
+	}
+	// Without an audience check any token the issuer mints for another
+	// application would be accepted.
+	audiences := internalaccess.OptionStrings(opts, "audience")
+	if len(audiences) == 0 {
+		return nil, fmt.Errorf("oidc-jwt provider %q: audience is required", cfg.Name)
+	}
+	leeway, err := internalaccess.OptionDuration(opts, "leeway", defaultLeeway)
+	if err != nil {
+		return nil, fmt.Errorf("oidc-jwt provider %q: %w", cfg.Name, err)
+	}
+	ttl, err := internalaccess.OptionDuration(opts, "jwks-cache-ttl", defaultCacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("oidc-jwt provider %q: %w", cfg.Name, err)
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	algs := internalaccess.OptionStrings(opts, "algorithms")
+	if len(algs) == 0 {
+		algs = supportedAlgorithms
+	}
+	for _, alg := range algs {
+		if !slices.Contains(supportedAlgorithms, alg) {
+			return nil, fmt.Errorf("oidc-jwt provider %q: unsupported algorithm %q", cfg.Name, alg)
+		}
+	}
+	claims := internalaccess.OptionStrings(opts, "identity-claim")
+	if len(claims) == 0 {
+		claims = defaultIdentityClaims
+	}
+	name := cfg.Name
+	if name == "" {
+		name = config.AccessProviderTypeOIDCJWT
+	}
+	return &provider{
+		name:           name,
+		issuer:         issuer,
+		audiences:      audiences,
+		algorithms:     algs,
+		identityClaims: claims,
+		requiredClaims: internalaccess.OptionStringMap(opts, "required-claims"),
+		identityPrefix: internalaccess.OptionString(opts, "identity-prefix"),
+		leeway:         leeway,
+		keys: &keySet{
+			issuer: issuer,
+			url:    internalaccess.OptionString(opts, "jwks-url"),
+			ttl:    ttl,
+			client: &http.Client{Timeout: 10 * time.Second},
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (p *provider) Identifier() string {
+	return p.name
+}
+
+// Authenticate verifies a bearer JWT. Tokens that are not JWT-shaped are left to
+// the next provider so plain API keys can be configured alongside.
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*internalaccess.Result, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, internalaccess.ErrNoCredentials
+	}
+	jwt, err := parseJWT(token)
+	if err == errNotJWT {
+		return nil, internalaccess.ErrNotHandled
+	}
+	if err != nil {
+		return nil, p.reject(err)
+	}
+	if !slices.Contains(p.algorithms, jwt.header.Alg) {
+		return nil, p.reject(fmt.Errorf("algorithm %q not allowed", jwt.header.Alg))
+	}
+	if err := p.verify(ctx, jwt); err != nil {
+		return nil, p.reject(err)
+	}
+	if err := p.validateClaims(jwt.claims); err != nil {
+		return nil, p.reject(err)
+	}
+	identity := ""
+	for _, claim := range p.identityClaims {
+		if identity = claimString(jwt.claims[claim]); identity != "" {
+			break
+		}
+	}
+	if identity == "" {
+		return nil, p.reject(fmt.Errorf("token has none of the identity claims %v", p.identityClaims))
+	}
+	return &internalaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: p.identityPrefix + identity,
+		Metadata: map[string]string{
+			"source":  "jwt",
+			"subject": claimString(jwt.claims["sub"]),
+			"issuer":  p.issuer,
+		},
+	}, nil
+}
+
+func (p *provider) reject(err error) error {
+	log.Debugf("oidc-jwt %s: rejected token: %v", p.name, err)
+	return internalaccess.ErrInvalidCredential
+}
+
+func (p *provider) verify(ctx context.Context, jwt *parsedJWT) error {
+	keys, err := p.keys.candidates(ctx, jwt.header.Kid)
+	if err != nil {
+		return err
+	}
+	lastErr := fmt.Errorf("no key matches alg %s", jwt.header.Alg)
+	for _, k := range keys {
+		if k.alg != "" && k.alg != jwt.header.Alg {
+			continue
+		}
+		if lastErr = verifySignature(jwt.header.Alg, k.key, jwt.signingInput, jwt.signature); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (p *provider) validateClaims(claims map[string]any) error {
+	if iss := claimString(claims["iss"]); strings.TrimSuffix(iss, "/") != strings.TrimSuffix(p.issuer, "/") {
+		return fmt.Errorf("issuer %q does not match", iss)
+	}
+	auds := claimStrings(claims["aud"])
+	if !slices.ContainsFunc(auds, func(a string) bool { return slices.Contains(p.audiences, a) }) {
+		return fmt.Errorf("audience %v not accepted", auds)
+	}
+	now := p.now()
+	exp, ok := claimTime(claims["exp"])
+	if !ok {
+		return fmt.Errorf("token has no exp claim")
+	}
+	if now.After(exp.Add(p.leeway)) {
+		return fmt.Errorf("token expired at %s", exp.Format(time.RFC3339))
+	}
+	if nbf, ok := claimTime(claims["nbf"]); ok && now.Add(p.leeway).Before(nbf) {
+		return fmt.Errorf("token not valid before %s", nbf.Format(time.RFC3339))
+	}
+	if iat, ok := claimTime(claims["iat"]); ok && now.Add(p.leeway).Before(iat) {
+		return fmt.Errorf("token issued in the future")
+	}
+	for claim, want := range p.requiredClaims {
+		if !slices.Contains(claimStrings(claims[claim]), want) {
+			return fmt.Errorf("claim %q does not contain %q", claim, want)
+		}
+	}
+	return nil
+}
+
+func bearerToken(r *http.Request) string {
+	if h := strings.TrimSpace(r.Header.Get("Authorization")); h != "" {
+		if scheme, rest, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(rest)
+		}
+		return h
+	}
+	if h := r.Header.Get("X-Api-Key"); h != "" {
+		return h
+	}
+	return r.Header.Get("X-Goog-Api-Key")
+}
+
+func claimString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return fmt.Sprintf("%.0f", t)
+	case bool:
+		return fmt.Sprint(t)
+	}
+	return ""
+}
+
+// claimStrings returns a string or string-array claim as a list.
+func claimStrings(v any) []string {
+	if list, ok := v.([]any); ok {
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s := claimString(item); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	if s := claimString(v); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func claimTime(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
@@ -0,0 +1,131 @@
+package oidcaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	internalaccess "github.com/nghyane/llm-mux/internal/access"
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + enc.EncodeToString(sig)
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"jwks_uri":"` + srv.URL + `/keys"}`))
+		case "/keys":
+			enc := base64.RawURLEncoding
+			doc, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+				"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+				"n": enc.EncodeToString(key.N.Bytes()),
+				"e": enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+			_, _ = w.Write(doc)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(&config.AccessProvider{Name: "sso", Type: config.AccessProviderTypeOIDCJWT, Config: map[string]any{
+		"issuer":          srv.URL,
+		"audience":        "llm-mux",
+		"required-claims": map[string]any{"groups": "llm-users"},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	valid := map[string]any{
+		"iss": srv.URL, "aud": []any{"llm-mux"}, "sub": "u1", "email": "dev@example.com",
+		"exp": now + 300, "iat": now, "groups": []any{"staff", "llm-users"},
+	}
+	with := func(k string, v any) map[string]any {
+		c := map[string]any{}
+		for key, val := range valid {
+			c[key] = val
+		}
+		c[k] = v
+		return c
+	}
+	auth := func(token string) (*internalaccess.Result, error) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return p.Authenticate(context.Background(), r)
+	}
+
+	res, err := auth(signRS256(t, key, "k1", valid))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if res.Principal != "dev@example.com" || res.Provider != "sso" || res.Metadata["subject"] != "u1" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	if _, err := auth(""); !errors.Is(err, internalaccess.ErrNoCredentials) {
+		t.Fatalf("missing token: got %v", err)
+	}
+	if _, err := auth("sk-plain-api-key"); !errors.Is(err, internalaccess.ErrNotHandled) {
+		t.Fatalf("non-JWT token: got %v", err)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rejected := map[string]string{
+		"expired":       signRS256(t, key, "k1", with("exp", now-3600)),
+		"wrong issuer":  signRS256(t, key, "k1", with("iss", "https://evil.example.com")),
+		"wrong aud":     signRS256(t, key, "k1", with("aud", "other")),
+		"missing group": signRS256(t, key, "k1", with("groups", []any{"staff"})),
+		"bad signature": signRS256(t, other, "k1", valid),
+		"unknown kid":   signRS256(t, key, "k2", valid),
+	}
+	for name, token := range rejected {
+		if _, err := auth(token); !errors.Is(err, internalaccess.ErrInvalidCredential) {
+			t.Errorf("%s: got %v, want ErrInvalidCredential", name, err)
+		}
+	}
+}
+
+func TestNewProviderRequiresIssuer(t *testing.T) {
+	if _, err := newProvider(&config.AccessProvider{Name: "sso"}, nil); err == nil {
+		t.Fatal("expected error without issuer")
+	}
+	if _, err := newProvider(&config.AccessProvider{Name: "sso", Config: map[string]any{"issuer": "https://x"}}, nil); err == nil {
+		t.Fatal("expected error without audience")
+	}
+	if _, err := newProvider(&config.AccessProvider{Name: "sso", Config: map[string]any{"issuer": "https://x", "audience": "llm-mux", "algorithms": "HS256"}}, nil); err == nil {
+		t.Fatal("expected error for HS256")
+	}
+}
@@ -0,0 +1,69 @@
+package access
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OptionString returns a string option from a provider's config map.
+func OptionString(opts map[string]any, key string) string {
+	if v, ok := opts[key]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+	return ""
+}
+
+// OptionStrings returns a list option, accepting either a YAML list or a
+// single comma-separated string.
+func OptionStrings(opts map[string]any, key string) []string {
+	var raw []string
+	switch v := opts[key].(type) {
+	case nil:
+		return nil
+	case []any:
+		for _, item := range v {
+			raw = append(raw, fmt.Sprint(item))
+		}
+	case []string:
+		raw = v
+	default:
+		raw = strings.Split(fmt.Sprint(v), ",")
+	}
+	out := make([]string, 0, len(raw))
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// OptionStringMap returns a map option with values converted to strings.
+func OptionStringMap(opts map[string]any, key string) map[string]string {
+	out := make(map[string]string)
+	switch v := opts[key].(type) {
+	case map[string]any:
+		for k, val := range v {
+			out[k] = fmt.Sprint(val)
+		}
+	case map[string]string:
+		for k, val := range v {
+			out[k] = val
+		}
+	}
+	return out
+}
+
+// OptionDuration parses a duration option, returning fallback when unset.
+func OptionDuration(opts map[string]any, key string, fallback time.Duration) (time.Duration, error) {
+	s := OptionString(opts, key)
+	if s == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, s)
+	}
+	return d, nil
+}
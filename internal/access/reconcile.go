@@ -174,7 +174,9 @@ func accessProviderMap(cfg *config.Config) map[string]*config.AccessProvider {
 		}
 		result[key] = providerCfg
 	}
-	if len(result) == 0 && len(cfg.APIKeys) > 0 {
+	// Top-level api-keys are served by an inline provider next to any
+	// configured ones; config-api-key entries are folded into them on load.
+	if len(cfg.APIKeys) > 0 {
 		if provider := config.MakeInlineAPIKeyProvider(cfg.APIKeys); provider != nil {
 			if key := providerIdentifier(provider); key != "" {
 				result[key] = provider
@@ -195,7 +197,7 @@ func collectProviderEntries(cfg *config.Config) []*config.AccessProvider {
 			entries = append(entries, providerCfg)
 		}
 	}
-	if len(entries) == 0 && len(cfg.APIKeys) > 0 {
+	if len(cfg.APIKeys) > 0 {
 		if inline := config.MakeInlineAPIKeyProvider(cfg.APIKeys); inline != nil {
 			entries = append(entries, inline)
 		}
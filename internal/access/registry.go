@@ -74,7 +74,7 @@ func BuildProviders(root *config.SDKConfig) ([]Provider, error) {
 		}
 		providers = append(providers, provider)
 	}
-	if len(providers) == 0 {
+	if len(root.APIKeys) > 0 {
 		if inline := config.MakeInlineAPIKeyProvider(root.APIKeys); inline != nil {
 			provider, err := BuildProvider(inline, root)
 			if err != nil {
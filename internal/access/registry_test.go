@@ -0,0 +1,64 @@
+package access
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/config"
+)
+
+type stubProvider struct{ id string }
+
+func (p *stubProvider) Identifier() string { return p.id }
+
+func (p *stubProvider) Authenticate(context.Context, *http.Request) (*Result, error) {
+	return nil, ErrNotHandled
+}
+
+func registerStubProviders() {
+	factory := func(cfg *config.AccessProvider, _ *config.SDKConfig) (Provider, error) {
+		return &stubProvider{id: cfg.Name}, nil
+	}
+	RegisterProvider("stub-sso", factory)
+	RegisterProvider(config.AccessProviderTypeConfigAPIKey, factory)
+}
+
+// Top-level api-keys keep working when external providers are configured and
+// are tried after them.
+func TestBuildProviders_KeepsAPIKeysWithExternalProviders(t *testing.T) {
+	registerStubProviders()
+	cfg := &config.SDKConfig{APIKeys: []string{"sk-local"}}
+	cfg.Access.Providers = []config.AccessProvider{{Name: "sso", Type: "stub-sso"}}
+
+	providers, err := BuildProviders(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 2 || providers[0].Identifier() != "sso" || providers[1].Identifier() != config.DefaultAccessProviderName {
+		t.Fatalf("expected sso then inline api-keys, got %v", identifiers(providers))
+	}
+}
+
+func TestReconcileProviders_KeepsAPIKeysWithExternalProviders(t *testing.T) {
+	registerStubProviders()
+	cfg := &config.Config{}
+	cfg.APIKeys = []string{"sk-local"}
+	cfg.Access.Providers = []config.AccessProvider{{Name: "sso", Type: "stub-sso"}}
+
+	providers, _, _, _, err := ReconcileProviders(nil, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 2 || providers[1].Identifier() != config.DefaultAccessProviderName {
+		t.Fatalf("expected inline api-keys after sso, got %v", identifiers(providers))
+	}
+}
+
+func identifiers(providers []Provider) []string {
+	ids := make([]string, 0, len(providers))
+	for _, p := range providers {
+		ids = append(ids, p.Identifier())
+	}
+	return ids
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/guardrail"
 	"github.com/nghyane/llm-mux/internal/interfaces"
 	"github.com/nghyane/llm-mux/internal/provider"
 	"github.com/nghyane/llm-mux/internal/registry"
@@ -56,6 +57,15 @@ func (h *BaseAPIHandler) getFallbackChain(model string) []string {
 	return h.Routing.GetFallbackChain(model)
 }
 
+// fallbackChainAfter returns the fallback models to try after err. Requests
+// blocked by a guardrail are not retried on other models.
+func (h *BaseAPIHandler) fallbackChainAfter(model string, err error) []string {
+	if _, blocked := guardrail.AsBlocked(err); blocked {
+		return nil
+	}
+	return h.getFallbackChain(model)
+}
+
 // Models returns all available models as maps from the global registry.
 func (h *BaseAPIHandler) Models() []map[string]any {
 	return registry.GetGlobalRegistry().GetAvailableModels("openai")
@@ -155,6 +165,7 @@ func extractErrorDetails(err error) (int, http.Header) {
 }
 
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	ctx = h.pinCachedContentOwner(ctx, rawJSON)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -167,7 +178,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		return resp.Payload, nil
 	}
 
-	fallbacks := h.getFallbackChain(normalizedModel)
+	fallbacks := h.fallbackChainAfter(normalizedModel, err)
 	for _, fallbackModel := range fallbacks {
 		fbProviders, fbNormalizedModel, fbMetadata, _ := h.getRequestDetails(fallbackModel)
 		if len(fbProviders) == 0 {
@@ -187,6 +198,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 }
 
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	ctx = h.pinCachedContentOwner(ctx, rawJSON)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -201,6 +213,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 }
 
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	ctx = h.pinCachedContentOwner(ctx, rawJSON)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -208,13 +221,14 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	ctx = withStreamKeepAlive(ctx)
 	req, opts := buildRequestOpts(normalizedModel, rawJSON, metadata, handlerType, alt, true)
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err == nil {
 		return h.wrapStreamChannel(ctx, chunks)
 	}
 
-	fallbacks := h.getFallbackChain(normalizedModel)
+	fallbacks := h.fallbackChainAfter(normalizedModel, err)
 	for _, fallbackModel := range fallbacks {
 		fbProviders, fbNormalizedModel, fbMetadata, _ := h.getRequestDetails(fallbackModel)
 		if len(fbProviders) == 0 {
@@ -234,6 +248,24 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return nil, errChan
 }
 
+// withStreamKeepAlive sends SSE comments while the request waits for a
+// concurrency slot or a cooling down auth, so clients and proxies do not time
+// out. Only responses already declared as text/event-stream get them.
+func withStreamKeepAlive(ctx context.Context) context.Context {
+	c, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok || c == nil || !strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "text/event-stream") {
+		return ctx
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return ctx
+	}
+	return provider.WithWaitKeepAlive(ctx, func() {
+		_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+		flusher.Flush()
+	})
+}
+
 func (h *BaseAPIHandler) wrapStreamChannel(ctx context.Context, chunks <-chan provider.StreamChunk) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
 	dataChan := make(chan []byte, 128)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -269,6 +301,53 @@ func (h *BaseAPIHandler) wrapStreamChannel(ctx context.Context, chunks <-chan pr
 	return dataChan, errChan
 }
 
+// pinCachedContentOwner routes requests that reference a known Gemini cache to
+// the auth that created it, since caches are not shared across projects or keys.
+func (h *BaseAPIHandler) pinCachedContentOwner(ctx context.Context, rawJSON []byte) context.Context {
+	name := gjson.GetBytes(rawJSON, "cachedContent").String()
+	if name == "" || h.AuthManager == nil {
+		return ctx
+	}
+	if owner, ok := h.AuthManager.CachedContentOwner(name); ok {
+		return provider.WithPinnedAuth(ctx, owner)
+	}
+	return ctx
+}
+
+// CreateCachedContentWithAuthManager creates a Gemini cache for modelName and
+// records which auth owns it.
+func (h *BaseAPIHandler) CreateCachedContentWithAuthManager(ctx context.Context, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	providers, normalizedModel, _, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	resp, err := h.AuthManager.CreateCachedContent(ctx, providers, normalizedModel, rawJSON)
+	if err != nil {
+		status, addon := extractErrorDetails(err)
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+	}
+	return resp.Payload, nil
+}
+
+// CachedContentWithAuthManager forwards a get, patch or delete call for a known
+// cache, or lists caches when req.Name is empty.
+func (h *BaseAPIHandler) CachedContentWithAuthManager(ctx context.Context, req provider.CachedContentRequest) ([]byte, *interfaces.ErrorMessage) {
+	var (
+		resp provider.Response
+		err  error
+	)
+	if req.Name == "" && req.Method == http.MethodGet {
+		resp, err = h.AuthManager.ListCachedContents(ctx, req.Query)
+	} else {
+		resp, err = h.AuthManager.CachedContent(ctx, req)
+	}
+	if err != nil {
+		status, addon := extractErrorDetails(err)
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+	}
+	return resp.Payload, nil
+}
+
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
 	resolvedModelName := util.ResolveAutoModel(modelName)
 	specifiedProvider := util.ExtractProviderFromPrefixedModelID(resolvedModelName)
@@ -344,6 +423,12 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 	c.Status(status)
+	if msg != nil {
+		if blocked, ok := guardrail.AsBlocked(msg.Error); ok {
+			c.Data(status, "application/json", blocked.Body())
+			return
+		}
+	}
 	if msg != nil && msg.Error != nil {
 		errResp := ErrorResponse{
 			Error: ErrorDetail{
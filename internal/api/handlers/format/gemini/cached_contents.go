@@ -0,0 +1,76 @@
+package gemini
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/api/handlers/format"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/tidwall/gjson"
+)
+
+// CreateCachedContent handles POST /v1beta/cachedContents. The cache is created
+// through an auth serving the requested model and later requests referencing it
+// are pinned to that auth.
+func (h *GeminiAPIHandler) CreateCachedContent(c *gin.Context) {
+	rawJSON, _ := c.GetRawData()
+	model := strings.TrimPrefix(gjson.GetBytes(rawJSON, "model").String(), "models/")
+	if model == "" {
+		c.JSON(http.StatusBadRequest, format.ErrorResponse{
+			Error: format.ErrorDetail{
+				Message: "Invalid request: model is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	cliCtx, cliCancel := h.GetContextWithCancel(c.Request.Context(), h, c)
+	resp, errMsg := h.CreateCachedContentWithAuthManager(cliCtx, model, rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", resp)
+	cliCancel()
+}
+
+// ListCachedContents handles GET /v1beta/cachedContents.
+func (h *GeminiAPIHandler) ListCachedContents(c *gin.Context) {
+	h.forwardCachedContent(c, "")
+}
+
+// CachedContent handles GET, PATCH and DELETE on /v1beta/cachedContents/:id.
+func (h *GeminiAPIHandler) CachedContent(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusNotFound, format.ErrorResponse{
+			Error: format.ErrorDetail{
+				Message: fmt.Sprintf("%s not found.", c.Request.URL.Path),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	h.forwardCachedContent(c, "cachedContents/"+id)
+}
+
+func (h *GeminiAPIHandler) forwardCachedContent(c *gin.Context, name string) {
+	var body []byte
+	if c.Request.Method == http.MethodPatch {
+		body, _ = c.GetRawData()
+	}
+	resp, errMsg := h.CachedContentWithAuthManager(c.Request.Context(), provider.CachedContentRequest{
+		Method: c.Request.Method,
+		Name:   name,
+		Query:  c.Request.URL.Query(),
+		Body:   body,
+	})
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", resp)
+}
@@ -0,0 +1,435 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/constant"
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/nghyane/llm-mux/internal/translator/from_ir"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxAudioUploadBytes matches the OpenAI transcription upload limit.
+	maxAudioUploadBytes = 25 << 20
+	// maxSpeechInputChars matches the OpenAI speech input limit.
+	maxSpeechInputChars = 4096
+	// Gemini TTS models return 16-bit little-endian mono PCM at 24 kHz.
+	speechSampleRate = 24000
+)
+
+var (
+	preferredTranscriptionModels = []string{"gemini-2.5-flash", "gemini-2.5-flash-lite", "gemini-3-flash-preview", "gemini-2.5-pro"}
+	preferredSpeechModels        = []string{"gemini-2.5-flash-preview-tts", "gemini-2.5-pro-preview-tts"}
+)
+
+// openAIVoices maps OpenAI voice names to Gemini prebuilt voices. Other names
+// are passed through so Gemini voices can be requested directly.
+var openAIVoices = map[string]string{
+	"alloy":   "Kore",
+	"ash":     "Orus",
+	"ballad":  "Enceladus",
+	"coral":   "Aoede",
+	"echo":    "Charon",
+	"fable":   "Fenrir",
+	"onyx":    "Iapetus",
+	"nova":    "Leda",
+	"sage":    "Sulafat",
+	"shimmer": "Zephyr",
+	"verse":   "Puck",
+}
+
+// speechFormats lists response formats and their content types. Formats other
+// than pcm and wav are transcoded with ffmpeg when it is installed.
+var speechFormats = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
+
+// transcriptSegment is a timed piece of a transcript.
+type transcriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+var transcriptSegmentsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"language": map[string]any{"type": "string"},
+		"segments": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"start": map[string]any{"type": "number"},
+					"end":   map[string]any{"type": "number"},
+					"text":  map[string]any{"type": "string"},
+				},
+				"required": []string{"start", "end", "text"},
+			},
+		},
+	},
+	"required": []string{"segments"},
+}
+
+// AudioTranscriptions handles the /v1/audio/transcriptions endpoint. The upload
+// is sent as an audio part to a multimodal model with a transcription instruction.
+func (h *OpenAIAPIHandler) AudioTranscriptions(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	value := func(key string) string {
+		if v := form.Value[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		writeMediaError(c, http.StatusBadRequest, "Invalid request: file is required")
+		return
+	}
+	fh := files[0]
+	if fh.Size > maxAudioUploadBytes {
+		writeMediaError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Invalid request: file exceeds %d bytes", maxAudioUploadBytes))
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxAudioUploadBytes+1))
+	_ = f.Close()
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	responseFormat := value("response_format")
+	if responseFormat == "" {
+		responseFormat = "json"
+	}
+	timed := false
+	switch responseFormat {
+	case "json", "text":
+	case "srt", "vtt", "verbose_json":
+		timed = true
+	default:
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: unsupported response_format %q", responseFormat))
+		return
+	}
+
+	model, err := resolveModelFromRegistry(value("model"), []string{"whisper", "gpt-"}, preferredTranscriptionModels, isAudioInputModel, "audio input")
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// input_audio only carries a format, so prefer the extension and fall back
+	// to the upload's MIME subtype.
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+	if ct := fh.Header.Get("Content-Type"); format == "" && strings.HasPrefix(ct, "audio/") {
+		format = strings.TrimPrefix(ct, "audio/")
+	}
+
+	instruction := "Transcribe the speech in this audio verbatim. Output only the transcript, with no commentary or formatting."
+	if timed {
+		instruction = "Transcribe the speech in this audio verbatim, split into short segments (one sentence or phrase each) " +
+			"with start and end times in seconds from the beginning of the audio. Also report the spoken language as an ISO-639-1 code."
+	}
+	if lang := value("language"); lang != "" {
+		instruction += " The audio is in language " + lang + "."
+	}
+	if prompt := value("prompt"); prompt != "" {
+		instruction += " Context and spelling hints: " + prompt
+	}
+
+	irReq := &ir.UnifiedChatRequest{
+		Model: model,
+		Messages: []ir.Message{{
+			Role: ir.RoleUser,
+			Content: []ir.ContentPart{
+				{Type: ir.ContentTypeText, Text: instruction},
+				{Type: ir.ContentTypeAudio, Audio: &ir.AudioPart{Format: format, Data: base64.StdEncoding.EncodeToString(data)}},
+			},
+		}},
+	}
+	if t := value("temperature"); t != "" {
+		if v, errParse := strconv.ParseFloat(t, 64); errParse == nil {
+			irReq.Temperature = &v
+		}
+	}
+	if timed {
+		irReq.ResponseSchema = transcriptSegmentsSchema
+		irReq.ResponseSchemaName = "transcript"
+	}
+	payload, err := from_ir.ToOpenAIRequest(irReq)
+	if err != nil {
+		writeMediaError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(c.Request.Context(), h, c)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, constant.OpenAI, model, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	cliCancel()
+	content := strings.TrimSpace(gjson.GetBytes(resp, "choices.0.message.content").String())
+
+	if !timed {
+		if responseFormat == "text" {
+			c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content+"\n"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"text": content})
+		return
+	}
+
+	var parsed struct {
+		Language string              `json:"language"`
+		Segments []transcriptSegment `json:"segments"`
+	}
+	if err := json.Unmarshal([]byte(stripJSONFence(content)), &parsed); err != nil {
+		writeMediaError(c, http.StatusBadGateway, "The model returned an invalid timed transcript")
+		return
+	}
+	switch responseFormat {
+	case "srt":
+		c.Data(http.StatusOK, "application/x-subrip; charset=utf-8", []byte(formatSRT(parsed.Segments)))
+	case "vtt":
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(formatVTT(parsed.Segments)))
+	default:
+		segments := make([]gin.H, 0, len(parsed.Segments))
+		texts := make([]string, 0, len(parsed.Segments))
+		duration := 0.0
+		for i, seg := range parsed.Segments {
+			text := strings.TrimSpace(seg.Text)
+			segments = append(segments, gin.H{"id": i, "start": seg.Start, "end": seg.End, "text": text})
+			texts = append(texts, text)
+			duration = math.Max(duration, seg.End)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"task":     "transcribe",
+			"language": parsed.Language,
+			"duration": duration,
+			"text":     strings.Join(texts, " "),
+			"segments": segments,
+		})
+	}
+}
+
+// AudioSpeech handles the /v1/audio/speech endpoint using a Gemini TTS model and
+// returns the audio bytes in the requested format.
+func (h *OpenAIAPIHandler) AudioSpeech(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	input := root.Get("input").String()
+	if strings.TrimSpace(input) == "" {
+		writeMediaError(c, http.StatusBadRequest, "Invalid request: input is required")
+		return
+	}
+	if len([]rune(input)) > maxSpeechInputChars {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: input must be at most %d characters", maxSpeechInputChars))
+		return
+	}
+
+	responseFormat := root.Get("response_format").String()
+	if responseFormat == "" {
+		// mp3 is the OpenAI default but needs ffmpeg; fall back to wav without it.
+		responseFormat = "mp3"
+		if _, errLook := exec.LookPath("ffmpeg"); errLook != nil {
+			responseFormat = "wav"
+		}
+	}
+	contentType, ok := speechFormats[responseFormat]
+	if !ok {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: unsupported response_format %q", responseFormat))
+		return
+	}
+	if responseFormat != "wav" && responseFormat != "pcm" {
+		if _, errLook := exec.LookPath("ffmpeg"); errLook != nil {
+			writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: response_format %q requires ffmpeg on the server; use wav or pcm", responseFormat))
+			return
+		}
+	}
+
+	model, err := resolveModelFromRegistry(root.Get("model").String(), []string{"tts-", "gpt-"}, preferredSpeechModels, func(id string) bool {
+		return strings.Contains(id, "-tts")
+	}, "speech output")
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	voice := root.Get("voice").String()
+	if mapped, ok := openAIVoices[strings.ToLower(voice)]; ok {
+		voice = mapped
+	}
+	if voice == "" {
+		voice = openAIVoices["alloy"]
+	}
+	text := input
+	if instructions := strings.TrimSpace(root.Get("instructions").String()); instructions != "" {
+		text = instructions + ":\n" + input
+	}
+	payload, err := from_ir.ToOpenAIRequest(&ir.UnifiedChatRequest{
+		Model:            model,
+		Messages:         []ir.Message{{Role: ir.RoleUser, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: text}}}},
+		ResponseModality: []string{ir.ResponseModalityAudio},
+		AudioConfig:      &ir.AudioConfig{Voice: voice, Format: "pcm16"},
+	})
+	if err != nil {
+		writeMediaError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(c.Request.Context(), h, c)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, constant.OpenAI, model, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	pcm, err := base64.StdEncoding.DecodeString(gjson.GetBytes(resp, "choices.0.message.audio.data").String())
+	if err != nil || len(pcm) == 0 {
+		writeMediaError(c, http.StatusBadGateway, "The model returned no audio")
+		cliCancel(fmt.Errorf("no audio in response"))
+		return
+	}
+
+	var out []byte
+	switch responseFormat {
+	case "pcm":
+		out = pcm
+	case "wav":
+		out = pcmToWAV(pcm, speechSampleRate)
+	default:
+		out, err = transcodePCM(cliCtx, pcm, responseFormat)
+		if err != nil {
+			writeMediaError(c, http.StatusInternalServerError, fmt.Sprintf("transcode to %s failed: %v", responseFormat, err))
+			cliCancel(err)
+			return
+		}
+	}
+	c.Data(http.StatusOK, contentType, out)
+	cliCancel()
+}
+
+// isAudioInputModel reports whether a model accepts audio input for transcription.
+func isAudioInputModel(id string) bool {
+	return strings.Contains(id, "gemini-") && !strings.Contains(id, "-tts") &&
+		!strings.Contains(id, "-image") && !strings.Contains(id, "embedding")
+}
+
+// stripJSONFence removes a ```json fence some models wrap around JSON output.
+func stripJSONFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	return strings.TrimSpace(strings.TrimSuffix(s, "```"))
+}
+
+func formatSRT(segments []transcriptSegment) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(seg.Start, ","), formatTimestamp(seg.End, ","), strings.TrimSpace(seg.Text))
+	}
+	return b.String()
+}
+
+func formatVTT(segments []transcriptSegment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, seg := range segments {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatTimestamp(seg.Start, "."), formatTimestamp(seg.End, "."), strings.TrimSpace(seg.Text))
+	}
+	return b.String()
+}
+
+// formatTimestamp renders seconds as HH:MM:SS<sep>mmm.
+func formatTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// pcmToWAV wraps 16-bit mono little-endian PCM in a RIFF/WAVE header.
+func pcmToWAV(pcm []byte, sampleRate int) []byte {
+	const channels, bitsPerSample = 1, 16
+	var buf bytes.Buffer
+	buf.Grow(44 + len(pcm))
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*bitsPerSample/8))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels*bitsPerSample/8))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+// transcodePCM converts speech PCM to a compressed format with ffmpeg.
+func transcodePCM(ctx context.Context, pcm []byte, format string) ([]byte, error) {
+	args := []string{"-hide_banner", "-loglevel", "error", "-f", "s16le", "-ar", strconv.Itoa(speechSampleRate), "-ac", "1", "-i", "pipe:0"}
+	switch format {
+	case "mp3":
+		args = append(args, "-f", "mp3")
+	case "opus":
+		args = append(args, "-c:a", "libopus", "-f", "ogg")
+	case "aac":
+		args = append(args, "-c:a", "aac", "-f", "adts")
+	case "flac":
+		args = append(args, "-f", "flac")
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	args = append(args, "pipe:1")
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdin = bytes.NewReader(pcm)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
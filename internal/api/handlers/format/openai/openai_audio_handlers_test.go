@@ -0,0 +1,50 @@
+package openai
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestFormatSubtitles(t *testing.T) {
+	segments := []transcriptSegment{
+		{Start: 0, End: 1.5, Text: " Hello there. "},
+		{Start: 3661.25, End: 3662, Text: "Bye."},
+	}
+	wantSRT := "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n2\n01:01:01,250 --> 01:01:02,000\nBye.\n\n"
+	if got := formatSRT(segments); got != wantSRT {
+		t.Errorf("formatSRT = %q, want %q", got, wantSRT)
+	}
+	wantVTT := "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nHello there.\n\n01:01:01.250 --> 01:01:02.000\nBye.\n\n"
+	if got := formatVTT(segments); got != wantVTT {
+		t.Errorf("formatVTT = %q, want %q", got, wantVTT)
+	}
+}
+
+func TestPCMToWAV(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4}
+	wav := pcmToWAV(pcm, speechSampleRate)
+	if len(wav) != 44+len(pcm) {
+		t.Fatalf("len = %d, want %d", len(wav), 44+len(pcm))
+	}
+	if string(wav[0:4]) != "RIFF" || string(wav[8:16]) != "WAVEfmt " || string(wav[36:40]) != "data" {
+		t.Fatalf("bad header: %q", wav[:44])
+	}
+	if got := binary.LittleEndian.Uint32(wav[24:28]); got != speechSampleRate {
+		t.Errorf("sample rate = %d", got)
+	}
+	if got := binary.LittleEndian.Uint32(wav[40:44]); got != uint32(len(pcm)) {
+		t.Errorf("data size = %d", got)
+	}
+}
+
+func TestStripJSONFence(t *testing.T) {
+	for in, want := range map[string]string{
+		`{"a":1}`:                 `{"a":1}`,
+		"```json\n{\"a\":1}\n```": `{"a":1}`,
+		"```\n{\"a\":1}```":       `{"a":1}`,
+	} {
+		if got := stripJSONFence(in); got != want {
+			t.Errorf("stripJSONFence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
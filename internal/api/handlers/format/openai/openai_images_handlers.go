@@ -0,0 +1,443 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/api/handlers/format"
+	"github.com/nghyane/llm-mux/internal/constant"
+	"github.com/nghyane/llm-mux/internal/interfaces"
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/translator/from_ir"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxImagesPerRequest caps the n parameter; each image is a separate upstream call.
+	maxImagesPerRequest = 10
+	// maxImageUploadBytes bounds a single uploaded image for /v1/images/edits.
+	maxImageUploadBytes = 20 << 20
+)
+
+// preferredImageModels lists Gemini image-output models in the order used when the
+// client asks for an OpenAI image model (dall-e-*, gpt-image-*) or none at all.
+var preferredImageModels = []string{
+	"gemini-2.5-flash-image",
+	"gemini-3-pro-image-preview",
+	"gemini-2.5-flash-image-preview",
+}
+
+// geminiAspectRatios are the aspect ratios accepted by Gemini image models.
+var geminiAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1}, {"2:3", 2.0 / 3}, {"3:2", 3.0 / 2}, {"3:4", 3.0 / 4}, {"4:3", 4.0 / 3},
+	{"4:5", 4.0 / 5}, {"5:4", 5.0 / 4}, {"9:16", 9.0 / 16}, {"16:9", 16.0 / 9}, {"21:9", 21.0 / 9},
+}
+
+// imageRequest is the normalized form of an images generation or edit call.
+type imageRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	ResponseFormat string
+	ImageConfig    *ir.ImageConfig
+	Images         []*ir.ImagePart
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint by running the
+// prompt against a Gemini image-output model with the IMAGE response modality.
+func (h *OpenAIAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	req := imageRequest{
+		Model:          root.Get("model").String(),
+		Prompt:         root.Get("prompt").String(),
+		N:              int(root.Get("n").Int()),
+		ResponseFormat: root.Get("response_format").String(),
+	}
+	req.ImageConfig, err = parseImageConfig(root.Get("size").String(), root.Get("aspect_ratio").String(), root.Get("image_size").String())
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.handleImages(c, req)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint. It accepts the OpenAI
+// multipart form (image, image[], mask, prompt, ...) and a JSON body whose
+// images are data URLs.
+func (h *OpenAIAPIHandler) ImageEdits(c *gin.Context) {
+	var (
+		req imageRequest
+		err error
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		req, err = parseImageEditForm(c)
+	} else {
+		req, err = parseImageEditJSON(c)
+	}
+	if err != nil {
+		writeMediaError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Images) == 0 {
+		writeMediaError(c, http.StatusBadRequest, "Invalid request: at least one image is required")
+		return
+	}
+	h.handleImages(c, req)
+}
+
+func (h *OpenAIAPIHandler) handleImages(c *gin.Context, req imageRequest) {
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeMediaError(c, http.StatusBadRequest, "Invalid request: prompt is required")
+		return
+	}
+	if req.N <= 0 {
+		req.N = 1
+	}
+	if req.N > maxImagesPerRequest {
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: n must be at most %d", maxImagesPerRequest))
+		return
+	}
+	switch req.ResponseFormat {
+	case "", "b64_json", "url":
+	default:
+		writeMediaError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: unsupported response_format %q", req.ResponseFormat))
+		return
+	}
+	model, errResolve := resolveImageModel(req.Model)
+	if errResolve != nil {
+		writeMediaError(c, http.StatusBadRequest, errResolve.Error())
+		return
+	}
+
+	msg := ir.Message{Role: ir.RoleUser}
+	for _, img := range req.Images {
+		msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeImage, Image: img})
+	}
+	msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeText, Text: req.Prompt})
+	payload, err := from_ir.ToOpenAIRequest(&ir.UnifiedChatRequest{
+		Model:            model,
+		Messages:         []ir.Message{msg},
+		ResponseModality: []string{ir.ResponseModalityText, ir.ResponseModalityImage},
+		ImageConfig:      req.ImageConfig,
+	})
+	if err != nil {
+		writeMediaError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(c.Request.Context(), h, c)
+	results, errMsg := h.fanOutImages(cliCtx, model, payload, req.N)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+
+	data := make([]gin.H, 0, len(results))
+	for _, res := range results {
+		for _, url := range res.urls {
+			item := gin.H{}
+			if req.ResponseFormat == "url" {
+				item["url"] = url
+			} else {
+				item["b64_json"] = url[strings.Index(url, ",")+1:]
+			}
+			if res.text != "" {
+				item["revised_prompt"] = res.text
+			}
+			data = append(data, item)
+		}
+	}
+	if len(data) == 0 {
+		writeMediaError(c, http.StatusBadGateway, "The model returned no image")
+		cliCancel(fmt.Errorf("no image in response"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"created": time.Now().Unix(),
+		"data":    data,
+	})
+	cliCancel()
+}
+
+type imageResult struct {
+	urls []string
+	text string
+}
+
+// fanOutImages issues n concurrent single-image requests, since Gemini image
+// models return one image per candidate and ignore candidateCount.
+func (h *OpenAIAPIHandler) fanOutImages(ctx context.Context, model string, payload []byte, n int) ([]imageResult, *interfaces.ErrorMessage) {
+	results := make([]imageResult, n)
+	errs := make([]*interfaces.ErrorMessage, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, errMsg := h.ExecuteWithAuthManager(ctx, constant.OpenAI, model, payload, "")
+			if errMsg != nil {
+				errs[i] = errMsg
+				return
+			}
+			message := gjson.GetBytes(resp, "choices.0.message")
+			for _, img := range message.Get("images").Array() {
+				if url := img.Get("image_url.url").String(); strings.HasPrefix(url, "data:") {
+					results[i].urls = append(results[i].urls, url)
+				}
+			}
+			results[i].text = strings.TrimSpace(message.Get("content").String())
+		}(i)
+	}
+	wg.Wait()
+
+	// Partial success still returns the images that were produced.
+	var ok []imageResult
+	for i := range results {
+		if errs[i] == nil {
+			ok = append(ok, results[i])
+		}
+	}
+	if len(ok) == 0 {
+		return nil, errs[0]
+	}
+	return ok, nil
+}
+
+// resolveImageModel maps the requested model to an available image-output model.
+// OpenAI image model names and empty values fall back to the preferred list.
+func resolveImageModel(requested string) (string, error) {
+	return resolveModelFromRegistry(requested, []string{"dall-e", "gpt-image"}, preferredImageModels, func(id string) bool {
+		return strings.Contains(id, "-image")
+	}, "image output")
+}
+
+// resolveModelFromRegistry picks the upstream model for an OpenAI media endpoint.
+// A requested model that is not an OpenAI-only name (openAIPrefixes) must satisfy
+// capable; it is passed on as-is so prefixed and dynamic IDs still route. Otherwise
+// the first available preferred model, then any available capable model, is used.
+func resolveModelFromRegistry(requested string, openAIPrefixes, preferred []string, capable func(string) bool, capability string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	isOpenAIName := false
+	for _, prefix := range openAIPrefixes {
+		if strings.HasPrefix(requested, prefix) {
+			isOpenAIName = true
+			break
+		}
+	}
+	if requested != "" && !isOpenAIName {
+		if !capable(requested) {
+			return "", fmt.Errorf("Model %s does not support %s", requested, capability)
+		}
+		return requested, nil
+	}
+	available := make(map[string]struct{})
+	var ids []string
+	for _, m := range registry.GetGlobalRegistry().GetAvailableModels("openai") {
+		if id, _ := m["id"].(string); id != "" {
+			available[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range preferred {
+		if _, ok := available[id]; ok {
+			return id, nil
+		}
+	}
+	for _, id := range ids {
+		if capable(id) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("No model with %s is available", capability)
+}
+
+// parseImageConfig converts an OpenAI size ("1536x1024", "auto") and the optional
+// aspect_ratio / image_size extensions into an ir.ImageConfig.
+func parseImageConfig(size, aspectRatio, imageSize string) (*ir.ImageConfig, error) {
+	cfg := &ir.ImageConfig{AspectRatio: aspectRatio, ImageSize: strings.ToUpper(imageSize)}
+	if size != "" && size != "auto" {
+		w, h, ok := strings.Cut(strings.ToLower(size), "x")
+		width, errW := strconv.Atoi(w)
+		height, errH := strconv.Atoi(h)
+		if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("Invalid request: size must be WIDTHxHEIGHT or auto, got %q", size)
+		}
+		if cfg.AspectRatio == "" {
+			cfg.AspectRatio = nearestAspectRatio(float64(width) / float64(height))
+		}
+		if cfg.ImageSize == "" {
+			switch longest := max(width, height); {
+			case longest > 2048:
+				cfg.ImageSize = "4K"
+			case longest > 1536:
+				cfg.ImageSize = "2K"
+			}
+		}
+	}
+	if cfg.AspectRatio == "" && cfg.ImageSize == "" {
+		return nil, nil
+	}
+	if cfg.AspectRatio == "" {
+		cfg.AspectRatio = "1:1"
+	}
+	return cfg, nil
+}
+
+func nearestAspectRatio(ratio float64) string {
+	best, bestDiff := "1:1", math.MaxFloat64
+	for _, ar := range geminiAspectRatios {
+		if diff := math.Abs(math.Log(ratio / ar.ratio)); diff < bestDiff {
+			best, bestDiff = ar.name, diff
+		}
+	}
+	return best
+}
+
+func parseImageEditForm(c *gin.Context) (imageRequest, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return imageRequest{}, fmt.Errorf("Invalid request: %v", err)
+	}
+	value := func(key string) string {
+		if v := form.Value[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	req := imageRequest{
+		Model:          value("model"),
+		Prompt:         value("prompt"),
+		ResponseFormat: value("response_format"),
+	}
+	if n := value("n"); n != "" {
+		if req.N, err = strconv.Atoi(n); err != nil {
+			return imageRequest{}, fmt.Errorf("Invalid request: n must be an integer")
+		}
+	}
+	if req.ImageConfig, err = parseImageConfig(value("size"), value("aspect_ratio"), value("image_size")); err != nil {
+		return imageRequest{}, err
+	}
+	for _, key := range []string{"image", "image[]"} {
+		for _, fh := range form.File[key] {
+			img, errRead := readImageUpload(fh)
+			if errRead != nil {
+				return imageRequest{}, errRead
+			}
+			req.Images = append(req.Images, img)
+		}
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		mask, errRead := readImageUpload(masks[0])
+		if errRead != nil {
+			return imageRequest{}, errRead
+		}
+		// Gemini has no mask input; send it as a reference image and describe its role.
+		req.Images = append(req.Images, mask)
+		req.Prompt += "\n\nThe last image is a mask: only edit the regions where the mask is transparent."
+	}
+	return req, nil
+}
+
+func parseImageEditJSON(c *gin.Context) (imageRequest, error) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		return imageRequest{}, fmt.Errorf("Invalid request: %v", err)
+	}
+	root := gjson.ParseBytes(rawJSON)
+	req := imageRequest{
+		Model:          root.Get("model").String(),
+		Prompt:         root.Get("prompt").String(),
+		N:              int(root.Get("n").Int()),
+		ResponseFormat: root.Get("response_format").String(),
+	}
+	if req.ImageConfig, err = parseImageConfig(root.Get("size").String(), root.Get("aspect_ratio").String(), root.Get("image_size").String()); err != nil {
+		return imageRequest{}, err
+	}
+	var urls []string
+	for _, item := range root.Get("images").Array() {
+		if item.Type == gjson.String {
+			urls = append(urls, item.String())
+		} else {
+			urls = append(urls, item.Get("image_url").String())
+		}
+	}
+	if v := root.Get("image"); v.Type == gjson.String {
+		urls = append(urls, v.String())
+	}
+	for _, u := range urls {
+		img, ok := parseImageDataURL(u)
+		if !ok {
+			return imageRequest{}, fmt.Errorf("Invalid request: images must be base64 data URLs")
+		}
+		req.Images = append(req.Images, img)
+	}
+	return req, nil
+}
+
+func readImageUpload(fh *multipart.FileHeader) (*ir.ImagePart, error) {
+	if fh.Size > maxImageUploadBytes {
+		return nil, fmt.Errorf("Invalid request: image %s exceeds %d bytes", fh.Filename, maxImageUploadBytes)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid request: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(io.LimitReader(f, maxImageUploadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid request: %v", err)
+	}
+	mimeType := fh.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("Invalid request: %s is not an image", fh.Filename)
+	}
+	return &ir.ImagePart{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func parseImageDataURL(u string) (*ir.ImagePart, bool) {
+	rest, ok := strings.CutPrefix(u, "data:")
+	if !ok {
+		return nil, false
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, false
+	}
+	return &ir.ImagePart{MimeType: strings.TrimSuffix(meta, ";base64"), Data: data}, true
+}
+
+func writeMediaError(c *gin.Context, status int, message string) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	c.JSON(status, format.ErrorResponse{
+		Error: format.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
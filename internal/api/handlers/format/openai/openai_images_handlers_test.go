@@ -0,0 +1,48 @@
+package openai
+
+import "testing"
+
+func TestParseImageConfig(t *testing.T) {
+	cases := []struct {
+		size, aspect, imageSize string
+		wantAspect, wantSize    string
+		wantNil                 bool
+	}{
+		{size: "", wantNil: true},
+		{size: "auto", wantNil: true},
+		{size: "1024x1024", wantAspect: "1:1"},
+		{size: "1536x1024", wantAspect: "3:2"},
+		{size: "1024x1792", wantAspect: "9:16", wantSize: "2K"},
+		{size: "3840x2160", wantAspect: "16:9", wantSize: "4K"},
+		{size: "1024x1024", aspect: "4:3", imageSize: "2k", wantAspect: "4:3", wantSize: "2K"},
+		{imageSize: "1K", wantAspect: "1:1", wantSize: "1K"},
+	}
+	for _, tc := range cases {
+		cfg, err := parseImageConfig(tc.size, tc.aspect, tc.imageSize)
+		if err != nil {
+			t.Fatalf("parseImageConfig(%q): %v", tc.size, err)
+		}
+		if tc.wantNil {
+			if cfg != nil {
+				t.Errorf("parseImageConfig(%q) = %+v, want nil", tc.size, cfg)
+			}
+			continue
+		}
+		if cfg == nil || cfg.AspectRatio != tc.wantAspect || cfg.ImageSize != tc.wantSize {
+			t.Errorf("parseImageConfig(%q, %q, %q) = %+v, want %s/%s", tc.size, tc.aspect, tc.imageSize, cfg, tc.wantAspect, tc.wantSize)
+		}
+	}
+	if _, err := parseImageConfig("big", "", ""); err == nil {
+		t.Error("expected error for malformed size")
+	}
+}
+
+func TestParseImageDataURL(t *testing.T) {
+	img, ok := parseImageDataURL("data:image/png;base64,iVBORw0KGgo=")
+	if !ok || img.MimeType != "image/png" || img.Data != "iVBORw0KGgo=" {
+		t.Fatalf("unexpected result %+v %v", img, ok)
+	}
+	if _, ok := parseImageDataURL("https://example.com/a.png"); ok {
+		t.Error("remote URLs should be rejected")
+	}
+}
@@ -0,0 +1,618 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/nghyane/llm-mux/internal/api/handlers/format"
+	"github.com/nghyane/llm-mux/internal/constant"
+	"github.com/nghyane/llm-mux/internal/interfaces"
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/translator/from_ir"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/nghyane/llm-mux/internal/translator/to_ir"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// realtimeReadLimit caps a single client event.
+const realtimeReadLimit = 16 << 20
+
+// OpenAIRealtimeAPIHandler serves text sessions over WebSocket using the
+// event protocol of the OpenAI Realtime API. Each response.create runs a
+// streaming chat completion over the conversation held by the session.
+type OpenAIRealtimeAPIHandler struct {
+	*format.BaseAPIHandler
+	upgrader websocket.Upgrader
+}
+
+// NewOpenAIRealtimeAPIHandler creates a realtime handler on top of apiHandlers.
+func NewOpenAIRealtimeAPIHandler(apiHandlers *format.BaseAPIHandler) *OpenAIRealtimeAPIHandler {
+	return &OpenAIRealtimeAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIRealtimeAPIHandler) HandlerType() string {
+	return constant.OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIRealtimeAPIHandler) Models() []map[string]any {
+	return h.BaseAPIHandler.Models()
+}
+
+// ServeHTTP upgrades the request and runs a session until the client
+// disconnects. The initial model comes from the model query parameter.
+func (h *OpenAIRealtimeAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(realtimeReadLimit)
+	ginCtx, _ := r.Context().Value(gin.ContextKey).(*gin.Context)
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &realtimeSession{
+		h:    h,
+		conn: conn,
+		c:    ginCtx,
+		ctx:  ctx,
+		config: realtimeSessionConfig{
+			ID:                      realtimeID("sess"),
+			Object:                  "realtime.session",
+			Model:                   strings.TrimSpace(r.URL.Query().Get("model")),
+			Modalities:              []string{"text"},
+			ToolChoice:              "auto",
+			MaxResponseOutputTokens: "inf",
+		},
+	}
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		_ = conn.Close()
+	}()
+	s.send(map[string]any{"type": "session.created", "session": s.config})
+	s.run()
+}
+
+// realtimeSessionConfig is the session object of session.created and
+// session.updated.
+type realtimeSessionConfig struct {
+	ID                      string         `json:"id"`
+	Object                  string         `json:"object"`
+	Model                   string         `json:"model"`
+	Modalities              []string       `json:"modalities"`
+	Instructions            string         `json:"instructions"`
+	Tools                   []realtimeTool `json:"tools"`
+	ToolChoice              any            `json:"tool_choice"`
+	Temperature             *float64       `json:"temperature,omitempty"`
+	MaxResponseOutputTokens any            `json:"max_response_output_tokens"`
+}
+
+type realtimeTool struct {
+	Type        string         `json:"type"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// realtimeItem is a conversation item: a message, a function call or the
+// output of a function call.
+type realtimeItem struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`
+	Type      string            `json:"type"`
+	Status    string            `json:"status,omitempty"`
+	Role      string            `json:"role,omitempty"`
+	Content   []realtimeContent `json:"content,omitempty"`
+	CallID    string            `json:"call_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Arguments string            `json:"arguments,omitempty"`
+	Output    string            `json:"output,omitempty"`
+}
+
+type realtimeContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type realtimeSession struct {
+	h    *OpenAIRealtimeAPIHandler
+	conn *websocket.Conn
+	c    *gin.Context
+	ctx  context.Context
+
+	writeMu sync.Mutex
+	wg      sync.WaitGroup
+
+	mu             sync.Mutex
+	config         realtimeSessionConfig
+	items          []realtimeItem
+	responseCancel context.CancelFunc
+}
+
+func realtimeID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:20]
+}
+
+func (s *realtimeSession) send(event map[string]any) {
+	event["event_id"] = realtimeID("event")
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("realtime: marshal %v event: %v", event["type"], err)
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	_ = s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (s *realtimeSession) sendError(clientEventID, errType, code, message string) {
+	detail := map[string]any{"type": errType, "code": code, "message": message}
+	if clientEventID != "" {
+		detail["event_id"] = clientEventID
+	}
+	s.send(map[string]any{"type": "error", "error": detail})
+}
+
+func (s *realtimeSession) run() {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		event := gjson.ParseBytes(data)
+		eventID := event.Get("event_id").String()
+		if !event.IsObject() {
+			s.sendError(eventID, "invalid_request_error", "invalid_json", "event is not a JSON object")
+			continue
+		}
+		switch typ := event.Get("type").String(); typ {
+		case "session.update":
+			s.updateSession(eventID, event.Get("session"))
+		case "conversation.item.create":
+			s.createItem(eventID, event)
+		case "conversation.item.delete":
+			s.deleteItem(eventID, event.Get("item_id").String())
+		case "response.create":
+			s.createResponse(eventID, event.Get("response"))
+		case "response.cancel":
+			s.mu.Lock()
+			if s.responseCancel != nil {
+				s.responseCancel()
+			}
+			s.mu.Unlock()
+		default:
+			s.sendError(eventID, "invalid_request_error", "unsupported_event", fmt.Sprintf("unsupported event type %q", typ))
+		}
+	}
+}
+
+// applyResponseSettings copies the settings present in obj onto cfg. It is
+// shared by session.update and the overrides of response.create.
+func applyResponseSettings(cfg *realtimeSessionConfig, obj gjson.Result) error {
+	if v := obj.Get("modalities"); v.Exists() {
+		var modalities []string
+		for _, m := range v.Array() {
+			if m.String() != "text" {
+				return fmt.Errorf("modality %q is not supported, only text", m.String())
+			}
+			modalities = append(modalities, "text")
+		}
+		cfg.Modalities = modalities
+	}
+	if v := obj.Get("model"); v.Exists() {
+		cfg.Model = strings.TrimSpace(v.String())
+	}
+	if v := obj.Get("instructions"); v.Exists() {
+		cfg.Instructions = v.String()
+	}
+	if v := obj.Get("tools"); v.Exists() {
+		var tools []realtimeTool
+		if err := json.Unmarshal([]byte(v.Raw), &tools); err != nil {
+			return fmt.Errorf("invalid tools: %w", err)
+		}
+		for _, t := range tools {
+			if t.Name == "" || (t.Type != "" && t.Type != "function") {
+				return fmt.Errorf("tools must be named functions")
+			}
+		}
+		cfg.Tools = tools
+	}
+	if v := obj.Get("tool_choice"); v.Exists() {
+		cfg.ToolChoice = v.Value()
+	}
+	if v := obj.Get("temperature"); v.Exists() {
+		t := v.Float()
+		cfg.Temperature = &t
+	}
+	if v := obj.Get("max_response_output_tokens"); v.Exists() {
+		if v.Type == gjson.Number {
+			if v.Int() <= 0 {
+				return fmt.Errorf("max_response_output_tokens must be positive")
+			}
+			cfg.MaxResponseOutputTokens = v.Int()
+		} else {
+			cfg.MaxResponseOutputTokens = "inf"
+		}
+	}
+	return nil
+}
+
+func (s *realtimeSession) updateSession(eventID string, obj gjson.Result) {
+	s.mu.Lock()
+	cfg := s.config
+	err := applyResponseSettings(&cfg, obj)
+	if err == nil {
+		s.config = cfg
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.sendError(eventID, "invalid_request_error", "invalid_session", err.Error())
+		return
+	}
+	s.send(map[string]any{"type": "session.updated", "session": cfg})
+}
+
+// parseRealtimeItem validates a client item and fills in its defaults.
+func parseRealtimeItem(obj gjson.Result) (realtimeItem, error) {
+	var item realtimeItem
+	if err := json.Unmarshal([]byte(obj.Raw), &item); err != nil {
+		return item, fmt.Errorf("invalid item: %w", err)
+	}
+	if item.ID == "" {
+		item.ID = realtimeID("item")
+	}
+	item.Object = "realtime.item"
+	item.Status = "completed"
+	switch item.Type {
+	case "message":
+		switch item.Role {
+		case "user", "system", "assistant":
+		default:
+			return item, fmt.Errorf("invalid message role %q", item.Role)
+		}
+		for _, c := range item.Content {
+			switch c.Type {
+			case "input_text", "text", "output_text":
+			default:
+				return item, fmt.Errorf("content type %q is not supported, only text", c.Type)
+			}
+		}
+	case "function_call":
+		if item.CallID == "" || item.Name == "" {
+			return item, fmt.Errorf("function_call items need call_id and name")
+		}
+	case "function_call_output":
+		if item.CallID == "" {
+			return item, fmt.Errorf("function_call_output items need call_id")
+		}
+	default:
+		return item, fmt.Errorf("unsupported item type %q", item.Type)
+	}
+	return item, nil
+}
+
+func (s *realtimeSession) createItem(eventID string, event gjson.Result) {
+	item, err := parseRealtimeItem(event.Get("item"))
+	if err != nil {
+		s.sendError(eventID, "invalid_request_error", "invalid_item", err.Error())
+		return
+	}
+	previous := event.Get("previous_item_id")
+	s.mu.Lock()
+	pos := len(s.items)
+	if previous.Exists() {
+		pos = -1
+		if previous.String() == "root" {
+			pos = 0
+		}
+		for i := range s.items {
+			if s.items[i].ID == previous.String() {
+				pos = i + 1
+			}
+		}
+	}
+	if pos < 0 {
+		s.mu.Unlock()
+		s.sendError(eventID, "invalid_request_error", "item_not_found", fmt.Sprintf("previous_item_id %q not found", previous.String()))
+		return
+	}
+	s.items = slices.Insert(s.items, pos, item)
+	prevID := ""
+	if pos > 0 {
+		prevID = s.items[pos-1].ID
+	}
+	s.mu.Unlock()
+	s.send(map[string]any{"type": "conversation.item.created", "previous_item_id": prevID, "item": item})
+}
+
+func (s *realtimeSession) deleteItem(eventID, itemID string) {
+	s.mu.Lock()
+	n := len(s.items)
+	s.items = slices.DeleteFunc(s.items, func(it realtimeItem) bool { return it.ID == itemID })
+	found := len(s.items) < n
+	s.mu.Unlock()
+	if !found {
+		s.sendError(eventID, "invalid_request_error", "item_not_found", fmt.Sprintf("item %q not found", itemID))
+		return
+	}
+	s.send(map[string]any{"type": "conversation.item.deleted", "item_id": itemID})
+}
+
+// buildRealtimeRequest maps the conversation onto an IR request.
+func buildRealtimeRequest(cfg realtimeSessionConfig, items []realtimeItem) *ir.UnifiedChatRequest {
+	req := &ir.UnifiedChatRequest{Model: cfg.Model, Temperature: cfg.Temperature}
+	if n, ok := cfg.MaxResponseOutputTokens.(int64); ok {
+		tokens := int(n)
+		req.MaxTokens = &tokens
+	}
+	if cfg.Instructions != "" {
+		req.Messages = append(req.Messages, ir.Message{Role: ir.RoleSystem, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: cfg.Instructions}}})
+	}
+	for _, item := range items {
+		switch item.Type {
+		case "message":
+			msg := ir.Message{Role: ir.Role(item.Role)}
+			for _, c := range item.Content {
+				msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeText, Text: c.Text})
+			}
+			req.Messages = append(req.Messages, msg)
+		case "function_call":
+			call := ir.ToolCall{ID: item.CallID, Name: item.Name, Args: item.Arguments}
+			if last := len(req.Messages) - 1; last >= 0 && req.Messages[last].Role == ir.RoleAssistant {
+				req.Messages[last].ToolCalls = append(req.Messages[last].ToolCalls, call)
+				continue
+			}
+			req.Messages = append(req.Messages, ir.Message{Role: ir.RoleAssistant, ToolCalls: []ir.ToolCall{call}})
+		case "function_call_output":
+			req.Messages = append(req.Messages, ir.Message{Role: ir.RoleTool, Content: []ir.ContentPart{{
+				Type:       ir.ContentTypeToolResult,
+				ToolResult: &ir.ToolResultPart{ToolCallID: item.CallID, Result: item.Output},
+			}}})
+		}
+	}
+	for _, t := range cfg.Tools {
+		req.Tools = append(req.Tools, ir.ToolDefinition{Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+	}
+	switch choice := cfg.ToolChoice.(type) {
+	case string:
+		req.ToolChoice = choice
+	case map[string]any:
+		if name, _ := choice["name"].(string); name != "" {
+			req.ToolChoice = "function"
+			req.ToolChoiceFunction = name
+		}
+	}
+	return req
+}
+
+func (s *realtimeSession) createResponse(eventID string, overrides gjson.Result) {
+	s.mu.Lock()
+	if s.responseCancel != nil {
+		s.mu.Unlock()
+		s.sendError(eventID, "invalid_request_error", "conversation_already_has_active_response", "a response is already in progress")
+		return
+	}
+	cfg := s.config
+	if err := applyResponseSettings(&cfg, overrides); err != nil {
+		s.mu.Unlock()
+		s.sendError(eventID, "invalid_request_error", "invalid_response", err.Error())
+		return
+	}
+	if cfg.Model == "" {
+		s.mu.Unlock()
+		s.sendError(eventID, "invalid_request_error", "missing_model", "set a model with the model query parameter or session.update")
+		return
+	}
+	payload, err := from_ir.ToOpenAIRequest(buildRealtimeRequest(cfg, s.items))
+	if err == nil {
+		payload, err = sjson.SetBytes(payload, "stream", true)
+	}
+	if err == nil {
+		payload, err = sjson.SetBytes(payload, "stream_options.include_usage", true)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		s.sendError(eventID, "server_error", "conversion_failed", err.Error())
+		return
+	}
+	ctx, cancel := s.h.GetContextWithCancel(s.ctx, s.h, s.c)
+	s.responseCancel = func() { cancel() }
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		var execErr error
+		defer func() {
+			s.mu.Lock()
+			s.responseCancel = nil
+			s.mu.Unlock()
+			cancel(execErr)
+		}()
+		execErr = s.streamResponse(ctx, cfg.Model, payload)
+	}()
+}
+
+// realtimeResponse accumulates the output of one response.
+type realtimeResponse struct {
+	s     *realtimeSession
+	id    string
+	text  *realtimeItem
+	calls map[int]*realtimeItem
+	// order holds the output items by output_index.
+	order []*realtimeItem
+	usage *ir.Usage
+}
+
+func (r *realtimeResponse) addItem(item *realtimeItem) int {
+	r.order = append(r.order, item)
+	r.s.send(map[string]any{"type": "response.output_item.added", "response_id": r.id, "output_index": len(r.order) - 1, "item": item})
+	return len(r.order) - 1
+}
+
+func (r *realtimeResponse) outputIndex(item *realtimeItem) int {
+	return slices.Index(r.order, item)
+}
+
+func (r *realtimeResponse) handle(ev ir.UnifiedEvent) error {
+	switch ev.Type {
+	case ir.EventTypeToken:
+		delta := ev.Content
+		if delta == "" {
+			delta = ev.Refusal
+		}
+		if delta == "" {
+			return nil
+		}
+		if r.text == nil {
+			r.text = &realtimeItem{ID: realtimeID("item"), Object: "realtime.item", Type: "message", Status: "in_progress", Role: "assistant", Content: []realtimeContent{{Type: "text"}}}
+			idx := r.addItem(r.text)
+			r.s.send(map[string]any{"type": "response.content_part.added", "response_id": r.id, "item_id": r.text.ID, "output_index": idx, "content_index": 0, "part": realtimeContent{Type: "text"}})
+		}
+		r.text.Content[0].Text += delta
+		r.s.send(map[string]any{"type": "response.output_text.delta", "response_id": r.id, "item_id": r.text.ID, "output_index": r.outputIndex(r.text), "content_index": 0, "delta": delta})
+	case ir.EventTypeToolCall, ir.EventTypeToolCallDelta:
+		if ev.ToolCall == nil {
+			return nil
+		}
+		call, ok := r.calls[ev.ToolCallIndex]
+		if !ok {
+			callID := ev.ToolCall.ID
+			if callID == "" {
+				callID = realtimeID("call")
+			}
+			call = &realtimeItem{ID: realtimeID("item"), Object: "realtime.item", Type: "function_call", Status: "in_progress", CallID: callID, Name: ev.ToolCall.Name}
+			r.calls[ev.ToolCallIndex] = call
+			r.addItem(call)
+		}
+		if ev.ToolCall.Args != "" {
+			call.Arguments += ev.ToolCall.Args
+			r.s.send(map[string]any{"type": "response.function_call_arguments.delta", "response_id": r.id, "item_id": call.ID, "output_index": r.outputIndex(call), "call_id": call.CallID, "delta": ev.ToolCall.Args})
+		}
+	case ir.EventTypeFinish:
+		if ev.Usage != nil {
+			r.usage = ev.Usage
+		}
+	case ir.EventTypeError:
+		return fmt.Errorf("%s", ev.ErrorMessage())
+	}
+	return nil
+}
+
+// finish closes the open output items and returns them.
+func (r *realtimeResponse) finish() []realtimeItem {
+	output := make([]realtimeItem, 0, len(r.order))
+	for idx, item := range r.order {
+		item.Status = "completed"
+		if item == r.text {
+			r.s.send(map[string]any{"type": "response.output_text.done", "response_id": r.id, "item_id": item.ID, "output_index": idx, "content_index": 0, "text": item.Content[0].Text})
+			r.s.send(map[string]any{"type": "response.content_part.done", "response_id": r.id, "item_id": item.ID, "output_index": idx, "content_index": 0, "part": item.Content[0]})
+		} else {
+			if item.Arguments == "" {
+				item.Arguments = "{}"
+			}
+			r.s.send(map[string]any{"type": "response.function_call_arguments.done", "response_id": r.id, "item_id": item.ID, "output_index": idx, "call_id": item.CallID, "name": item.Name, "arguments": item.Arguments})
+		}
+		r.s.send(map[string]any{"type": "response.output_item.done", "response_id": r.id, "output_index": idx, "item": item})
+		output = append(output, *item)
+	}
+	return output
+}
+
+// streamResponse runs one response and appends its output to the
+// conversation unless it failed.
+func (s *realtimeSession) streamResponse(ctx context.Context, model string, payload []byte) error {
+	r := &realtimeResponse{s: s, id: realtimeID("resp"), calls: make(map[int]*realtimeItem)}
+	s.send(map[string]any{"type": "response.created", "response": map[string]any{"id": r.id, "object": "realtime.response", "status": "in_progress", "output": []any{}}})
+
+	dataChan, errChan := s.h.ExecuteStreamWithAuthManager(ctx, constant.OpenAI, model, payload, "")
+	var failure *interfaces.ErrorMessage
+	for dataChan != nil || errChan != nil {
+		select {
+		case <-ctx.Done():
+			dataChan, errChan = nil, nil
+		case chunk, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			for _, line := range bytes.Split(chunk, []byte("\n")) {
+				events, err := to_ir.ParseOpenAIChunk(line)
+				for _, ev := range events {
+					if err == nil {
+						err = r.handle(ev)
+					}
+				}
+				if err != nil && failure == nil {
+					failure = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err}
+				}
+			}
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			if errMsg != nil && failure == nil {
+				failure = errMsg
+			}
+		}
+	}
+
+	output := r.finish()
+	resp := map[string]any{"id": r.id, "object": "realtime.response", "output": output}
+	var result error
+	switch {
+	case failure != nil:
+		result = failure.Error
+		message := "upstream request failed"
+		if failure.Error != nil {
+			message = failure.Error.Error()
+		}
+		code := "upstream_error"
+		if failure.StatusCode > 0 {
+			code = fmt.Sprintf("http_%d", failure.StatusCode)
+		}
+		resp["status"] = "failed"
+		resp["status_details"] = map[string]any{"type": "failed", "error": map[string]any{"type": "server_error", "code": code, "message": message}}
+	case ctx.Err() != nil:
+		result = ctx.Err()
+		resp["status"] = "cancelled"
+		resp["status_details"] = map[string]any{"type": "cancelled", "reason": "client_cancelled"}
+	default:
+		resp["status"] = "completed"
+	}
+	if r.usage != nil {
+		resp["usage"] = map[string]any{"input_tokens": r.usage.PromptTokens, "output_tokens": r.usage.CompletionTokens, "total_tokens": r.usage.TotalTokens}
+	}
+	if failure == nil && len(output) > 0 {
+		s.mu.Lock()
+		s.items = append(s.items, output...)
+		s.mu.Unlock()
+		for _, item := range output {
+			s.send(map[string]any{"type": "conversation.item.created", "item": item})
+		}
+	}
+	s.send(map[string]any{"type": "response.done", "response": resp})
+	return result
+}
@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nghyane/llm-mux/internal/api/handlers/format"
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+// realtimeExecutor streams a fixed chat completion and records the last
+// request payload.
+type realtimeExecutor struct {
+	payloads chan []byte
+}
+
+func (e *realtimeExecutor) Identifier() string { return "realtime-test" }
+
+func (e *realtimeExecutor) Execute(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
+	return provider.Response{}, fmt.Errorf("not implemented")
+}
+
+func (e *realtimeExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (<-chan provider.StreamChunk, error) {
+	e.payloads <- req.Payload
+	out := make(chan provider.StreamChunk, 4)
+	out <- provider.StreamChunk{Payload: []byte(`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`)}
+	out <- provider.StreamChunk{Payload: []byte(`data: {"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`)}
+	out <- provider.StreamChunk{Payload: []byte(`data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9}}`)}
+	close(out)
+	return out, nil
+}
+
+func (e *realtimeExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
+	return auth, nil
+}
+
+func (e *realtimeExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
+	return provider.Response{}, nil
+}
+
+func TestRealtimeSession_TextResponse(t *testing.T) {
+	manager := provider.NewManager(nil, nil, nil)
+	t.Cleanup(manager.Stop)
+	exec := &realtimeExecutor{payloads: make(chan []byte, 1)}
+	manager.RegisterExecutor(exec)
+	_, _ = manager.Register(context.Background(), &provider.Auth{ID: "realtime-auth", Provider: "realtime-test", Status: provider.StatusActive})
+	registry.GetGlobalRegistry().RegisterClient("realtime-auth", "realtime-test", []*registry.ModelInfo{{ID: "realtime-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("realtime-auth") })
+
+	handler := NewOpenAIRealtimeAPIHandler(format.NewBaseAPIHandlers(&config.SDKConfig{}, nil, manager, nil))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?model=realtime-test-model", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	next := func() gjson.Result {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return gjson.ParseBytes(data)
+	}
+	expect := func(typ string) gjson.Result {
+		t.Helper()
+		for {
+			ev := next()
+			if ev.Get("type").String() == "error" {
+				t.Fatalf("error event: %s", ev.Raw)
+			}
+			if ev.Get("type").String() == typ {
+				return ev
+			}
+		}
+	}
+
+	if ev := expect("session.created"); ev.Get("session.model").String() != "realtime-test-model" {
+		t.Errorf("session = %s", ev.Get("session").Raw)
+	}
+	_ = conn.WriteJSON(map[string]any{"type": "session.update", "session": map[string]any{"instructions": "Be brief."}})
+	expect("session.updated")
+	_ = conn.WriteJSON(map[string]any{"type": "conversation.item.create", "item": map[string]any{
+		"type": "message", "role": "user", "content": []any{map[string]any{"type": "input_text", "text": "Say hello"}},
+	}})
+	expect("conversation.item.created")
+	_ = conn.WriteJSON(map[string]any{"type": "response.create"})
+
+	var text strings.Builder
+	for {
+		ev := next()
+		switch ev.Get("type").String() {
+		case "response.output_text.delta":
+			text.WriteString(ev.Get("delta").String())
+			continue
+		case "error":
+			t.Fatalf("error event: %s", ev.Raw)
+		case "response.done":
+			if got := ev.Get("response.status").String(); got != "completed" {
+				t.Fatalf("status = %s: %s", got, ev.Raw)
+			}
+			if got := ev.Get("response.usage.total_tokens").Int(); got != 9 {
+				t.Errorf("total_tokens = %d", got)
+			}
+			if got := ev.Get("response.output.0.content.0.text").String(); got != "Hello" {
+				t.Errorf("output text = %q", got)
+			}
+		default:
+			continue
+		}
+		break
+	}
+	if text.String() != "Hello" {
+		t.Errorf("deltas = %q, want Hello", text.String())
+	}
+
+	payload := gjson.ParseBytes(<-exec.payloads)
+	if got := payload.Get("messages.#").Int(); got != 2 {
+		t.Fatalf("messages = %s", payload.Get("messages").Raw)
+	}
+	if payload.Get("messages.0.role").String() != "system" || !payload.Get("stream").Bool() {
+		t.Errorf("payload = %s", payload.Raw)
+	}
+}
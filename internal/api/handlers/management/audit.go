@@ -0,0 +1,190 @@
+package management
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/audit"
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	auditTargetKey = "auditTarget"
+	// auditBodyLimit caps how much of an error response is kept for the entry.
+	auditBodyLimit = 4096
+)
+
+// setAuditTarget names the object a mutation acts on when it is not in the URL.
+func setAuditTarget(c *gin.Context, target string) {
+	if target != "" {
+		c.Set(auditTargetKey, target)
+	}
+}
+
+// auditCapture keeps the start of the response body so failures can be recorded.
+type auditCapture struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *auditCapture) Write(b []byte) (int, error) {
+	if room := auditBodyLimit - w.buf.Len(); room > 0 {
+		w.buf.Write(b[:min(len(b), room)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditCapture) WriteString(s string) (int, error) {
+	if room := auditBodyLimit - w.buf.Len(); room > 0 {
+		w.buf.WriteString(s[:min(len(s), room)])
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// isMutation reports whether the request can change server state.
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// auditRequest runs the remaining handlers and records the mutation with the
+// state diff it caused.
+func (h *Handler) auditRequest(c *gin.Context, actor *managementActor) {
+	before := h.auditSnapshot()
+	capture := &auditCapture{ResponseWriter: c.Writer}
+	c.Writer = capture
+
+	c.Next()
+
+	c.Writer = capture.ResponseWriter
+	status := c.Writer.Status()
+	entry := audit.Entry{
+		Time:     time.Now().UTC(),
+		Actor:    actor.Name,
+		ClientIP: c.ClientIP(),
+		Action:   c.Request.Method + " " + strings.TrimPrefix(c.FullPath(), "/v1/management"),
+		Target:   auditTarget(c),
+		Changes:  audit.Diff(before, h.auditSnapshot()),
+		Result:   audit.ResultSuccess,
+		Status:   status,
+	}
+	if status >= http.StatusBadRequest {
+		entry.Result = audit.ResultFailure
+		body := capture.buf.Bytes()
+		for _, path := range []string{"error.message", "error"} {
+			if v := gjson.GetBytes(body, path); v.Type == gjson.String {
+				entry.Error = v.String()
+				break
+			}
+		}
+	}
+	h.auditLog.Record(c.Request.Context(), entry)
+	logManagementAction(c, actor)
+}
+
+func auditTarget(c *gin.Context) string {
+	if v, ok := c.Get(auditTargetKey); ok {
+		if s, _ := v.(string); s != "" {
+			return s
+		}
+	}
+	for _, p := range []string{"name", "state"} {
+		if v := c.Param(p); v != "" {
+			return v
+		}
+	}
+	// "value" is deliberately skipped: api-key deletes pass the key itself.
+	for _, q := range []string{"id", "name", "provider", "index"} {
+		if v := c.Query(q); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// auditSnapshot captures the state management calls can change: the config,
+// the registered auths and the management tokens.
+func (h *Handler) auditSnapshot() map[string]any {
+	snap := map[string]any{}
+	if cfg := h.getConfig(); cfg != nil {
+		snap["config"] = audit.Snapshot(cfg)
+	}
+	if h.authManager != nil {
+		auths := map[string]any{}
+		for _, a := range h.authManager.List() {
+			auths[a.ID] = map[string]any{
+				"provider":  a.Provider,
+				"label":     a.Label,
+				"disabled":  a.Disabled,
+				"proxy_url": a.ProxyURL,
+			}
+		}
+		snap["auths"] = auths
+	}
+	tokens := map[string]any{}
+	now := time.Now()
+	for _, t := range config.ListManagementTokens() {
+		tokens[t.Name] = newManagementTokenView(&t, now)
+	}
+	snap["tokens"] = audit.Snapshot(tokens)
+	return snap
+}
+
+// GetAuditLog returns audit entries, newest first. Query parameters: since,
+// until (RFC3339 or YYYY-MM-DD), actor, action, target, result and limit.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	f := audit.Filter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		Result: c.Query("result"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			continue
+		}
+		t, err := parseAuditTime(raw)
+		if err != nil {
+			respondBadRequest(c, "invalid "+name+": use RFC3339 or YYYY-MM-DD")
+			return
+		}
+		*dst = t
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			respondBadRequest(c, "invalid limit")
+			return
+		}
+		f.Limit = n
+	}
+	if !h.auditLog.Enabled() {
+		respondError(c, http.StatusNotFound, ErrCodeNotFound, "audit log is disabled")
+		return
+	}
+	entries, err := h.auditLog.Query(c.Request.Context(), f)
+	if err != nil {
+		respondInternalError(c, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	respondOK(c, gin.H{"entries": entries})
+}
+
+func parseAuditTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, raw, time.Local)
+}
@@ -118,6 +118,7 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 	for _, auth := range auths {
 		if entry := h.buildAuthFileEntry(auth); entry != nil {
 			h.enrichWithQuotaState(entry, auth.ID, quotaManager, now)
+			h.enrichWithHealth(entry, auth.ID)
 			files = append(files, entry)
 		}
 	}
@@ -129,6 +130,15 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 	respondOK(c, gin.H{"files": files})
 }
 
+// enrichWithHealth adds the health prober history, when the auth has been probed.
+func (h *Handler) enrichWithHealth(entry gin.H, authID string) {
+	if e := h.authManager.GetAuthEntry(authID); e != nil {
+		if health := e.Health(); health != nil {
+			entry["health"] = health
+		}
+	}
+}
+
 func (h *Handler) enrichWithQuotaState(entry gin.H, authID string, qm *provider.QuotaManager, now time.Time) {
 	if qm == nil {
 		return
@@ -182,6 +192,15 @@ func (h *Handler) enrichWithQuotaState(entry gin.H, authID string, qm *provider.
 		if !state.RealQuota.FetchedAt.IsZero() {
 			realQuota["fetched_at"] = state.RealQuota.FetchedAt
 		}
+		if state.RealQuota.Source != "" {
+			realQuota["source"] = state.RealQuota.Source
+		}
+		if state.RealQuota.Tier != "" {
+			realQuota["tier"] = state.RealQuota.Tier
+		}
+		if len(state.RealQuota.Groups) > 0 {
+			realQuota["groups"] = state.RealQuota.Groups
+		}
 		if len(realQuota) > 0 {
 			qs["real_quota"] = realQuota
 		}
@@ -640,6 +659,29 @@ func (h *Handler) RefreshAuthFile(c *gin.Context) {
 	respondOK(c, gin.H{"status": "ok", "message": "refresh triggered"})
 }
 
+// CheckAuthHealth runs a health probe for one auth and returns its health history.
+func (h *Handler) CheckAuthHealth(c *gin.Context) {
+	if h.authManager == nil {
+		respondError(c, http.StatusServiceUnavailable, ErrCodeInternalError, "core auth manager unavailable")
+		return
+	}
+	id := c.Query("id")
+	if id == "" {
+		id = c.Query("name")
+	}
+	if id == "" {
+		respondBadRequest(c, "id or name is required")
+		return
+	}
+	health, err := h.authManager.ProbeAuthHealth(c.Request.Context(), id)
+	if err != nil {
+		respondNotFound(c, err.Error())
+		return
+	}
+	setAuditTarget(c, id)
+	respondOK(c, gin.H{"id": id, "health": health})
+}
+
 func (h *Handler) authIDForPath(path string) string {
 	path = strings.TrimSpace(path)
 	if path == "" {
@@ -6,16 +6,18 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/registry"
 	"github.com/nghyane/llm-mux/internal/usage"
 	"github.com/nghyane/llm-mux/internal/util"
-	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -38,9 +40,13 @@ func (h *Handler) GetConfig(c *gin.Context) {
 		return
 	}
 	h.cfgMu.RLock()
-	cfgCopy := *cfg
+	cfgCopy, err := cfg.WithSecretRefs()
 	h.cfgMu.RUnlock()
-	respondOK(c, &cfgCopy)
+	if err != nil {
+		respondInternalError(c, err.Error())
+		return
+	}
+	respondOK(c, cfgCopy)
 }
 
 type releaseInfo struct {
@@ -159,36 +165,20 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		respondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "cannot read request body")
 		return
 	}
-	var cfg config.Config
-	if err = yaml.Unmarshal(body, &cfg); err != nil {
-		respondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	// Refuse file references before validation reads them.
+	if paths := config.FileSecretRefs(body); len(paths) > 0 {
+		respondError(c, http.StatusForbidden, ErrCodeForbidden, "file: secret references can only be set in the config file on disk: "+strings.Join(paths, ", "))
 		return
 	}
-	// Validate config using LoadConfigOptional with optional=false to enforce parsing
-	tmpDir := filepath.Dir(h.configFilePath)
-	tmpFile, err := os.CreateTemp(tmpDir, "config-validate-*.yaml")
-	if err != nil {
-		respondError(c, http.StatusInternalServerError, ErrCodeWriteFailed, err.Error())
+	// Reject the config before persisting it; the watcher would otherwise
+	// refuse to apply it and the file on disk would no longer match memory.
+	newCfg, issues := config.ValidateConfigFile(h.configFilePath, body, config.ValidateOptions{KnownModels: registry.KnownModelIDs()})
+	if config.ValidationErr(issues) != nil {
+		respondInvalidConfig(c, issues)
 		return
 	}
-	tempFile := tmpFile.Name()
-	if _, errWrite := tmpFile.Write(body); errWrite != nil {
-		_ = tmpFile.Close()
-		_ = os.Remove(tempFile)
-		respondError(c, http.StatusInternalServerError, ErrCodeWriteFailed, errWrite.Error())
-		return
-	}
-	if errClose := tmpFile.Close(); errClose != nil {
-		_ = os.Remove(tempFile)
-		respondError(c, http.StatusInternalServerError, ErrCodeWriteFailed, errClose.Error())
-		return
-	}
-	defer func() {
-		_ = os.Remove(tempFile)
-	}()
-	_, err = config.LoadConfigOptional(tempFile, false)
-	if err != nil {
-		respondError(c, http.StatusUnprocessableEntity, ErrCodeInvalidConfig, err.Error())
+	if names := changedCommandSinks(h.getConfig(), newCfg); len(names) > 0 {
+		respondError(c, http.StatusForbidden, ErrCodeForbidden, "command event sinks can only be changed in the config file on disk: "+strings.Join(names, ", "))
 		return
 	}
 	h.mu.Lock()
@@ -198,7 +188,7 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		return
 	}
 	// Reload into handler to keep memory in sync
-	newCfg, err := config.LoadConfig(h.configFilePath)
+	newCfg, err = config.LoadConfig(h.configFilePath)
 	if err != nil {
 		respondError(c, http.StatusInternalServerError, ErrCodeReloadFailed, err.Error())
 		return
@@ -206,11 +196,39 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	h.cfgMu.Lock()
 	h.cfg = newCfg
 	h.cfgMu.Unlock()
-	respondOK(c, gin.H{"ok": true, "changed": []string{"config"}})
+	resp := gin.H{"ok": true, "changed": []string{"config"}}
+	if len(issues) > 0 {
+		resp["warnings"] = issues
+	}
+	respondOK(c, resp)
+}
+
+// changedCommandSinks returns the command event sinks of next that are not
+// configured identically in current. Command sinks run programs on the
+// server, so management uploads may keep them but not add or change them.
+func changedCommandSinks(current, next *config.Config) []string {
+	if next == nil {
+		return nil
+	}
+	var existing []config.EventSink
+	if current != nil {
+		existing = current.Events.Sinks
+	}
+	var names []string
+	for _, sink := range next.Events.Sinks {
+		if !strings.EqualFold(strings.TrimSpace(sink.Type), config.EventSinkCommand) {
+			continue
+		}
+		if !slices.ContainsFunc(existing, func(e config.EventSink) bool { return reflect.DeepEqual(e, sink) }) {
+			names = append(names, sink.Name)
+		}
+	}
+	return names
 }
 
 // GetConfigYAML returns the raw config.yaml file bytes without re-encoding.
-// It preserves comments and original formatting/styles.
+// It preserves comments and original formatting/styles. With ?effective=true
+// it returns config.yaml merged with its include: files and config.d drop-ins.
 func (h *Handler) GetConfigYAML(c *gin.Context) {
 	data, err := os.ReadFile(h.configFilePath)
 	if err != nil {
@@ -221,6 +239,18 @@ func (h *Handler) GetConfigYAML(c *gin.Context) {
 		respondInternalError(c, err.Error())
 		return
 	}
+	if effective, _ := strconv.ParseBool(c.Query("effective")); effective {
+		set, errSet := config.ReadConfigSet(h.configFilePath, data)
+		if errSet != nil {
+			respondInternalError(c, errSet.Error())
+			return
+		}
+		if data, err = set.EffectiveYAML(); err != nil {
+			respondInternalError(c, err.Error())
+			return
+		}
+		c.Header("X-Config-Files", strings.Join(set.Files(), ","))
+	}
 	c.Header("Content-Type", "application/yaml; charset=utf-8")
 	c.Header("Cache-Control", "no-store")
 	c.Header("X-Content-Type-Options", "nosniff")
@@ -0,0 +1,64 @@
+package management
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/events"
+)
+
+type eventSinkView struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ListEventSinks returns the configured event sinks and the known event types.
+func (h *Handler) ListEventSinks(c *gin.Context) {
+	sinks := []eventSinkView{}
+	for _, s := range events.Default().Sinks() {
+		sinks = append(sinks, eventSinkView{Name: s.Name(), Type: s.Type()})
+	}
+	respondOK(c, gin.H{"sinks": sinks, "types": events.Types})
+}
+
+// TestEventSinks fires a sample event at one sink (body "sink") or all sinks,
+// bypassing debouncing and per-sink filters. "type" selects the event type to
+// preview; it defaults to "test".
+func (h *Handler) TestEventSinks(c *gin.Context) {
+	var body struct {
+		Sink string `json:"sink"`
+		Type string `json:"type"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			respondBadRequest(c, "invalid body: expected {\"sink\": string, \"type\": string}")
+			return
+		}
+	}
+	typ := events.Test
+	if t := strings.TrimSpace(body.Type); t != "" {
+		if !slices.Contains(events.Types, events.Type(t)) {
+			respondBadRequest(c, "unknown event type "+t)
+			return
+		}
+		typ = events.Type(t)
+	}
+	setAuditTarget(c, body.Sink)
+	e := events.Event{
+		Type:     typ,
+		Severity: events.SeverityInfo,
+		Provider: "example",
+		Message:  "test event from llm-mux (sent by " + ActorFromContext(c) + ")",
+	}
+	results, err := events.Default().Fire(c.Request.Context(), strings.TrimSpace(body.Sink), e)
+	if err != nil {
+		if body.Sink != "" {
+			respondNotFound(c, err.Error())
+			return
+		}
+		respondBadRequest(c, err.Error())
+		return
+	}
+	respondOK(c, gin.H{"results": results})
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/audit"
 	"github.com/nghyane/llm-mux/internal/auth/login"
 	"github.com/nghyane/llm-mux/internal/buildinfo"
 	"github.com/nghyane/llm-mux/internal/config"
@@ -41,17 +42,21 @@ type Handler struct {
 	logDir         string
 	httpClient     *http.Client
 	httpClientOnce sync.Once
+	auditLog       *audit.Logger
 }
 
 func NewHandler(cfg *config.Config, configFilePath string, manager *provider.Manager) *Handler {
-	return &Handler{
+	h := &Handler{
 		cfg:            cfg,
 		configFilePath: configFilePath,
 		failedAttempts: make(map[string]*attemptInfo),
 		authManager:    manager,
 		usagePlugin:    usage.GetLoggerPlugin(),
 		tokenStore:     login.GetTokenStore(),
+		auditLog:       audit.Default(),
 	}
+	h.auditLog.Configure(cfg)
+	return h
 }
 
 // SetConfig updates the in-memory config reference when the server hot-reloads.
@@ -59,6 +64,7 @@ func (h *Handler) SetConfig(cfg *config.Config) {
 	h.cfgMu.Lock()
 	h.cfg = cfg
 	h.cfgMu.Unlock()
+	h.auditLog.Configure(cfg)
 }
 
 // getConfig returns the current config with proper synchronization.
@@ -98,7 +104,8 @@ func (h *Handler) SetLogDirectory(dir string) {
 }
 
 // Middleware enforces access control for management endpoints.
-// All requests (local and remote) require a valid management key.
+// All requests (local and remote) require the management key or a named
+// management token; per-route scopes are checked by RequireScope.
 // Additionally, remote access requires allow-remote-management=true.
 // Key priority: MANAGEMENT_PASSWORD env > $XDG_CONFIG_HOME/llm-mux/credentials.json
 func (h *Handler) Middleware() gin.HandlerFunc {
@@ -166,13 +173,6 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			}
 		}
 
-		// Check if management key is configured
-		if managementKey == "" {
-			respondError(c, http.StatusForbidden, ErrCodeForbidden, "management key not configured")
-			c.Abort()
-			return
-		}
-
 		// Accept either Authorization: Bearer <key> or X-Management-Key
 		var provided string
 		if ah := c.GetHeader("Authorization"); ah != "" {
@@ -196,18 +196,35 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		// For localhost, also accept the runtime local password
-		if localClient {
-			if lp := h.localPassword; lp != "" {
-				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
-					c.Next()
+		var actor *managementActor
+		switch {
+		case localClient && h.localPassword != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(h.localPassword)) == 1:
+			// For localhost, also accept the runtime local password
+			actor = &managementActor{Name: actorLocalPassword, Scopes: []string{config.ManagementScopeAdmin}}
+		case managementKey != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(managementKey)) == 1:
+			actor = &managementActor{Name: actorManagementKey, Scopes: []string{config.ManagementScopeAdmin}}
+		default:
+			if tok := config.FindManagementToken(provided); tok != nil {
+				// Known but unusable tokens don't count towards the IP ban.
+				if tok.Revoked() {
+					respondUnauthorized(c, fmt.Sprintf("management token %q has been revoked", tok.Name))
+					c.Abort()
 					return
 				}
+				if tok.Expired(time.Now()) {
+					respondUnauthorized(c, fmt.Sprintf("management token %q has expired", tok.Name))
+					c.Abort()
+					return
+				}
+				actor = &managementActor{Name: tok.Name, Scopes: tok.Scopes}
 			}
 		}
-
-		// Validate against management key using constant-time comparison
-		if subtle.ConstantTimeCompare([]byte(provided), []byte(managementKey)) != 1 {
+		if actor == nil {
+			if managementKey == "" && len(config.ListManagementTokens()) == 0 {
+				respondError(c, http.StatusForbidden, ErrCodeForbidden, "management key not configured")
+				c.Abort()
+				return
+			}
 			if !localClient {
 				fail()
 			}
@@ -226,6 +243,11 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
+		c.Set(managementActorKey, actor)
+		if isMutation(c.Request.Method) {
+			h.auditRequest(c, actor)
+			return
+		}
 		c.Next()
 	}
 }
@@ -236,6 +258,10 @@ func (h *Handler) persist(c *gin.Context) bool {
 	defer h.mu.Unlock()
 	// Preserve comments when writing
 	cfg := h.getConfig()
+	if paths := cfg.UnresolvedFileRefs(); len(paths) > 0 {
+		respondError(c, http.StatusForbidden, ErrCodeForbidden, "file: secret references can only be set in the config file on disk: "+strings.Join(paths, ", "))
+		return false
+	}
 	if err := config.SaveConfigPreserveComments(h.configFilePath, cfg); err != nil {
 		respondError(c, http.StatusInternalServerError, ErrCodeWriteFailed, fmt.Sprintf("failed to save config: %v", err))
 		return false
@@ -250,6 +276,9 @@ func (h *Handler) persistSilent() bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	cfg := h.getConfig()
+	if len(cfg.UnresolvedFileRefs()) > 0 {
+		return false
+	}
 	return config.SaveConfigPreserveComments(h.configFilePath, cfg) == nil
 }
 
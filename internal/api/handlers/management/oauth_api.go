@@ -84,6 +84,7 @@ func (h *Handler) OAuthStart(c *gin.Context) {
 
 	// Normalize provider name
 	providerName := normalizeProvider(req.Provider)
+	setAuditTarget(c, providerName)
 
 	// Handle device flow providers separately
 	switch providerName {
@@ -471,6 +472,7 @@ func (h *Handler) OAuthComplete(c *gin.Context) {
 	}
 
 	providerName := oauthReq.Provider
+	setAuditTarget(c, providerName)
 	ctx := c.Request.Context()
 
 	callback := &oauthCallbackData{Code: req.Code}
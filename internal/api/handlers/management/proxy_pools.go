@@ -0,0 +1,30 @@
+package management
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/proxypool"
+)
+
+// GetProxyPools returns the health of every proxy pool member and the member
+// each pooled auth currently uses.
+func (h *Handler) GetProxyPools(c *gin.Context) {
+	pools := proxypool.Default().Status()
+	assignments := make([]gin.H, 0)
+	if h.authManager != nil {
+		for _, auth := range h.authManager.List() {
+			if auth == nil {
+				continue
+			}
+			name, ok := proxypool.PoolName(auth.ProxyURL)
+			if !ok {
+				continue
+			}
+			entry := gin.H{"id": auth.ID, "provider": auth.Provider, "pool": name}
+			if member, found := proxypool.Default().Resolve(name, auth.ID); found {
+				entry["proxy"] = proxypool.Redact(member)
+			}
+			assignments = append(assignments, entry)
+		}
+	}
+	respondOK(c, gin.H{"pools": pools, "auths": assignments})
+}
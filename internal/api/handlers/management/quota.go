@@ -1,6 +1,12 @@
 package management
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
 
 func (h *Handler) GetSwitchProject(c *gin.Context) {
 	respondOK(c, gin.H{"switch-project": h.cfg.QuotaExceeded.SwitchProject})
@@ -33,3 +39,72 @@ func (h *Handler) PutSwitchPreviewModel(c *gin.Context) {
 	}
 	respondOK(c, gin.H{"switch-preview-model": h.cfg.QuotaExceeded.SwitchPreviewModel})
 }
+
+// GetQuota reports the remaining quota and reset time of every auth, with the
+// per model group or rate-limit window details reported by the upstream.
+// ?provider= filters by provider; ?refresh=true fetches fresh data first.
+func (h *Handler) GetQuota(c *gin.Context) {
+	if h.authManager == nil {
+		respondError(c, http.StatusServiceUnavailable, ErrCodeInternalError, "core auth manager unavailable")
+		return
+	}
+	providerName := c.Query("provider")
+	if c.Query("refresh") == "true" {
+		h.authManager.RefreshQuotas(c.Request.Context(), providerName)
+	}
+	qm := h.authManager.GetQuotaManager()
+	now := time.Now()
+	auths := make([]gin.H, 0)
+	for _, auth := range h.authManager.List() {
+		if auth == nil || (providerName != "" && auth.Provider != providerName) {
+			continue
+		}
+		entry := gin.H{
+			"id":       auth.ID,
+			"provider": auth.Provider,
+		}
+		if auth.Label != "" {
+			entry["label"] = auth.Label
+		}
+		if auth.Disabled {
+			entry["disabled"] = true
+		}
+		if qm != nil {
+			if state := qm.GetState(auth.ID); state != nil {
+				if now.Before(state.CooldownUntil) {
+					entry["in_cooldown"] = true
+					entry["cooldown_until"] = state.CooldownUntil
+				}
+				if rq := state.RealQuota; rq != nil {
+					entry["source"] = rq.Source
+					entry["remaining_fraction"] = rq.RemainingFraction
+					entry["fetched_at"] = rq.FetchedAt
+					if rq.Tier != "" {
+						entry["tier"] = rq.Tier
+					}
+					if rq.RemainingTokens > 0 {
+						entry["remaining"] = rq.RemainingTokens
+					}
+					if !rq.WindowResetAt.IsZero() {
+						entry["reset_at"] = rq.WindowResetAt
+					}
+					if len(rq.Groups) > 0 {
+						entry["groups"] = rq.Groups
+					}
+				}
+			}
+		}
+		auths = append(auths, entry)
+	}
+	sort.Slice(auths, func(i, j int) bool {
+		pi, _ := auths[i]["provider"].(string)
+		pj, _ := auths[j]["provider"].(string)
+		if pi != pj {
+			return pi < pj
+		}
+		ii, _ := auths[i]["id"].(string)
+		ij, _ := auths[j]["id"].(string)
+		return ii < ij
+	})
+	respondOK(c, gin.H{"auths": auths})
+}
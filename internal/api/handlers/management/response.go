@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nghyane/llm-mux/internal/buildinfo"
+	"github.com/nghyane/llm-mux/internal/config"
 )
 
 // APIResponse is the standard response envelope for v1 management API.
@@ -30,6 +31,8 @@ type APIError struct {
 type APIErrorDetail struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// Issues lists every problem found when Code is INVALID_CONFIG.
+	Issues []config.ValidationIssue `json:"issues,omitempty"`
 }
 
 // Standard error codes for management API.
@@ -66,6 +69,21 @@ func respondError(c *gin.Context, status int, code, message string) {
 	})
 }
 
+// respondInvalidConfig sends a 422 listing the validation issues of a rejected config.
+func respondInvalidConfig(c *gin.Context, issues []config.ValidationIssue) {
+	message := "config validation failed"
+	if err := config.ValidationErr(issues); err != nil {
+		message = err.Error()
+	}
+	c.JSON(http.StatusUnprocessableEntity, APIError{
+		Error: APIErrorDetail{
+			Code:    ErrCodeInvalidConfig,
+			Message: message,
+			Issues:  issues,
+		},
+	})
+}
+
 // respondBadRequest sends a 400 Bad Request error.
 func respondBadRequest(c *gin.Context, message string) {
 	respondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, message)
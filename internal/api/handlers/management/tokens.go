@@ -0,0 +1,153 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+const (
+	managementActorKey = "managementActor"
+	// Actor names for the built-in credentials.
+	actorManagementKey = "management-key"
+	actorLocalPassword = "local-password"
+)
+
+// managementActor identifies who is calling the management API.
+type managementActor struct {
+	Name   string
+	Scopes []string
+}
+
+func (a *managementActor) hasScope(scope string) bool {
+	return slices.Contains(a.Scopes, config.ManagementScopeAdmin) || slices.Contains(a.Scopes, scope)
+}
+
+// ActorFromContext returns the name of the credential that authenticated the
+// current management request, or "" outside the management middleware.
+func ActorFromContext(c *gin.Context) string {
+	if actor := actorFromContext(c); actor != nil {
+		return actor.Name
+	}
+	return ""
+}
+
+func actorFromContext(c *gin.Context) *managementActor {
+	v, ok := c.Get(managementActorKey)
+	if !ok {
+		return nil
+	}
+	actor, _ := v.(*managementActor)
+	return actor
+}
+
+// RequireScope rejects management requests whose credential lacks scope.
+// It must run after Middleware.
+func (h *Handler) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := actorFromContext(c)
+		if actor == nil || !actor.hasScope(scope) {
+			name := "anonymous"
+			if actor != nil {
+				name = actor.Name
+			}
+			respondError(c, http.StatusForbidden, ErrCodeForbidden, fmt.Sprintf("token %q lacks the %q scope", name, scope))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// logManagementAction logs a state-changing management call with the actor that made it.
+func logManagementAction(c *gin.Context, actor *managementActor) {
+	log.Infof("management: %s %s by %s from %s -> %d", c.Request.Method, c.FullPath(), actor.Name, c.ClientIP(), c.Writer.Status())
+}
+
+type managementTokenView struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func newManagementTokenView(t *config.ManagementToken, now time.Time) managementTokenView {
+	status := "active"
+	switch {
+	case t.Revoked():
+		status = "revoked"
+	case t.Expired(now):
+		status = "expired"
+	}
+	return managementTokenView{
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		Status:    status,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+	}
+}
+
+// ListManagementTokens returns all named management tokens without their secrets.
+func (h *Handler) ListManagementTokens(c *gin.Context) {
+	now := time.Now()
+	tokens := config.ListManagementTokens()
+	out := make([]managementTokenView, 0, len(tokens))
+	for i := range tokens {
+		out = append(out, newManagementTokenView(&tokens[i], now))
+	}
+	respondOK(c, gin.H{"tokens": out, "scopes": config.ManagementScopes})
+}
+
+// CreateManagementToken creates a named token. The secret is only returned here.
+func (h *Handler) CreateManagementToken(c *gin.Context) {
+	var body struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn string   `json:"expires_in"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBadRequest(c, "invalid body: expected {\"name\": string, \"scopes\": [string], \"expires_in\": duration}")
+		return
+	}
+	var ttl time.Duration
+	if strings.TrimSpace(body.ExpiresIn) != "" {
+		d, err := time.ParseDuration(strings.TrimSpace(body.ExpiresIn))
+		if err != nil || d <= 0 {
+			respondBadRequest(c, fmt.Sprintf("invalid expires_in %q", body.ExpiresIn))
+			return
+		}
+		ttl = d
+	}
+	setAuditTarget(c, body.Name)
+	secret, tok, err := config.CreateManagementToken(body.Name, body.Scopes, ttl)
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	respondOK(c, gin.H{"token": secret, "info": newManagementTokenView(tok, time.Now())})
+}
+
+// RevokeManagementToken revokes a named token.
+func (h *Handler) RevokeManagementToken(c *gin.Context) {
+	name := c.Param("name")
+	if err := config.RevokeManagementToken(name); err != nil {
+		if errors.Is(err, config.ErrManagementTokenNotFound) {
+			respondNotFound(c, err.Error())
+			return
+		}
+		respondInternalError(c, err.Error())
+		return
+	}
+	respondOK(c, gin.H{"status": "revoked", "name": name})
+}
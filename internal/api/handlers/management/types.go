@@ -37,6 +37,8 @@ type CacheSummary struct {
 	CacheCreationTokens int64   `json:"cache_creation_tokens"`
 	CacheReadTokens     int64   `json:"cache_read_tokens"`
 	CacheHitRate        float64 `json:"cache_hit_rate"`
+	// SavingsUSD is the estimated net saving from cache reads minus cache-write premiums.
+	SavingsUSD float64 `json:"savings_usd,omitempty"`
 }
 
 // UsageProviderStats represents per-provider statistics.
@@ -110,10 +110,15 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 		log.Warnf("usage: failed to query model stats: %v", err)
 	} else if len(modelStats) > 0 {
 		byModel := make(map[string]UsageModelStats, len(modelStats))
-		var totalCost float64
+		var totalCost, totalSavings float64
 		for _, ms := range modelStats {
 			modelCost := usage.CalculateCostUSD(ms.Model, ms.InputTokens, ms.OutputTokens, 0)
 			totalCost += modelCost
+			cache := buildCacheSummary(ms.CacheCreationInputTokens, ms.CacheReadInputTokens, ms.InputTokens)
+			if cache != nil {
+				cache.SavingsUSD = usage.CalculateCacheSavingsUSD(ms.Model, ms.CacheCreationInputTokens, ms.CacheReadInputTokens)
+				totalSavings += cache.SavingsUSD
+			}
 			byModel[ms.Model] = UsageModelStats{
 				Provider: ms.Provider,
 				Requests: ms.Requests,
@@ -125,12 +130,15 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 					Output:    ms.OutputTokens,
 					Reasoning: ms.ReasoningTokens,
 				},
-				Cache:   buildCacheSummary(ms.CacheCreationInputTokens, ms.CacheReadInputTokens, ms.InputTokens),
+				Cache:   cache,
 				CostUSD: modelCost,
 			}
 		}
 		response.ByModel = byModel
 		response.Summary.CostUSD = totalCost
+		if response.Summary.Cache != nil {
+			response.Summary.Cache.SavingsUSD = totalSavings
+		}
 	}
 
 	if ipStats, err := backend.QueryIPStats(ctx, from); err != nil {
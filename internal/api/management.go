@@ -2,6 +2,10 @@
 package api
 
 import (
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/api/handlers/format/claude"
+	managementHandlers "github.com/nghyane/llm-mux/internal/api/handlers/management"
+	"github.com/nghyane/llm-mux/internal/config"
 	log "github.com/nghyane/llm-mux/internal/logging"
 )
 
@@ -20,74 +24,113 @@ func (s *Server) registerManagementRoutes() {
 
 	mgmt := s.engine.Group("/v1/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+
+	// Read-only usage and status.
+	read := mgmt.Group("", s.mgmt.RequireScope(config.ManagementScopeRead))
+	{
+		read.GET("/usage", s.mgmt.GetUsageStatistics)
+		read.GET("/latest-version", s.mgmt.GetLatestVersion)
+		read.GET("/auth-files", s.mgmt.ListAuthFiles)
+		read.GET("/quota", s.mgmt.GetQuota)
+		read.GET("/proxy-pools", s.mgmt.GetProxyPools)
+		read.GET("/oauth/status/:state", s.mgmt.OAuthStatus)
+	}
+
+	// Auth operators: login, upload, toggle and refresh credentials.
+	auth := mgmt.Group("", s.mgmt.RequireScope(config.ManagementScopeAuth))
 	{
-		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
-		mgmt.DELETE("/usage", s.mgmt.ResetUsage)
-		mgmt.GET("/config", s.mgmt.GetConfig)
-		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
-		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
-		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
-
-		mgmt.GET("/debug", s.mgmt.GetDebug)
-		mgmt.PUT("/debug", s.mgmt.PutDebug)
-
-		mgmt.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
-		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
-
-		mgmt.GET("/usage-statistics-enabled", s.mgmt.GetUsageStatisticsEnabled)
-		mgmt.PUT("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
-
-		mgmt.GET("/proxy-url", s.mgmt.GetProxyURL)
-		mgmt.PUT("/proxy-url", s.mgmt.PutProxyURL)
-		mgmt.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
-
-		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
-		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
-
-		mgmt.GET("/quota-exceeded/switch-preview-model", s.mgmt.GetSwitchPreviewModel)
-		mgmt.PUT("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
-
-		mgmt.GET("/api-keys", s.mgmt.GetAPIKeys)
-		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
-		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
-		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
-
-		mgmt.GET("/providers", s.mgmt.GetProviders)
-		mgmt.PUT("/providers", s.mgmt.PutProviders)
-		mgmt.DELETE("/providers", s.mgmt.DeleteProvider)
-
-		mgmt.GET("/logs", s.mgmt.GetLogs)
-		mgmt.DELETE("/logs", s.mgmt.DeleteLogs)
-		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
-		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
-		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
-		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
-		mgmt.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
-		mgmt.PUT("/ws-auth", s.mgmt.PutWebsocketAuth)
-
-		mgmt.GET("/request-retry", s.mgmt.GetRequestRetry)
-		mgmt.PUT("/request-retry", s.mgmt.PutRequestRetry)
-		mgmt.GET("/max-retry-interval", s.mgmt.GetMaxRetryInterval)
-		mgmt.PUT("/max-retry-interval", s.mgmt.PutMaxRetryInterval)
-
-		mgmt.GET("/oauth-excluded-models", s.mgmt.GetOAuthExcludedModels)
-		mgmt.PUT("/oauth-excluded-models", s.mgmt.PutOAuthExcludedModels)
-		mgmt.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
-		mgmt.DELETE("/oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels)
-
-		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
-		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
-		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
-		mgmt.POST("/auth-files/refresh", s.mgmt.RefreshAuthFile)
-		mgmt.POST("/auth-files/import", s.mgmt.ImportRawJSON)
-		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
-		mgmt.PATCH("/auth-files/toggle", s.mgmt.ToggleAuthFile)
-		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
+		auth.POST("/auth-files", s.mgmt.UploadAuthFile)
+		auth.POST("/auth-files/refresh", s.mgmt.RefreshAuthFile)
+		auth.POST("/auth-files/health-check", s.mgmt.CheckAuthHealth)
+		auth.POST("/auth-files/import", s.mgmt.ImportRawJSON)
+		auth.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
+		auth.PATCH("/auth-files/toggle", s.mgmt.ToggleAuthFile)
+		auth.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		// Unified OAuth API endpoints
-		mgmt.POST("/oauth/start", s.mgmt.OAuthStart)
-		mgmt.GET("/oauth/status/:state", s.mgmt.OAuthStatus)
-		mgmt.POST("/oauth/cancel/:state", s.mgmt.OAuthCancel)
-		mgmt.POST("/oauth/complete", s.mgmt.OAuthComplete)
+		auth.POST("/oauth/start", s.mgmt.OAuthStart)
+		auth.POST("/oauth/cancel/:state", s.mgmt.OAuthCancel)
+		auth.POST("/oauth/complete", s.mgmt.OAuthComplete)
+
+		// Account test chat used by the UI; usage is attributed to the actor.
+		testHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
+		auth.POST("/test/messages", func(c *gin.Context) {
+			c.Set("apiKey", "management:"+managementHandlers.ActorFromContext(c))
+			testHandlers.ClaudeMessages(c)
+		})
+	}
+
+	// Log readers.
+	logs := mgmt.Group("", s.mgmt.RequireScope(config.ManagementScopeLogs))
+	{
+		logs.GET("/logs", s.mgmt.GetLogs)
+		logs.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
+		logs.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
+		logs.GET("/audit", s.mgmt.GetAuditLog)
+	}
+
+	// Config admins: config.yaml, runtime settings and destructive resets.
+	cfg := mgmt.Group("", s.mgmt.RequireScope(config.ManagementScopeConfig))
+	{
+		cfg.DELETE("/usage", s.mgmt.ResetUsage)
+		cfg.GET("/config", s.mgmt.GetConfig)
+		cfg.GET("/config.yaml", s.mgmt.GetConfigYAML)
+		cfg.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+
+		cfg.GET("/debug", s.mgmt.GetDebug)
+		cfg.PUT("/debug", s.mgmt.PutDebug)
+
+		cfg.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
+		cfg.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
+
+		cfg.GET("/usage-statistics-enabled", s.mgmt.GetUsageStatisticsEnabled)
+		cfg.PUT("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
+
+		cfg.GET("/proxy-url", s.mgmt.GetProxyURL)
+		cfg.PUT("/proxy-url", s.mgmt.PutProxyURL)
+		cfg.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
+
+		cfg.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
+		cfg.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
+
+		cfg.GET("/quota-exceeded/switch-preview-model", s.mgmt.GetSwitchPreviewModel)
+		cfg.PUT("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
+
+		cfg.GET("/api-keys", s.mgmt.GetAPIKeys)
+		cfg.PUT("/api-keys", s.mgmt.PutAPIKeys)
+		cfg.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
+		cfg.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+
+		cfg.GET("/providers", s.mgmt.GetProviders)
+		cfg.PUT("/providers", s.mgmt.PutProviders)
+		cfg.DELETE("/providers", s.mgmt.DeleteProvider)
+
+		cfg.DELETE("/logs", s.mgmt.DeleteLogs)
+		cfg.GET("/request-log", s.mgmt.GetRequestLog)
+		cfg.PUT("/request-log", s.mgmt.PutRequestLog)
+		cfg.GET("/events/sinks", s.mgmt.ListEventSinks)
+		cfg.POST("/events/test", s.mgmt.TestEventSinks)
+
+		cfg.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
+		cfg.PUT("/ws-auth", s.mgmt.PutWebsocketAuth)
+
+		cfg.GET("/request-retry", s.mgmt.GetRequestRetry)
+		cfg.PUT("/request-retry", s.mgmt.PutRequestRetry)
+		cfg.GET("/max-retry-interval", s.mgmt.GetMaxRetryInterval)
+		cfg.PUT("/max-retry-interval", s.mgmt.PutMaxRetryInterval)
+
+		cfg.GET("/oauth-excluded-models", s.mgmt.GetOAuthExcludedModels)
+		cfg.PUT("/oauth-excluded-models", s.mgmt.PutOAuthExcludedModels)
+		cfg.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
+		cfg.DELETE("/oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels)
+	}
+
+	// Admin only: raw credential downloads and token management.
+	admin := mgmt.Group("", s.mgmt.RequireScope(config.ManagementScopeAdmin))
+	{
+		admin.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
+		admin.GET("/tokens", s.mgmt.ListManagementTokens)
+		admin.POST("/tokens", s.mgmt.CreateManagementToken)
+		admin.DELETE("/tokens/:name", s.mgmt.RevokeManagementToken)
 	}
 }
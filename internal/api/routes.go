@@ -2,9 +2,10 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/nghyane/llm-mux/internal/json"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -16,7 +17,9 @@ import (
 	"github.com/nghyane/llm-mux/internal/api/handlers/format/ollama"
 	"github.com/nghyane/llm-mux/internal/api/handlers/format/openai"
 	"github.com/nghyane/llm-mux/internal/api/middleware"
+	"github.com/nghyane/llm-mux/internal/json"
 	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/mcp"
 	"github.com/nghyane/llm-mux/internal/oauth"
 )
 
@@ -41,7 +44,12 @@ func (s *Server) setupRoutes() {
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.POST("/images/generations", openaiHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiHandlers.ImageEdits)
+		v1.POST("/audio/transcriptions", openaiHandlers.AudioTranscriptions)
+		v1.POST("/audio/speech", openaiHandlers.AudioSpeech)
 	}
+	s.AttachWebsocketRoute("/v1/realtime", openai.NewOpenAIRealtimeAPIHandler(s.handlers), WithClientAuth())
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
@@ -51,6 +59,22 @@ func (s *Server) setupRoutes() {
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/:action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/:action", geminiHandlers.GeminiGetHandler)
+		v1beta.POST("/cachedContents", geminiHandlers.CreateCachedContent)
+		v1beta.GET("/cachedContents", geminiHandlers.ListCachedContents)
+		v1beta.GET("/cachedContents/:id", geminiHandlers.CachedContent)
+		v1beta.PATCH("/cachedContents/:id", geminiHandlers.CachedContent)
+		v1beta.DELETE("/cachedContents/:id", geminiHandlers.CachedContent)
+	}
+
+	// Model Context Protocol (streamable HTTP transport)
+	mcpHandler := s.mcpHTTPHandler()
+	mcpGroup := s.engine.Group("/mcp")
+	mcpGroup.Use(middleware.RequestSizeLimitMiddleware(s.cfg.MaxRequestSize))
+	mcpGroup.Use(s.conditionalAuthMiddleware())
+	{
+		mcpGroup.POST("", mcpHandler)
+		mcpGroup.GET("", mcpHandler)
+		mcpGroup.DELETE("", mcpHandler)
 	}
 
 	// Root endpoint
@@ -66,33 +90,24 @@ func (s *Server) setupRoutes() {
 	})
 	s.engine.POST("/v1internal:method", geminiCLIHandlers.CLIHandler)
 
-	// Ollama compatible API routes (no authentication required, like in the example)
-	// Handle /api/version without auth (before auth check)
-	s.engine.GET("/api/version", ollamaHandlers.Version)
-	s.engine.GET("/ollama/api/version", ollamaHandlers.Version)
-
-	// Handle other Ollama endpoints (with optional auth - can work without API key)
-	apiGroup := s.engine.Group("/api")
-	apiGroup.Use(middleware.RequestSizeLimitMiddleware(s.cfg.MaxRequestSize))
-	apiGroup.Use(s.conditionalAuthMiddleware())
-	{
-		apiGroup.GET("/tags", ollamaHandlers.Tags)
-		apiGroup.POST("/chat", ollamaHandlers.Chat)
-		apiGroup.POST("/generate", ollamaHandlers.Generate)
-		apiGroup.POST("/show", ollamaHandlers.Show)
-		// OpenCode compatibility: /api/messages -> Claude messages handler
-		apiGroup.POST("/messages", claudeCodeHandlers.ClaudeMessages)
+	// Ollama compatible API routes. /api/version is anonymous by default
+	// (see anonymous-access.routes) so Ollama clients can probe the server.
+	ollamaRoutes := func(group *gin.RouterGroup) {
+		group.Use(middleware.RequestSizeLimitMiddleware(s.cfg.MaxRequestSize))
+		group.Use(s.conditionalAuthMiddleware())
+		group.GET("/version", ollamaHandlers.Version)
+		group.GET("/tags", ollamaHandlers.Tags)
+		group.POST("/chat", ollamaHandlers.Chat)
+		group.POST("/generate", ollamaHandlers.Generate)
+		group.POST("/show", ollamaHandlers.Show)
 	}
+	apiGroup := s.engine.Group("/api")
+	ollamaRoutes(apiGroup)
+	// OpenCode compatibility: /api/messages -> Claude messages handler
+	apiGroup.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 
 	// Also support /ollama/api/* paths
-	ollamaGroup := s.engine.Group("/ollama/api")
-	ollamaGroup.Use(middleware.RequestSizeLimitMiddleware(s.cfg.MaxRequestSize))
-	{
-		ollamaGroup.GET("/tags", ollamaHandlers.Tags)
-		ollamaGroup.POST("/chat", ollamaHandlers.Chat)
-		ollamaGroup.POST("/generate", ollamaHandlers.Generate)
-		ollamaGroup.POST("/show", ollamaHandlers.Show)
-	}
+	ollamaRoutes(s.engine.Group("/ollama/api"))
 
 	// OAuth callback endpoints (reuse main server port)
 	// These endpoints receive provider redirects and persist
@@ -149,12 +164,43 @@ func (s *Server) unifiedModelsHandler(openaiHandler *openai.OpenAIAPIHandler, cl
 	}
 }
 
+// mcpHTTPHandler serves the MCP endpoint unless it is disabled via mcp.disable-http.
+// The flag is checked per request so config reloads take effect without re-registering routes.
+func (s *Server) mcpHTTPHandler() gin.HandlerFunc {
+	transport := mcp.NewHTTPTransport(s.mcpServer)
+	return func(c *gin.Context) {
+		if s.cfg != nil && s.cfg.MCP.DisableHTTP {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		transport.Handle(c)
+	}
+}
+
+// WebsocketRouteOption customizes a route registered with AttachWebsocketRoute.
+type WebsocketRouteOption func(*websocketRouteOptions)
+
+type websocketRouteOptions struct {
+	clientAuth bool
+}
+
+// WithClientAuth authenticates the route like the client API routes, through
+// the anonymous-access policy, instead of the ws-auth setting.
+func WithClientAuth() WebsocketRouteOption {
+	return func(o *websocketRouteOptions) { o.clientAuth = true }
+}
+
 // AttachWebsocketRoute registers a websocket upgrade handler on the primary Gin engine.
 // The handler is served as-is without additional middleware beyond the standard stack already configured.
-func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
+// The Gin context is available to the handler through gin.ContextKey on the request context.
+func (s *Server) AttachWebsocketRoute(path string, handler http.Handler, opts ...WebsocketRouteOption) {
 	if s == nil || s.engine == nil || handler == nil {
 		return
 	}
+	var options websocketRouteOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 	trimmed := strings.TrimSpace(path)
 	if trimmed == "" {
 		trimmed = "/v1/ws"
@@ -178,25 +224,22 @@ func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
 		}
 		authMiddleware(c)
 	}
+	if options.clientAuth {
+		conditionalAuth = s.conditionalAuthMiddleware()
+	}
 	finalHandler := func(c *gin.Context) {
-		handler.ServeHTTP(c.Writer, c.Request)
+		handler.ServeHTTP(c.Writer, c.Request.WithContext(context.WithValue(c.Request.Context(), gin.ContextKey, c)))
 		c.Abort()
 	}
 
 	s.engine.GET(trimmed, conditionalAuth, finalHandler)
 }
 
-// conditionalAuthMiddleware returns middleware that checks disable-auth config flag.
-// If disable-auth is true, all requests are allowed without authentication.
-// Also allows skip-auth=true query param for testing purposes.
-// Otherwise, standard authentication is applied.
+// conditionalAuthMiddleware authenticates client requests unless the
+// anonymous-access policy allows the request without credentials.
 func (s *Server) conditionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if s.cfg != nil && s.cfg.DisableAuth {
-			c.Next()
-			return
-		}
-		if c.Query("skip-auth") == "true" {
+		if s.allowAnonymous(c.Request) {
 			c.Next()
 			return
 		}
@@ -204,6 +247,50 @@ func (s *Server) conditionalAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// allowAnonymous applies the anonymous-access policy: disable-auth or a listed
+// route opens the request, and localhost-only then limits that to loopback
+// peers. The TCP peer address is used so forwarding headers cannot spoof it.
+func (s *Server) allowAnonymous(r *http.Request) bool {
+	cfg := s.cfg
+	if cfg == nil {
+		return false
+	}
+	if !cfg.DisableAuth && !cfg.AnonymousAccess.AllowsPath(r.URL.Path) {
+		return false
+	}
+	if cfg.AnonymousAccess.LocalhostOnly && !isLoopbackPeer(r.RemoteAddr) {
+		return false
+	}
+	return true
+}
+
+func isLoopbackPeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// warnAnonymousExposure logs when clients on other hosts can use the proxy
+// without credentials. The server listens on every interface, so only
+// localhost-only keeps anonymous access local.
+func (s *Server) warnAnonymousExposure() {
+	cfg := s.cfg
+	if cfg == nil || cfg.AnonymousAccess.LocalhostOnly {
+		return
+	}
+	switch {
+	case cfg.DisableAuth:
+		log.Warnf("disable-auth is enabled: anyone who can reach port %d can use your providers; set anonymous-access.localhost-only: true to restrict access to this machine", cfg.Port)
+	case len(cfg.AnonymousAccess.Routes) > 0:
+		log.Warnf("anonymous-access.routes %v are reachable without credentials from other hosts; set anonymous-access.localhost-only: true to restrict them to this machine", cfg.AnonymousAccess.Routes)
+	case len(s.accessManager.Providers()) == 0:
+		log.Warnf("no API keys are configured: requests from any host are accepted without credentials")
+	}
+}
+
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
 // using the configured authentication providers. When no providers are available,
 // it allows all requests (legacy behaviour).
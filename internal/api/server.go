@@ -23,8 +23,10 @@ import (
 	"github.com/nghyane/llm-mux/internal/api/middleware"
 	"github.com/nghyane/llm-mux/internal/api/modules"
 	ampmodule "github.com/nghyane/llm-mux/internal/api/modules/amp"
+	"github.com/nghyane/llm-mux/internal/audit"
 	"github.com/nghyane/llm-mux/internal/config"
 	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/mcp"
 	"github.com/nghyane/llm-mux/internal/provider"
 	"github.com/nghyane/llm-mux/internal/registry"
 	"github.com/nghyane/llm-mux/internal/usage"
@@ -41,6 +43,7 @@ type serverOptionConfig struct {
 	keepAliveEnabled     bool
 	keepAliveTimeout     time.Duration
 	keepAliveOnTimeout   func()
+	disableListener      bool
 }
 
 // ServerOption customises HTTP server construction.
@@ -94,6 +97,15 @@ func WithKeepAliveEndpoint(timeout time.Duration, onTimeout func()) ServerOption
 	}
 }
 
+// WithoutListener builds the server without binding the HTTP port.
+// Start returns immediately; used by commands such as `llm-mux mcp` that only
+// need the routing pipeline.
+func WithoutListener() ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.disableListener = true
+	}
+}
+
 // WithRequestLoggerFactory customises request logger creation.
 func WithRequestLoggerFactory(factory func(*config.Config, string) log.RequestLogger) ServerOption {
 	return func(cfg *serverOptionConfig) {
@@ -124,6 +136,7 @@ type Server struct {
 
 	mgmt      *managementHandlers.Handler
 	ampModule *ampmodule.AmpModule
+	mcpServer *mcp.Server
 
 	managementRoutesRegistered atomic.Bool
 	managementRoutesEnabled    atomic.Bool
@@ -134,6 +147,7 @@ type Server struct {
 	keepAliveOnTimeout func()
 	keepAliveHeartbeat chan struct{}
 	keepAliveStop      chan struct{}
+	disableListener    bool
 }
 
 // NewServer creates and initializes a new API server instance.
@@ -195,16 +209,18 @@ func NewServer(cfg *config.Config, authManager *provider.Manager, accessManager
 		}
 	}
 	s := &Server{
-		engine:         engine,
-		handlers:       format.NewBaseAPIHandlers(&cfg.SDKConfig, &cfg.Routing, authManager, providerNames),
-		cfg:            cfg,
-		accessManager:  accessManager,
-		requestLogger:  requestLogger,
-		loggerToggle:   toggle,
-		configFilePath: configFilePath,
-		currentPath:    wd,
-		wsRoutes:       make(map[string]struct{}),
-	}
+		engine:          engine,
+		handlers:        format.NewBaseAPIHandlers(&cfg.SDKConfig, &cfg.Routing, authManager, providerNames),
+		cfg:             cfg,
+		accessManager:   accessManager,
+		requestLogger:   requestLogger,
+		loggerToggle:    toggle,
+		configFilePath:  configFilePath,
+		currentPath:     wd,
+		wsRoutes:        make(map[string]struct{}),
+		disableListener: optionState.disableListener,
+	}
+	s.mcpServer = mcp.NewServer(s.handlers, cfg.MCP.DefaultModel)
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
@@ -280,6 +296,12 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to start HTTP server: server not initialized")
 	}
 
+	if s.disableListener {
+		log.Debug("API server listener disabled")
+		return nil
+	}
+	s.warnAnonymousExposure()
+
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
 	if useTLS {
 		cert := strings.TrimSpace(s.cfg.TLS.Cert)
@@ -329,6 +351,9 @@ func (s *Server) Stop(ctx context.Context) error {
 		log.Warnf("Failed to stop usage persistence: %v", err)
 	}
 
+	// Flush queued audit webhooks and close the audit store.
+	audit.Default().Close()
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -456,6 +481,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	s.handlers.OpenAICompatProviders = providerNames
 
 	s.handlers.UpdateClients(&cfg.SDKConfig)
+	s.mcpServer.SetDefaultModel(cfg.MCP.DefaultModel)
 
 	if s.mgmt != nil {
 		s.mgmt.SetConfig(cfg)
@@ -479,6 +505,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	codexAPIKeyCount := 0
 	vertexAICompatCount := len(cfg.VertexCompatAPIKey)
 	openAICompatCount := 0
+	ollamaCount := 0
 	for _, p := range cfg.Providers {
 		keys := p.GetAPIKeys()
 		switch p.Type {
@@ -494,11 +521,13 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 			openAICompatCount += len(keys)
 		case "vertex-compat":
 			vertexAICompatCount += len(keys)
+		case "ollama":
+			ollamaCount += max(len(keys), 1)
 		}
 	}
 
-	total := authFiles + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + openAICompatCount
-	log.Infof("server clients and configuration updated: %d clients (%d auth files + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d OpenAI-compat)",
+	total := authFiles + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + openAICompatCount + ollamaCount
+	log.Infof("server clients and configuration updated: %d clients (%d auth files + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d OpenAI-compat + %d Ollama)",
 		total,
 		authFiles,
 		geminiAPIKeyCount,
@@ -506,9 +535,23 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		codexAPIKeyCount,
 		vertexAICompatCount,
 		openAICompatCount,
+		ollamaCount,
 	)
 }
 
+// Listening reports whether Start binds the HTTP port.
+func (s *Server) Listening() bool {
+	return s != nil && !s.disableListener
+}
+
+// MCPServer returns the Model Context Protocol server sharing this server's routing pipeline.
+func (s *Server) MCPServer() *mcp.Server {
+	if s == nil {
+		return nil
+	}
+	return s.mcpServer
+}
+
 func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	if s == nil {
 		return
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -108,3 +109,64 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+type staticKeyProvider struct{ key string }
+
+func (p staticKeyProvider) Identifier() string { return "static" }
+
+func (p staticKeyProvider) Authenticate(_ context.Context, r *http.Request) (*access.Result, error) {
+	switch r.Header.Get("Authorization") {
+	case "":
+		return nil, access.ErrNoCredentials
+	case "Bearer " + p.key:
+		return &access.Result{Provider: "static", Principal: p.key}, nil
+	}
+	return nil, access.ErrInvalidCredential
+}
+
+func TestAnonymousAccessPolicy(t *testing.T) {
+	server := newTestServer(t)
+	server.accessManager.SetProviders([]access.Provider{staticKeyProvider{key: "test-key"}})
+
+	do := func(path, remote, authz string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	const remote, local = "203.0.113.7:5000", "127.0.0.1:5000"
+
+	if code := do("/v1/models?skip-auth=true", remote, ""); code != http.StatusUnauthorized {
+		t.Fatalf("skip-auth query bypassed auth: status %d", code)
+	}
+	if code := do("/ollama/api/tags", remote, ""); code != http.StatusUnauthorized {
+		t.Fatalf("/ollama/api/tags without key: status %d, want 401", code)
+	}
+	if code := do("/ollama/api/tags", remote, "Bearer test-key"); code != http.StatusOK {
+		t.Fatalf("/ollama/api/tags with key: status %d, want 200", code)
+	}
+	if code := do("/api/version", remote, ""); code != http.StatusOK {
+		t.Fatalf("default anonymous /api/version: status %d, want 200", code)
+	}
+
+	server.cfg.AnonymousAccess = proxyconfig.AnonymousAccessConfig{Routes: []string{"/api/*"}, LocalhostOnly: true}
+	if code := do("/api/tags", local, ""); code != http.StatusOK {
+		t.Fatalf("anonymous route from loopback: status %d, want 200", code)
+	}
+	if code := do("/api/tags", remote, ""); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous route from remote with localhost-only: status %d, want 401", code)
+	}
+
+	server.cfg.AnonymousAccess = proxyconfig.AnonymousAccessConfig{Routes: []string{}, LocalhostOnly: true}
+	server.cfg.DisableAuth = true
+	if code := do("/v1/models", local, ""); code != http.StatusOK {
+		t.Fatalf("disable-auth from loopback: status %d, want 200", code)
+	}
+	if code := do("/v1/models", remote, ""); code != http.StatusUnauthorized {
+		t.Fatalf("disable-auth from remote with localhost-only: status %d, want 401", code)
+	}
+}
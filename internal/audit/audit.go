@@ -0,0 +1,391 @@
+// Package audit records an append-only trail of management and credential
+// changes with the actor that made them.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+// Result values for Entry.Result.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Redacted replaces secret values in recorded changes.
+const Redacted = "[redacted]"
+
+// DefaultFileName is the JSONL file used when neither audit.file nor usage.dsn is set.
+const DefaultFileName = "audit.jsonl"
+
+// Entry is one audited action.
+type Entry struct {
+	ID       int64     `json:"id,omitempty"`
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	ClientIP string    `json:"client_ip,omitempty"`
+	Action   string    `json:"action"`
+	Target   string    `json:"target,omitempty"`
+	Changes  []Change  `json:"changes,omitempty"`
+	Result   string    `json:"result"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Change is a single value that differs between the before and after state.
+// Before is absent for additions and After for removals.
+type Change struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	Since  time.Time
+	Until  time.Time
+	Actor  string
+	Action string // substring match
+	Target string // substring match
+	Result string
+	Limit  int
+}
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+func (f Filter) limit() int {
+	switch {
+	case f.Limit <= 0:
+		return defaultQueryLimit
+	case f.Limit > maxQueryLimit:
+		return maxQueryLimit
+	}
+	return f.Limit
+}
+
+func (f Filter) match(e *Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Result != "" && e.Result != f.Result {
+		return false
+	}
+	if f.Action != "" && !strings.Contains(strings.ToLower(e.Action), strings.ToLower(f.Action)) {
+		return false
+	}
+	if f.Target != "" && !strings.Contains(strings.ToLower(e.Target), strings.ToLower(f.Target)) {
+		return false
+	}
+	return true
+}
+
+// Store persists entries. Implementations only ever append.
+type Store interface {
+	Append(ctx context.Context, e *Entry) error
+	// Query returns matching entries, newest first.
+	Query(ctx context.Context, f Filter) ([]Entry, error)
+	Close() error
+}
+
+// Logger writes entries to the configured store and webhook.
+type Logger struct {
+	mu      sync.RWMutex
+	store   Store
+	webhook *webhookSink
+	key     string
+}
+
+var (
+	defaultLogger     *Logger
+	defaultLoggerOnce sync.Once
+)
+
+// Default returns the process-wide audit logger.
+func Default() *Logger {
+	defaultLoggerOnce.Do(func() { defaultLogger = &Logger{} })
+	return defaultLogger
+}
+
+// Configure (re)opens the store and webhook for cfg. It is a no-op when the
+// relevant settings are unchanged.
+func (l *Logger) Configure(cfg *config.Config) {
+	if l == nil || cfg == nil {
+		return
+	}
+	ac := cfg.Audit
+	key := fmt.Sprintf("%t|%s|%s|%s|%s|%s", ac.Disable, ac.File, cfg.Usage.DSN, config.CredentialsDir(), ac.WebhookURL, ac.WebhookSecret)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if key == l.key {
+		return
+	}
+	l.key = key
+	if l.store != nil {
+		_ = l.store.Close()
+		l.store = nil
+	}
+	if l.webhook != nil {
+		l.webhook.stop()
+		l.webhook = nil
+	}
+	if ac.Disable {
+		return
+	}
+	store, err := openStore(ac.File, cfg.Usage.DSN)
+	if err != nil {
+		log.Errorf("audit: failed to open store: %v", err)
+	} else {
+		l.store = store
+	}
+	if strings.TrimSpace(ac.WebhookURL) != "" {
+		l.webhook = newWebhookSink(ac.WebhookURL, ac.WebhookSecret)
+	}
+}
+
+func openStore(file, dsn string) (Store, error) {
+	if file = strings.TrimSpace(file); file != "" {
+		return NewFileStore(expandHome(file))
+	}
+	if parsed, err := config.ParseDSN(dsn); err == nil && parsed != nil {
+		return NewSQLStore(parsed)
+	}
+	dir := config.CredentialsDir()
+	if dir == "" {
+		return nil, fmt.Errorf("no audit file, usage DSN or config directory available")
+	}
+	return NewFileStore(filepath.Join(dir, DefaultFileName))
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return os.ExpandEnv(path)
+}
+
+// Enabled reports whether entries are being stored.
+func (l *Logger) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.store != nil
+}
+
+// Record stores e and forwards it to the webhook. Failures are logged, not returned,
+// so auditing never blocks the audited operation.
+func (l *Logger) Record(ctx context.Context, e Entry) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Result == "" {
+		e.Result = ResultSuccess
+	}
+	l.mu.RLock()
+	store, webhook := l.store, l.webhook
+	l.mu.RUnlock()
+	if store != nil {
+		if err := store.Append(context.WithoutCancel(ctx), &e); err != nil {
+			log.Errorf("audit: failed to append entry %s by %s: %v", e.Action, e.Actor, err)
+		}
+	}
+	if webhook != nil {
+		webhook.send(e)
+	}
+}
+
+// Query returns stored entries matching f, newest first.
+func (l *Logger) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, fmt.Errorf("audit log is disabled")
+	}
+	l.mu.RLock()
+	store := l.store
+	l.mu.RUnlock()
+	if store == nil {
+		return nil, fmt.Errorf("audit log is disabled")
+	}
+	return store.Query(ctx, f)
+}
+
+// Close releases the store and stops the webhook.
+func (l *Logger) Close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.store != nil {
+		_ = l.store.Close()
+		l.store = nil
+	}
+	if l.webhook != nil {
+		l.webhook.stop()
+		l.webhook = nil
+	}
+	l.key = ""
+}
+
+// secretSegment matches path segments whose values must never be recorded.
+var secretSegment = regexp.MustCompile(`(?i)(^|[-_.])(api-?keys?|keys?|secrets?|tokens?|passwords?|authorization|cookies?|credentials?|private-?key)$`)
+
+func isSecretPath(path string) bool {
+	for _, seg := range strings.Split(path, ".") {
+		if secretSegment.MatchString(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot converts v to a generic JSON tree for Diff. It returns nil when v
+// cannot be marshalled.
+func Snapshot(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Diff compares two snapshots and returns the changed leaf values sorted by
+// path. Values under secret-looking keys are replaced with Redacted.
+func Diff(before, after any) []Change {
+	b, a := map[string]any{}, map[string]any{}
+	flatten("", before, b)
+	flatten("", after, a)
+	paths := make([]string, 0, len(b)+len(a))
+	for p := range b {
+		paths = append(paths, p)
+	}
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, p := range paths {
+		bv, inB := b[p]
+		av, inA := a[p]
+		if inB && inA && equalJSON(bv, av) {
+			continue
+		}
+		ch := Change{Path: p}
+		secret := isSecretPath(p)
+		if inB {
+			ch.Before = redact(bv, secret)
+		}
+		if inA {
+			ch.After = redact(av, secret)
+		}
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+func redact(v any, secret bool) any {
+	if secret && v != nil && v != "" {
+		return Redacted
+	}
+	if s, ok := v.(string); ok && strings.Contains(s, "@") {
+		// Strip passwords from proxy and database URLs.
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				u.User = url.UserPassword(u.User.Username(), "xxxxx")
+				return u.String()
+			}
+		}
+	}
+	return v
+}
+
+func flatten(prefix string, v any, out map[string]any) {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 && prefix != "" {
+			out[prefix] = t
+			return
+		}
+		for k, child := range t {
+			flatten(join(prefix, k), child, out)
+		}
+	case []any:
+		if len(t) == 0 && prefix != "" {
+			out[prefix] = t
+			return
+		}
+		for i, child := range t {
+			flatten(join(prefix, strconv.Itoa(i)), child, out)
+		}
+	default:
+		if prefix != "" {
+			out[prefix] = t
+		}
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func equalJSON(a, b any) bool {
+	switch at := a.(type) {
+	case map[string]any:
+		bt, ok := b.(map[string]any)
+		return ok && len(at) == 0 && len(bt) == 0
+	case []any:
+		bt, ok := b.([]any)
+		return ok && len(at) == 0 && len(bt) == 0
+	}
+	return a == b
+}
+
+// sortNewestFirst orders entries by time descending, then by ID.
+func sortNewestFirst(entries []Entry) {
+	slices.SortStableFunc(entries, func(x, y Entry) int {
+		if c := y.Time.Compare(x.Time); c != 0 {
+			return c
+		}
+		return int(y.ID - x.ID)
+	})
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+// FileStore appends entries as JSON lines to a file opened in append-only mode.
+// The file is created on the first append.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	nextID int64
+	closed bool
+}
+
+// NewFileStore returns a store appending to path.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("audit file path is empty")
+	}
+	return &FileStore{path: path}, nil
+}
+
+// openLocked opens the file and continues IDs from its last entry so they stay
+// unique across restarts. Caller holds s.mu.
+func (s *FileStore) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = s.scan(func(e *Entry) bool {
+		if e.ID > s.nextID {
+			s.nextID = e.ID
+		}
+		return true
+	})
+	s.file = f
+	return nil
+}
+
+// Append implements Store.
+func (s *FileStore) Append(_ context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("audit file %s is closed", s.path)
+	}
+	if s.file == nil {
+		if err := s.openLocked(); err != nil {
+			return err
+		}
+	}
+	s.nextID++
+	e.ID = s.nextID
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = s.file.Write(data)
+	return err
+}
+
+// Query implements Store by scanning the whole file.
+func (s *FileStore) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	var out []Entry
+	err := s.scan(func(e *Entry) bool {
+		if f.match(e) {
+			out = append(out, *e)
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sortNewestFirst(out)
+	if limit := f.limit(); len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *FileStore) scan(fn func(*Entry) bool) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		if !fn(&e) {
+			break
+		}
+	}
+	return sc.Err()
+}
+
+// Close implements Store.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
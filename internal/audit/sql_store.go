@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+	_ "modernc.org/sqlite"
+)
+
+// SQLStore keeps entries in an audit_log table next to usage_records.
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLStore opens the database described by the usage DSN and creates the
+// audit_log table when missing.
+func NewSQLStore(dsn *config.ParsedDSN) (*SQLStore, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	switch {
+	case dsn.IsPostgres():
+		db, err = sql.Open("pgx", dsn.URL)
+	case dsn.IsSQLite():
+		db, err = sql.Open("sqlite", dsn.Path+"?_pragma=busy_timeout(5000)")
+	default:
+		return nil, fmt.Errorf("unsupported audit backend %q", dsn.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLStore{db: db, postgres: dsn.IsPostgres()}
+	idCol := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	if s.postgres {
+		idCol = "id BIGSERIAL PRIMARY KEY"
+	}
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			` + idCol + `,
+			occurred_at TIMESTAMP NOT NULL,
+			actor TEXT NOT NULL DEFAULT '',
+			client_ip TEXT NOT NULL DEFAULT '',
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			changes TEXT NOT NULL DEFAULT '',
+			result TEXT NOT NULL,
+			status INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT ''
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_audit_time ON audit_log(occurred_at)",
+		"CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor)",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("create audit_log: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// rebind converts ? placeholders to $n for Postgres.
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Append implements Store.
+func (s *SQLStore) Append(ctx context.Context, e *Entry) error {
+	changes := ""
+	if len(e.Changes) > 0 {
+		data, err := json.Marshal(e.Changes)
+		if err != nil {
+			return err
+		}
+		changes = string(data)
+	}
+	query := s.rebind("INSERT INTO audit_log (occurred_at, actor, client_ip, action, target, changes, result, status, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	args := []any{e.Time.UTC(), e.Actor, e.ClientIP, e.Action, e.Target, changes, e.Result, e.Status, e.Error}
+	if s.postgres {
+		return s.db.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&e.ID)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// Query implements Store.
+func (s *SQLStore) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !f.Since.IsZero() {
+		where = append(where, "occurred_at >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "occurred_at < ?")
+		args = append(args, f.Until.UTC())
+	}
+	if f.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Result != "" {
+		where = append(where, "result = ?")
+		args = append(args, f.Result)
+	}
+	if f.Action != "" {
+		where = append(where, "LOWER(action) LIKE ?")
+		args = append(args, "%"+strings.ToLower(f.Action)+"%")
+	}
+	if f.Target != "" {
+		where = append(where, "LOWER(target) LIKE ?")
+		args = append(args, "%"+strings.ToLower(f.Target)+"%")
+	}
+	query := "SELECT id, occurred_at, actor, client_ip, action, target, changes, result, status, error FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY occurred_at DESC, id DESC LIMIT " + strconv.Itoa(f.limit())
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []Entry
+	for rows.Next() {
+		var (
+			e       Entry
+			changes string
+		)
+		if err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.ClientIP, &e.Action, &e.Target, &changes, &e.Result, &e.Status, &e.Error); err != nil {
+			return nil, err
+		}
+		if changes != "" {
+			_ = json.Unmarshal([]byte(changes), &e.Changes)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Close implements Store.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
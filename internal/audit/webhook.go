@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+const webhookQueueSize = 256
+
+// webhookSink POSTs entries to a URL from a background goroutine so a slow
+// receiver never delays the audited request.
+type webhookSink struct {
+	url      string
+	secret   string
+	client   *http.Client
+	queue    chan Entry
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newWebhookSink(url, secret string) *webhookSink {
+	w := &webhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan Entry, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+func (w *webhookSink) send(e Entry) {
+	select {
+	case <-w.done:
+	case w.queue <- e:
+	default:
+		log.Warnf("audit: webhook queue full, dropping entry %s by %s", e.Action, e.Actor)
+	}
+}
+
+func (w *webhookSink) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case e := <-w.queue:
+			w.post(e)
+		case <-w.done:
+			// Drain what is already queued before exiting.
+			for {
+				select {
+				case e := <-w.queue:
+					w.post(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *webhookSink) post(e Entry) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("audit: invalid webhook URL: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Audit-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		log.Warnf("audit: webhook delivery failed: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warnf("audit: webhook returned status %d", resp.StatusCode)
+	}
+}
+
+func (w *webhookSink) stop() {
+	w.stopOnce.Do(func() { close(w.done) })
+	w.wg.Wait()
+}
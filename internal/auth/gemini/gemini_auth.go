@@ -19,6 +19,7 @@ import (
 	"github.com/nghyane/llm-mux/internal/browser"
 	"github.com/nghyane/llm-mux/internal/config"
 	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/proxypool"
 	"github.com/nghyane/llm-mux/internal/util"
 	"github.com/tidwall/gjson"
 	"golang.org/x/net/proxy"
@@ -65,7 +66,12 @@ func NewGeminiAuth() *GeminiAuth {
 //   - error: An error if the client configuration fails, nil otherwise
 func (g *GeminiAuth) GetAuthenticatedClient(ctx context.Context, ts *GeminiTokenStorage, cfg *config.Config, noBrowser ...bool) (*http.Client, error) {
 	// Configure proxy settings for the HTTP client if a proxy URL is provided.
-	proxyURL, err := url.Parse(cfg.ProxyURL)
+	rawProxy, ok := proxypool.Default().ResolveURL(cfg.ProxyURL, "")
+	if !ok {
+		pool, _ := proxypool.PoolName(cfg.ProxyURL)
+		return nil, proxypool.UnavailableError(pool)
+	}
+	proxyURL, err := url.Parse(rawProxy)
 	if err == nil {
 		var transport *http.Transport
 		switch proxyURL.Scheme {
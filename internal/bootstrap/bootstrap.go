@@ -11,6 +11,8 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/nghyane/llm-mux/internal/access/config_access"
+	headeraccess "github.com/nghyane/llm-mux/internal/access/header_access"
+	oidcaccess "github.com/nghyane/llm-mux/internal/access/oidc_access"
 	authlogin "github.com/nghyane/llm-mux/internal/auth/login"
 	"github.com/nghyane/llm-mux/internal/cli/env"
 	"github.com/nghyane/llm-mux/internal/config"
@@ -119,6 +121,8 @@ func Bootstrap(configPath string) (*Result, error) {
 
 	// Register built-in access providers
 	configaccess.Register()
+	oidcaccess.Register()
+	headeraccess.Register()
 
 	return &Result{
 		Config:         cfg,
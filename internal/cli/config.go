@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configValidateStrict          bool
+	configValidateAllowUnresolved bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the configuration file",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a config file for unknown keys, type errors and broken references",
+	Long: `Check a config file against the configuration schema: unknown keys, wrong
+value types, invalid provider entries, secret references that cannot be
+resolved and routing references to unknown providers or models. Files
+pulled in through include: and the config.d directory are checked as well.
+
+Model references are checked against the built-in model catalog; models
+discovered from upstream APIs at runtime are reported as warnings only.
+Exits non-zero when errors are found, or warnings with --strict.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := cfgFile
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if path == "" {
+			path = "$XDG_CONFIG_HOME/llm-mux/config.yaml"
+		}
+		if resolved, err := util.ResolveAuthDir(path); err == nil {
+			path = resolved
+		}
+		_, issues := config.ValidateConfigFile(path, nil, config.ValidateOptions{
+			KnownModels:            registry.StaticModelIDs(),
+			AllowUnresolvedSecrets: configValidateAllowUnresolved,
+		})
+		warnings := 0
+		for _, issue := range issues {
+			fmt.Printf("%s: %s\n", issue.Severity, issue)
+			if issue.Severity == config.IssueWarning {
+				warnings++
+			}
+		}
+		cmd.SilenceUsage = true
+		if len(issues)-warnings > 0 {
+			return fmt.Errorf("%s: %d error(s), %d warning(s)", path, len(issues)-warnings, warnings)
+		}
+		if configValidateStrict && warnings > 0 {
+			return fmt.Errorf("%s: %d warning(s)", path, warnings)
+		}
+		fmt.Printf("%s is valid\n", path)
+		return nil
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of config.yaml",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+func init() {
+	configValidateCmd.Flags().BoolVar(&configValidateStrict, "strict", false, "treat warnings as errors")
+	configValidateCmd.Flags().BoolVar(&configValidateAllowUnresolved, "allow-unresolved-secrets", false, "report ${VAR}, env: and file: references that cannot be resolved here as warnings")
+
+	configCmd.AddCommand(configValidateCmd, configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nghyane/llm-mux/internal/api"
+	"github.com/nghyane/llm-mux/internal/bootstrap"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/service"
+	"github.com/nghyane/llm-mux/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run llm-mux as an MCP server over stdio",
+	Long: `Run llm-mux as a Model Context Protocol server speaking JSON-RPC over stdio.
+
+The full routing pipeline (auths, fallbacks, usage tracking) is loaded from the
+configuration, but no HTTP port is bound. Stdout carries protocol messages only;
+all logs are written to stderr. The process exits when stdin is closed.`,
+	Run: func(c *cobra.Command, args []string) {
+		// Keep the real stdout for protocol traffic and send everything else to stderr.
+		protocolOut := os.Stdout
+		os.Stdout = os.Stderr
+		log.SetupBaseLogger()
+		log.SetOutput(os.Stderr)
+
+		configPath := cfgFile
+		if configPath == "" {
+			configPath = "$XDG_CONFIG_HOME/llm-mux/config.yaml"
+		}
+
+		result, err := bootstrap.Bootstrap(configPath)
+		if err != nil {
+			log.Fatalf("Failed to bootstrap: %v", err)
+		}
+		cfg := result.Config
+
+		usage.SetStatisticsEnabled(cfg.Usage.DSN != "")
+		if cfg.Usage.DSN != "" {
+			initUsageBackend(cfg)
+		}
+		if err := log.ConfigureLogOutput(cfg.LoggingToFile); err != nil {
+			log.Fatalf("Failed to configure log output: %v", err)
+		}
+
+		ctxSignal, cancelSignal := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancelSignal()
+		runCtx, cancelRun := context.WithCancel(ctxSignal)
+		defer cancelRun()
+
+		svc, err := service.NewBuilder().
+			WithConfig(cfg).
+			WithConfigPath(result.ConfigFilePath).
+			WithServerOptions(api.WithoutListener()).
+			WithHooks(service.Hooks{
+				OnAfterStart: func(s *service.Service) {
+					go func() {
+						defer cancelRun()
+						if errServe := s.Server().MCPServer().ServeStdio(runCtx, os.Stdin, protocolOut); errServe != nil && !errors.Is(errServe, context.Canceled) {
+							log.Errorf("mcp stdio transport stopped: %v", errServe)
+						}
+					}()
+				},
+			}).
+			Build()
+		if err != nil {
+			log.Fatalf("failed to build proxy service: %v", err)
+		}
+
+		if err := svc.Run(runCtx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("mcp service exited with error: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenScopes  []string
+	tokenExpires time.Duration
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage named management API tokens",
+	Long: `Manage named management API tokens stored in credentials.json.
+
+Scopes: read (usage/status), auth (login, toggle, refresh), config (config
+admin), logs (log reader) and admin (everything, including tokens).
+A running server picks up changes without a restart.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a token and print its secret",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		secret, tok, err := config.CreateManagementToken(args[0], tokenScopes, tokenExpires)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created token %q with scopes %s\n", tok.Name, strings.Join(tok.Scopes, ","))
+		if tok.ExpiresAt != nil {
+			fmt.Printf("Expires: %s\n", tok.ExpiresAt.Local().Format(time.RFC3339))
+		}
+		fmt.Printf("  %s\n", secret)
+		fmt.Println("Store it now; it cannot be shown again.")
+		return nil
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tokens",
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens := config.ListManagementTokens()
+		if len(tokens) == 0 {
+			fmt.Println("No management tokens.")
+			return
+		}
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSCOPES\tSTATUS\tCREATED\tEXPIRES")
+		for i := range tokens {
+			t := &tokens[i]
+			status := "active"
+			switch {
+			case t.Revoked():
+				status = "revoked"
+			case t.Expired(now):
+				status = "expired"
+			}
+			expires := "never"
+			if t.ExpiresAt != nil {
+				expires = t.ExpiresAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Name, strings.Join(t.Scopes, ","), status, t.CreatedAt.Local().Format(time.DateTime), expires)
+		}
+		_ = w.Flush()
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.RevokeManagementToken(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked token %q\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	tokenCreateCmd.Flags().StringSliceVar(&tokenScopes, "scope", nil, "scopes to grant (read, auth, config, logs, admin)")
+	tokenCreateCmd.Flags().DurationVar(&tokenExpires, "expires", 0, "lifetime, e.g. 720h (default: never expires)")
+	_ = tokenCreateCmd.MarkFlagRequired("scope")
+
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
@@ -0,0 +1,56 @@
+package config
+
+import "strings"
+
+// DefaultAnonymousRoutes are served without credentials when
+// anonymous-access.routes is not set. They expose no model access.
+var DefaultAnonymousRoutes = []string{"/api/version", "/ollama/api/version"}
+
+// AnonymousAccessConfig is the policy for unauthenticated client requests.
+type AnonymousAccessConfig struct {
+	// Routes lists request paths that may be served without credentials. A
+	// trailing "*" matches every path with that prefix, e.g. "/ollama/api/*".
+	// When unset, DefaultAnonymousRoutes applies; an empty list allows none.
+	Routes []string `yaml:"routes,omitempty" json:"routes"`
+
+	// LocalhostOnly serves anonymous requests only to loopback clients. Remote
+	// clients must authenticate even on anonymous routes or with disable-auth.
+	LocalhostOnly bool `yaml:"localhost-only,omitempty" json:"localhost-only"`
+}
+
+// EffectiveRoutes returns the configured routes or the defaults when unset.
+func (a AnonymousAccessConfig) EffectiveRoutes() []string {
+	if a.Routes == nil {
+		return DefaultAnonymousRoutes
+	}
+	return a.Routes
+}
+
+// AllowsPath reports whether path matches one of the anonymous routes.
+func (a AnonymousAccessConfig) AllowsPath(path string) bool {
+	for _, route := range a.EffectiveRoutes() {
+		route = strings.TrimSpace(route)
+		if prefix, ok := strings.CutSuffix(route, "*"); ok {
+			if prefix != "" && strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if route != "" && path == strings.TrimSuffix(route, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AnonymousAccessEnabled reports whether anonymous clients can reach routes
+// beyond the defaults, either through disable-auth or explicitly listed routes.
+func (c *Config) AnonymousAccessEnabled() bool {
+	if c == nil {
+		return false
+	}
+	if c.DisableAuth {
+		return true
+	}
+	return len(c.AnonymousAccess.Routes) > 0
+}
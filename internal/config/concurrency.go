@@ -0,0 +1,29 @@
+package config
+
+// ConcurrencyConfig caps in-flight upstream requests and queues the excess
+// instead of failing it.
+type ConcurrencyConfig struct {
+	// MaxPerAuth caps in-flight requests per credential. 0 means unlimited.
+	// A provider entry's max-in-flight overrides it for that entry's keys.
+	MaxPerAuth int `yaml:"max-per-auth,omitempty" json:"max-per-auth,omitempty"`
+
+	// MaxPerProvider caps in-flight requests across all credentials of a
+	// provider, keyed by provider (claude, gemini-cli, ...).
+	MaxPerProvider map[string]int `yaml:"max-per-provider,omitempty" json:"max-per-provider,omitempty"`
+
+	// QueueSize bounds the requests waiting for a slot per provider. Requests
+	// beyond it fail at once with 429. Default: 100.
+	QueueSize int `yaml:"queue-size,omitempty" json:"queue-size,omitempty"`
+
+	// MaxQueueWait bounds how long a request waits for a slot, e.g. "30s". Default: 30s.
+	MaxQueueWait string `yaml:"max-queue-wait,omitempty" json:"max-queue-wait,omitempty"`
+
+	// CooldownWait lets requests that find every credential cooling down wait
+	// for the earliest recovery when it is at most this far away, e.g. "60s".
+	// Empty or 0 returns the cooldown error at once.
+	CooldownWait string `yaml:"cooldown-wait,omitempty" json:"cooldown-wait,omitempty"`
+
+	// KeepAliveInterval is how often streaming requests that are waiting get an
+	// SSE comment so clients and proxies keep the connection open. Default: 15s.
+	KeepAliveInterval string `yaml:"keep-alive-interval,omitempty" json:"keep-alive-interval,omitempty"`
+}
@@ -64,6 +64,12 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeOIDCJWT validates bearer JWTs against an OIDC issuer's JWKS.
+	AccessProviderTypeOIDCJWT = "oidc-jwt"
+
+	// AccessProviderTypeTrustedHeader trusts an identity header set by an auth proxy.
+	AccessProviderTypeTrustedHeader = "trusted-header"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
@@ -119,6 +125,10 @@ type Config struct {
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 	DisableAuth   bool `yaml:"disable-auth" json:"disable-auth"`
 
+	// AnonymousAccess lists routes served without credentials and can restrict
+	// anonymous access, including disable-auth, to loopback clients.
+	AnonymousAccess AnonymousAccessConfig `yaml:"anonymous-access,omitempty" json:"anonymous-access"`
+
 	// Providers is the unified provider configuration.
 	Providers []Provider `yaml:"providers,omitempty" json:"providers,omitempty"`
 
@@ -130,6 +140,42 @@ type Config struct {
 	Payload             PayloadConfig       `yaml:"payload" json:"payload"`
 	Routing             RoutingConfig       `yaml:"routing,omitempty" json:"routing,omitempty"`
 
+	// PromptCache enables automatic Anthropic cache_control breakpoints for Claude backends.
+	PromptCache PromptCacheConfig `yaml:"prompt-cache" json:"prompt-cache"`
+
+	// GeminiCache controls automatic Gemini context caching (cachedContents).
+	GeminiCache GeminiCacheConfig `yaml:"gemini-cache" json:"gemini-cache"`
+
+	// MCP configures the Model Context Protocol server (stdio via `llm-mux mcp` and HTTP at /mcp).
+	MCP MCPConfig `yaml:"mcp" json:"mcp"`
+
+	// Audit configures the append-only audit trail of management and credential changes.
+	Audit AuditConfig `yaml:"audit" json:"audit"`
+
+	// Events delivers auth, quota and breaker lifecycle notifications to webhooks or commands.
+	Events EventsConfig `yaml:"events,omitempty" json:"events"`
+
+	// HealthCheck periodically probes every auth so broken accounts leave rotation before user traffic hits them.
+	HealthCheck HealthCheckConfig `yaml:"health-check,omitempty" json:"health-check"`
+
+	// Concurrency caps in-flight requests per auth and provider and queues the excess.
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty" json:"concurrency"`
+
+	// Hedging fires a second attempt for slow requests on latency-sensitive routes.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging"`
+
+	// Transport tunes outbound TLS (CA bundles, client certificates), timeouts and HTTP/2.
+	Transport TransportConfig `yaml:"transport,omitempty" json:"transport"`
+
+	// ProxyPools defines named egress proxy pools referenced as proxy-url: "pool:<name>".
+	ProxyPools []ProxyPool `yaml:"proxy-pools,omitempty" json:"proxy-pools,omitempty"`
+
+	// ToolRepair validates and repairs upstream tool calls against the request's tool schemas.
+	ToolRepair ToolRepairConfig `yaml:"tool-repair,omitempty" json:"tool-repair"`
+
+	// Guardrails applies deny lists, secret detection, PII masking and external classifiers to prompts.
+	Guardrails GuardrailsConfig `yaml:"guardrails,omitempty" json:"guardrails"`
+
 	// UseCanonicalTranslator enables the unified IR translator architecture (default: true).
 	UseCanonicalTranslator bool `yaml:"use-canonical-translator" json:"use-canonical-translator" default:"true"`
 
@@ -141,6 +187,13 @@ type Config struct {
 	// MaxResponseSize is the maximum response body size to read into memory in bytes.
 	// Set to 0 to use the default (100MB). Applies to non-streaming responses only.
 	MaxResponseSize int64 `yaml:"max-response-size" json:"max-response-size"`
+
+	// Include lists further YAML files, paths or globs relative to this file, merged into the config.
+	// Files in the config.d directory next to config.yaml are merged too; see ReadConfigSet.
+	Include []string `yaml:"include,omitempty" json:"-"`
+
+	// secretRefs maps yaml paths to the references their values were resolved from.
+	secretRefs map[string]secretRef
 }
 
 // TLSConfig holds HTTPS server settings.
@@ -200,6 +253,92 @@ type AmpCode struct {
 	ModelMappings                 []AmpModelMapping `yaml:"model-mappings" json:"model-mappings"`
 }
 
+// MCPConfig controls the Model Context Protocol server.
+type MCPConfig struct {
+	// DisableHTTP turns off the streamable HTTP endpoint at /mcp on the main port.
+	DisableHTTP bool `yaml:"disable-http" json:"disable-http"`
+
+	// DefaultModel is used by the chat tool and sampling requests that name no model.
+	// When empty, the first available model in the registry is used.
+	DefaultModel string `yaml:"default-model,omitempty" json:"default-model,omitempty"`
+}
+
+// AuditConfig controls the audit trail written for every management API mutation.
+// Entries go to File when set, otherwise to the usage DSN when configured,
+// otherwise to audit.jsonl next to credentials.json.
+type AuditConfig struct {
+	// Disable turns off audit logging entirely.
+	Disable bool `yaml:"disable" json:"disable"`
+
+	// File is a JSONL file to append entries to. Overrides the usage DSN.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// WebhookURL receives each entry as a JSON POST in addition to storage.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+
+	// WebhookSecret signs webhook bodies with HMAC-SHA256 (X-Audit-Signature header).
+	WebhookSecret string `yaml:"webhook-secret,omitempty" json:"webhook-secret,omitempty"`
+}
+
+// PromptCacheConfig controls automatic insertion of Anthropic prompt-cache breakpoints.
+// Breakpoints are only added to requests routed to Claude backends, and never beyond
+// the four-breakpoint limit imposed by the API (client-supplied breakpoints count too).
+type PromptCacheConfig struct {
+	// Enabled turns on automatic breakpoint insertion. Default: false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Models restricts the policy to matching model patterns (e.g. "claude-sonnet-*").
+	// Empty applies it to every model served by a Claude backend.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// MaxBreakpoints caps the total number of breakpoints per request. Default and maximum: 4.
+	MaxBreakpoints int `yaml:"max-breakpoints,omitempty" json:"max-breakpoints,omitempty"`
+
+	// DisableSystem skips the breakpoint on the system prompt.
+	DisableSystem bool `yaml:"disable-system" json:"disable-system"`
+
+	// DisableTools skips the breakpoint on the tool definitions.
+	DisableTools bool `yaml:"disable-tools" json:"disable-tools"`
+
+	// RecentTurns is the number of trailing user turns that receive a breakpoint.
+	// Default: 2. Set to -1 to disable turn breakpoints.
+	RecentTurns int `yaml:"recent-turns,omitempty" json:"recent-turns,omitempty"`
+
+	// TTL is the cache lifetime: "5m" (default) or "1h".
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// MinTokens overrides the minimum cacheable prefix length per model pattern.
+	// Built-in minimums follow Anthropic's documented limits (1024, 2048 or 4096 tokens).
+	MinTokens []PromptCacheMinTokens `yaml:"min-tokens,omitempty" json:"min-tokens,omitempty"`
+}
+
+// PromptCacheMinTokens sets the minimum cacheable prefix length for matching models.
+type PromptCacheMinTokens struct {
+	Model  string `yaml:"model" json:"model"`
+	Tokens int    `yaml:"tokens" json:"tokens"`
+}
+
+// GeminiCacheConfig controls automatic creation of Gemini cachedContents for
+// large, repeated system instructions and tool lists. Applies to the Gemini
+// API-key, AI Studio and Vertex (service account) executors.
+type GeminiCacheConfig struct {
+	// Auto enables automatic cache creation. Default: false.
+	Auto bool `yaml:"auto" json:"auto"`
+
+	// MinTokens is the minimum estimated size of the cacheable prefix. Default: 4096.
+	MinTokens int `yaml:"min-tokens,omitempty" json:"min-tokens,omitempty"`
+
+	// MinRepeats is how many requests must share a prefix before it is cached. Default: 2.
+	MinRepeats int `yaml:"min-repeats,omitempty" json:"min-repeats,omitempty"`
+
+	// TTL is the lifetime of created caches (e.g. "30m", "1h"). Default: "1h".
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// RefreshBefore extends a cache's TTL when it is used within this window
+	// before expiry (e.g. "10m"). Default: "10m".
+	RefreshBefore string `yaml:"refresh-before,omitempty" json:"refresh-before,omitempty"`
+}
+
 // PayloadConfig defines default and override parameter rules applied to provider payloads.
 type PayloadConfig struct {
 	Default  []PayloadRule `yaml:"default" json:"default"`
@@ -338,8 +477,9 @@ func LoadConfig(configFile string) (*Config, error) {
 }
 
 // LoadConfigOptional reads YAML from configFile.
-// If optional is true and the file is missing, it returns a default Config.
-// If optional is true and the file is empty or invalid, it returns a default Config.
+// If optional is true and the file is missing or empty, it returns a default Config.
+// Invalid YAML is always an error: falling back to defaults would silently drop
+// every configured provider.
 func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Read the entire configuration file into memory.
 	data, err := os.ReadFile(configFile)
@@ -358,19 +498,36 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		return NewDefaultConfig(), nil
 	}
 
+	// Merge included files and config.d drop-ins into one document.
+	set, err := ReadConfigSet(configFile, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if set.HasIncludes() {
+		if data, err = set.EffectiveYAML(); err != nil {
+			return nil, fmt.Errorf("failed to merge config files: %w", err)
+		}
+	}
+
 	// Unmarshal the YAML data into the Config struct.
 	// Start with defaults so absent keys keep sensible values.
 	cfg := *NewDefaultConfig()
 	if err = yaml.Unmarshal(data, &cfg); err != nil {
-		if optional {
-			// In cloud deploy mode, if YAML parsing fails, return default config instead of error.
-			return NewDefaultConfig(), nil
-		}
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err = secretIssuesErr(cfg.resolveSecrets()); err != nil {
+		return nil, err
+	}
+	normalizeLoadedConfig(&cfg)
+
+	// Return the populated configuration struct.
+	return &cfg, nil
+}
 
+// normalizeLoadedConfig applies the post-parse normalization shared by every loader.
+func normalizeLoadedConfig(cfg *Config) {
 	// Sync request authentication providers with inline API keys for backwards compatibility.
-	syncInlineAccessProvider(&cfg)
+	syncInlineAccessProvider(cfg)
 
 	cfg.Providers = SanitizeProviders(cfg.Providers)
 
@@ -379,8 +536,8 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 
 	cfg.Routing.Init()
 
-	// Return the populated configuration struct.
-	return &cfg, nil
+	// Normalization moves list entries; keep secret references with their values.
+	cfg.rebaseSecretRefs()
 }
 
 func syncInlineAccessProvider(cfg *Config) {
@@ -392,7 +549,14 @@ func syncInlineAccessProvider(cfg *Config) {
 			cfg.APIKeys = append([]string(nil), provider.APIKeys...)
 		}
 	}
-	cfg.Access.Providers = nil
+	// Inline keys now live in APIKeys; keep only external providers such as oidc-jwt.
+	var external []AccessProvider
+	for _, p := range cfg.Access.Providers {
+		if t := strings.TrimSpace(p.Type); t != "" && t != AccessProviderTypeConfigAPIKey {
+			external = append(external, p)
+		}
+	}
+	cfg.Access.Providers = external
 }
 
 // NormalizeHeaders trims header keys and values and removes empty pairs.
@@ -501,6 +665,14 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 		return fmt.Errorf("expected generated root mapping node")
 	}
 
+	// Write secret references back instead of the values they resolved to.
+	persistCfg.restoreSecretRefs(generated.Content[0])
+
+	// Leave what included files and drop-ins define in those files.
+	if set, errSet := ReadConfigSet(configFile, data); errSet == nil && set.HasIncludes() {
+		stripFragments(generated.Content[0], set.merged(1), original.Content[0])
+	}
+
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "oauth-excluded-models")
 
 	// Merge generated into original in-place, preserving comments/order of existing nodes.
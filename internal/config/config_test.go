@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig_KeepsExternalAccessProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+auth:
+  providers:
+    - name: sso
+      type: oidc-jwt
+      config:
+        issuer: https://login.example.com
+    - name: keys
+      type: config-api-key
+      api-keys: [sk-local]
+`)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0] != "sk-local" {
+		t.Fatalf("inline keys not merged into api-keys: %v", cfg.APIKeys)
+	}
+	if len(cfg.Access.Providers) != 1 || cfg.Access.Providers[0].Type != AccessProviderTypeOIDCJWT {
+		t.Fatalf("expected only the oidc-jwt provider to remain, got %+v", cfg.Access.Providers)
+	}
+}
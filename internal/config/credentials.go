@@ -21,14 +21,17 @@ const (
 )
 
 type Credentials struct {
-	ManagementKey string    `json:"management-key"`
-	CreatedAt     time.Time `json:"created-at"`
-	Version       int       `json:"version"`
+	ManagementKey string            `json:"management-key"`
+	CreatedAt     time.Time         `json:"created-at"`
+	Version       int               `json:"version"`
+	Tokens        []ManagementToken `json:"tokens,omitempty"`
 }
 
 var (
 	cache   *Credentials
 	cacheMu sync.RWMutex
+	// cacheModTime lets a running server pick up tokens added by the CLI.
+	cacheModTime time.Time
 )
 
 // CredentialsDir returns the credentials directory following XDG Base Directory spec.
@@ -61,18 +64,36 @@ func GenerateManagementKey() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-// LoadCredentials loads credentials with priority: ENV > file
+// LoadCredentials loads credentials with priority: ENV > file.
+// Named management tokens always come from the file.
 func LoadCredentials() (*Credentials, error) {
 	// Priority 1: Environment variable (LLM_MUX_MANAGEMENT_KEY with legacy fallback)
 	for _, envKey := range []string{"LLM_MUX_MANAGEMENT_KEY", "MANAGEMENT_PASSWORD"} {
 		if key := strings.TrimSpace(os.Getenv(envKey)); key != "" {
-			return &Credentials{ManagementKey: key, CreatedAt: time.Now(), Version: CredentialsVersion}, nil
+			creds := &Credentials{ManagementKey: key, CreatedAt: time.Now(), Version: CredentialsVersion}
+			if fileCreds, _ := loadCredentialsFile(); fileCreds != nil {
+				creds.Tokens = fileCreds.Tokens
+			}
+			return creds, nil
 		}
 	}
+	return loadCredentialsFile()
+}
+
+// loadCredentialsFile loads credentials from the cache or the credentials file.
+func loadCredentialsFile() (*Credentials, error) {
+	path := CredentialsFilePath()
+	if path == "" {
+		return nil, nil
+	}
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
 
 	// Priority 2: Cache
 	cacheMu.RLock()
-	if cache != nil {
+	if cache != nil && cacheModTime.Equal(modTime) {
 		c := *cache
 		cacheMu.RUnlock()
 		return &c, nil
@@ -80,10 +101,6 @@ func LoadCredentials() (*Credentials, error) {
 	cacheMu.RUnlock()
 
 	// Priority 3: File
-	path := CredentialsFilePath()
-	if path == "" {
-		return nil, nil
-	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -118,12 +135,13 @@ func LoadCredentials() (*Credentials, error) {
 		}
 	}
 
-	if creds.ManagementKey == "" {
+	if creds.ManagementKey == "" && len(creds.Tokens) == 0 {
 		return nil, nil
 	}
 
 	cacheMu.Lock()
 	cache = &creds
+	cacheModTime = modTime
 	cacheMu.Unlock()
 
 	return &creds, nil
@@ -154,6 +172,9 @@ func SaveCredentials(creds *Credentials) error {
 
 	cacheMu.Lock()
 	cache = creds
+	if info, err := os.Stat(path); err == nil {
+		cacheModTime = info.ModTime()
+	}
 	cacheMu.Unlock()
 
 	return nil
@@ -165,6 +186,10 @@ func CreateCredentials() (string, error) {
 		return "", err
 	}
 	creds := &Credentials{ManagementKey: key, CreatedAt: time.Now(), Version: CredentialsVersion}
+	// Regenerating the key keeps named tokens.
+	if existing, _ := loadCredentialsFile(); existing != nil {
+		creds.Tokens = existing.Tokens
+	}
 	if err := SaveCredentials(creds); err != nil {
 		return "", err
 	}
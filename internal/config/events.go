@@ -0,0 +1,54 @@
+package config
+
+// Event sink types for EventSink.Type.
+const (
+	EventSinkWebhook = "webhook"
+	EventSinkSlack   = "slack"
+	EventSinkCommand = "command"
+)
+
+// EventsConfig configures lifecycle notifications.
+type EventsConfig struct {
+	// Debounce suppresses repeats of the same event (type, provider and auth)
+	// within this window, e.g. "10m". Default: 10m; "0" disables debouncing.
+	Debounce string `yaml:"debounce,omitempty" json:"debounce,omitempty"`
+
+	// Sinks receive the events.
+	Sinks []EventSink `yaml:"sinks,omitempty" json:"sinks,omitempty"`
+
+	// UsageThreshold emits usage.threshold once per day when daily totals exceed it.
+	UsageThreshold UsageThreshold `yaml:"usage-threshold,omitempty" json:"usage-threshold"`
+}
+
+// EventSink is a single notification destination.
+type EventSink struct {
+	// Name identifies the sink in logs and the test endpoint.
+	Name string `yaml:"name" json:"name"`
+
+	// Type is webhook, slack or command.
+	Type string `yaml:"type" json:"type"`
+
+	// URL is the webhook or Slack incoming-webhook URL.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Secret signs webhook bodies with HMAC-SHA256 (X-Event-Signature header).
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+
+	// Headers are added to webhook requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Command is run for command sinks with the event JSON on stdin.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Events limits the sink to these event types. Empty means all.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Timeout bounds each delivery, e.g. "10s". Default: 10s.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// UsageThreshold holds daily limits for the usage.threshold event. Zero disables a limit.
+type UsageThreshold struct {
+	DailyTokens   int64 `yaml:"daily-tokens,omitempty" json:"daily-tokens,omitempty"`
+	DailyRequests int64 `yaml:"daily-requests,omitempty" json:"daily-requests,omitempty"`
+}
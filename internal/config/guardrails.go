@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Secret detectors for GuardrailSecrets.Detectors.
+var GuardrailSecretDetectors = []string{
+	"aws-access-key", "aws-secret-key", "private-key", "jwt",
+	"github-token", "slack-token", "google-api-key", "api-key",
+}
+
+// PII types for GuardrailPII.Mask.
+var GuardrailPIITypes = []string{"email", "phone", "credit-card", "ssn", "ip-address"}
+
+// Guardrail secret actions for GuardrailSecrets.Action.
+const (
+	GuardrailActionBlock  = "block"
+	GuardrailActionRedact = "redact"
+)
+
+// GuardrailsConfig checks prompts before they are sent upstream and responses
+// before they reach the client.
+type GuardrailsConfig struct {
+	// Enable turns guardrails on. Default: false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Policies are matched in order by client key and model; the first match
+	// applies. Requests matching no policy pass unchecked.
+	Policies []GuardrailPolicy `yaml:"policies,omitempty" json:"policies,omitempty"`
+}
+
+// GuardrailPolicy is one set of checks.
+type GuardrailPolicy struct {
+	// Name identifies the policy in logs and error messages.
+	Name string `yaml:"name" json:"name"`
+
+	// APIKeys limits the policy to these client keys or principals (wildcards
+	// supported). Empty means every client.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Models limits the policy to matching models (wildcards supported). Empty
+	// means every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Deny blocks requests containing any keyword or pattern.
+	Deny GuardrailDeny `yaml:"deny,omitempty" json:"deny"`
+
+	// Secrets detects credentials in prompts.
+	Secrets GuardrailSecrets `yaml:"secrets,omitempty" json:"secrets"`
+
+	// PII masks personal data with placeholders that are restored in the response.
+	PII GuardrailPII `yaml:"pii,omitempty" json:"pii"`
+
+	// Webhook asks an external classifier whether to allow the request.
+	Webhook GuardrailWebhook `yaml:"webhook,omitempty" json:"webhook"`
+
+	// CheckOutput also applies the deny list and secret detectors to responses.
+	CheckOutput bool `yaml:"check-output,omitempty" json:"check-output,omitempty"`
+}
+
+// GuardrailDeny lists blocked content.
+type GuardrailDeny struct {
+	// Keywords match case-insensitively.
+	Keywords []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+
+	// Patterns are Go regular expressions, e.g. "(?i)ignore previous instructions".
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// GuardrailSecrets configures the credential detectors.
+type GuardrailSecrets struct {
+	// Enable turns secret detection on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Detectors limits detection to these detectors. Empty means all.
+	Detectors []string `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+
+	// Action is block or redact. Default: block.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// GuardrailPII configures reversible PII masking.
+type GuardrailPII struct {
+	// Mask lists the PII types to replace with placeholders such as [EMAIL_1].
+	Mask []string `yaml:"mask,omitempty" json:"mask,omitempty"`
+}
+
+// GuardrailWebhook configures an external classifier. It receives the prompt
+// text as JSON and answers {"action": "allow"|"block", "reason": "..."}.
+type GuardrailWebhook struct {
+	// URL of the classifier. Empty disables the webhook.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Headers are added to classifier requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Timeout bounds each call, e.g. "2s". Default: 5s.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// FailOpen allows requests when the classifier is unreachable or errors.
+	// Default: false (such requests are blocked).
+	FailOpen bool `yaml:"fail-open,omitempty" json:"fail-open,omitempty"`
+}
+
+func (c *Config) validateGuardrails(add func(severity, path, format string, args ...any)) {
+	for i, p := range c.Guardrails.Policies {
+		path := fmt.Sprintf("guardrails.policies[%d]", i)
+		if strings.TrimSpace(p.Name) == "" {
+			add(IssueError, path+".name", "is required")
+		}
+		for j, pattern := range p.Deny.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				add(IssueError, fmt.Sprintf("%s.deny.patterns[%d]", path, j), "invalid pattern: %v", err)
+			}
+		}
+		for _, d := range p.Secrets.Detectors {
+			if !slices.Contains(GuardrailSecretDetectors, strings.TrimSpace(d)) {
+				add(IssueError, path+".secrets.detectors", "unknown detector %q", d)
+			}
+		}
+		switch strings.TrimSpace(p.Secrets.Action) {
+		case "", GuardrailActionBlock, GuardrailActionRedact:
+		default:
+			add(IssueError, path+".secrets.action", "must be block or redact")
+		}
+		for _, t := range p.PII.Mask {
+			if !slices.Contains(GuardrailPIITypes, strings.TrimSpace(t)) {
+				add(IssueError, path+".pii.mask", "unknown PII type %q", t)
+			}
+		}
+		if u := strings.TrimSpace(p.Webhook.URL); u != "" {
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+				add(IssueError, path+".webhook.url", "must be an http or https URL")
+			}
+		}
+		if v := strings.TrimSpace(p.Webhook.Timeout); v != "" {
+			if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+				add(IssueError, path+".webhook.timeout", "invalid duration %q", v)
+			}
+		}
+	}
+}
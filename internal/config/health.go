@@ -0,0 +1,35 @@
+package config
+
+// HealthCheckConfig configures the periodic auth health prober.
+type HealthCheckConfig struct {
+	// Enable turns on periodic probing of every enabled auth.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Interval between probe rounds, e.g. "15m". Default: 15m.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// RequestInterval is the minimum time between scheduled probes of an auth
+	// that can only be checked with a one-token completion, which uses quota,
+	// e.g. "1h". Default: 1h.
+	RequestInterval string `yaml:"request-interval,omitempty" json:"request-interval,omitempty"`
+
+	// Timeout bounds each probe, e.g. "30s". Default: 30s.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// History is the number of results kept per auth. Default: 20.
+	History int `yaml:"history,omitempty" json:"history,omitempty"`
+
+	// FailureThreshold is the number of consecutive transient failures before an
+	// auth is taken out of rotation. Auth and quota errors apply at once. Default: 2.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// Concurrency bounds probes running at the same time. Default: 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// Providers limits probing to these providers. Empty means all.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Models sets the model used for one-token probe requests, keyed by provider.
+	// Defaults to the first model registered for the auth.
+	Models map[string]string `yaml:"models,omitempty" json:"models,omitempty"`
+}
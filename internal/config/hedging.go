@@ -0,0 +1,24 @@
+package config
+
+// HedgingConfig starts a second attempt on another credential or provider when
+// the first has not answered within a recent latency percentile. The first
+// attempt to answer wins and the other is cancelled.
+type HedgingConfig struct {
+	// Enable turns hedging on. Default: false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Models limits hedging to matching models (wildcards supported). Empty
+	// means every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Percentile of recent time-to-first-byte after which the second attempt
+	// starts, between 0 and 100. Default: 95.
+	Percentile float64 `yaml:"percentile,omitempty" json:"percentile,omitempty"`
+
+	// MinDelay is the shortest wait before hedging, e.g. "250ms". Default: 250ms.
+	MinDelay string `yaml:"min-delay,omitempty" json:"min-delay,omitempty"`
+
+	// MaxDelay is the longest wait before hedging, also used until enough
+	// latency samples exist, e.g. "10s". Default: 10s.
+	MaxDelay string `yaml:"max-delay,omitempty" json:"max-delay,omitempty"`
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DropInDir is the directory next to the main config file whose *.yaml and
+// *.yml files are merged into the configuration in lexical order.
+const DropInDir = "config.d"
+
+// Fragment is one YAML file contributing to the effective configuration.
+type Fragment struct {
+	Path string
+	Data []byte
+	root *yaml.Node
+}
+
+// ConfigSet is a main config file together with the files it pulls in, in
+// merge order: the main file, its include: entries (recursively, depth first),
+// then the config.d drop-ins and their includes.
+//
+// Merge semantics: mappings are merged key by key, lists are appended, and for
+// anything else the later file wins.
+type ConfigSet struct {
+	Fragments []Fragment
+	// WatchDirs are directories where a new file can change the set: the
+	// drop-in directory and the directories of glob includes.
+	WatchDirs []string
+}
+
+// ReadConfigSet loads configFile and everything it includes. When mainData is
+// non-nil it is used as the content of configFile instead of reading it.
+func ReadConfigSet(configFile string, mainData []byte) (*ConfigSet, error) {
+	if mainData == nil {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		mainData = data
+	}
+	r := &setReader{set: &ConfigSet{}, visited: make(map[string]bool), watch: make(map[string]bool)}
+	if err := r.add(configFile, mainData); err != nil {
+		return nil, err
+	}
+	dropIn := filepath.Join(filepath.Dir(configFile), DropInDir)
+	if info, err := os.Stat(dropIn); err == nil && info.IsDir() {
+		r.watchDir(dropIn)
+		files, errList := yamlFilesIn(dropIn)
+		if errList != nil {
+			return nil, errList
+		}
+		for _, file := range files {
+			if err = r.addFile(file); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r.set, nil
+}
+
+// Files returns the paths of every file in the set, main file first.
+func (s *ConfigSet) Files() []string {
+	files := make([]string, len(s.Fragments))
+	for i, f := range s.Fragments {
+		files[i] = f.Path
+	}
+	return files
+}
+
+// HasIncludes reports whether the set holds more than the main file.
+func (s *ConfigSet) HasIncludes() bool {
+	return s != nil && len(s.Fragments) > 1
+}
+
+// merged returns the root mapping of fragments[from:] merged in order,
+// without include keys.
+func (s *ConfigSet) merged(from int) *yaml.Node {
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, f := range s.Fragments[from:] {
+		mergeFragment(out, f.root)
+	}
+	removeMapKey(out, "include")
+	return out
+}
+
+// EffectiveYAML renders the merged configuration. Secret references are left
+// unresolved, so the output is as safe to show as the files themselves.
+func (s *ConfigSet) EffectiveYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(s.merged(0)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type setReader struct {
+	set     *ConfigSet
+	visited map[string]bool
+	watch   map[string]bool
+}
+
+func (r *setReader) addFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("include: %w", err)
+	}
+	return r.add(path, data)
+}
+
+func (r *setReader) add(path string, data []byte) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	if r.visited[abs] {
+		return fmt.Errorf("include: %s is included more than once or recursively", path)
+	}
+	r.visited[abs] = true
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 && !(doc.Content[0].Kind == yaml.ScalarNode && doc.Content[0].Tag == "!!null") {
+		if doc.Content[0].Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: top level must be a mapping", path)
+		}
+		root = doc.Content[0]
+	}
+	r.set.Fragments = append(r.set.Fragments, Fragment{Path: path, Data: data, root: root})
+
+	idx := findMapKeyIndex(root, "include")
+	if idx < 0 {
+		return nil
+	}
+	list := root.Content[idx+1]
+	if list.Kind != yaml.SequenceNode {
+		return nil // reported by the schema check
+	}
+	base := filepath.Dir(path)
+	for _, item := range list.Content {
+		pattern := strings.TrimSpace(item.Value)
+		if pattern == "" {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(base, pattern)
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			if err = r.addFile(pattern); err != nil {
+				return err
+			}
+			continue
+		}
+		r.watchDir(filepath.Dir(pattern))
+		matches, errGlob := filepath.Glob(pattern)
+		if errGlob != nil {
+			return fmt.Errorf("include %q: %w", pattern, errGlob)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err = r.addFile(match); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *setReader) watchDir(dir string) {
+	if !r.watch[dir] {
+		r.watch[dir] = true
+		r.set.WatchDirs = append(r.set.WatchDirs, dir)
+	}
+}
+
+func yamlFilesIn(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if ext := filepath.Ext(e.Name()); ext == ".yaml" || ext == ".yml" {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeFragment merges src into dst: mappings key by key, lists appended,
+// anything else replaced.
+func mergeFragment(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if value.Kind == yaml.AliasNode && value.Alias != nil {
+			value = value.Alias
+		}
+		idx := findMapKeyIndex(dst, key.Value)
+		if idx < 0 {
+			dst.Content = append(dst.Content, deepCopyNode(key), deepCopyNode(value))
+			continue
+		}
+		existing := dst.Content[idx+1]
+		switch {
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeFragment(existing, value)
+		case existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				existing.Content = append(existing.Content, deepCopyNode(item))
+			}
+		default:
+			dst.Content[idx+1] = deepCopyNode(value)
+		}
+	}
+}
+
+// stripFragments removes from gen, the rendering of an effective config, what
+// the non-main fragments contribute, so that saving gen into the main file
+// and merging the fragments over it again yields the same config. orig is
+// the main file's node at the same position, or nil.
+//
+// Values that differ from the fragments are kept: a change to a value a
+// fragment defines is written to the main file, where the fragment still
+// overrides it, and list items a fragment defines cannot be removed here.
+func stripFragments(gen, frag, orig *yaml.Node) {
+	if gen == nil || frag == nil || gen.Kind != yaml.MappingNode || frag.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(frag.Content); i += 2 {
+		key := frag.Content[i].Value
+		idx := findMapKeyIndex(gen, key)
+		if idx < 0 {
+			continue
+		}
+		gv, fv := gen.Content[idx+1], frag.Content[i+1]
+		var ov *yaml.Node
+		if oi := findMapKeyIndex(orig, key); oi >= 0 {
+			ov = orig.Content[oi+1]
+		}
+		switch {
+		case gv.Kind == yaml.MappingNode && fv.Kind == yaml.MappingNode:
+			stripFragments(gv, fv, ov)
+			if len(gv.Content) == 0 && ov == nil {
+				removeMapKey(gen, key)
+			}
+		case gv.Kind == yaml.SequenceNode && fv.Kind == yaml.SequenceNode:
+			// Drop the fragment's items wherever they ended up; items added
+			// through the API may follow them.
+			end := len(gv.Content)
+			for j := len(fv.Content) - 1; j >= 0; j-- {
+				for k := end - 1; k >= 0; k-- {
+					if nodeCovers(gv.Content[k], fv.Content[j]) {
+						gv.Content = append(gv.Content[:k], gv.Content[k+1:]...)
+						end = k
+						break
+					}
+				}
+			}
+			if len(gv.Content) == 0 && ov == nil {
+				removeMapKey(gen, key)
+			}
+		default:
+			if nodeCovers(gv, fv) {
+				// Leave the main file's own value, if any, untouched.
+				removeMapKey(gen, key)
+			}
+		}
+	}
+}
+
+// nodeCovers reports whether gen holds everything frag does. Mappings in gen
+// may carry extra keys, since rendering a loaded config adds defaults, and
+// scalars compare after the trimming and case folding applied on load.
+func nodeCovers(gen, frag *yaml.Node) bool {
+	if gen.Kind == yaml.AliasNode && gen.Alias != nil {
+		gen = gen.Alias
+	}
+	if frag.Kind == yaml.AliasNode && frag.Alias != nil {
+		frag = frag.Alias
+	}
+	if gen.Kind != frag.Kind {
+		return false
+	}
+	switch gen.Kind {
+	case yaml.ScalarNode:
+		return strings.EqualFold(strings.TrimSpace(gen.Value), strings.TrimSpace(frag.Value))
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(frag.Content); i += 2 {
+			idx := findMapKeyIndex(gen, frag.Content[i].Value)
+			if idx < 0 || !nodeCovers(gen.Content[idx+1], frag.Content[i+1]) {
+				return false
+			}
+		}
+		return true
+	default:
+		if len(gen.Content) != len(frag.Content) {
+			return false
+		}
+		for i := range frag.Content {
+			if !nodeCovers(gen.Content[i], frag.Content[i]) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigSet_MergesIncludesAndDropIns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, `port: 8317
+include:
+  - providers/*.yaml
+api-keys:
+  - main-key
+routing:
+  aliases:
+    fast: gpt-4o-mini
+`)
+	writeConfigFile(t, filepath.Join(dir, "providers", "groq.yaml"), `providers:
+  - type: openai
+    name: groq
+    api-key: gsk-1
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: llama-3.3-70b
+`)
+	writeConfigFile(t, filepath.Join(dir, DropInDir, "10-team.yaml"), `api-keys:
+  - team-key
+routing:
+  aliases:
+    smart: claude-sonnet-4
+`)
+	writeConfigFile(t, filepath.Join(dir, DropInDir, "20-port.yml"), "port: 9000\n")
+	writeConfigFile(t, filepath.Join(dir, DropInDir, "notes.txt"), "ignored")
+
+	set, err := ReadConfigSet(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(set.Files()); got != 4 {
+		t.Fatalf("files = %v", set.Files())
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("port = %d, want the later file to win", cfg.Port)
+	}
+	if strings.Join(cfg.APIKeys, ",") != "main-key,team-key" {
+		t.Errorf("api-keys = %v, want lists appended", cfg.APIKeys)
+	}
+	if cfg.Routing.Aliases["fast"] != "gpt-4o-mini" || cfg.Routing.Aliases["smart"] != "claude-sonnet-4" {
+		t.Errorf("aliases = %v, want maps merged", cfg.Routing.Aliases)
+	}
+	if len(cfg.Providers) != 1 || cfg.Providers[0].Name != "groq" {
+		t.Errorf("providers = %+v", cfg.Providers)
+	}
+}
+
+func TestConfigSet_SaveLeavesFragmentValuesInTheirFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, "port: 8317\napi-keys:\n  - main-key\n")
+	writeConfigFile(t, filepath.Join(dir, DropInDir, "team.yaml"), `api-keys:
+  - team-key
+providers:
+  - type: openai
+    name: groq
+    api-key: gsk-1
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: llama-3.3-70b
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.APIKeys = append(cfg.APIKeys, "new-key")
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "team-key") || strings.Contains(string(data), "groq") {
+		t.Fatalf("drop-in values copied into config.yaml:\n%s", data)
+	}
+
+	reloaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Providers) != 1 {
+		t.Errorf("providers = %d after save, want 1", len(reloaded.Providers))
+	}
+	if !strings.Contains(strings.Join(reloaded.APIKeys, ","), "new-key") || len(reloaded.APIKeys) != 3 {
+		t.Errorf("api-keys = %v", reloaded.APIKeys)
+	}
+}
+
+func TestConfigSet_RejectsIncludeCycles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, "include:\n  - a.yaml\n")
+	writeConfigFile(t, filepath.Join(dir, "a.yaml"), "include:\n  - config.yaml\n")
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an include cycle error")
+	}
+}
+
+func TestValidateConfigFile_ReportsIssueFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, path, "port: 8317\n")
+	dropIn := filepath.Join(dir, DropInDir, "typo.yaml")
+	writeConfigFile(t, dropIn, "prot: 9000\n")
+
+	_, issues := ValidateConfigFile(path, nil, ValidateOptions{})
+	if ValidationErr(issues) == nil {
+		t.Fatal("expected an unknown key error")
+	}
+	if issues[0].File != dropIn || issues[0].Line != 1 {
+		t.Errorf("issue = %+v, want file %s line 1", issues[0], dropIn)
+	}
+}
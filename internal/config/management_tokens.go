@@ -0,0 +1,211 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Management token scopes. ManagementScopeAdmin grants every other scope.
+const (
+	ManagementScopeRead   = "read"   // usage statistics and status
+	ManagementScopeAuth   = "auth"   // OAuth login, auth file upload, toggle and refresh
+	ManagementScopeConfig = "config" // config.yaml and runtime settings
+	ManagementScopeLogs   = "logs"   // server and request error logs
+	ManagementScopeAdmin  = "admin"  // everything, including token management
+)
+
+// ManagementScopes lists all valid management token scopes.
+var ManagementScopes = []string{
+	ManagementScopeRead,
+	ManagementScopeAuth,
+	ManagementScopeConfig,
+	ManagementScopeLogs,
+	ManagementScopeAdmin,
+}
+
+const managementTokenPrefix = "llmx_"
+
+// ErrManagementTokenNotFound is returned when no token has the given name.
+var ErrManagementTokenNotFound = errors.New("management token not found")
+
+var managementTokenName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ManagementToken is a named, scoped management credential stored in
+// credentials.json. Only the SHA-256 of the secret is persisted.
+type ManagementToken struct {
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"key-hash"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created-at"`
+	ExpiresAt *time.Time `json:"expires-at,omitempty"`
+	RevokedAt *time.Time `json:"revoked-at,omitempty"`
+}
+
+// Expired reports whether the token has passed its expiry time.
+func (t *ManagementToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Revoked reports whether the token has been revoked.
+func (t *ManagementToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// HasScope reports whether the token grants scope.
+func (t *ManagementToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, ManagementScopeAdmin) || slices.Contains(t.Scopes, scope)
+}
+
+// tokenMu serializes read-modify-write cycles on the token list.
+var tokenMu sync.Mutex
+
+// NormalizeManagementScopes lower-cases, de-duplicates and validates scopes.
+func NormalizeManagementScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	for _, raw := range scopes {
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			if s == "" {
+				continue
+			}
+			if !slices.Contains(ManagementScopes, s) {
+				return nil, fmt.Errorf("unknown scope %q (valid: %s)", s, strings.Join(ManagementScopes, ", "))
+			}
+			if !slices.Contains(out, s) {
+				out = append(out, s)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return out, nil
+}
+
+// CreateManagementToken adds a named token and returns its secret. The secret
+// cannot be recovered later. A zero ttl creates a token that never expires.
+func CreateManagementToken(name string, scopes []string, ttl time.Duration) (string, *ManagementToken, error) {
+	name = strings.TrimSpace(name)
+	if !managementTokenName.MatchString(name) {
+		return "", nil, fmt.Errorf("invalid token name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	scopes, err := NormalizeManagementScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	secret := managementTokenPrefix + hex.EncodeToString(b)
+
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	creds, err := loadCredentialsFile()
+	if err != nil {
+		return "", nil, err
+	}
+	if creds == nil {
+		creds = &Credentials{CreatedAt: time.Now(), Version: CredentialsVersion}
+	}
+	tokens := slices.Clone(creds.Tokens)
+	for i := range tokens {
+		if tokens[i].Name == name {
+			if !tokens[i].Revoked() && !tokens[i].Expired(time.Now()) {
+				return "", nil, fmt.Errorf("token %q already exists", name)
+			}
+			// Reusing the name of a dead token replaces it.
+			tokens = slices.Delete(tokens, i, i+1)
+			break
+		}
+	}
+	tok := ManagementToken{
+		Name:      name,
+		KeyHash:   hashManagementToken(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if ttl > 0 {
+		exp := tok.CreatedAt.Add(ttl)
+		tok.ExpiresAt = &exp
+	}
+	tokens = append(tokens, tok)
+	updated := *creds
+	updated.Tokens = tokens
+	if err := SaveCredentials(&updated); err != nil {
+		return "", nil, err
+	}
+	return secret, &tok, nil
+}
+
+// RevokeManagementToken marks a token as revoked. Revoked tokens stay in the
+// file so their name keeps showing up in listings and logs.
+func RevokeManagementToken(name string) error {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	creds, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+	if creds == nil {
+		return fmt.Errorf("%w: %s", ErrManagementTokenNotFound, name)
+	}
+	tokens := slices.Clone(creds.Tokens)
+	for i := range tokens {
+		if tokens[i].Name != name {
+			continue
+		}
+		if tokens[i].Revoked() {
+			return nil
+		}
+		now := time.Now().UTC()
+		tokens[i].RevokedAt = &now
+		updated := *creds
+		updated.Tokens = tokens
+		return SaveCredentials(&updated)
+	}
+	return fmt.Errorf("%w: %s", ErrManagementTokenNotFound, name)
+}
+
+// ListManagementTokens returns all named tokens, including revoked and expired ones.
+func ListManagementTokens() []ManagementToken {
+	creds, _ := LoadCredentials()
+	if creds == nil {
+		return nil
+	}
+	return slices.Clone(creds.Tokens)
+}
+
+// FindManagementToken returns the token whose secret matches, regardless of its
+// expiry or revocation state, or nil.
+func FindManagementToken(secret string) *ManagementToken {
+	if !strings.HasPrefix(secret, managementTokenPrefix) {
+		return nil
+	}
+	creds, _ := LoadCredentials()
+	if creds == nil {
+		return nil
+	}
+	hash := []byte(hashManagementToken(secret))
+	for i := range creds.Tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(creds.Tokens[i].KeyHash)) == 1 {
+			tok := creds.Tokens[i]
+			return &tok
+		}
+	}
+	return nil
+}
+
+func hashManagementToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagementTokens_Lifecycle(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LLM_MUX_MANAGEMENT_KEY", "")
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	InvalidateCache()
+	t.Cleanup(InvalidateCache)
+
+	key, err := CreateCredentials()
+	if err != nil {
+		t.Fatalf("CreateCredentials: %v", err)
+	}
+
+	secret, tok, err := CreateManagementToken("ci-reader", []string{"read,logs", "READ"}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateManagementToken: %v", err)
+	}
+	if len(tok.Scopes) != 2 || !tok.HasScope(ManagementScopeLogs) || tok.HasScope(ManagementScopeConfig) {
+		t.Errorf("unexpected scopes %v", tok.Scopes)
+	}
+	if tok.ExpiresAt == nil {
+		t.Error("expected expiry to be set")
+	}
+	if _, _, err := CreateManagementToken("ci-reader", []string{"read"}, 0); err == nil {
+		t.Error("expected duplicate name to be rejected")
+	}
+	if _, _, err := CreateManagementToken("bad", []string{"root"}, 0); err == nil {
+		t.Error("expected unknown scope to be rejected")
+	}
+
+	found := FindManagementToken(secret)
+	if found == nil || found.Name != "ci-reader" {
+		t.Fatalf("FindManagementToken = %+v", found)
+	}
+	if FindManagementToken(secret+"x") != nil || FindManagementToken(key) != nil {
+		t.Error("unexpected match for a wrong secret")
+	}
+	if GetManagementKey() != key {
+		t.Error("management key must survive token changes")
+	}
+
+	if err := RevokeManagementToken("ci-reader"); err != nil {
+		t.Fatalf("RevokeManagementToken: %v", err)
+	}
+	if found := FindManagementToken(secret); found == nil || !found.Revoked() {
+		t.Error("expected revoked token to still be found and marked revoked")
+	}
+	if err := RevokeManagementToken("missing"); !errors.Is(err, ErrManagementTokenNotFound) {
+		t.Errorf("expected ErrManagementTokenNotFound, got %v", err)
+	}
+
+	// Regenerating the management key keeps tokens.
+	if _, err := CreateCredentials(); err != nil {
+		t.Fatalf("CreateCredentials: %v", err)
+	}
+	if len(ListManagementTokens()) != 1 {
+		t.Errorf("tokens lost after key regeneration: %v", ListManagementTokens())
+	}
+}
@@ -17,13 +17,25 @@ const (
 
 	// ProviderTypeVertexCompat uses Vertex AI-compatible endpoints (zenmux, etc.).
 	ProviderTypeVertexCompat ProviderType = "vertex-compat"
+
+	// ProviderTypeOllama uses the native Ollama /api/chat API (Ollama, llama.cpp servers
+	// with Ollama compatibility) with model discovery from /api/tags.
+	ProviderTypeOllama ProviderType = "ollama"
+
+	// ProviderTypeAzureOpenAI uses Azure OpenAI deployments, authenticated with
+	// an api-key or a Microsoft Entra ID client credential.
+	ProviderTypeAzureOpenAI ProviderType = "azure-openai"
+
+	// ProviderTypeBedrock uses the AWS Bedrock Converse API with SigV4 signing
+	// (or a Bedrock API key).
+	ProviderTypeBedrock ProviderType = "bedrock"
 )
 
 // Provider represents a unified API provider configuration.
 // This replaces the legacy gemini-api-key, claude-api-key, codex-api-key,
 // openai-compatibility, and vertex-api-key configurations.
 type Provider struct {
-	// Type specifies the provider type (gemini, anthropic, openai, vertex-compat).
+	// Type specifies the provider type (gemini, anthropic, openai, vertex-compat, ollama, azure-openai, bedrock).
 	Type ProviderType `yaml:"type" json:"type"`
 
 	// Name is a display name for this provider instance.
@@ -43,8 +55,8 @@ type Provider struct {
 	APIKeys []ProviderAPIKey `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
 
 	// BaseURL is the API endpoint URL.
-	// Required for: openai, vertex-compat
-	// Optional for: gemini, anthropic (uses default if not set)
+	// Required for: openai, vertex-compat, azure-openai (the resource endpoint, e.g. https://name.openai.azure.com)
+	// Optional for: gemini, anthropic, ollama, bedrock (uses default if not set)
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
 	// ProxyURL sets a proxy for this provider's requests.
@@ -54,12 +66,79 @@ type Provider struct {
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 
 	// Models defines available models for this provider.
-	// Required for: openai, vertex-compat
-	// Optional for: gemini, anthropic (uses built-in registry if not set)
+	// Required for: openai, vertex-compat, azure-openai (name is the deployment, alias the model clients use),
+	// bedrock (name is the model ID or inference profile, alias the model clients use)
+	// Optional for: gemini, anthropic (uses built-in registry if not set), ollama (discovered if not set)
 	Models []ProviderModel `yaml:"models,omitempty" json:"models,omitempty"`
 
 	// ExcludedModels lists model names to exclude from this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// MaxInFlight caps concurrent requests per key of this provider, overriding
+	// concurrency.max-per-auth. 0 uses the global setting.
+	MaxInFlight int `yaml:"max-in-flight,omitempty" json:"max-in-flight,omitempty"`
+
+	// Transport overrides the global transport settings for this provider's requests.
+	Transport *TransportSettings `yaml:"transport,omitempty" json:"transport,omitempty"`
+
+	// KeepAlive is how long the server keeps a model loaded after a request ("10m", "-1" for
+	// forever, "0" to unload). A keep_alive sent by the client takes precedence. Only for: ollama
+	KeepAlive string `yaml:"keep-alive,omitempty" json:"keep-alive,omitempty"`
+
+	// NumCtx is the context window requested from the server (options.num_ctx). A num_ctx
+	// sent by the client takes precedence. Only for: ollama
+	NumCtx int `yaml:"num-ctx,omitempty" json:"num-ctx,omitempty"`
+
+	// APIVersion is the api-version query parameter sent with every request.
+	// Defaults to the current GA version for chat and preview for responses. Only for: azure-openai
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// WireAPI selects the upstream API: "chat" (chat completions, default) or
+	// "responses". Only for: azure-openai
+	WireAPI string `yaml:"wire-api,omitempty" json:"wire-api,omitempty"`
+
+	// EntraID authenticates with a Microsoft Entra ID client credential instead
+	// of an api-key. Only for: azure-openai
+	EntraID *EntraIDCredential `yaml:"entra-id,omitempty" json:"entra-id,omitempty"`
+
+	// Region is the AWS region requests are sent to and signed for. Only for: bedrock
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
+	// InferenceProfile prefixes model IDs with a cross-region inference profile:
+	// "geo" picks the profile for the region's geography (us., eu., apac.), "global"
+	// uses global. and empty sends model IDs unchanged. Only for: bedrock
+	InferenceProfile string `yaml:"inference-profile,omitempty" json:"inference-profile,omitempty"`
+
+	// AWS sets the credentials requests are signed with. When unset, credentials
+	// come from the environment (static keys, then web identity), then the shared
+	// credentials file. An api-key is sent as a Bedrock API key instead. Only for: bedrock
+	AWS *AWSCredential `yaml:"aws,omitempty" json:"aws,omitempty"`
+}
+
+// AWSCredential selects the credentials used for SigV4 request signing.
+type AWSCredential struct {
+	AccessKeyID     string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+	SessionToken    string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// Profile reads keys from the shared credentials file (~/.aws/credentials or
+	// AWS_SHARED_CREDENTIALS_FILE) instead.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// EntraIDCredential is a Microsoft Entra ID (Azure AD) app registration used
+// to obtain bearer tokens with the client credentials flow.
+type EntraIDCredential struct {
+	TenantID     string `yaml:"tenant-id" json:"tenant-id"`
+	ClientID     string `yaml:"client-id" json:"client-id"`
+	ClientSecret string `yaml:"client-secret" json:"client-secret"`
+
+	// Scope defaults to https://cognitiveservices.azure.com/.default.
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+
+	// Authority defaults to https://login.microsoftonline.com; set it for
+	// sovereign clouds.
+	Authority string `yaml:"authority,omitempty" json:"authority,omitempty"`
 }
 
 // ProviderAPIKey represents an API key with optional per-key settings.
@@ -69,6 +148,9 @@ type ProviderAPIKey struct {
 
 	// ProxyURL overrides the provider's proxy for this key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Transport overrides the provider's transport settings for this key.
+	Transport *TransportSettings `yaml:"transport,omitempty" json:"transport,omitempty"`
 }
 
 // ProviderModel defines a model available from this provider.
@@ -110,6 +192,13 @@ func (p *Provider) GetDisplayName() string {
 	return string(p.Type)
 }
 
+// AllowsKeyless reports whether the provider can be used without an api-key:
+// local Ollama servers, Azure OpenAI with Entra ID, and Bedrock, which signs
+// requests with AWS credentials.
+func (p *Provider) AllowsKeyless() bool {
+	return p.Type == ProviderTypeOllama || p.Type == ProviderTypeBedrock || (p.Type == ProviderTypeAzureOpenAI && p.EntraID != nil)
+}
+
 // Validate checks if the provider configuration is valid.
 func (p *Provider) Validate() error {
 	if p.Type == "" {
@@ -117,13 +206,13 @@ func (p *Provider) Validate() error {
 	}
 
 	// Check API key
-	if p.APIKey == "" && len(p.APIKeys) == 0 {
+	if !p.AllowsKeyless() && p.APIKey == "" && len(p.APIKeys) == 0 {
 		return &ProviderValidationError{Field: "api-key", Message: "api-key or api-keys is required"}
 	}
 
 	// Type-specific validation
 	switch p.Type {
-	case ProviderTypeOpenAI, ProviderTypeVertexCompat:
+	case ProviderTypeOpenAI, ProviderTypeVertexCompat, ProviderTypeAzureOpenAI:
 		if p.BaseURL == "" {
 			return &ProviderValidationError{Field: "base-url", Message: "base-url is required for " + string(p.Type)}
 		}
@@ -131,6 +220,31 @@ func (p *Provider) Validate() error {
 			return &ProviderValidationError{Field: "models", Message: "models is required for " + string(p.Type)}
 		}
 	}
+	if p.Type == ProviderTypeAzureOpenAI {
+		if p.WireAPI != "" && p.WireAPI != "chat" && p.WireAPI != "responses" {
+			return &ProviderValidationError{Field: "wire-api", Message: "wire-api must be chat or responses"}
+		}
+		if e := p.EntraID; e != nil && (e.TenantID == "" || e.ClientID == "" || e.ClientSecret == "") {
+			return &ProviderValidationError{Field: "entra-id", Message: "tenant-id, client-id and client-secret are required"}
+		}
+	}
+	if p.Type == ProviderTypeBedrock {
+		if p.Region == "" {
+			return &ProviderValidationError{Field: "region", Message: "region is required for bedrock"}
+		}
+		if len(p.Models) == 0 {
+			return &ProviderValidationError{Field: "models", Message: "models is required for bedrock"}
+		}
+		if p.InferenceProfile != "" && p.InferenceProfile != "geo" && p.InferenceProfile != "global" {
+			return &ProviderValidationError{Field: "inference-profile", Message: "inference-profile must be geo or global"}
+		}
+		if a := p.AWS; a != nil && (a.AccessKeyID == "") != (a.SecretAccessKey == "") {
+			return &ProviderValidationError{Field: "aws", Message: "access-key-id and secret-access-key must be set together"}
+		}
+	}
+	if p.MaxInFlight < 0 {
+		return &ProviderValidationError{Field: "max-in-flight", Message: "max-in-flight must not be negative"}
+	}
 
 	return nil
 }
@@ -169,6 +283,24 @@ func SanitizeProviders(providers []Provider) []Provider {
 		p.BaseURL = strings.TrimRight(strings.TrimSpace(p.BaseURL), "/")
 		p.ProxyURL = strings.TrimSpace(p.ProxyURL)
 		p.Headers = NormalizeHeaders(p.Headers)
+		p.KeepAlive = strings.TrimSpace(p.KeepAlive)
+		p.APIVersion = strings.TrimSpace(p.APIVersion)
+		p.WireAPI = strings.ToLower(strings.TrimSpace(p.WireAPI))
+		if e := p.EntraID; e != nil {
+			e.TenantID = strings.TrimSpace(e.TenantID)
+			e.ClientID = strings.TrimSpace(e.ClientID)
+			e.ClientSecret = strings.TrimSpace(e.ClientSecret)
+			e.Scope = strings.TrimSpace(e.Scope)
+			e.Authority = strings.TrimRight(strings.TrimSpace(e.Authority), "/")
+		}
+		p.Region = strings.ToLower(strings.TrimSpace(p.Region))
+		p.InferenceProfile = strings.ToLower(strings.TrimSpace(p.InferenceProfile))
+		if a := p.AWS; a != nil {
+			a.AccessKeyID = strings.TrimSpace(a.AccessKeyID)
+			a.SecretAccessKey = strings.TrimSpace(a.SecretAccessKey)
+			a.SessionToken = strings.TrimSpace(a.SessionToken)
+			a.Profile = strings.TrimSpace(a.Profile)
+		}
 
 		// Normalize API keys
 		validKeys := make([]ProviderAPIKey, 0, len(p.APIKeys))
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyPoolPrefix marks proxy-url values that reference a proxy pool, e.g.
+// "pool:egress".
+const ProxyPoolPrefix = "pool:"
+
+// ProxyPool is a named set of egress proxies. Auths use it through
+// proxy-url: "pool:<name>" and fail over between healthy members.
+type ProxyPool struct {
+	// Name is referenced as proxy-url: "pool:<name>".
+	Name string `yaml:"name" json:"name"`
+
+	// URLs are the members: http://, https:// or socks5:// proxy URLs.
+	URLs []string `yaml:"urls" json:"urls"`
+
+	// CheckURL is fetched through each member by the health check. Empty
+	// only checks that the proxy accepts connections.
+	CheckURL string `yaml:"check-url,omitempty" json:"check-url,omitempty"`
+
+	// CheckInterval is the time between health checks, e.g. "30s". Default: 30s.
+	CheckInterval string `yaml:"check-interval,omitempty" json:"check-interval,omitempty"`
+
+	// CheckTimeout bounds one health check, e.g. "5s". Default: 5s.
+	CheckTimeout string `yaml:"check-timeout,omitempty" json:"check-timeout,omitempty"`
+}
+
+// validateProxyURL reports a malformed proxy-url or one that references an
+// unknown pool.
+func validateProxyURL(raw string, pools map[string]bool) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > len(ProxyPoolPrefix) && strings.EqualFold(raw[:len(ProxyPoolPrefix)], ProxyPoolPrefix) {
+		name := strings.TrimSpace(raw[len(ProxyPoolPrefix):])
+		if !pools[name] {
+			return fmt.Errorf("unknown proxy pool %q", name)
+		}
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		if u.Host == "" {
+			return fmt.Errorf("proxy URL %q has no host", raw)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or pool:<name>)", u.Scheme)
+	}
+}
+
+// validateProxyPools checks the pools and every proxy-url that references one.
+func (c *Config) validateProxyPools(add func(severity, path, format string, args ...any)) {
+	pools := make(map[string]bool, len(c.ProxyPools))
+	for i, p := range c.ProxyPools {
+		path := fmt.Sprintf("proxy-pools[%d]", i)
+		name := strings.TrimSpace(p.Name)
+		switch {
+		case name == "":
+			add(IssueError, path+".name", "name is required")
+		case pools[name]:
+			add(IssueError, path+".name", "duplicate proxy pool %q", name)
+		}
+		pools[name] = true
+		if len(p.URLs) == 0 {
+			add(IssueError, path+".urls", "at least one proxy URL is required")
+		}
+		for j, u := range p.URLs {
+			if _, isPool := strings.CutPrefix(strings.TrimSpace(u), ProxyPoolPrefix); isPool {
+				add(IssueError, fmt.Sprintf("%s.urls[%d]", path, j), "pools cannot reference other pools")
+				continue
+			}
+			if strings.TrimSpace(u) == "" {
+				add(IssueError, fmt.Sprintf("%s.urls[%d]", path, j), "proxy URL is empty")
+			} else if err := validateProxyURL(u, nil); err != nil {
+				add(IssueError, fmt.Sprintf("%s.urls[%d]", path, j), "%v", err)
+			}
+		}
+		for _, d := range []struct{ field, value string }{
+			{"check-interval", p.CheckInterval},
+			{"check-timeout", p.CheckTimeout},
+		} {
+			if v := strings.TrimSpace(d.value); v != "" {
+				if dur, err := time.ParseDuration(v); err != nil || dur <= 0 {
+					add(IssueError, path+"."+d.field, "invalid duration %q", v)
+				}
+			}
+		}
+	}
+
+	if err := validateProxyURL(c.ProxyURL, pools); err != nil {
+		add(IssueError, "proxy-url", "%v", err)
+	}
+	for i, p := range c.Providers {
+		if err := validateProxyURL(p.ProxyURL, pools); err != nil {
+			add(IssueError, fmt.Sprintf("providers[%d].proxy-url", i), "%v", err)
+		}
+		for j, k := range p.APIKeys {
+			if err := validateProxyURL(k.ProxyURL, pools); err != nil {
+				add(IssueError, fmt.Sprintf("providers[%d].api-keys[%d].proxy-url", i, j), "%v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SchemaID is the $id of the generated config schema.
+const SchemaID = "https://github.com/nghyane/llm-mux/config.schema.json"
+
+var (
+	schemaOnce sync.Once
+	schemaDoc  map[string]any
+)
+
+// Schema returns the JSON Schema of config.yaml, derived from the yaml tags of
+// Config. Objects reject unknown keys; maps accept any key.
+// The returned map is shared and must not be modified.
+func Schema() map[string]any {
+	schemaOnce.Do(func() {
+		schemaDoc = schemaForType(reflect.TypeOf(Config{}))
+		schemaDoc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schemaDoc["$id"] = SchemaID
+		schemaDoc["title"] = "llm-mux configuration"
+		if props, ok := schemaDoc["properties"].(map[string]any); ok {
+			if providers, ok := props["providers"].(map[string]any); ok {
+				if item, ok := providers["items"].(map[string]any); ok {
+					if itemProps, ok := item["properties"].(map[string]any); ok {
+						itemProps["type"] = map[string]any{"type": "string", "enum": providerTypeNames()}
+					}
+				}
+			}
+		}
+	})
+	return schemaDoc
+}
+
+func providerTypeNames() []any {
+	return []any{
+		string(ProviderTypeGemini),
+		string(ProviderTypeAnthropic),
+		string(ProviderTypeOpenAI),
+		string(ProviderTypeVertexCompat),
+		string(ProviderTypeOllama),
+		string(ProviderTypeAzureOpenAI),
+		string(ProviderTypeBedrock),
+	}
+}
+
+func schemaForType(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]any)
+		collectStructProperties(t, props)
+		return map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	default:
+		return map[string]any{}
+	}
+}
+
+func collectStructProperties(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") || (f.Anonymous && name == "") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectStructProperties(ft, props)
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		props[name] = schemaForType(f.Type)
+	}
+}
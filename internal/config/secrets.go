@@ -0,0 +1,410 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret reference forms accepted in the secret-bearing fields of config.yaml
+// (see secretKeys):
+//
+//	${NAME} or ${NAME:-default}  interpolated anywhere in the value
+//	env:NAME                      the whole value comes from an environment variable
+//	file:/run/secrets/name        the whole value is read from a file (trailing newline trimmed)
+//
+// References are resolved on load and written back unresolved when the config
+// is persisted, so config.yaml and the stores that sync it stay secret-free.
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+)
+
+// secretKeys are the yaml keys whose values may hold references: credentials,
+// and URLs or DSNs that can embed them. Values of any headers map qualify too.
+// Other strings are taken literally, so a reference cannot pull a secret into
+// a field that is sent somewhere else, such as a base-url.
+var secretKeys = map[string]bool{
+	"api-key":           true,
+	"api-keys":          true,
+	"key":               true,
+	"upstream-api-key":  true,
+	"vertex-api-key":    true,
+	"secret":            true,
+	"client-secret":     true,
+	"shared-secret":     true,
+	"webhook-secret":    true,
+	"access-key-id":     true,
+	"secret-access-key": true,
+	"session-token":     true,
+	"token":             true,
+	"password":          true,
+	"dsn":               true,
+	"proxy-url":         true,
+	"urls":              true,
+	"url":               true,
+	"webhook-url":       true,
+}
+
+// isSecretPath reports whether the value at a yaml key path such as
+// "providers[0].api-keys[1].key" may hold a reference.
+func isSecretPath(path string) bool {
+	field := secretListIndex.ReplaceAllString(path, "")
+	if i := strings.Index(field, "headers."); i == 0 || (i > 0 && field[i-1] == '.') {
+		return true
+	}
+	return secretKeys[field[strings.LastIndexByte(field, '.')+1:]]
+}
+
+// FileSecretRefs returns the paths of the secret fields in data that are read
+// from a file. Configs uploaded through the management API may not use them,
+// since the file contents would be sent wherever the uploaded config says.
+func FileSecretRefs(data []byte) []string {
+	cfg := &Config{}
+	if yaml.Unmarshal(data, cfg) != nil {
+		return nil
+	}
+	return cfg.UnresolvedFileRefs()
+}
+
+// UnresolvedFileRefs returns the paths of the secret fields of c whose value
+// is a file: reference. Loaded configs hold the file contents instead, so a
+// reference here was set through the management API and would be read on the
+// next reload.
+func (c *Config) UnresolvedFileRefs() []string {
+	var paths []string
+	walkStrings(reflect.ValueOf(c).Elem(), "", func(path, s string) (string, bool) {
+		if isSecretPath(path) && strings.HasPrefix(s, secretFilePrefix) {
+			paths = append(paths, path)
+		}
+		return s, false
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+// secretRef remembers the raw form of a resolved value.
+type secretRef struct {
+	raw      string
+	resolved string
+}
+
+// secretIssue is a reference that could not be resolved.
+type secretIssue struct {
+	path string
+	err  error
+}
+
+// hasSecretRef reports whether s contains a reference.
+func hasSecretRef(s string) bool {
+	return strings.Contains(s, "${") || strings.HasPrefix(s, secretEnvPrefix) || strings.HasPrefix(s, secretFilePrefix)
+}
+
+// resolveSecretRef expands the references in s.
+func resolveSecretRef(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, secretEnvPrefix):
+		name := strings.TrimSpace(strings.TrimPrefix(s, secretEnvPrefix))
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(s, secretFilePrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(s, secretFilePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", s)
+		}
+		b.WriteString(s[:start])
+		expr := s[start+2 : start+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", fmt.Errorf("empty variable name in %q", s)
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok || (v == "" && hasDefault) {
+			if !hasDefault {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			v = def
+		}
+		b.WriteString(v)
+		s = s[start+end+1:]
+	}
+}
+
+// resolveSecrets replaces references in the secret-bearing fields of c,
+// recording the raw forms for persistence.
+func (c *Config) resolveSecrets() []secretIssue {
+	var issues []secretIssue
+	c.secretRefs = nil
+	resolve := func(path, raw string) (string, bool) {
+		if !isSecretPath(path) || !hasSecretRef(raw) {
+			return raw, false
+		}
+		v, err := resolveSecretRef(raw)
+		if err != nil {
+			issues = append(issues, secretIssue{path: path, err: err})
+			return raw, false
+		}
+		if c.secretRefs == nil {
+			c.secretRefs = make(map[string]secretRef)
+		}
+		c.secretRefs[path] = secretRef{raw: raw, resolved: v}
+		return v, true
+	}
+	walkStrings(reflect.ValueOf(c).Elem(), "", resolve)
+	return issues
+}
+
+// walkStrings visits the strings reachable from v, using yaml key paths such
+// as "providers[0].api-key", and stores the replacement when fn changes one.
+func walkStrings(v reflect.Value, path string, fn func(path, s string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface && v.Elem().Kind() == reflect.String {
+			return // handled by the containing map or slice
+		}
+		walkStrings(v.Elem(), path, fn)
+	case reflect.String:
+		if s, changed := fn(path, v.String()); changed && v.CanSet() {
+			v.SetString(s)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			child := path
+			if !strings.Contains(opts, "inline") {
+				if name == "" {
+					name = strings.ToLower(f.Name)
+				}
+				child = joinSchemaPath(path, name)
+			}
+			walkStrings(v.Field(i), child, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			child := path + "[" + strconv.Itoa(i) + "]"
+			if item.Kind() == reflect.Interface && !item.IsNil() && item.Elem().Kind() == reflect.String {
+				if s, changed := fn(child, item.Elem().String()); changed {
+					item.Set(reflect.ValueOf(s))
+				}
+				continue
+			}
+			walkStrings(item, child, fn)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			child := joinSchemaPath(path, key.String())
+			item := v.MapIndex(key)
+			elem := item
+			if elem.Kind() == reflect.Interface && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.String {
+				if s, changed := fn(child, elem.String()); changed {
+					v.SetMapIndex(key, reflect.ValueOf(s).Convert(v.Type().Elem()))
+				}
+				continue
+			}
+			// Map values are not addressable; nested structs or maps are
+			// updated through a copy.
+			cp := reflect.New(item.Type()).Elem()
+			cp.Set(item)
+			walkStrings(cp, child, fn)
+			v.SetMapIndex(key, cp)
+		}
+	}
+}
+
+// restoreSecretRefs puts the raw references back into a YAML rendering of c.
+// A value is restored only at the field its reference was recorded for, and
+// only while it still equals what the reference resolved to.
+func (c *Config) restoreSecretRefs(node *yaml.Node) {
+	if c == nil || len(c.secretRefs) == 0 {
+		return
+	}
+	refs := newSecretAligner(c.secretRefs)
+	restoreNode(node, "", func(path, value string) (string, bool) {
+		if ref, ok := refs.match(path, value); ok {
+			return ref.raw, true
+		}
+		return "", false
+	})
+}
+
+// rebaseSecretRefs re-keys the recorded references to the paths their values
+// have after normalization, which drops list entries such as disabled
+// providers and empty api-keys.
+func (c *Config) rebaseSecretRefs() {
+	if len(c.secretRefs) == 0 {
+		return
+	}
+	refs := newSecretAligner(c.secretRefs)
+	rebased := make(map[string]secretRef, len(c.secretRefs))
+	walkStrings(reflect.ValueOf(c).Elem(), "", func(path, s string) (string, bool) {
+		if ref, ok := refs.match(path, s); ok {
+			rebased[path] = secretRef{raw: ref.raw, resolved: s}
+		}
+		return s, false
+	})
+	c.secretRefs = rebased
+}
+
+// secretListIndex matches the list indices of a yaml key path.
+var secretListIndex = regexp.MustCompile(`\[(\d+)\]`)
+
+// secretField returns the field a path belongs to regardless of list
+// positions, e.g. "providers[].api-key". Inline keys of the legacy
+// config-api-key access provider are moved to api-keys on load.
+func secretField(path string) string {
+	field := secretListIndex.ReplaceAllString(path, "[]")
+	if field == "access.providers[].api-keys[]" {
+		return "api-keys[]"
+	}
+	return field
+}
+
+// secretAligner hands out recorded references to the values of a config in
+// document order. Entries of a list keep their order when others are added
+// or removed, so each value is matched against the next references recorded
+// for the same field: a value never takes a reference from another field,
+// and each reference is used once.
+type secretAligner struct {
+	refs   map[string]secretRef
+	queues map[string][]string
+}
+
+func newSecretAligner(refs map[string]secretRef) *secretAligner {
+	paths := make([]string, 0, len(refs))
+	for path := range refs {
+		paths = append(paths, path)
+	}
+	// Order list entries numerically, so that items[10] follows items[9].
+	sortKey := func(path string) string {
+		return secretListIndex.ReplaceAllStringFunc(path, func(m string) string {
+			return fmt.Sprintf("[%010s]", m[1:len(m)-1])
+		})
+	}
+	sort.Slice(paths, func(i, j int) bool { return sortKey(paths[i]) < sortKey(paths[j]) })
+	a := &secretAligner{refs: refs, queues: make(map[string][]string)}
+	for _, path := range paths {
+		field := secretField(path)
+		a.queues[field] = append(a.queues[field], path)
+	}
+	return a
+}
+
+// match returns the reference for a value at path, if the next references
+// recorded for its field include one that resolved to the value.
+func (a *secretAligner) match(path, value string) (secretRef, bool) {
+	if value == "" {
+		return secretRef{}, false
+	}
+	field := secretField(path)
+	queue := a.queues[field]
+	for i, p := range queue {
+		ref := a.refs[p]
+		if ref.resolved == value || strings.TrimSpace(ref.resolved) == value {
+			a.queues[field] = queue[i+1:]
+			return ref, true
+		}
+	}
+	return secretRef{}, false
+}
+
+func restoreNode(n *yaml.Node, path string, fn func(path, value string) (string, bool)) {
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			restoreNode(child, path, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			restoreNode(n.Content[i+1], joinSchemaPath(path, n.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range n.Content {
+			restoreNode(child, path+"["+strconv.Itoa(i)+"]", fn)
+		}
+	case yaml.ScalarNode:
+		if n.Tag != "!!str" && n.Tag != "" {
+			return
+		}
+		if raw, ok := fn(path, n.Value); ok {
+			n.Value = raw
+			n.Tag = "!!str"
+			n.Style = 0
+		}
+	}
+}
+
+// WithSecretRefs returns a copy of c in which resolved secrets are replaced
+// by their references, for display through the management API.
+func (c *Config) WithSecretRefs() (*Config, error) {
+	if c == nil || len(c.secretRefs) == 0 {
+		return c, nil
+	}
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, err
+	}
+	c.restoreSecretRefs(&node)
+	out := &Config{}
+	if err := node.Decode(out); err != nil {
+		return nil, err
+	}
+	out.secretRefs = c.secretRefs
+	out.Routing.Init()
+	return out, nil
+}
+
+func secretIssuesErr(issues []secretIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(issues))
+	for i, issue := range issues {
+		msgs[i] = issue.path + ": " + issue.err.Error()
+	}
+	return fmt.Errorf("unresolved secret references: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretRefs_ResolvedOnLoadAndNeverPersisted(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "upstream-key")
+	if err := os.WriteFile(secretFile, []byte("amp-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_GROQ_KEY", "gsk-secret")
+	t.Setenv("TEST_PG_PASS", "pg-secret")
+	path := filepath.Join(dir, "config.yaml")
+	raw := `port: 8317
+usage:
+  dsn: "postgres://mux:${TEST_PG_PASS}@db:5432/mux"
+ampcode:
+  upstream-api-key: "file:` + secretFile + `"
+providers:
+  - type: openai
+    name: groq
+    api-key: env:TEST_GROQ_KEY
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: llama-3.3-70b
+`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Providers[0].APIKey != "gsk-secret" || cfg.AmpCode.UpstreamAPIKey != "amp-secret" ||
+		cfg.Usage.DSN != "postgres://mux:pg-secret@db:5432/mux" {
+		t.Fatalf("references not resolved: %q %q %q", cfg.Providers[0].APIKey, cfg.AmpCode.UpstreamAPIKey, cfg.Usage.DSN)
+	}
+
+	cfg.Port = 9000
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(path)
+	for _, secret := range []string{"gsk-secret", "amp-secret", "pg-secret"} {
+		if strings.Contains(string(saved), secret) {
+			t.Fatalf("persisted config leaks %q:\n%s", secret, saved)
+		}
+	}
+	for _, ref := range []string{"env:TEST_GROQ_KEY", "${TEST_PG_PASS}", "file:" + secretFile, "port: 9000"} {
+		if !strings.Contains(string(saved), ref) {
+			t.Fatalf("persisted config lost %q:\n%s", ref, saved)
+		}
+	}
+
+	shown, err := cfg.WithSecretRefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shown.Providers[0].APIKey != "env:TEST_GROQ_KEY" || cfg.Providers[0].APIKey != "gsk-secret" {
+		t.Fatalf("WithSecretRefs: got %q, live config %q", shown.Providers[0].APIKey, cfg.Providers[0].APIKey)
+	}
+}
+
+func TestSecretRefs_UnresolvedIsAnError(t *testing.T) {
+	data := []byte("api-keys: [\"${TEST_MISSING_SECRET}\", \"${TEST_MISSING_SECRET:-fallback}\"]\n")
+	cfg, issues := ValidateYAML(data, ValidateOptions{})
+	if ValidationErr(issues) == nil || len(issues) != 1 || issues[0].Path != "api-keys[0]" {
+		t.Fatalf("expected one unresolved reference error, got %v", issues)
+	}
+	if cfg.APIKeys[1] != "fallback" {
+		t.Fatalf("default not applied: %q", cfg.APIKeys[1])
+	}
+	if _, issues = ValidateYAML(data, ValidateOptions{AllowUnresolvedSecrets: true}); ValidationErr(issues) != nil {
+		t.Fatalf("AllowUnresolvedSecrets should downgrade to warnings: %v", issues)
+	}
+}
+
+func TestSecretRefs_RestoredByFieldAfterListChanges(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-shared")
+	t.Setenv("TEST_GROQ_KEY", "gsk-secret")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	raw := `port: 8317
+ampcode:
+  upstream-api-key: sk-shared
+providers:
+  - type: openai
+    name: disabled
+    enabled: false
+    api-key: env:TEST_OPENAI_KEY
+    base-url: https://api.openai.com/v1
+    models:
+      - name: m
+  - type: openai
+    name: openai
+    api-key: env:TEST_OPENAI_KEY
+    base-url: https://api.openai.com/v1
+    models:
+      - name: m
+  - type: openai
+    name: groq
+    api-key: env:TEST_GROQ_KEY
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: m
+`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Providers) != 2 {
+		t.Fatalf("expected the disabled provider to be dropped, got %d providers", len(cfg.Providers))
+	}
+
+	// Drop a provider, as the management API does, so the next one moves up.
+	cfg.Providers = cfg.Providers[1:]
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "gsk-secret") || !strings.Contains(string(saved), "env:TEST_GROQ_KEY") {
+		t.Fatalf("moved provider lost its reference:\n%s", saved)
+	}
+	// A literal that happens to equal a resolved secret is left alone.
+	if !strings.Contains(string(saved), "upstream-api-key: sk-shared") {
+		t.Fatalf("literal value was replaced by a reference:\n%s", saved)
+	}
+}
+
+func TestSecretRefs_OnlySecretFieldsResolved(t *testing.T) {
+	t.Setenv("TEST_GROQ_KEY", "gsk-secret")
+	data := []byte(`providers:
+  - type: openai
+    name: groq
+    api-key: env:TEST_GROQ_KEY
+    base-url: "https://example.com/${TEST_GROQ_KEY}"
+    headers:
+      Authorization: "Bearer ${TEST_GROQ_KEY}"
+    models:
+      - name: m
+`)
+	cfg, issues := ValidateYAML(data, ValidateOptions{})
+	if ValidationErr(issues) != nil {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+	p := cfg.Providers[0]
+	if p.APIKey != "gsk-secret" || p.Headers["Authorization"] != "Bearer gsk-secret" {
+		t.Fatalf("secret fields not resolved: %q %q", p.APIKey, p.Headers["Authorization"])
+	}
+	if p.BaseURL != "https://example.com/${TEST_GROQ_KEY}" {
+		t.Fatalf("base-url must be taken literally, got %q", p.BaseURL)
+	}
+
+	if got := FileSecretRefs([]byte("ampcode:\n  upstream-api-key: file:/run/secrets/amp\nproviders:\n  - type: openai\n    name: x\n    base-url: file:/not/a/secret\n")); len(got) != 1 || got[0] != "ampcode.upstream-api-key" {
+		t.Fatalf("FileSecretRefs = %v", got)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import "strings"
+
+// MaxToolRepairReprompts caps ToolRepairConfig.Reprompt.
+const MaxToolRepairReprompts = 3
+
+// ToolRepairConfig validates tool calls returned by upstreams against the
+// schemas declared in the request and repairs broken JSON, misspelled tool
+// names and mistyped arguments before they reach the client.
+type ToolRepairConfig struct {
+	// Enable turns tool call repair on. Default: false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Providers limits repair to these providers, e.g. "github-copilot",
+	// "kiro", "qwen". Empty means every provider that supports it.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// FuzzyToolNames maps unknown tool names to the closest declared tool.
+	// Default: true.
+	FuzzyToolNames *bool `yaml:"fuzzy-tool-names,omitempty" json:"fuzzy-tool-names,omitempty"`
+
+	// Reprompt is how many times a non-streaming request is retried with a
+	// correction message when a tool call cannot be repaired. Default: 0 (off).
+	Reprompt int `yaml:"reprompt,omitempty" json:"reprompt,omitempty"`
+}
+
+// AppliesTo reports whether repair is enabled for providerName.
+func (c ToolRepairConfig) AppliesTo(providerName string) bool {
+	if !c.Enable {
+		return false
+	}
+	if len(c.Providers) == 0 {
+		return true
+	}
+	for _, p := range c.Providers {
+		if strings.EqualFold(strings.TrimSpace(p), providerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// FuzzyNames reports whether unknown tool names are matched to declared tools.
+func (c ToolRepairConfig) FuzzyNames() bool {
+	return c.FuzzyToolNames == nil || *c.FuzzyToolNames
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/transport"
+)
+
+// TransportSettings tunes outbound connections to upstream providers. Empty
+// fields keep the built-in defaults or the settings of the enclosing level.
+type TransportSettings struct {
+	// CACert is a PEM bundle trusted in addition to the system roots, e.g. the
+	// CA of a TLS-intercepting egress gateway.
+	CACert string `yaml:"ca-cert,omitempty" json:"ca-cert,omitempty"`
+
+	// ClientCert and ClientKey are PEM files presented for mutual TLS.
+	ClientCert string `yaml:"client-cert,omitempty" json:"client-cert,omitempty"`
+	ClientKey  string `yaml:"client-key,omitempty" json:"client-key,omitempty"`
+
+	// DialTimeout bounds TCP connection setup, e.g. "3s".
+	DialTimeout string `yaml:"dial-timeout,omitempty" json:"dial-timeout,omitempty"`
+
+	// TLSHandshakeTimeout bounds the TLS handshake, e.g. "10s".
+	TLSHandshakeTimeout string `yaml:"tls-handshake-timeout,omitempty" json:"tls-handshake-timeout,omitempty"`
+
+	// ResponseHeaderTimeout bounds the wait for response headers after the
+	// request is sent, e.g. "600s". Defaults to stream-timeout when set.
+	ResponseHeaderTimeout string `yaml:"response-header-timeout,omitempty" json:"response-header-timeout,omitempty"`
+
+	// HTTP2 turns HTTP/2 on or off. Default: on.
+	HTTP2 *bool `yaml:"http2,omitempty" json:"http2,omitempty"`
+}
+
+// TransportConfig holds the global transport settings and per-provider
+// overrides. Provider entries and their api-keys can override both.
+type TransportConfig struct {
+	TransportSettings `yaml:",inline"`
+
+	// Providers overrides the global settings per provider (claude,
+	// gemini-cli, antigravity, ...), including OAuth-backed ones.
+	Providers map[string]TransportSettings `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// Settings converts s for the transport package. Invalid durations are
+// ignored; Validate reports them.
+func (s *TransportSettings) Settings() transport.Settings {
+	if s == nil {
+		return transport.Settings{}
+	}
+	dur := func(v string) time.Duration {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			return 0
+		}
+		return d
+	}
+	return transport.Settings{
+		CACert:                strings.TrimSpace(s.CACert),
+		ClientCert:            strings.TrimSpace(s.ClientCert),
+		ClientKey:             strings.TrimSpace(s.ClientKey),
+		DialTimeout:           dur(s.DialTimeout),
+		TLSHandshakeTimeout:   dur(s.TLSHandshakeTimeout),
+		ResponseHeaderTimeout: dur(s.ResponseHeaderTimeout),
+		HTTP2:                 s.HTTP2,
+	}
+}
+
+// validate reports problems with s under path through add.
+func (s *TransportSettings) validate(path string, add func(severity, path, format string, args ...any)) {
+	if s == nil {
+		return
+	}
+	for _, d := range []struct{ field, value string }{
+		{"dial-timeout", s.DialTimeout},
+		{"tls-handshake-timeout", s.TLSHandshakeTimeout},
+		{"response-header-timeout", s.ResponseHeaderTimeout},
+	} {
+		if v := strings.TrimSpace(d.value); v != "" {
+			if dur, err := time.ParseDuration(v); err != nil || dur < 0 {
+				add(IssueError, path+"."+d.field, "invalid duration %q", v)
+			}
+		}
+	}
+	if (strings.TrimSpace(s.ClientCert) == "") != (strings.TrimSpace(s.ClientKey) == "") {
+		add(IssueError, path, "client-cert and client-key must be set together")
+		return
+	}
+	if err := (&transport.Settings{
+		CACert:     strings.TrimSpace(s.CACert),
+		ClientCert: strings.TrimSpace(s.ClientCert),
+		ClientKey:  strings.TrimSpace(s.ClientKey),
+	}).Check(); err != nil {
+		add(IssueError, path, "%v", err)
+	}
+}
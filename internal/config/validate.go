@@ -0,0 +1,423 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validation issue severities. Errors make a config unusable; warnings flag
+// settings that are probably mistakes but do not prevent loading.
+const (
+	IssueError   = "error"
+	IssueWarning = "warning"
+)
+
+// ValidationIssue is a single problem found in a configuration.
+type ValidationIssue struct {
+	// File is set for schema issues found in an included file or drop-in.
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File)
+		b.WriteString(": ")
+	}
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidationError reports the error-level issues of a rejected configuration.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// ValidationErr returns a *ValidationError holding the error-level issues, or
+// nil when there are none.
+func ValidationErr(issues []ValidationIssue) error {
+	var errs []ValidationIssue
+	for _, issue := range issues {
+		if issue.Severity == IssueError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: errs}
+}
+
+// ValidateOptions supplies context the config file alone does not contain.
+type ValidateOptions struct {
+	// KnownModels lists model IDs served by built-in or registered providers.
+	// When empty, routing model references are not checked.
+	KnownModels []string
+
+	// AllowUnresolvedSecrets reports secret references that cannot be
+	// resolved in this environment as warnings instead of errors.
+	AllowUnresolvedSecrets bool
+}
+
+// builtinProviderKeys are the executor identifiers accepted in
+// routing.provider-priority besides the names of configured providers.
+var builtinProviderKeys = []string{
+	"claude", "antigravity", "gemini-cli", "vertex", "aistudio", "codex",
+	"github-copilot", "qwen", "iflow", "cline", "kiro", "gemini", "ollama", "azure-openai", "bedrock",
+	"openai-compatibility",
+}
+
+// legacyConfigKeys were replaced by providers and are ignored when present.
+var legacyConfigKeys = map[string]bool{
+	"generative-language-api-key": true,
+	"gemini-api-key":              true,
+	"claude-api-key":              true,
+	"codex-api-key":               true,
+	"openai-compatibility":        true,
+}
+
+// ValidateYAML checks raw config.yaml content against Schema() and the
+// semantic rules of Config.Validate. It returns the parsed and normalized
+// configuration, or nil when the YAML cannot be decoded, together with every
+// issue found.
+func ValidateYAML(data []byte, opts ValidateOptions) (*Config, []ValidationIssue) {
+	var issues []ValidationIssue
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, append(issues, ValidationIssue{Severity: IssueError, Message: err.Error()})
+	}
+	if len(doc.Content) > 0 {
+		validateNode(doc.Content[0], Schema(), "", &issues)
+	}
+	return validateMerged(data, opts, issues)
+}
+
+// ValidateConfigFile is ValidateYAML for configFile together with its include:
+// files and config.d drop-ins. Each file is checked against the schema on its
+// own, so issues point at the file and line to fix; the semantic rules run on
+// the merged result. When data is non-nil it replaces the main file's content.
+func ValidateConfigFile(configFile string, data []byte, opts ValidateOptions) (*Config, []ValidationIssue) {
+	set, err := ReadConfigSet(configFile, data)
+	if err != nil {
+		return nil, []ValidationIssue{{Severity: IssueError, Message: err.Error()}}
+	}
+	if !set.HasIncludes() {
+		return ValidateYAML(set.Fragments[0].Data, opts)
+	}
+	var issues []ValidationIssue
+	for i, f := range set.Fragments {
+		start := len(issues)
+		validateNode(f.root, Schema(), "", &issues)
+		if i > 0 {
+			for j := start; j < len(issues); j++ {
+				issues[j].File = f.Path
+			}
+		}
+	}
+	merged, err := set.EffectiveYAML()
+	if err != nil {
+		return nil, append(issues, ValidationIssue{Severity: IssueError, Message: err.Error()})
+	}
+	return validateMerged(merged, opts, issues)
+}
+
+// validateMerged decodes schema-checked YAML and applies Config.Validate.
+func validateMerged(data []byte, opts ValidateOptions, issues []ValidationIssue) (*Config, []ValidationIssue) {
+	cfg := NewDefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		if ValidationErr(issues) == nil {
+			issues = append(issues, ValidationIssue{Severity: IssueError, Message: err.Error()})
+		}
+		return nil, issues
+	}
+	// References are resolved first, so that validation and normalization
+	// see the values that will be used.
+	severity := IssueError
+	if opts.AllowUnresolvedSecrets {
+		severity = IssueWarning
+	}
+	for _, si := range cfg.resolveSecrets() {
+		issues = append(issues, ValidationIssue{Path: si.path, Severity: severity, Message: si.err.Error()})
+	}
+	issues = append(issues, cfg.Validate(opts)...)
+	normalizeLoadedConfig(cfg)
+	return cfg, issues
+}
+
+// Validate checks cross-field rules that the schema cannot express: provider
+// settings, server settings and routing references to providers and models.
+func (c *Config) Validate(opts ValidateOptions) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(severity, path, format string, args ...any) {
+		issues = append(issues, ValidationIssue{Path: path, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		add(IssueError, "port", "must be between 0 and 65535")
+	}
+	if c.TLS.Enable && (strings.TrimSpace(c.TLS.Cert) == "" || strings.TrimSpace(c.TLS.Key) == "") {
+		add(IssueError, "tls", "cert and key are required when tls is enabled")
+	}
+	for _, d := range []struct{ path, value string }{
+		{"concurrency.max-queue-wait", c.Concurrency.MaxQueueWait},
+		{"concurrency.cooldown-wait", c.Concurrency.CooldownWait},
+		{"concurrency.keep-alive-interval", c.Concurrency.KeepAliveInterval},
+		{"hedging.min-delay", c.Hedging.MinDelay},
+		{"hedging.max-delay", c.Hedging.MaxDelay},
+	} {
+		if v := strings.TrimSpace(d.value); v != "" {
+			if dur, err := time.ParseDuration(v); err != nil || dur < 0 {
+				add(IssueError, d.path, "invalid duration %q", v)
+			}
+		}
+	}
+	if c.Concurrency.MaxPerAuth < 0 || c.Concurrency.QueueSize < 0 {
+		add(IssueError, "concurrency", "limits must not be negative")
+	}
+	if p := c.Hedging.Percentile; p < 0 || p > 100 {
+		add(IssueError, "hedging.percentile", "must be between 0 and 100")
+	}
+	if n := c.ToolRepair.Reprompt; n < 0 || n > MaxToolRepairReprompts {
+		add(IssueError, "tool-repair.reprompt", "must be between 0 and %d", MaxToolRepairReprompts)
+	}
+	c.validateProxyPools(add)
+	c.validateGuardrails(add)
+	c.Transport.TransportSettings.validate("transport", add)
+	for _, name := range sortedKeys(c.Transport.Providers) {
+		s := c.Transport.Providers[name]
+		s.validate("transport.providers."+name, add)
+	}
+
+	providerKeys := make(map[string]bool, len(builtinProviderKeys)+len(c.Providers))
+	for _, key := range builtinProviderKeys {
+		providerKeys[key] = true
+	}
+	models := make(map[string]bool, len(opts.KnownModels))
+	for _, id := range opts.KnownModels {
+		models[id] = true
+	}
+	for i := range c.Providers {
+		p := c.Providers[i]
+		p.Type = ProviderType(strings.ToLower(strings.TrimSpace(string(p.Type))))
+		providerKeys[string(p.Type)] = true
+		providerKeys[strings.ToLower(strings.TrimSpace(p.Name))] = true
+		for _, m := range p.Models {
+			models[strings.TrimSpace(m.Name)] = true
+			models[strings.TrimSpace(m.Alias)] = true
+		}
+		if !p.IsEnabled() {
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			path := fmt.Sprintf("providers[%d]", i)
+			if verr, ok := err.(*ProviderValidationError); ok {
+				add(IssueError, path+"."+verr.Field, "%s", verr.Message)
+			} else {
+				add(IssueError, path, "%v", err)
+			}
+		}
+		p.Transport.validate(fmt.Sprintf("providers[%d].transport", i), add)
+		for j := range p.APIKeys {
+			p.APIKeys[j].Transport.validate(fmt.Sprintf("providers[%d].api-keys[%d].transport", i, j), add)
+		}
+	}
+
+	for _, name := range sortedKeys(c.Routing.ProviderPriority) {
+		if !providerKeys[strings.ToLower(name)] {
+			add(IssueError, "routing.provider-priority."+name, "unknown provider %q", name)
+		}
+	}
+
+	if len(opts.KnownModels) == 0 {
+		return issues
+	}
+	// User-facing alias names are valid fallback targets too.
+	for alias := range c.Routing.Aliases {
+		models[alias] = true
+	}
+	// Some providers discover models at runtime, so unknown models are warnings.
+	checkModel := func(path, model string) {
+		if model = strings.TrimSpace(model); model != "" && !models[model] {
+			add(IssueWarning, path, "unknown model %q", model)
+		}
+	}
+	for _, alias := range sortedKeys(c.Routing.Aliases) {
+		checkModel("routing.aliases."+alias, c.Routing.Aliases[alias])
+	}
+	for _, model := range sortedKeys(c.Routing.Fallbacks) {
+		checkModel("routing.fallbacks."+model, model)
+		for i, target := range c.Routing.Fallbacks[model] {
+			checkModel(fmt.Sprintf("routing.fallbacks.%s[%d]", model, i), target)
+		}
+	}
+	return issues
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateNode checks a YAML node against the subset of JSON Schema produced
+// by Schema(): type, properties, additionalProperties, items and enum.
+func validateNode(n *yaml.Node, schema map[string]any, path string, issues *[]ValidationIssue) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*issues = append(*issues, ValidationIssue{Path: path, Line: n.Line, Severity: IssueError, Message: fmt.Sprintf(format, args...)})
+	}
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		if n.Kind != yaml.MappingNode {
+			fail("expected a mapping, got %s", describeNode(n))
+			return
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			child := joinSchemaPath(path, key.Value)
+			if prop, ok := props[key.Value].(map[string]any); ok {
+				validateNode(value, prop, child, issues)
+				continue
+			}
+			switch addl := schema["additionalProperties"].(type) {
+			case map[string]any:
+				validateNode(value, addl, child, issues)
+			case bool:
+				if addl {
+					continue
+				}
+				issue := ValidationIssue{Path: child, Line: key.Line, Severity: IssueError, Message: "unknown key"}
+				if path == "" && legacyConfigKeys[key.Value] {
+					issue.Severity = IssueWarning
+					issue.Message = "legacy key is ignored; configure it under providers"
+				} else if suggestion := closestKey(key.Value, props); suggestion != "" {
+					issue.Message = fmt.Sprintf("unknown key (did you mean %q?)", suggestion)
+				}
+				*issues = append(*issues, issue)
+			}
+		}
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			fail("expected a list, got %s", describeNode(n))
+			return
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range n.Content {
+			validateNode(item, items, path+"["+strconv.Itoa(i)+"]", issues)
+		}
+	case "string":
+		if n.Kind != yaml.ScalarNode {
+			fail("expected a string, got %s", describeNode(n))
+			return
+		}
+		if enum, ok := schema["enum"].([]any); ok {
+			for _, v := range enum {
+				if s, _ := v.(string); strings.EqualFold(s, strings.TrimSpace(n.Value)) {
+					return
+				}
+			}
+			fail("must be one of %v, got %q", enum, n.Value)
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!int" {
+			fail("expected an integer, got %s", describeNode(n))
+		}
+	case "number":
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float") {
+			fail("expected a number, got %s", describeNode(n))
+		}
+	case "boolean":
+		// yaml.v3 still decodes YAML 1.1 yes/no/on/off into bool fields.
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!bool" && !yaml11Bools[strings.ToLower(n.Value)]) {
+			fail("expected true or false, got %s", describeNode(n))
+		}
+	}
+}
+
+var yaml11Bools = map[string]bool{"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describeNode(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return strconv.Quote(n.Value)
+	}
+}
+
+// closestKey returns the known key within edit distance 2 of key, if any.
+func closestKey(key string, props map[string]any) string {
+	best, bestDist := "", 3
+	for _, name := range sortedKeys(props) {
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
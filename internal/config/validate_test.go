@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateYAML_DefaultConfigIsValid(t *testing.T) {
+	cfg, issues := ValidateYAML(GenerateDefaultConfigYAML(), ValidateOptions{})
+	if cfg == nil || len(issues) != 0 {
+		t.Fatalf("default config should validate cleanly, got %v", issues)
+	}
+}
+
+func TestValidateYAML_ReportsIssues(t *testing.T) {
+	data := []byte(`
+prot: 8317
+request-retry: lots
+gemini-api-key: []
+providers:
+  - type: openai
+    api-key: sk-test
+    base-url: https://api.example.com/v1
+  - type: openai
+    name: groq
+    api-key: gsk-test
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: llama-3.3-70b
+        alias: llama70b
+`)
+	cfg, issues := ValidateYAML(data, ValidateOptions{})
+	if cfg != nil {
+		t.Fatal("type errors should prevent decoding")
+	}
+	want := map[string]string{
+		"prot":           "did you mean \"port\"",
+		"request-retry":  "expected an integer",
+		"gemini-api-key": "legacy key",
+	}
+	for _, issue := range issues {
+		if frag, ok := want[issue.Path]; ok && strings.Contains(issue.Message, frag) && issue.Line > 0 {
+			delete(want, issue.Path)
+		}
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing issues %v in %v", want, issues)
+	}
+
+	data = []byte(`
+providers:
+  - type: openai
+    api-key: sk-test
+    base-url: https://api.example.com/v1
+  - type: openai
+    name: groq
+    api-key: gsk-test
+    base-url: https://api.groq.com/openai/v1
+    models:
+      - name: llama-3.3-70b
+        alias: llama70b
+routing:
+  provider-priority:
+    groq: 1
+    claud: 2
+  fallbacks:
+    llama70b: [gpt-5, gpt-9000]
+`)
+	cfg, issues = ValidateYAML(data, ValidateOptions{KnownModels: []string{"gpt-5"}})
+	if cfg == nil {
+		t.Fatalf("semantic issues should not prevent decoding: %v", issues)
+	}
+	err := ValidationErr(issues)
+	if err == nil || len(err.(*ValidationError).Issues) != 2 {
+		t.Fatalf("expected two errors, got %v", issues)
+	}
+	got := map[string]string{}
+	for _, issue := range issues {
+		got[issue.Path] = issue.Severity
+	}
+	if got["providers[0].models"] != IssueError || got["routing.provider-priority.claud"] != IssueError {
+		t.Fatalf("missing provider errors in %v", issues)
+	}
+	if got["routing.fallbacks.llama70b[1]"] != IssueWarning || got["routing.fallbacks.llama70b[0]"] != "" {
+		t.Fatalf("unexpected model reference issues %v", issues)
+	}
+}
@@ -0,0 +1,327 @@
+// Package events delivers auth, quota and circuit breaker lifecycle
+// notifications to operator-configured sinks (webhooks, Slack, local commands).
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+// Type names an event.
+type Type string
+
+// Event types.
+const (
+	AuthDisabled      Type = "auth.disabled"
+	AuthRevoked       Type = "auth.revoked"
+	RefreshFailed     Type = "auth.refresh_failed"
+	ProviderExhausted Type = "provider.exhausted"
+	BreakerOpened     Type = "breaker.opened"
+	BreakerClosed     Type = "breaker.closed"
+	UsageThreshold    Type = "usage.threshold"
+	ConfigRejected    Type = "config.rejected"
+	Test              Type = "test"
+)
+
+// Types lists every event type sinks can subscribe to.
+var Types = []Type{AuthDisabled, AuthRevoked, RefreshFailed, ProviderExhausted, BreakerOpened, BreakerClosed, UsageThreshold, ConfigRejected, Test}
+
+// Severity values for Event.Severity.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	defaultDebounce = 10 * time.Minute
+	queueSize       = 256
+)
+
+// Event is a single notification.
+type Event struct {
+	Type     Type           `json:"type"`
+	Time     time.Time      `json:"time"`
+	Severity string         `json:"severity"`
+	Host     string         `json:"host,omitempty"`
+	Provider string         `json:"provider,omitempty"`
+	AuthID   string         `json:"auth_id,omitempty"`
+	Label    string         `json:"label,omitempty"`
+	Model    string         `json:"model,omitempty"`
+	Message  string         `json:"message"`
+	Details  map[string]any `json:"details,omitempty"`
+	// Suppressed counts repeats dropped by debouncing since the last delivery.
+	Suppressed int `json:"suppressed,omitempty"`
+	// Key overrides the debounce grouping (type, provider, auth and model).
+	Key string `json:"-"`
+}
+
+func (e *Event) debounceKey() string {
+	if e.Key != "" {
+		return string(e.Type) + "|" + e.Key
+	}
+	return strings.Join([]string{string(e.Type), e.Provider, e.AuthID, e.Model}, "|")
+}
+
+type debounceState struct {
+	last       time.Time
+	suppressed int
+}
+
+// Dispatcher debounces events and fans them out to sinks from a background goroutine.
+type Dispatcher struct {
+	mu        sync.RWMutex
+	sinks     []Sink
+	debounce  time.Duration
+	threshold config.UsageThreshold
+	key       string
+
+	recentMu sync.Mutex
+	recent   map[string]*debounceState
+
+	queue     chan Event
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	now       func() time.Time
+}
+
+var (
+	defaultDispatcher     *Dispatcher
+	defaultDispatcherOnce sync.Once
+)
+
+// Default returns the process-wide dispatcher.
+func Default() *Dispatcher {
+	defaultDispatcherOnce.Do(func() { defaultDispatcher = NewDispatcher() })
+	return defaultDispatcher
+}
+
+// NewDispatcher returns a dispatcher without sinks.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		debounce: defaultDebounce,
+		recent:   make(map[string]*debounceState),
+		queue:    make(chan Event, queueSize),
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Configure rebuilds the sinks from cfg. Invalid sinks are logged and skipped.
+// It is a no-op when the events section is unchanged.
+func (d *Dispatcher) Configure(cfg *config.Config) {
+	if d == nil || cfg == nil {
+		return
+	}
+	raw, _ := json.Marshal(cfg.Events)
+	key := string(raw)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key == d.key {
+		return
+	}
+	d.key = key
+
+	d.debounce = defaultDebounce
+	if s := strings.TrimSpace(cfg.Events.Debounce); s != "" {
+		if s == "0" {
+			d.debounce = 0
+		} else if dur, err := time.ParseDuration(s); err == nil && dur >= 0 {
+			d.debounce = dur
+		} else {
+			log.Warnf("events: invalid debounce %q, using %s", s, defaultDebounce)
+		}
+	}
+	d.threshold = cfg.Events.UsageThreshold
+
+	sinks := make([]Sink, 0, len(cfg.Events.Sinks))
+	for i := range cfg.Events.Sinks {
+		sink, err := NewSink(cfg.Events.Sinks[i])
+		if err != nil {
+			log.Errorf("events: sink %d: %v", i, err)
+			continue
+		}
+		sinks = append(sinks, sink)
+	}
+	d.sinks = sinks
+	if len(sinks) > 0 {
+		d.startOnce.Do(func() {
+			d.wg.Add(1)
+			go d.run()
+		})
+	}
+}
+
+// Sinks returns the configured sinks.
+func (d *Dispatcher) Sinks() []Sink {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Sink(nil), d.sinks...)
+}
+
+func (d *Dispatcher) usageThreshold() config.UsageThreshold {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.threshold
+}
+
+// Emit queues e for delivery unless the same event was delivered within the
+// debounce window. It never blocks; events are dropped when the queue is full.
+func (d *Dispatcher) Emit(e Event) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	active, debounce := len(d.sinks) > 0, d.debounce
+	d.mu.RUnlock()
+	if !active {
+		return
+	}
+	now := d.now()
+	if e.Time.IsZero() {
+		e.Time = now.UTC()
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityWarning
+	}
+	if e.Host == "" {
+		e.Host, _ = os.Hostname()
+	}
+
+	if debounce > 0 {
+		key := e.debounceKey()
+		d.recentMu.Lock()
+		st := d.recent[key]
+		if st != nil && now.Sub(st.last) < debounce {
+			st.suppressed++
+			d.recentMu.Unlock()
+			return
+		}
+		if st == nil {
+			st = &debounceState{}
+			d.recent[key] = st
+		}
+		e.Suppressed = st.suppressed
+		st.last, st.suppressed = now, 0
+		d.pruneLocked(now, debounce)
+		d.recentMu.Unlock()
+	}
+
+	select {
+	case <-d.done:
+	case d.queue <- e:
+	default:
+		log.Warnf("events: queue full, dropping %s event: %s", e.Type, e.Message)
+	}
+}
+
+// pruneLocked drops debounce state that can no longer suppress anything.
+func (d *Dispatcher) pruneLocked(now time.Time, debounce time.Duration) {
+	if len(d.recent) < 1024 {
+		return
+	}
+	for k, st := range d.recent {
+		if now.Sub(st.last) >= debounce {
+			delete(d.recent, k)
+		}
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case e := <-d.queue:
+			d.deliver(e)
+		case <-d.done:
+			for {
+				select {
+				case e := <-d.queue:
+					d.deliver(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(e Event) {
+	var wg sync.WaitGroup
+	for _, sink := range d.Sinks() {
+		if !sink.Accepts(e.Type) {
+			continue
+		}
+		wg.Add(1)
+		go func(s Sink) {
+			defer wg.Done()
+			if err := s.Deliver(context.Background(), e); err != nil {
+				log.Warnf("events: sink %s failed to deliver %s: %v", s.Name(), e.Type, err)
+			}
+		}(sink)
+	}
+	wg.Wait()
+}
+
+// TestResult is the outcome of a test delivery to one sink.
+type TestResult struct {
+	Sink  string `json:"sink"`
+	Type  string `json:"type"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Fire delivers e synchronously to the named sink, or to every sink when name
+// is empty, bypassing debouncing and event filters.
+func (d *Dispatcher) Fire(ctx context.Context, name string, e Event) ([]TestResult, error) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityInfo
+	}
+	if e.Host == "" {
+		e.Host, _ = os.Hostname()
+	}
+	var results []TestResult
+	for _, sink := range d.Sinks() {
+		if name != "" && sink.Name() != name {
+			continue
+		}
+		res := TestResult{Sink: sink.Name(), Type: sink.Type(), OK: true}
+		if err := sink.Deliver(ctx, e); err != nil {
+			res.OK, res.Error = false, err.Error()
+		}
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("event sink %q not found", name)
+		}
+		return nil, fmt.Errorf("no event sinks configured")
+	}
+	return results, nil
+}
+
+// Close delivers queued events and stops the dispatcher.
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.stopOnce.Do(func() { close(d.done) })
+	d.wg.Wait()
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	sigs   []string
+}
+
+func (r *recorder) handler(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.sigs = append(r.sigs, req.Header.Get("X-Event-Signature"))
+	r.mu.Unlock()
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func TestDispatcherDebouncesAndFilters(t *testing.T) {
+	var hook, slack recorder
+	hookSrv := httptest.NewServer(http.HandlerFunc(hook.handler))
+	defer hookSrv.Close()
+	slackSrv := httptest.NewServer(http.HandlerFunc(slack.handler))
+	defer slackSrv.Close()
+
+	d := NewDispatcher()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	d.Configure(&config.Config{Events: config.EventsConfig{
+		Debounce: "5m",
+		Sinks: []config.EventSink{
+			{Name: "ops", Type: "webhook", URL: hookSrv.URL, Secret: "s3cret"},
+			{Name: "chat", Type: "slack", URL: slackSrv.URL, Events: []string{string(ProviderExhausted)}},
+		},
+	}})
+
+	disabled := Event{Type: AuthDisabled, Provider: "claude", AuthID: "a1", Message: "auth disabled"}
+	d.Emit(disabled)
+	d.Emit(disabled) // debounced
+	now = now.Add(6 * time.Minute)
+	d.Emit(disabled)
+	d.Emit(Event{Type: ProviderExhausted, Provider: "claude", Severity: SeverityCritical, Message: "all claude auths are cooling down"})
+	d.Close()
+
+	if hook.count() != 3 {
+		t.Fatalf("webhook received %d events, want 3", hook.count())
+	}
+	var second Event
+	if err := json.Unmarshal(hook.bodies[1], &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Type != AuthDisabled || second.Suppressed != 1 {
+		t.Fatalf("second delivery = %+v, want auth.disabled with suppressed=1", second)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(hook.bodies[0])
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); hook.sigs[0] != want {
+		t.Fatalf("signature = %q, want %q", hook.sigs[0], want)
+	}
+
+	if slack.count() != 1 {
+		t.Fatalf("slack received %d events, want only provider.exhausted", slack.count())
+	}
+	var msg struct{ Text string }
+	_ = json.Unmarshal(slack.bodies[0], &msg)
+	if !strings.Contains(msg.Text, ":rotating_light: *provider.exhausted*") || !strings.Contains(msg.Text, "provider: `claude`") {
+		t.Fatalf("unexpected slack text %q", msg.Text)
+	}
+}
+
+func TestFireAndCommandSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "event.json")
+	d := NewDispatcher()
+	d.Configure(&config.Config{Events: config.EventsConfig{Sinks: []config.EventSink{
+		{Name: "script", Type: "command", Command: []string{"sh", "-c", `cat > "$0"; test "$LLM_MUX_EVENT_TYPE" = test`, out}, Events: []string{"auth.revoked"}},
+		{Name: "broken", Type: "command", Command: []string{"sh", "-c", "echo boom; exit 3"}},
+	}}})
+	defer d.Close()
+
+	results, err := d.Fire(context.Background(), "", Event{Type: Test, Message: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].OK || results[1].OK || !strings.Contains(results[1].Error, "boom") {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), `"message":"hello"`) {
+		t.Fatalf("command did not receive event JSON: %s (%v)", data, err)
+	}
+	if _, err := d.Fire(context.Background(), "missing", Event{Type: Test}); err == nil {
+		t.Fatal("expected error for unknown sink")
+	}
+}
+
+func TestNewSinkValidation(t *testing.T) {
+	cases := []config.EventSink{
+		{Type: "webhook", URL: "http://x"},
+		{Name: "a", Type: "webhook"},
+		{Name: "a", Type: "command"},
+		{Name: "a", Type: "pager"},
+		{Name: "a", Type: "slack", URL: "http://x", Events: []string{"auth.exploded"}},
+	}
+	for _, c := range cases {
+		if _, err := NewSink(c); err == nil {
+			t.Errorf("NewSink(%+v) succeeded, want error", c)
+		}
+	}
+}
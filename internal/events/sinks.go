@@ -0,0 +1,198 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+const (
+	defaultSinkTimeout = 10 * time.Second
+	// maxErrorOutput caps how much of a failed response or command output is reported.
+	maxErrorOutput = 512
+)
+
+// Sink delivers events to one destination.
+type Sink interface {
+	Name() string
+	Type() string
+	// Accepts reports whether the sink subscribes to t.
+	Accepts(t Type) bool
+	Deliver(ctx context.Context, e Event) error
+}
+
+// NewSink builds a sink from its configuration.
+func NewSink(cfg config.EventSink) (Sink, error) {
+	base := sinkBase{name: strings.TrimSpace(cfg.Name), typ: strings.ToLower(strings.TrimSpace(cfg.Type)), timeout: defaultSinkTimeout}
+	if base.name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	for _, t := range cfg.Events {
+		t = strings.TrimSpace(t)
+		if !slices.Contains(Types, Type(t)) {
+			return nil, fmt.Errorf("sink %s: unknown event type %q", base.name, t)
+		}
+		base.events = append(base.events, Type(t))
+	}
+	if s := strings.TrimSpace(cfg.Timeout); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("sink %s: invalid timeout %q", base.name, s)
+		}
+		base.timeout = d
+	}
+
+	switch base.typ {
+	case config.EventSinkWebhook, config.EventSinkSlack:
+		if strings.TrimSpace(cfg.URL) == "" {
+			return nil, fmt.Errorf("sink %s: url is required", base.name)
+		}
+		return &webhookSink{
+			sinkBase: base,
+			url:      strings.TrimSpace(cfg.URL),
+			secret:   cfg.Secret,
+			headers:  cfg.Headers,
+			slack:    base.typ == config.EventSinkSlack,
+			client:   &http.Client{},
+		}, nil
+	case config.EventSinkCommand:
+		if len(cfg.Command) == 0 || strings.TrimSpace(cfg.Command[0]) == "" {
+			return nil, fmt.Errorf("sink %s: command is required", base.name)
+		}
+		return &commandSink{sinkBase: base, argv: append([]string(nil), cfg.Command...)}, nil
+	}
+	return nil, fmt.Errorf("sink %s: unknown type %q (want webhook, slack or command)", base.name, cfg.Type)
+}
+
+type sinkBase struct {
+	name    string
+	typ     string
+	events  []Type
+	timeout time.Duration
+}
+
+func (b *sinkBase) Name() string { return b.name }
+
+func (b *sinkBase) Type() string { return b.typ }
+
+func (b *sinkBase) Accepts(t Type) bool {
+	return len(b.events) == 0 || slices.Contains(b.events, t)
+}
+
+// webhookSink POSTs the event as JSON, or as a Slack incoming-webhook message.
+type webhookSink struct {
+	sinkBase
+	url     string
+	secret  string
+	headers map[string]string
+	slack   bool
+	client  *http.Client
+}
+
+func (w *webhookSink) Deliver(ctx context.Context, e Event) error {
+	var payload any = e
+	if w.slack {
+		payload = map[string]string{"text": slackText(e)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Event-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorOutput))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func slackText(e Event) string {
+	icon := ":information_source:"
+	switch e.Severity {
+	case SeverityWarning:
+		icon = ":warning:"
+	case SeverityCritical:
+		icon = ":rotating_light:"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s* %s", icon, e.Type, e.Message)
+	var fields []string
+	for _, f := range [][2]string{{"provider", e.Provider}, {"auth", e.AuthID}, {"label", e.Label}, {"model", e.Model}, {"host", e.Host}} {
+		if f[1] != "" {
+			fields = append(fields, f[0]+": `"+f[1]+"`")
+		}
+	}
+	if e.Suppressed > 0 {
+		fields = append(fields, fmt.Sprintf("repeated %d more times", e.Suppressed))
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+		b.WriteString(strings.Join(fields, " · "))
+	}
+	return b.String()
+}
+
+// commandSink runs a local command with the event JSON on stdin and the main
+// fields in LLM_MUX_EVENT_* environment variables.
+type commandSink struct {
+	sinkBase
+	argv []string
+}
+
+func (c *commandSink) Deliver(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"LLM_MUX_EVENT_TYPE="+string(e.Type),
+		"LLM_MUX_EVENT_SEVERITY="+e.Severity,
+		"LLM_MUX_EVENT_MESSAGE="+e.Message,
+		"LLM_MUX_EVENT_PROVIDER="+e.Provider,
+		"LLM_MUX_EVENT_AUTH_ID="+e.AuthID,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) > maxErrorOutput {
+			out = out[:maxErrorOutput]
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/usage"
+)
+
+// UsageWatcher is a usage plugin that emits UsageThreshold once per day per
+// limit when the day's totals exceed events.usage-threshold.
+type UsageWatcher struct {
+	d        *Dispatcher
+	mu       sync.Mutex
+	day      string
+	tokens   int64
+	requests int64
+	fired    map[string]bool
+}
+
+// NewUsageWatcher returns a watcher emitting through d.
+func NewUsageWatcher(d *Dispatcher) *UsageWatcher {
+	return &UsageWatcher{d: d, fired: make(map[string]bool)}
+}
+
+// HandleUsage implements usage.Plugin.
+func (w *UsageWatcher) HandleUsage(ctx context.Context, record usage.Record) {
+	limits := w.d.usageThreshold()
+	if limits.DailyTokens <= 0 && limits.DailyRequests <= 0 {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.Local()
+	day := at.Format(time.DateOnly)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if day != w.day {
+		w.day, w.tokens, w.requests = day, 0, 0
+		clear(w.fired)
+		w.seed(ctx, at)
+	}
+	w.requests++
+	if record.Usage != nil {
+		w.tokens += record.Usage.TotalTokens
+	}
+	w.check("tokens", w.tokens, limits.DailyTokens)
+	w.check("requests", w.requests, limits.DailyRequests)
+}
+
+// seed starts the day from persisted statistics so a restart does not reset
+// the totals. Records still queued for the backend are not included.
+func (w *UsageWatcher) seed(ctx context.Context, at time.Time) {
+	plugin := usage.GetLoggerPlugin()
+	if plugin == nil || plugin.GetBackend() == nil {
+		return
+	}
+	start := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	stats, err := plugin.GetBackend().QueryGlobalStats(ctx, start)
+	if err != nil || stats == nil {
+		return
+	}
+	w.tokens, w.requests = stats.TotalTokens, stats.TotalRequests
+}
+
+func (w *UsageWatcher) check(metric string, value, limit int64) {
+	if limit <= 0 || value < limit || w.fired[metric] {
+		return
+	}
+	w.fired[metric] = true
+	w.d.Emit(Event{
+		Type:     UsageThreshold,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("daily %s reached %d (threshold %d)", metric, value, limit),
+		Details:  map[string]any{"metric": metric, "value": value, "threshold": limit, "day": w.day},
+		Key:      w.day + "|" + metric,
+	})
+}
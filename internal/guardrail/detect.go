@@ -0,0 +1,111 @@
+package guardrail
+
+import (
+	"regexp"
+	"strings"
+)
+
+// detector finds one kind of sensitive value in text.
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// secretDetectors are the built-in credential detectors, keyed by the names in
+// config.GuardrailSecretDetectors.
+var secretDetectors = map[string]*detector{
+	"aws-access-key": {re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	"aws-secret-key": {re: regexp.MustCompile(`(?i)\baws_?secret_?access_?key\b["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	"private-key":    {re: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----(?s:.*?)(?:-----END (?:[A-Z]+ )*PRIVATE KEY-----|$)`)},
+	"jwt":            {re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	"github-token":   {re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	"slack-token":    {re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	"google-api-key": {re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	"api-key":        {re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+}
+
+// piiDetectors are the built-in PII detectors, keyed by the names in
+// config.GuardrailPIITypes.
+var piiDetectors = map[string]*detector{
+	"email":       {re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)},
+	"phone":       {re: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]\d{3,4}[ .-]\d{3,4}\b`)},
+	"credit-card": {re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhn},
+	"ssn":         {re: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	"ip-address":  {re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+}
+
+func init() {
+	for name, d := range secretDetectors {
+		d.name = name
+	}
+	for name, d := range piiDetectors {
+		d.name = name
+	}
+}
+
+// find returns the byte ranges of valid matches in text.
+func (d *detector) find(text string) [][]int {
+	matches := d.re.FindAllStringIndex(text, -1)
+	if d.valid == nil {
+		return matches
+	}
+	out := matches[:0]
+	for _, m := range matches {
+		if d.valid(text[m[0]:m[1]]) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// label turns a detector name into a placeholder label, e.g. "credit-card"
+// into "CREDIT_CARD".
+func label(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// denyList matches blocked keywords and patterns.
+type denyList struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// match returns a short description of the first deny rule text violates.
+func (d *denyList) match(text string) (string, bool) {
+	if len(d.keywords) > 0 {
+		lower := strings.ToLower(text)
+		for _, k := range d.keywords {
+			if strings.Contains(lower, k) {
+				return "keyword " + k, true
+			}
+		}
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(text) {
+			return "pattern " + re.String(), true
+		}
+	}
+	return "", false
+}
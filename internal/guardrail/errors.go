@@ -0,0 +1,63 @@
+package guardrail
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/nghyane/llm-mux/internal/provider"
+)
+
+// Stages at which a guardrail blocks.
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+)
+
+// BlockedError is returned when a guardrail policy blocks a request or response.
+type BlockedError struct {
+	Policy string
+	Stage  string
+	Reason string
+	// Format is the client format the error body is rendered in.
+	Format provider.Format
+}
+
+func (e *BlockedError) Error() string {
+	subject := "request"
+	if e.Stage == StageOutput {
+		subject = "response"
+	}
+	return fmt.Sprintf("%s blocked by guardrail policy %q: %s", subject, e.Policy, e.Reason)
+}
+
+// StatusCode implements the status accessor used by the API handlers.
+func (e *BlockedError) StatusCode() int { return http.StatusBadRequest }
+
+// Body renders the error in the native error shape of the client format.
+func (e *BlockedError) Body() []byte {
+	msg := e.Error()
+	var v any
+	switch e.Format {
+	case provider.FormatClaude:
+		v = map[string]any{"type": "error", "error": map[string]any{"type": "invalid_request_error", "message": msg}}
+	case provider.FormatGemini, "gemini-cli":
+		v = map[string]any{"error": map[string]any{"code": http.StatusBadRequest, "message": msg, "status": "INVALID_ARGUMENT"}}
+	case provider.FormatOllama:
+		v = map[string]any{"error": msg}
+	default:
+		v = map[string]any{"error": map[string]any{"message": msg, "type": "invalid_request_error", "param": nil, "code": "content_blocked"}}
+	}
+	body, _ := json.Marshal(v)
+	return body
+}
+
+// AsBlocked returns the BlockedError in err's chain, if any.
+func AsBlocked(err error) (*BlockedError, bool) {
+	var blocked *BlockedError
+	if errors.As(err, &blocked) {
+		return blocked, true
+	}
+	return nil, false
+}
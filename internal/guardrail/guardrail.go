@@ -0,0 +1,256 @@
+// Package guardrail enforces content policies on requests before they are sent
+// upstream and on the responses returned to clients: deny lists, secret
+// detection, reversible PII masking and external classifiers.
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/sseutil"
+)
+
+// Guard applies the configured policies. It implements provider.RequestGuard.
+type Guard struct {
+	policies []*policy
+}
+
+// policy is a compiled config.GuardrailPolicy.
+type policy struct {
+	name        string
+	apiKeys     []string
+	models      []string
+	deny        denyList
+	secrets     []*detector
+	redact      bool
+	pii         []*detector
+	webhook     *webhook
+	checkOutput bool
+}
+
+// New compiles cfg. It returns nil when guardrails are disabled or no policy
+// is configured.
+func New(cfg config.GuardrailsConfig) (*Guard, error) {
+	if !cfg.Enable || len(cfg.Policies) == 0 {
+		return nil, nil
+	}
+	client := &http.Client{}
+	g := &Guard{}
+	for i, pc := range cfg.Policies {
+		p, err := compilePolicy(pc, client)
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i, err)
+		}
+		g.policies = append(g.policies, p)
+	}
+	return g, nil
+}
+
+func compilePolicy(pc config.GuardrailPolicy, client *http.Client) (*policy, error) {
+	p := &policy{
+		name:        strings.TrimSpace(pc.Name),
+		apiKeys:     pc.APIKeys,
+		models:      pc.Models,
+		redact:      strings.TrimSpace(pc.Secrets.Action) == config.GuardrailActionRedact,
+		checkOutput: pc.CheckOutput,
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	for _, k := range pc.Deny.Keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			p.deny.keywords = append(p.deny.keywords, k)
+		}
+	}
+	for _, pattern := range pc.Deny.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid deny pattern %q: %w", p.name, pattern, err)
+		}
+		p.deny.patterns = append(p.deny.patterns, re)
+	}
+	if pc.Secrets.Enable {
+		names := pc.Secrets.Detectors
+		if len(names) == 0 {
+			names = config.GuardrailSecretDetectors
+		}
+		for _, name := range names {
+			d, ok := secretDetectors[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown secret detector %q", p.name, name)
+			}
+			p.secrets = append(p.secrets, d)
+		}
+	}
+	for _, name := range pc.PII.Mask {
+		d, ok := piiDetectors[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown PII type %q", p.name, name)
+		}
+		p.pii = append(p.pii, d)
+	}
+	if u := strings.TrimSpace(pc.Webhook.URL); u != "" {
+		w := &webhook{url: u, headers: pc.Webhook.Headers, timeout: defaultWebhookTimeout, failOpen: pc.Webhook.FailOpen, client: client}
+		if s := strings.TrimSpace(pc.Webhook.Timeout); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("%s: invalid webhook timeout %q", p.name, s)
+			}
+			w.timeout = d
+		}
+		p.webhook = w
+	}
+	return p, nil
+}
+
+// matches reports whether the policy applies to a client key and model.
+func (p *policy) matches(clientKey, model string) bool {
+	return matchAny(p.apiKeys, clientKey) && matchAny(p.models, model)
+}
+
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return sseutil.MatchModelPattern(pattern, value)
+	})
+}
+
+// policyFor returns the first policy matching the request.
+func (g *Guard) policyFor(clientKey, model string) *policy {
+	for _, p := range g.policies {
+		if p.matches(clientKey, model) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Inspect implements provider.RequestGuard.
+func (g *Guard) Inspect(ctx context.Context, req provider.Request, opts provider.Options) (provider.Request, provider.ResponseGuard, error) {
+	if g == nil {
+		return req, nil, nil
+	}
+	clientKey := provider.ClientKeyFromContext(ctx)
+	p := g.policyFor(clientKey, req.Model)
+	if p == nil {
+		return req, nil, nil
+	}
+	block := func(reason string) error {
+		log.Infof("guardrail: policy %s blocked a request from %q for %s: %s", p.name, clientKey, req.Model, reason)
+		return &BlockedError{Policy: p.name, Stage: StageInput, Reason: reason, Format: opts.SourceFormat}
+	}
+
+	leaves := collectText(req.Payload)
+	texts := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		texts[i] = leaf.value
+	}
+
+	for _, text := range texts {
+		if rule, ok := p.deny.match(text); ok {
+			log.Debugf("guardrail: policy %s: request matched deny %s", p.name, rule)
+			return req, nil, block("content matches a deny rule")
+		}
+	}
+	for i, text := range texts {
+		for _, d := range p.secrets {
+			if len(d.find(text)) == 0 {
+				continue
+			}
+			if !p.redact {
+				return req, nil, block("content contains a secret (" + d.name + ")")
+			}
+			text = d.re.ReplaceAllString(text, "[REDACTED_"+label(d.name)+"]")
+		}
+		texts[i] = text
+	}
+
+	masker := newMasker(p.pii, texts)
+	for i := range texts {
+		texts[i] = masker.mask(texts[i])
+	}
+
+	if p.webhook != nil {
+		in := webhookRequest{Policy: p.name, Stage: StageInput, Model: req.Model, Client: clientKey, Format: opts.SourceFormat.String(), Texts: texts}
+		if reason, blocked := p.webhook.check(ctx, in); blocked {
+			return req, nil, block(reason)
+		}
+	}
+
+	req.Payload = rewriteText(req.Payload, leaves, texts)
+	if masker.empty() && !p.checkOutput {
+		return req, nil, nil
+	}
+	return req, &session{policy: p, masker: masker, format: opts.SourceFormat, model: req.Model, clientKey: clientKey}, nil
+}
+
+// session post-processes the response to one request: it restores masked PII
+// and, when the policy checks output, applies the deny list and secret
+// detectors. It implements provider.ResponseGuard.
+type session struct {
+	policy    *policy
+	masker    *masker
+	format    provider.Format
+	model     string
+	clientKey string
+}
+
+func (s *session) blocked(reason string) error {
+	log.Infof("guardrail: policy %s blocked a response to %q for %s: %s", s.policy.name, s.clientKey, s.model, reason)
+	return &BlockedError{Policy: s.policy.name, Stage: StageOutput, Reason: reason, Format: s.format}
+}
+
+// checkText applies the output checks to text and returns the block reason.
+func (s *session) checkText(text string) (string, bool) {
+	if _, ok := s.policy.deny.match(text); ok {
+		return "content matches a deny rule", true
+	}
+	for _, d := range s.policy.secrets {
+		if len(d.find(text)) > 0 {
+			return "content contains a secret (" + d.name + ")", true
+		}
+	}
+	return "", false
+}
+
+// Response implements provider.ResponseGuard.
+func (s *session) Response(ctx context.Context, payload []byte) ([]byte, error) {
+	payload = s.masker.restoreBytes(payload)
+	if !s.policy.checkOutput {
+		return payload, nil
+	}
+	leaves := collectText(payload)
+	texts := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		texts[i] = leaf.value
+		if _, ok := s.policy.deny.match(leaf.value); ok {
+			return nil, s.blocked("content matches a deny rule")
+		}
+		for _, d := range s.policy.secrets {
+			if len(d.find(texts[i])) == 0 {
+				continue
+			}
+			if !s.policy.redact {
+				return nil, s.blocked("content contains a secret (" + d.name + ")")
+			}
+			texts[i] = d.re.ReplaceAllString(texts[i], "[REDACTED_"+label(d.name)+"]")
+		}
+	}
+	if s.policy.webhook != nil {
+		in := webhookRequest{Policy: s.policy.name, Stage: StageOutput, Model: s.model, Client: s.clientKey, Format: s.format.String(), Texts: texts}
+		if reason, blocked := s.policy.webhook.check(ctx, in); blocked {
+			return nil, s.blocked(reason)
+		}
+	}
+	return rewriteText(payload, leaves, texts), nil
+}
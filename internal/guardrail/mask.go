@@ -0,0 +1,130 @@
+package guardrail
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderRe matches placeholders such as [EMAIL_1].
+var placeholderRe = regexp.MustCompile(`\[([A-Z][A-Z_]*)_(\d+)\]`)
+
+// masker replaces PII with numbered placeholders and restores the originals
+// in responses. The same value always maps to the same placeholder.
+type masker struct {
+	detectors []*detector
+	byValue   map[string]string
+	byHolder  map[string]string
+	next      map[string]int
+	// used holds placeholders already present in the request, which are
+	// never assigned so that restoring cannot rewrite the client's own text.
+	used     map[string]bool
+	replacer *strings.Replacer
+}
+
+func newMasker(detectors []*detector, texts []string) *masker {
+	m := &masker{
+		detectors: detectors,
+		byValue:   make(map[string]string),
+		byHolder:  make(map[string]string),
+		next:      make(map[string]int),
+		used:      make(map[string]bool),
+	}
+	if len(detectors) == 0 {
+		return m
+	}
+	for _, text := range texts {
+		for _, p := range placeholderRe.FindAllString(text, -1) {
+			m.used[p] = true
+		}
+	}
+	return m
+}
+
+func (m *masker) empty() bool {
+	return m == nil || len(m.byHolder) == 0
+}
+
+// mask replaces every PII match in text with its placeholder.
+func (m *masker) mask(text string) string {
+	for _, d := range m.detectors {
+		matches := d.find(text)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range matches {
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(m.placeholder(d.name, text[loc[0]:loc[1]]))
+			last = loc[1]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+func (m *masker) placeholder(name, value string) string {
+	if p, ok := m.byValue[value]; ok {
+		return p
+	}
+	lbl := label(name)
+	var p string
+	for {
+		m.next[lbl]++
+		p = "[" + lbl + "_" + strconv.Itoa(m.next[lbl]) + "]"
+		if !m.used[p] {
+			break
+		}
+	}
+	m.byValue[value] = p
+	m.byHolder[p] = value
+	m.replacer = nil
+	return p
+}
+
+// restore replaces placeholders in s with the original values.
+func (m *masker) restore(s string) string {
+	if m.empty() || !strings.Contains(s, "[") {
+		return s
+	}
+	if m.replacer == nil {
+		pairs := make([]string, 0, 2*len(m.byHolder))
+		for p, v := range m.byHolder {
+			pairs = append(pairs, p, v)
+		}
+		m.replacer = strings.NewReplacer(pairs...)
+	}
+	return m.replacer.Replace(s)
+}
+
+// restoreBytes is restore for a raw payload. Masked values never need JSON
+// escaping, so placeholders can be replaced in the encoded document.
+func (m *masker) restoreBytes(b []byte) []byte {
+	if m.empty() || bytes.IndexByte(b, '[') < 0 {
+		return b
+	}
+	return []byte(m.restore(string(b)))
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a
+// proper prefix of a placeholder, i.e. text that may be completed by the next
+// stream delta.
+func (m *masker) partialSuffix(s string) int {
+	if m.empty() {
+		return 0
+	}
+	i := strings.LastIndexByte(s, '[')
+	if i < 0 {
+		return 0
+	}
+	tail := s[i:]
+	for p := range m.byHolder {
+		if len(tail) < len(p) && strings.HasPrefix(p, tail) {
+			return len(tail)
+		}
+	}
+	return 0
+}
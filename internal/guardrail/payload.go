@@ -0,0 +1,112 @@
+package guardrail
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// skipKeys are object keys whose values are identifiers, settings or binary
+// data rather than conversation text. They are neither scanned nor rewritten.
+var skipKeys = map[string]bool{
+	"model": true, "role": true, "type": true, "id": true, "name": true, "object": true,
+	"tool_call_id": true, "tool_use_id": true, "call_id": true, "item_id": true,
+	"signature": true, "thoughtSignature": true, "thought_signature": true, "encrypted_content": true,
+	"data": true, "b64_json": true, "mime_type": true, "mimeType": true, "media_type": true,
+	"tools": true, "tool_choice": true, "response_format": true, "cache_control": true,
+	"generationConfig": true, "safetySettings": true, "stream_options": true, "metadata": true,
+	"cachedContent": true, "finish_reason": true, "stop_reason": true, "system_fingerprint": true,
+}
+
+// textLeaf is a string value of a JSON document and its sjson path.
+type textLeaf struct {
+	path  string
+	value string
+}
+
+// collectText returns the conversation text of a request or response payload:
+// every string value outside skipKeys, excluding data URLs.
+func collectText(payload []byte) []textLeaf {
+	if !gjson.ValidBytes(payload) {
+		return nil
+	}
+	var leaves []textLeaf
+	walkText(gjson.ParseBytes(payload), "", &leaves)
+	return leaves
+}
+
+func walkText(v gjson.Result, path string, leaves *[]textLeaf) {
+	switch {
+	case v.IsObject():
+		v.ForEach(func(key, value gjson.Result) bool {
+			if k := key.String(); !skipKeys[k] {
+				walkText(value, joinPath(path, escapeKey(k)), leaves)
+			}
+			return true
+		})
+	case v.IsArray():
+		i := 0
+		v.ForEach(func(_, value gjson.Result) bool {
+			walkText(value, joinPath(path, strconv.Itoa(i)), leaves)
+			i++
+			return true
+		})
+	case v.Type == gjson.String:
+		if s := v.String(); s != "" && !strings.HasPrefix(s, "data:") {
+			*leaves = append(*leaves, textLeaf{path: path, value: s})
+		}
+	}
+}
+
+// rewriteText sets changed leaves in payload.
+func rewriteText(payload []byte, leaves []textLeaf, changed []string) []byte {
+	for i, leaf := range leaves {
+		if changed[i] == leaf.value {
+			continue
+		}
+		if out, err := sjson.SetBytes(payload, leaf.path, changed[i]); err == nil {
+			payload = out
+		}
+	}
+	return payload
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// escapeKey escapes gjson/sjson path syntax in an object key.
+func escapeKey(k string) string {
+	if !strings.ContainsAny(k, `\.*?|#@!=<>%`) && !isDigits(k) {
+		return k
+	}
+	var b strings.Builder
+	if isDigits(k) {
+		// A numeric key would address an array element.
+		b.WriteByte(':')
+	}
+	for i := 0; i < len(k); i++ {
+		if strings.IndexByte(`\.*?|#@!=<>%`, k[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(k[i])
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
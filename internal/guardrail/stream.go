@@ -0,0 +1,411 @@
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// outputWindow is how much recent streamed text output checks look at, so
+	// that matches spanning several deltas are still found. It also caps how
+	// much of a token is held back for secret redaction.
+	outputWindow = 4 << 10
+	// webhookSegment is how much streamed text is held back and sent to the
+	// classifier at once.
+	webhookSegment = 1 << 10
+)
+
+// deltaSite is an incremental text field of a stream event. Key identifies
+// the content block it belongs to across events.
+type deltaSite struct {
+	key  string
+	path string
+}
+
+// carry is the start of a placeholder held back until the next delta of the
+// same block, together with the event it came from so it can be re-emitted
+// on its own.
+type carry struct {
+	text   string
+	event  string
+	prefix string
+	json   string
+	suffix string
+	path   string
+}
+
+// payload renders the carried text as a standalone event framed like the
+// original one.
+func (c *carry) payload() []byte {
+	data, err := sjson.Set(c.json, c.path, c.text)
+	if err != nil {
+		return nil
+	}
+	if c.prefix == "" {
+		return []byte(data + c.suffix)
+	}
+	return []byte(c.event + c.prefix + data + "\n\n")
+}
+
+// streamState rewrites one response stream.
+type streamState struct {
+	s       *session
+	ctx     context.Context
+	carries map[string]*carry
+	order   []string
+	// window is the recent streamed text the output checks look at.
+	window string
+	// held are payloads waiting for the classifier, and unchecked is their text.
+	held      [][]byte
+	unchecked string
+}
+
+// Stream implements provider.ResponseGuard. Placeholders are restored in
+// every event; a placeholder split across deltas is held back until it is
+// complete. When the policy checks output:
+//   - secrets are redacted per token when the action is redact, so the last
+//     token of each block is held back until it ends;
+//   - a rolling window of the streamed text is checked after every chunk, and
+//     the stream ends with a BlockedError on a match;
+//   - with a webhook, text is held back in segments until the classifier
+//     allows it.
+func (s *session) Stream(ctx context.Context, chunks <-chan provider.StreamChunk) <-chan provider.StreamChunk {
+	out := make(chan provider.StreamChunk)
+	go func() {
+		defer close(out)
+		st := &streamState{s: s, ctx: ctx, carries: make(map[string]*carry)}
+		send := func(chunk provider.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		drain := func() {
+			for range chunks {
+			}
+		}
+		// forward sends the payloads that passed the checks; it returns false
+		// once the stream has ended.
+		forward := func(parts [][]byte, final bool) bool {
+			ready, err := st.release(parts, final)
+			for _, p := range ready {
+				if !send(provider.StreamChunk{Payload: p}) {
+					drain()
+					return false
+				}
+			}
+			if err != nil {
+				send(provider.StreamChunk{Err: err})
+				drain()
+				return false
+			}
+			return true
+		}
+		for chunk := range chunks {
+			if chunk.Err != nil || len(chunk.Payload) == 0 {
+				if !forward(st.flushAll(), chunk.Err != nil) {
+					return
+				}
+				if !send(chunk) {
+					drain()
+					return
+				}
+				continue
+			}
+			if !forward(st.process(chunk.Payload), false) {
+				return
+			}
+		}
+		forward(st.flushAll(), true)
+	}()
+	return out
+}
+
+// release applies the output checks to newly processed payloads and returns
+// those that may be sent. With a webhook, payloads are held until a segment
+// of text has been allowed by the classifier; final releases everything
+// left.
+func (st *streamState) release(parts [][]byte, final bool) ([][]byte, error) {
+	s := st.s
+	if !s.policy.checkOutput {
+		return parts, nil
+	}
+	if reason, blocked := s.checkText(st.window); blocked {
+		return nil, s.blocked(reason)
+	}
+	if s.policy.webhook == nil {
+		return parts, nil
+	}
+	st.held = append(st.held, parts...)
+	if !final && len(st.unchecked) < webhookSegment {
+		return nil, nil
+	}
+	if st.unchecked != "" {
+		in := webhookRequest{Policy: s.policy.name, Stage: StageOutput, Model: s.model, Client: s.clientKey, Format: s.format.String(), Texts: []string{st.unchecked}}
+		st.unchecked = ""
+		if reason, blocked := s.policy.webhook.check(st.ctx, in); blocked {
+			st.held = nil
+			return nil, s.blocked(reason)
+		}
+	}
+	ready := st.held
+	st.held = nil
+	return ready, nil
+}
+
+// process rewrites one chunk. Held-back text whose block does not continue
+// in this chunk is emitted as separate payloads before the event that ends
+// the block.
+func (st *streamState) process(payload []byte) [][]byte {
+	var (
+		parts    [][]byte
+		buf      []byte
+		eventPos = -1
+	)
+	cut := func(pos int, flushed [][]byte) {
+		if len(flushed) == 0 {
+			return
+		}
+		if pos > 0 {
+			parts = append(parts, bytes.Clone(buf[:pos]))
+		}
+		parts = append(parts, flushed...)
+		buf = append([]byte(nil), buf[pos:]...)
+		if eventPos >= 0 {
+			eventPos -= pos
+		}
+	}
+	event := ""
+	for len(payload) > 0 {
+		line := payload
+		if i := bytes.IndexByte(payload, '\n'); i >= 0 {
+			line = payload[:i+1]
+		}
+		payload = payload[len(line):]
+		text := string(line)
+		trimmed := strings.TrimRight(text, "\r\n")
+
+		var prefix, data string
+		switch {
+		case strings.HasPrefix(trimmed, "event:"):
+			eventPos = len(buf)
+			event = text
+			buf = append(buf, line...)
+			continue
+		case strings.HasPrefix(trimmed, "data:"):
+			data = strings.TrimPrefix(trimmed, "data:")
+			prefix = "data:"
+			if strings.HasPrefix(data, " ") {
+				data = data[1:]
+				prefix = "data: "
+			}
+		case strings.HasPrefix(trimmed, "{"):
+			data = trimmed
+		default:
+			if trimmed == "" {
+				eventPos, event = -1, ""
+			}
+			buf = append(buf, line...)
+			continue
+		}
+		pos := len(buf)
+		if eventPos >= 0 {
+			pos = eventPos
+		}
+		if data == "[DONE]" || !gjson.Valid(data) {
+			if data == "[DONE]" {
+				cut(pos, st.flushAll())
+			}
+			buf = append(buf, line...)
+			continue
+		}
+
+		data = st.s.masker.restore(data)
+		sites := st.sites(data)
+		cut(pos, st.flushExcept(sites))
+		for _, site := range sites {
+			value := gjson.Get(data, site.path).String()
+			if c, ok := st.carries[site.key]; ok {
+				value = st.s.masker.restore(c.text + value)
+				st.dropCarry(site.key)
+			}
+			if n := st.holdBack(value); n > 0 {
+				st.carries[site.key] = &carry{text: value[len(value)-n:], event: event, prefix: prefix, json: data, suffix: text[len(trimmed):], path: site.path}
+				st.order = append(st.order, site.key)
+				value = value[:len(value)-n]
+			}
+			value = st.redact(value)
+			if updated, err := sjson.Set(data, site.path, value); err == nil {
+				data = updated
+			}
+			st.observe(value)
+		}
+		buf = append(buf, prefix...)
+		buf = append(buf, data...)
+		buf = append(buf, text[len(trimmed):]...)
+		if prefix != "" {
+			eventPos = -1
+		}
+	}
+	if len(buf) > 0 {
+		parts = append(parts, buf)
+	}
+	return parts
+}
+
+// redacting reports whether streamed secrets are redacted rather than blocked.
+func (st *streamState) redacting() bool {
+	p := st.s.policy
+	return p.checkOutput && p.redact && len(p.secrets) > 0
+}
+
+// holdBack returns the length of the suffix of a delta value that must wait
+// for the next delta of its block: an incomplete placeholder and, when
+// redacting, the trailing token, which may be the start of a secret.
+func (st *streamState) holdBack(value string) int {
+	n := st.s.masker.partialSuffix(value)
+	if st.redacting() {
+		token := len(value) - (strings.LastIndexAny(value, " \t\r\n") + 1)
+		if token > n && token <= outputWindow {
+			n = token
+		}
+	}
+	return n
+}
+
+// redact replaces secrets in streamed text when the policy redacts them.
+func (st *streamState) redact(text string) string {
+	if !st.redacting() {
+		return text
+	}
+	for _, d := range st.s.policy.secrets {
+		text = d.re.ReplaceAllString(text, "[REDACTED_"+label(d.name)+"]")
+	}
+	return text
+}
+
+// observe appends streamed text to the output check window and to the text
+// awaiting the classifier.
+func (st *streamState) observe(text string) {
+	if !st.s.policy.checkOutput || text == "" {
+		return
+	}
+	st.window += text
+	if len(st.window) > outputWindow {
+		st.window = st.window[len(st.window)-outputWindow:]
+	}
+	if st.s.policy.webhook != nil {
+		st.unchecked += text
+	}
+}
+
+func (st *streamState) dropCarry(key string) {
+	delete(st.carries, key)
+	for i, k := range st.order {
+		if k == key {
+			st.order = append(st.order[:i], st.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// flushExcept emits held-back text of blocks not continued by sites.
+func (st *streamState) flushExcept(sites []deltaSite) [][]byte {
+	if len(st.carries) == 0 {
+		return nil
+	}
+	var out [][]byte
+	for _, key := range append([]string(nil), st.order...) {
+		continued := false
+		for _, site := range sites {
+			if site.key == key {
+				continued = true
+				break
+			}
+		}
+		if continued {
+			continue
+		}
+		c := st.carries[key]
+		st.dropCarry(key)
+		c.text = st.redact(c.text)
+		st.observe(c.text)
+		if p := c.payload(); p != nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (st *streamState) flushAll() [][]byte {
+	return st.flushExcept(nil)
+}
+
+// sites returns the delta fields of a stream event in the client format.
+func (st *streamState) sites(data string) []deltaSite {
+	var sites []deltaSite
+	switch st.s.format {
+	case provider.FormatClaude:
+		if gjson.Get(data, "type").String() != "content_block_delta" {
+			return nil
+		}
+		key := "b" + gjson.Get(data, "index").Raw
+		switch gjson.Get(data, "delta.type").String() {
+		case "text_delta":
+			sites = append(sites, deltaSite{key: key, path: "delta.text"})
+		case "input_json_delta":
+			sites = append(sites, deltaSite{key: key, path: "delta.partial_json"})
+		}
+	case "openai-response":
+		switch gjson.Get(data, "type").String() {
+		case "response.output_text.delta":
+			sites = append(sites, deltaSite{key: "o" + gjson.Get(data, "output_index").Raw + "." + gjson.Get(data, "content_index").Raw, path: "delta"})
+		case "response.function_call_arguments.delta":
+			sites = append(sites, deltaSite{key: "f" + gjson.Get(data, "output_index").Raw, path: "delta"})
+		}
+	case provider.FormatGemini, "gemini-cli":
+		root := ""
+		if st.s.format == "gemini-cli" {
+			root = "response."
+		}
+		gjson.Get(data, root+"candidates").ForEach(func(ci, cand gjson.Result) bool {
+			i := strconv.Itoa(int(ci.Int()))
+			cand.Get("content.parts").ForEach(func(pj, part gjson.Result) bool {
+				if part.Get("text").Type == gjson.String {
+					j := strconv.Itoa(int(pj.Int()))
+					sites = append(sites, deltaSite{key: "g" + i + "." + j, path: root + "candidates." + i + ".content.parts." + j + ".text"})
+				}
+				return true
+			})
+			return true
+		})
+	case provider.FormatOllama:
+		if gjson.Get(data, "message.content").Type == gjson.String {
+			sites = append(sites, deltaSite{key: "m", path: "message.content"})
+		}
+	default:
+		gjson.Get(data, "choices").ForEach(func(ci, choice gjson.Result) bool {
+			i := strconv.Itoa(int(ci.Int()))
+			if choice.Get("delta.content").Type == gjson.String {
+				sites = append(sites, deltaSite{key: "c" + i, path: "choices." + i + ".delta.content"})
+			}
+			choice.Get("delta.tool_calls").ForEach(func(tj, call gjson.Result) bool {
+				if call.Get("function.arguments").Type == gjson.String {
+					j := strconv.Itoa(int(tj.Int()))
+					sites = append(sites, deltaSite{key: "t" + i + "." + call.Get("index").Raw, path: "choices." + i + ".delta.tool_calls." + j + ".function.arguments"})
+				}
+				return true
+			})
+			return true
+		})
+	}
+	return sites
+}
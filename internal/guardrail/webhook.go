@@ -0,0 +1,102 @@
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+const (
+	defaultWebhookTimeout = 5 * time.Second
+	// maxWebhookResponse caps how much of a classifier response is read.
+	maxWebhookResponse = 64 << 10
+)
+
+// webhook asks an external classifier whether to allow content.
+type webhook struct {
+	url      string
+	headers  map[string]string
+	timeout  time.Duration
+	failOpen bool
+	client   *http.Client
+}
+
+// webhookRequest is the JSON body sent to the classifier.
+type webhookRequest struct {
+	Policy string   `json:"policy"`
+	Stage  string   `json:"stage"`
+	Model  string   `json:"model"`
+	Client string   `json:"client,omitempty"`
+	Format string   `json:"format,omitempty"`
+	Texts  []string `json:"texts"`
+}
+
+// webhookResponse is the classifier's verdict.
+type webhookResponse struct {
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// check returns a reason when the classifier blocks the content. Transport and
+// protocol errors block too unless the webhook fails open.
+func (w *webhook) check(ctx context.Context, in webhookRequest) (string, bool) {
+	verdict, err := w.call(ctx, in)
+	if err != nil {
+		if w.failOpen {
+			log.Warnf("guardrail: policy %s: classifier unavailable, allowing: %v", in.Policy, err)
+			return "", false
+		}
+		return "classifier unavailable", true
+	}
+	switch strings.ToLower(strings.TrimSpace(verdict.Action)) {
+	case "", "allow":
+		return "", false
+	default:
+		reason := strings.TrimSpace(verdict.Reason)
+		if reason == "" {
+			reason = "rejected by classifier"
+		}
+		return reason, true
+	}
+}
+
+func (w *webhook) call(ctx context.Context, in webhookRequest) (webhookResponse, error) {
+	var out webhookResponse
+	body, err := json.Marshal(in)
+	if err != nil {
+		return out, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	if err != nil {
+		return out, err
+	}
+	if resp.StatusCode >= 300 {
+		return out, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, fmt.Errorf("invalid response: %w", err)
+	}
+	return out, nil
+}
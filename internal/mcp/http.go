@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+// SessionHeader carries the streamable HTTP session identifier.
+const SessionHeader = "Mcp-Session-Id"
+
+const sessionIdleTTL = time.Hour
+
+// HTTPTransport implements the MCP streamable HTTP transport. Every POST is
+// answered with a single application/json body; the optional server-to-client
+// SSE stream is not offered, so GET returns 405 as permitted by the spec.
+type HTTPTransport struct {
+	server *Server
+
+	mu       sync.Mutex
+	sessions map[string]time.Time
+}
+
+// NewHTTPTransport wraps server for use on the main HTTP port.
+func NewHTTPTransport(server *Server) *HTTPTransport {
+	return &HTTPTransport{server: server, sessions: make(map[string]time.Time)}
+}
+
+// Handle serves GET, POST and DELETE on the MCP endpoint.
+func (t *HTTPTransport) Handle(c *gin.Context) {
+	switch c.Request.Method {
+	case http.MethodPost:
+		t.handlePost(c)
+	case http.MethodDelete:
+		t.handleDelete(c)
+	default:
+		c.Header("Allow", "POST, DELETE")
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *HTTPTransport) handlePost(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(nil, codeParseError, "failed to read request body"))
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	initializing := isInitialize(body)
+	sessionID := c.GetHeader(SessionHeader)
+	if sessionID != "" && !initializing && !t.touch(sessionID) {
+		c.JSON(http.StatusNotFound, errorResponse(nil, codeInvalidRequest, "unknown or expired session"))
+		return
+	}
+
+	resp := t.server.HandleMessage(c.Request.Context(), body)
+	if initializing && !bytes.Contains(resp, []byte(`"error"`)) {
+		c.Header(SessionHeader, t.newSession())
+	}
+	if resp == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", resp)
+}
+
+func (t *HTTPTransport) handleDelete(c *gin.Context) {
+	sessionID := c.GetHeader(SessionHeader)
+	if sessionID == "" {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	t.mu.Lock()
+	_, ok := t.sessions[sessionID]
+	delete(t.sessions, sessionID)
+	t.mu.Unlock()
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (t *HTTPTransport) newSession() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	id := hex.EncodeToString(buf[:])
+	now := time.Now()
+	t.mu.Lock()
+	for sid, seen := range t.sessions {
+		if now.Sub(seen) > sessionIdleTTL {
+			delete(t.sessions, sid)
+		}
+	}
+	t.sessions[id] = now
+	t.mu.Unlock()
+	return id
+}
+
+func (t *HTTPTransport) touch(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seen, ok := t.sessions[id]
+	if !ok || time.Since(seen) > sessionIdleTTL {
+		delete(t.sessions, id)
+		return false
+	}
+	t.sessions[id] = time.Now()
+	return true
+}
+
+func isInitialize(body []byte) bool {
+	if len(body) == 0 || body[0] != '{' {
+		return false
+	}
+	var probe struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(body, &probe) == nil && probe.Method == "initialize"
+}
@@ -0,0 +1,589 @@
+package mcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/nghyane/llm-mux/internal/buildinfo"
+	"github.com/nghyane/llm-mux/internal/constant"
+	"github.com/nghyane/llm-mux/internal/interfaces"
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/tidwall/gjson"
+)
+
+const defaultMaxTokens = 4096
+
+// Executor is the subset of format.BaseAPIHandler used to reach provider.Manager.
+// Requests are issued in Claude Messages format so routing, fallbacks and usage
+// accounting behave exactly like the /v1/messages endpoint.
+type Executor interface {
+	ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage)
+	ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage)
+	Models() []map[string]any
+}
+
+// Server dispatches MCP JSON-RPC messages to the llm-mux execution pipeline.
+// It is transport agnostic; see ServeStdio and HTTPHandler.
+type Server struct {
+	exec Executor
+
+	mu           sync.RWMutex
+	defaultModel string
+}
+
+// NewServer creates an MCP server backed by the given executor.
+// defaultModel is used when neither the tool arguments nor sampling hints name a model.
+func NewServer(exec Executor, defaultModel string) *Server {
+	return &Server{exec: exec, defaultModel: strings.TrimSpace(defaultModel)}
+}
+
+// SetDefaultModel updates the fallback model after a config reload.
+func (s *Server) SetDefaultModel(model string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.defaultModel = strings.TrimSpace(model)
+	s.mu.Unlock()
+}
+
+func (s *Server) getDefaultModel() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaultModel
+}
+
+// HandleMessage processes a raw JSON-RPC payload (single message or batch) and
+// returns the encoded response. It returns nil when nothing must be sent back,
+// i.e. when the payload only contained notifications or responses.
+func (s *Server) HandleMessage(ctx context.Context, raw []byte) []byte {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return encodeResponse(errorResponse(nil, codeParseError, "parse error"))
+		}
+		if len(batch) == 0 {
+			return encodeResponse(errorResponse(nil, codeInvalidRequest, "empty batch"))
+		}
+		responses := make([]*Response, 0, len(batch))
+		for _, item := range batch {
+			if resp := s.handleSingle(ctx, item); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		out, _ := json.Marshal(responses)
+		return out
+	}
+	if resp := s.handleSingle(ctx, trimmed); resp != nil {
+		return encodeResponse(resp)
+	}
+	return nil
+}
+
+func (s *Server) handleSingle(ctx context.Context, raw []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(nil, codeParseError, "parse error")
+	}
+	if req.Method == "" {
+		// Responses to server-initiated requests are not used by this server.
+		return nil
+	}
+	if req.JSONRPC != "2.0" {
+		if req.IsNotification() {
+			return nil
+		}
+		return errorResponse(req.ID, codeInvalidRequest, "jsonrpc must be \"2.0\"")
+	}
+	result, rpcErr := s.Dispatch(ctx, &req)
+	if req.IsNotification() {
+		return nil
+	}
+	if rpcErr != nil {
+		return &Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// Dispatch executes a single decoded request and returns its result.
+func (s *Server) Dispatch(ctx context.Context, req *Request) (any, *RPCError) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return toolsListResult{Tools: toolDefinitions()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "sampling/createMessage":
+		return s.createMessage(ctx, req.Params)
+	case "notifications/initialized", "notifications/cancelled", "notifications/roots/list_changed":
+		return nil, nil
+	default:
+		return nil, &RPCError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, *RPCError) {
+	var p initializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "invalid initialize params"}
+		}
+	}
+	version := LatestProtocolVersion
+	for _, v := range supportedProtocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+	if p.ClientInfo.Name != "" {
+		log.Debugf("mcp: initialize from %s %s (protocol %s)", p.ClientInfo.Name, p.ClientInfo.Version, p.ProtocolVersion)
+	}
+	return initializeResult{
+		ProtocolVersion: version,
+		Capabilities: map[string]any{
+			"tools":    map[string]any{"listChanged": false},
+			"sampling": map[string]any{},
+		},
+		ServerInfo: implementation{Name: "llm-mux", Version: buildinfo.Version},
+		Instructions: "Use the chat tool or sampling/createMessage to run prompts against models pooled by llm-mux. " +
+			"Call list_models to discover model IDs.",
+	}, nil
+}
+
+func toolDefinitions() []Tool {
+	messagesSchema := map[string]any{
+		"type":        "array",
+		"description": "Conversation turns. Ignored when prompt is set.",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"role":    map[string]any{"type": "string", "enum": []string{"user", "assistant"}},
+				"content": map[string]any{"type": "string"},
+			},
+			"required": []string{"role", "content"},
+		},
+	}
+	return []Tool{
+		{
+			Name:        "chat",
+			Title:       "Chat completion",
+			Description: "Send a prompt or conversation to a model served by llm-mux and return the assistant reply.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"model":       map[string]any{"type": "string", "description": "Model ID; see list_models. Defaults to the configured MCP model."},
+					"prompt":      map[string]any{"type": "string", "description": "Single user message."},
+					"messages":    messagesSchema,
+					"system":      map[string]any{"type": "string", "description": "Optional system prompt."},
+					"max_tokens":  map[string]any{"type": "integer", "minimum": 1},
+					"temperature": map[string]any{"type": "number", "minimum": 0, "maximum": 2},
+				},
+			},
+		},
+		{
+			Name:        "list_models",
+			Title:       "List models",
+			Description: "List model IDs currently available through llm-mux.",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+		{
+			Name:        "count_tokens",
+			Title:       "Count tokens",
+			Description: "Count input tokens for a prompt or conversation against a model.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"model":    map[string]any{"type": "string"},
+					"prompt":   map[string]any{"type": "string"},
+					"messages": messagesSchema,
+					"system":   map[string]any{"type": "string"},
+				},
+			},
+		},
+	}
+}
+
+type chatArguments struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	System      string   `json:"system"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature *float64 `json:"temperature"`
+}
+
+func (a *chatArguments) claudeMessages() ([]map[string]any, error) {
+	if strings.TrimSpace(a.Prompt) != "" {
+		return []map[string]any{textMessage("user", a.Prompt)}, nil
+	}
+	if len(a.Messages) == 0 {
+		return nil, fmt.Errorf("either prompt or messages is required")
+	}
+	out := make([]map[string]any, 0, len(a.Messages))
+	for _, m := range a.Messages {
+		role := strings.ToLower(strings.TrimSpace(m.Role))
+		if role != "user" && role != "assistant" {
+			return nil, fmt.Errorf("unsupported message role %q", m.Role)
+		}
+		out = append(out, textMessage(role, m.Content))
+	}
+	return out, nil
+}
+
+func textMessage(role, text string) map[string]any {
+	return map[string]any{
+		"role":    role,
+		"content": []map[string]any{{"type": "text", "text": text}},
+	}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, *RPCError) {
+	var p toolCallParams
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		return nil, &RPCError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+	}
+	switch p.Name {
+	case "list_models":
+		return s.toolListModels(), nil
+	case "chat", "count_tokens":
+		var args chatArguments
+		if len(p.Arguments) > 0 {
+			if err := json.Unmarshal(p.Arguments, &args); err != nil {
+				return nil, &RPCError{Code: codeInvalidParams, Message: "invalid arguments: " + err.Error()}
+			}
+		}
+		if p.Name == "chat" {
+			return s.toolChat(ctx, &args), nil
+		}
+		return s.toolCountTokens(ctx, &args), nil
+	default:
+		return nil, &RPCError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+}
+
+func (s *Server) toolListModels() toolCallResult {
+	models := s.exec.Models()
+	type entry struct {
+		ID      string `json:"id"`
+		OwnedBy string `json:"owned_by,omitempty"`
+	}
+	entries := make([]entry, 0, len(models))
+	for _, m := range models {
+		id, _ := m["id"].(string)
+		if id == "" {
+			continue
+		}
+		owner, _ := m["owned_by"].(string)
+		entries = append(entries, entry{ID: id, OwnedBy: owner})
+	}
+	structured := map[string]any{"models": entries}
+	text, _ := json.Marshal(structured)
+	return toolCallResult{
+		Content:           []Content{{Type: "text", Text: string(text)}},
+		StructuredContent: structured,
+	}
+}
+
+func (s *Server) toolChat(ctx context.Context, args *chatArguments) toolCallResult {
+	messages, err := args.claudeMessages()
+	if err != nil {
+		return toolError(err.Error())
+	}
+	model, err := s.resolveModel(args.Model, nil)
+	if err != nil {
+		return toolError(err.Error())
+	}
+	maxTokens := args.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	payload := buildClaudePayload(model, args.System, messages, maxTokens, args.Temperature, nil)
+	resp, errMsg := s.exec.ExecuteWithAuthManager(ctx, constant.Claude, model, payload, "")
+	if errMsg != nil {
+		return toolError(errorText(errMsg))
+	}
+	reply := parseClaudeResponse(resp)
+	return toolCallResult{
+		Content: []Content{{Type: "text", Text: reply.text}},
+		StructuredContent: map[string]any{
+			"model":       reply.model,
+			"text":        reply.text,
+			"stop_reason": reply.stopReason,
+		},
+	}
+}
+
+func (s *Server) toolCountTokens(ctx context.Context, args *chatArguments) toolCallResult {
+	messages, err := args.claudeMessages()
+	if err != nil {
+		return toolError(err.Error())
+	}
+	model, err := s.resolveModel(args.Model, nil)
+	if err != nil {
+		return toolError(err.Error())
+	}
+	payload := buildClaudePayload(model, args.System, messages, 0, nil, nil)
+	resp, errMsg := s.exec.ExecuteCountWithAuthManager(ctx, constant.Claude, model, payload, "")
+	if errMsg != nil {
+		return toolError(errorText(errMsg))
+	}
+	tokens := gjson.GetBytes(resp, "input_tokens").Int()
+	structured := map[string]any{"model": model, "input_tokens": tokens}
+	return toolCallResult{
+		Content:           []Content{{Type: "text", Text: fmt.Sprintf("%d", tokens)}},
+		StructuredContent: structured,
+	}
+}
+
+func (s *Server) createMessage(ctx context.Context, params json.RawMessage) (any, *RPCError) {
+	var p createMessageParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &RPCError{Code: codeInvalidParams, Message: "invalid sampling params: " + err.Error()}
+	}
+	if len(p.Messages) == 0 {
+		return nil, &RPCError{Code: codeInvalidParams, Message: "messages is required"}
+	}
+	messages := make([]map[string]any, 0, len(p.Messages))
+	for _, m := range p.Messages {
+		blocks, err := samplingContentToClaude(m.Content)
+		if err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		role := strings.ToLower(m.Role)
+		if role != "user" && role != "assistant" {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "unsupported message role: " + m.Role}
+		}
+		messages = append(messages, map[string]any{"role": role, "content": blocks})
+	}
+	var hints []modelHint
+	if p.ModelPreferences != nil {
+		hints = p.ModelPreferences.Hints
+	}
+	model, err := s.resolveModel("", hints)
+	if err != nil {
+		return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	maxTokens := p.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	payload := buildClaudePayload(model, p.SystemPrompt, messages, maxTokens, p.Temperature, p.StopSequences)
+	resp, errMsg := s.exec.ExecuteWithAuthManager(ctx, constant.Claude, model, payload, "")
+	if errMsg != nil {
+		return nil, &RPCError{Code: codeInternalError, Message: errorText(errMsg), Data: map[string]any{"status": errMsg.StatusCode}}
+	}
+	reply := parseClaudeResponse(resp)
+	if reply.model == "" {
+		reply.model = model
+	}
+	return createMessageResult{
+		Role:       "assistant",
+		Content:    Content{Type: "text", Text: reply.text},
+		Model:      reply.model,
+		StopReason: reply.stopReason,
+	}, nil
+}
+
+// samplingContentToClaude converts a sampling content block (or array of blocks)
+// into Claude content blocks.
+func samplingContentToClaude(raw json.RawMessage) ([]map[string]any, error) {
+	var blocks []Content
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &blocks); err != nil {
+			return nil, fmt.Errorf("invalid message content: %w", err)
+		}
+	} else {
+		var single Content
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return nil, fmt.Errorf("invalid message content: %w", err)
+		}
+		blocks = []Content{single}
+	}
+	out := make([]map[string]any, 0, len(blocks))
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			out = append(out, map[string]any{"type": "text", "text": b.Text})
+		case "image":
+			if b.Data == "" || b.MimeType == "" {
+				return nil, fmt.Errorf("image content requires data and mimeType")
+			}
+			out = append(out, map[string]any{
+				"type": "image",
+				"source": map[string]any{
+					"type":       "base64",
+					"media_type": b.MimeType,
+					"data":       b.Data,
+				},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported content type %q", b.Type)
+		}
+	}
+	return out, nil
+}
+
+// resolveModel picks the model for a request: explicit argument first, then
+// sampling hints (exact ID, then substring match as described by the MCP spec),
+// then the configured default, then the first model in the registry.
+func (s *Server) resolveModel(explicit string, hints []modelHint) (string, error) {
+	if m := strings.TrimSpace(explicit); m != "" {
+		return m, nil
+	}
+	if len(hints) > 0 {
+		ids := s.modelIDs()
+		for _, hint := range hints {
+			name := strings.ToLower(strings.TrimSpace(hint.Name))
+			if name == "" {
+				continue
+			}
+			for _, id := range ids {
+				if strings.ToLower(id) == name {
+					return id, nil
+				}
+			}
+			for _, id := range ids {
+				if strings.Contains(strings.ToLower(id), name) {
+					return id, nil
+				}
+			}
+		}
+	}
+	if m := s.getDefaultModel(); m != "" {
+		return m, nil
+	}
+	if ids := s.modelIDs(); len(ids) > 0 {
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("no model available")
+}
+
+func (s *Server) modelIDs() []string {
+	models := s.exec.Models()
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		if id, ok := m["id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func buildClaudePayload(model, system string, messages []map[string]any, maxTokens int, temperature *float64, stop []string) []byte {
+	body := map[string]any{
+		"model":    model,
+		"messages": messages,
+	}
+	if maxTokens > 0 {
+		body["max_tokens"] = maxTokens
+	}
+	if strings.TrimSpace(system) != "" {
+		body["system"] = system
+	}
+	if temperature != nil {
+		body["temperature"] = *temperature
+	}
+	if len(stop) > 0 {
+		body["stop_sequences"] = stop
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+type claudeReply struct {
+	text       string
+	model      string
+	stopReason string
+}
+
+func parseClaudeResponse(resp []byte) claudeReply {
+	if len(resp) >= 2 && resp[0] == 0x1f && resp[1] == 0x8b {
+		if gr, err := gzip.NewReader(bytes.NewReader(resp)); err == nil {
+			if decompressed, errRead := io.ReadAll(gr); errRead == nil {
+				resp = decompressed
+			}
+			_ = gr.Close()
+		}
+	}
+	root := gjson.ParseBytes(resp)
+	var sb strings.Builder
+	root.Get("content").ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() == "text" {
+			sb.WriteString(block.Get("text").String())
+		}
+		return true
+	})
+	return claudeReply{
+		text:       sb.String(),
+		model:      root.Get("model").String(),
+		stopReason: mapStopReason(root.Get("stop_reason").String()),
+	}
+}
+
+func mapStopReason(reason string) string {
+	switch reason {
+	case "end_turn":
+		return "endTurn"
+	case "max_tokens":
+		return "maxTokens"
+	case "stop_sequence":
+		return "stopSequence"
+	case "tool_use":
+		return "toolUse"
+	default:
+		return reason
+	}
+}
+
+func toolError(msg string) toolCallResult {
+	return toolCallResult{Content: []Content{{Type: "text", Text: msg}}, IsError: true}
+}
+
+func errorText(msg *interfaces.ErrorMessage) string {
+	if msg == nil || msg.Error == nil {
+		return "upstream request failed"
+	}
+	return msg.Error.Error()
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: message}}
+}
+
+func encodeResponse(resp *Response) []byte {
+	if resp == nil {
+		return nil
+	}
+	if len(resp.ID) == 0 {
+		resp.ID = json.RawMessage("null")
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		out, _ = json.Marshal(errorResponse(resp.ID, codeInternalError, "failed to encode response"))
+	}
+	return out
+}
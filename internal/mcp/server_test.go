@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/interfaces"
+	"github.com/nghyane/llm-mux/internal/json"
+)
+
+type fakeExecutor struct {
+	models    []map[string]any
+	lastModel string
+	lastBody  []byte
+}
+
+func (f *fakeExecutor) ExecuteWithAuthManager(_ context.Context, _, modelName string, rawJSON []byte, _ string) ([]byte, *interfaces.ErrorMessage) {
+	f.lastModel = modelName
+	f.lastBody = rawJSON
+	return []byte(`{"model":"` + modelName + `","stop_reason":"end_turn","content":[{"type":"text","text":"hello"}]}`), nil
+}
+
+func (f *fakeExecutor) ExecuteCountWithAuthManager(_ context.Context, _, modelName string, rawJSON []byte, _ string) ([]byte, *interfaces.ErrorMessage) {
+	f.lastModel = modelName
+	f.lastBody = rawJSON
+	return []byte(`{"input_tokens":42}`), nil
+}
+
+func (f *fakeExecutor) Models() []map[string]any { return f.models }
+
+func newTestServer() (*Server, *fakeExecutor) {
+	exec := &fakeExecutor{models: []map[string]any{
+		{"id": "gemini-2.5-pro", "owned_by": "google"},
+		{"id": "claude-sonnet-4-5", "owned_by": "anthropic"},
+	}}
+	return NewServer(exec, ""), exec
+}
+
+func call(t *testing.T, s *Server, raw string) map[string]any {
+	t.Helper()
+	out := s.HandleMessage(context.Background(), []byte(raw))
+	if out == nil {
+		t.Fatalf("expected response for %s", raw)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", out, err)
+	}
+	return resp
+}
+
+func TestInitializeNegotiatesVersion(t *testing.T) {
+	s, _ := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`)
+	result := resp["result"].(map[string]any)
+	if got := result["protocolVersion"]; got != "2024-11-05" {
+		t.Fatalf("protocolVersion = %v, want 2024-11-05", got)
+	}
+
+	resp = call(t, s, `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	result = resp["result"].(map[string]any)
+	if got := result["protocolVersion"]; got != LatestProtocolVersion {
+		t.Fatalf("protocolVersion = %v, want %s", got, LatestProtocolVersion)
+	}
+}
+
+func TestNotificationHasNoResponse(t *testing.T) {
+	s, _ := newTestServer()
+	if out := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); out != nil {
+		t.Fatalf("expected nil response, got %s", out)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	s, _ := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`)
+	errObj, ok := resp["error"].(map[string]any)
+	if !ok || int(errObj["code"].(float64)) != codeMethodNotFound {
+		t.Fatalf("expected method-not-found error, got %v", resp)
+	}
+}
+
+func TestToolsList(t *testing.T) {
+	s, _ := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	tools := resp["result"].(map[string]any)["tools"].([]any)
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		names[tool.(map[string]any)["name"].(string)] = true
+	}
+	for _, want := range []string{"chat", "list_models", "count_tokens"} {
+		if !names[want] {
+			t.Errorf("tool %q missing from tools/list", want)
+		}
+	}
+}
+
+func TestChatToolUsesDefaultModel(t *testing.T) {
+	s, exec := newTestServer()
+	s.SetDefaultModel("claude-sonnet-4-5")
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"chat","arguments":{"prompt":"hi"}}}`)
+	result := resp["result"].(map[string]any)
+	if isErr, _ := result["isError"].(bool); isErr {
+		t.Fatalf("unexpected tool error: %v", result)
+	}
+	text := result["content"].([]any)[0].(map[string]any)["text"]
+	if text != "hello" {
+		t.Fatalf("text = %v, want hello", text)
+	}
+	if exec.lastModel != "claude-sonnet-4-5" {
+		t.Fatalf("model = %q, want default model", exec.lastModel)
+	}
+	if !bytes.Contains(exec.lastBody, []byte(`"max_tokens":4096`)) {
+		t.Fatalf("payload missing default max_tokens: %s", exec.lastBody)
+	}
+}
+
+func TestChatToolMissingPromptIsToolError(t *testing.T) {
+	s, _ := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"chat","arguments":{}}}`)
+	result := resp["result"].(map[string]any)
+	if isErr, _ := result["isError"].(bool); !isErr {
+		t.Fatalf("expected isError result, got %v", result)
+	}
+}
+
+func TestCountTokensTool(t *testing.T) {
+	s, _ := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"count_tokens","arguments":{"model":"gemini-2.5-pro","prompt":"hi"}}}`)
+	result := resp["result"].(map[string]any)
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(text, "42") {
+		t.Fatalf("count text = %q, want 42", text)
+	}
+}
+
+func TestCreateMessageResolvesModelHint(t *testing.T) {
+	s, exec := newTestServer()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"hi"}}],"modelPreferences":{"hints":[{"name":"sonnet"}]},"maxTokens":16}}`)
+	result := resp["result"].(map[string]any)
+	if result["role"] != "assistant" || result["stopReason"] != "endTurn" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if exec.lastModel != "claude-sonnet-4-5" {
+		t.Fatalf("model = %q, want hint match claude-sonnet-4-5", exec.lastModel)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	s, _ := newTestServer()
+	out := s.HandleMessage(context.Background(), []byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`))
+	var resps []map[string]any
+	if err := json.Unmarshal(out, &resps); err != nil {
+		t.Fatalf("invalid batch response %s: %v", out, err)
+	}
+	if len(resps) != 1 {
+		t.Fatalf("batch responses = %d, want 1", len(resps))
+	}
+}
+
+func TestHTTPTransportSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, _ := newTestServer()
+	transport := NewHTTPTransport(s)
+	router := gin.New()
+	router.Any("/mcp", transport.Handle)
+
+	do := func(method, body, session string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set(SessionHeader, session)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, "")
+	session := w.Header().Get(SessionHeader)
+	if w.Code != http.StatusOK || session == "" {
+		t.Fatalf("initialize: code=%d session=%q", w.Code, session)
+	}
+	if w = do(http.MethodPost, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, session); w.Code != http.StatusAccepted {
+		t.Fatalf("notification code = %d, want 202", w.Code)
+	}
+	if w = do(http.MethodGet, "", session); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET code = %d, want 405", w.Code)
+	}
+	if w = do(http.MethodDelete, "", session); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE code = %d, want 204", w.Code)
+	}
+	if w = do(http.MethodPost, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, session); w.Code != http.StatusNotFound {
+		t.Fatalf("expired session code = %d, want 404", w.Code)
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	s, _ := newTestServer()
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" + `{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n")
+	var out bytes.Buffer
+	if err := s.ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("ServeStdio: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"id":1`) {
+		t.Fatalf("unexpected stdio output: %q", out.String())
+	}
+}
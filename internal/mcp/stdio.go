@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+// ServeStdio runs the MCP stdio transport: newline-delimited JSON-RPC messages
+// are read from in and responses are written to out. Requests are handled
+// concurrently so a long chat call does not block pings or cancellations;
+// notifications/cancelled aborts the matching in-flight request.
+// It returns when in reaches EOF or ctx is cancelled.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		writeMu  sync.Mutex
+		wg       sync.WaitGroup
+		inflight sync.Map // request id (raw JSON) -> context.CancelFunc
+	)
+	write := func(data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			log.Warnf("mcp: failed to write stdio response: %v", err)
+		}
+	}
+
+	reader := bufio.NewReaderSize(in, 64*1024)
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				wg.Wait()
+				select {
+				case err := <-readErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			var req Request
+			if err := json.Unmarshal(line, &req); err == nil && req.Method == "notifications/cancelled" {
+				var p cancelledParams
+				if errParams := json.Unmarshal(req.Params, &p); errParams == nil {
+					if fn, found := inflight.LoadAndDelete(string(p.RequestID)); found {
+						fn.(context.CancelFunc)()
+					}
+				}
+				continue
+			}
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			key := string(req.ID)
+			if key != "" {
+				inflight.Store(key, reqCancel)
+			}
+			wg.Add(1)
+			go func(payload []byte) {
+				defer wg.Done()
+				defer reqCancel()
+				resp := s.HandleMessage(reqCtx, payload)
+				if key != "" {
+					inflight.Delete(key)
+				}
+				if reqCtx.Err() != nil && ctx.Err() == nil {
+					// Cancelled by the client: the spec says no response is sent.
+					return
+				}
+				write(resp)
+			}(line)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/nghyane/llm-mux/internal/json"
@@ -15,8 +18,15 @@ const (
 )
 
 func fetchAntigravityQuota(ctx context.Context, accessToken string) *RealQuotaSnapshot {
+	snapshot, _ := queryAntigravityQuota(ctx, accessToken)
+	return snapshot
+}
+
+// queryAntigravityQuota is fetchAntigravityQuota with the failure reason,
+// used by the health prober to tell revoked credentials from network errors.
+func queryAntigravityQuota(ctx context.Context, accessToken string) (*RealQuotaSnapshot, error) {
 	if accessToken == "" {
-		return nil
+		return nil, &Error{Code: "missing_token", Message: "no access token"}
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, quotaFetchTimeout)
@@ -24,7 +34,7 @@ func fetchAntigravityQuota(ctx context.Context, accessToken string) *RealQuotaSn
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, antigravityQuotaEndpoint, nil)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
@@ -32,12 +42,17 @@ func fetchAntigravityQuota(ctx context.Context, accessToken string) *RealQuotaSn
 
 	resp, err := transport.SharedClient.Do(req)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &Error{
+			Code:       "quota_fetch_failed",
+			Message:    fmt.Sprintf("getQuotaInfo: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))),
+			HTTPStatus: resp.StatusCode,
+		}
 	}
 
 	var quotaResp struct {
@@ -46,12 +61,13 @@ func fetchAntigravityQuota(ctx context.Context, accessToken string) *RealQuotaSn
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&quotaResp); err != nil {
-		return nil
+		return nil, err
 	}
 
 	snapshot := &RealQuotaSnapshot{
 		RemainingFraction: quotaResp.RemainingFraction,
 		FetchedAt:         time.Now(),
+		Source:            QuotaSourceAntigravity,
 	}
 
 	if quotaResp.ResetTime != "" {
@@ -60,5 +76,5 @@ func fetchAntigravityQuota(ctx context.Context, accessToken string) *RealQuotaSn
 		}
 	}
 
-	return snapshot
+	return snapshot, nil
 }
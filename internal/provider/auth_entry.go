@@ -439,6 +439,9 @@ type AuthEntry struct {
 
 	// COW model states
 	modelStates atomic.Pointer[ModelStatesSnapshot]
+
+	// Latest health probe results (see health.go)
+	health atomic.Pointer[AuthHealth]
 }
 
 // NewAuthEntry creates a new AuthEntry from an Auth.
@@ -444,10 +444,12 @@ func (r *AuthRegistry) doRefresh(authID string) {
 	}
 
 	auth := entry.ToAuth()
+	var lastErr error
 	for attempt := 0; attempt < 3; attempt++ {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		updated, err := exec.Refresh(ctx, auth)
 		cancel()
+		lastErr = err
 
 		if err == nil && updated != nil {
 			entry.UpdateMetadata(func(old *AuthMetadata) *AuthMetadata {
@@ -483,6 +485,9 @@ func (r *AuthRegistry) doRefresh(authID string) {
 	}
 
 	log.Warnf("auth_registry: failed refresh %s after 3 attempts", authID)
+	if lh, ok := r.hook.(LifecycleHook); ok && lastErr != nil {
+		lh.OnRefreshFailed(context.Background(), auth, lastErr)
+	}
 }
 
 func (r *AuthRegistry) markDirty(authID string) {
@@ -552,6 +557,16 @@ func (r *AuthRegistry) flushPending() {
 }
 
 func (r *AuthRegistry) MarkResult(ctx context.Context, result Result) {
+	r.markResult(ctx, result, true)
+}
+
+// MarkProbeResult records the outcome of a health probe like MarkResult. A
+// probe never holds an in-flight slot, so none is released.
+func (r *AuthRegistry) MarkProbeResult(ctx context.Context, result Result) {
+	r.markResult(ctx, result, false)
+}
+
+func (r *AuthRegistry) markResult(ctx context.Context, result Result, inFlight bool) {
 	if result.AuthID == "" {
 		return
 	}
@@ -565,7 +580,7 @@ func (r *AuthRegistry) MarkResult(ctx context.Context, result Result) {
 
 	// Always decrement active requests for non-success results
 	// This ensures active count stays accurate even if stream errors occur
-	if !result.Success {
+	if inFlight && !result.Success {
 		entry.DecrementActiveRequests()
 	}
 
@@ -609,6 +624,10 @@ func (r *AuthRegistry) handleSuccessResult(ctx context.Context, entry *AuthEntry
 }
 
 func (r *AuthRegistry) handleFailureResult(ctx context.Context, entry *AuthEntry, result Result, now time.Time) {
+	// Proxy failures never reached the upstream, so they say nothing about the auth.
+	if result.Error != nil && CategorizeError(0, result.Error.Message) == CategoryProxyError {
+		return
+	}
 	if result.Model == "" {
 		r.applyAuthLevelFailure(entry, result, now)
 		return
@@ -463,6 +463,31 @@ func TestAuthRegistry_MarkResultFailure(t *testing.T) {
 	}
 }
 
+func TestAuthRegistry_MarkResultProxyFailureKeepsAuth(t *testing.T) {
+	registry := NewAuthRegistry(nil, nil)
+	ctx := context.Background()
+
+	_, _ = registry.Register(ctx, &Auth{ID: "mark-proxy", Provider: "claude", Status: StatusActive})
+
+	registry.MarkResult(ctx, Result{
+		AuthID:   "mark-proxy",
+		Provider: "claude",
+		Model:    "claude-3-opus",
+		Success:  false,
+		Error: &Error{
+			Message: `Post "https://api.anthropic.com/v1/messages": proxyconnect tcp: dial tcp 10.0.0.1:3128: connect: connection refused`,
+		},
+	})
+
+	entry := registry.GetEntry("mark-proxy")
+	if _, ok := entry.ModelStates().Get("claude-3-opus"); ok {
+		t.Error("proxy failure created a model state")
+	}
+	if entry.Metadata().Status != StatusActive {
+		t.Errorf("Expected StatusActive, got %v", entry.Metadata().Status)
+	}
+}
+
 func TestAuthMetadata_Clone(t *testing.T) {
 	original := &AuthMetadata{
 		Label:      "Test",
@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+// CachedContentRequest describes a call to the Gemini cachedContents API.
+type CachedContentRequest struct {
+	// Method is the HTTP method (GET, POST, PATCH, DELETE).
+	Method string
+	// Name is the cache resource name ("cachedContents/abc" or a full Vertex
+	// resource path). Empty targets the collection (create and list).
+	Name  string
+	Query url.Values
+	Body  []byte
+}
+
+// CachedContentExecutor is implemented by executors whose upstream supports
+// Gemini context caching. The response payload is the upstream JSON body.
+type CachedContentExecutor interface {
+	CachedContent(ctx context.Context, auth *Auth, req CachedContentRequest) (Response, error)
+}
+
+// cachedContentOwner records which auth and client created a cache. Caches
+// only exist in the project/key that created them, so every later use must go
+// to that auth, and only the creating client key may manage them.
+type cachedContentOwner struct {
+	authID     string
+	provider   string
+	clientKey  string
+	expireTime time.Time
+}
+
+type cachedContentOwners struct {
+	mu   sync.RWMutex
+	byID map[string]cachedContentOwner
+}
+
+func newCachedContentOwners() *cachedContentOwners {
+	return &cachedContentOwners{byID: make(map[string]cachedContentOwner)}
+}
+
+// CachedContentID returns the trailing cache identifier of a resource name, so
+// "cachedContents/abc" and "projects/p/locations/l/cachedContents/abc" match.
+func CachedContentID(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if idx := strings.LastIndex(name, "cachedContents/"); idx >= 0 {
+		return name[idx+len("cachedContents/"):]
+	}
+	return name
+}
+
+func (o *cachedContentOwners) set(name string, owner cachedContentOwner) {
+	id := CachedContentID(name)
+	if id == "" {
+		return
+	}
+	o.mu.Lock()
+	o.byID[id] = owner
+	o.mu.Unlock()
+}
+
+func (o *cachedContentOwners) get(name string) (cachedContentOwner, bool) {
+	id := CachedContentID(name)
+	o.mu.RLock()
+	owner, ok := o.byID[id]
+	o.mu.RUnlock()
+	if !ok {
+		return cachedContentOwner{}, false
+	}
+	if !owner.expireTime.IsZero() && time.Now().After(owner.expireTime) {
+		o.remove(name)
+		return cachedContentOwner{}, false
+	}
+	return owner, true
+}
+
+func (o *cachedContentOwners) remove(name string) {
+	o.mu.Lock()
+	delete(o.byID, CachedContentID(name))
+	o.mu.Unlock()
+}
+
+// authIDs returns the distinct auths that own at least one live cache created
+// by clientKey.
+func (o *cachedContentOwners) authIDs(clientKey string) []string {
+	now := time.Now()
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, owner := range o.byID {
+		if owner.clientKey != clientKey || !owner.expireTime.IsZero() && now.After(owner.expireTime) {
+			continue
+		}
+		if _, ok := seen[owner.authID]; ok {
+			continue
+		}
+		seen[owner.authID] = struct{}{}
+		ids = append(ids, owner.authID)
+	}
+	return ids
+}
+
+// record parses an upstream cachedContent resource and remembers its owner.
+func (o *cachedContentOwners) record(payload []byte, auth *Auth, clientKey string) {
+	var meta struct {
+		Name       string `json:"name"`
+		ExpireTime string `json:"expireTime"`
+	}
+	if err := json.Unmarshal(payload, &meta); err != nil || meta.Name == "" {
+		return
+	}
+	owner := cachedContentOwner{authID: auth.ID, provider: auth.Provider, clientKey: clientKey}
+	if t, err := time.Parse(time.RFC3339Nano, meta.ExpireTime); err == nil {
+		owner.expireTime = t
+	}
+	o.set(meta.Name, owner)
+}
+
+// CachedContentOwner returns the auth that owns the named cache, if known.
+func (m *Manager) CachedContentOwner(name string) (string, bool) {
+	owner, ok := m.cachedContents.get(name)
+	return owner.authID, ok
+}
+
+// CreateCachedContent creates a cache through the first available auth among
+// providers whose executor supports context caching, and records that auth as
+// the owner so later requests referencing the cache are pinned to it. The
+// client key of ctx is recorded too, and only it may manage the cache. The
+// request guard, when set, inspects the cached contents first; masked values
+// stay masked in the cache.
+func (m *Manager) CreateCachedContent(ctx context.Context, providers []string, model string, body []byte) (Response, error) {
+	req, _, err := m.inspectRequest(ctx, Request{Model: model, Payload: body}, Options{SourceFormat: FormatGemini})
+	if err != nil {
+		return Response{}, err
+	}
+	body = req.Payload
+	var lastErr error
+	for _, prov := range m.normalizeProviders(providers) {
+		exec, ok := m.executorFor(prov).(CachedContentExecutor)
+		if !ok {
+			continue
+		}
+		tried := make(map[string]struct{})
+		for {
+			auth, _, errPick := m.pickNextFromRegistry(ctx, prov, model, Options{}, tried)
+			if errPick != nil {
+				if lastErr == nil {
+					lastErr = errPick
+				}
+				break
+			}
+			tried[auth.ID] = struct{}{}
+			setSelectedAuth(ctx, auth.ID)
+			resp, err := exec.CachedContent(ctx, auth, CachedContentRequest{Method: http.MethodPost, Body: body})
+			if err == nil {
+				m.cachedContents.record(resp.Payload, auth, ClientKeyFromContext(ctx))
+				return resp, nil
+			}
+			lastErr = err
+			if isClientCachedContentError(err) {
+				return Response{}, err
+			}
+		}
+	}
+	if lastErr != nil {
+		return Response{}, lastErr
+	}
+	return Response{}, &Error{Code: "provider_not_found", Message: "no provider supports cached content for this model", HTTPStatus: http.StatusBadRequest}
+}
+
+// CachedContent proxies get, patch and delete calls for a cache created by
+// the client key of ctx to its owning auth and keeps the ownership record in
+// sync with the result. Caches of other clients are reported as not found.
+func (m *Manager) CachedContent(ctx context.Context, req CachedContentRequest) (Response, error) {
+	owner, ok := m.cachedContents.get(req.Name)
+	if !ok || owner.clientKey != ClientKeyFromContext(ctx) {
+		return Response{}, &Error{Code: "not_found", Message: "cached content not found: " + req.Name, HTTPStatus: http.StatusNotFound}
+	}
+	auth, exec, err := m.cachedContentTarget(owner.authID)
+	if err != nil {
+		return Response{}, err
+	}
+	setSelectedAuth(ctx, auth.ID)
+	resp, err := exec.CachedContent(ctx, auth, req)
+	if err != nil {
+		var se StatusCodeError
+		if errors.As(err, &se) && se.StatusCode() == http.StatusNotFound {
+			m.cachedContents.remove(req.Name)
+		}
+		return Response{}, err
+	}
+	switch req.Method {
+	case http.MethodDelete:
+		m.cachedContents.remove(req.Name)
+	case http.MethodPatch:
+		m.cachedContents.record(resp.Payload, auth, owner.clientKey)
+	}
+	return resp, nil
+}
+
+// ListCachedContents merges the cache listings of every auth that owns a cache
+// created by the client key of ctx, keeping only that client's caches; caches
+// created by other clients or outside llm-mux are left out. Upstream
+// pagination tokens are not forwarded.
+func (m *Manager) ListCachedContents(ctx context.Context, query url.Values) (Response, error) {
+	clientKey := ClientKeyFromContext(ctx)
+	merged := make([]json.RawMessage, 0)
+	for _, authID := range m.cachedContents.authIDs(clientKey) {
+		auth, exec, err := m.cachedContentTarget(authID)
+		if err != nil {
+			continue
+		}
+		resp, err := exec.CachedContent(ctx, auth, CachedContentRequest{Method: http.MethodGet, Query: query})
+		if err != nil {
+			log.Warnf("cached content list failed for auth %s: %v", authID, err)
+			continue
+		}
+		var page struct {
+			CachedContents []json.RawMessage `json:"cachedContents"`
+		}
+		if err := json.Unmarshal(resp.Payload, &page); err != nil {
+			continue
+		}
+		for _, item := range page.CachedContents {
+			var meta struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(item, &meta); err != nil {
+				continue
+			}
+			owner, ok := m.cachedContents.get(meta.Name)
+			if !ok || owner.authID != auth.ID || owner.clientKey != clientKey {
+				continue
+			}
+			m.cachedContents.record(item, auth, clientKey)
+			merged = append(merged, item)
+		}
+	}
+	payload, err := json.Marshal(map[string]any{"cachedContents": merged})
+	if err != nil {
+		return Response{}, err
+	}
+	return Response{Payload: payload}, nil
+}
+
+func (m *Manager) cachedContentTarget(authID string) (*Auth, CachedContentExecutor, error) {
+	auth, ok := m.GetByID(authID)
+	if !ok || auth == nil || auth.Disabled {
+		return nil, nil, &Error{Code: "auth_not_found", Message: "auth owning cached content is unavailable", HTTPStatus: http.StatusServiceUnavailable}
+	}
+	exec, ok := m.executorFor(auth.Provider).(CachedContentExecutor)
+	if !ok {
+		return nil, nil, &Error{Code: "executor_not_found", Message: "executor does not support cached content", HTTPStatus: http.StatusNotImplemented}
+	}
+	return auth, exec, nil
+}
+
+// isClientCachedContentError reports upstream rejections caused by the request
+// itself (e.g. content below the minimum size), which other auths would reject too.
+func isClientCachedContentError(err error) bool {
+	var se StatusCodeError
+	if !errors.As(err, &se) {
+		return false
+	}
+	code := se.StatusCode()
+	return code == http.StatusBadRequest || code == http.StatusNotFound || code == http.StatusUnprocessableEntity
+}
+
+type pinnedAuthContextKey struct{}
+
+// WithPinnedAuth restricts auth selection for requests using ctx to authID.
+// Providers that do not own the auth yield no candidates.
+func WithPinnedAuth(ctx context.Context, authID string) context.Context {
+	if authID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedAuthContextKey{}, authID)
+}
+
+// PinnedAuthFromContext returns the auth id set by WithPinnedAuth.
+func PinnedAuthFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(pinnedAuthContextKey{}).(string)
+	return id
+}
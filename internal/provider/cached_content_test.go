@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+type fakeCacheExecutor struct {
+	stubExecutor
+	calls   []string
+	created int
+}
+
+func (e *fakeCacheExecutor) CachedContent(ctx context.Context, auth *Auth, req CachedContentRequest) (Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, req.Method+" "+auth.ID+" "+req.Name)
+	expire := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	switch req.Method {
+	case http.MethodPost:
+		e.created++
+		return Response{Payload: []byte(fmt.Sprintf(`{"name":"cachedContents/c%d","expireTime":%q}`, e.created, expire))}, nil
+	case http.MethodGet:
+		if req.Name == "" {
+			return Response{Payload: []byte(fmt.Sprintf(`{"cachedContents":[{"name":"cachedContents/c1","expireTime":%q},{"name":"cachedContents/external","expireTime":%q}]}`, expire, expire))}, nil
+		}
+		return Response{Payload: []byte(fmt.Sprintf(`{"name":%q,"expireTime":%q}`, req.Name, expire))}, nil
+	case http.MethodDelete:
+		return Response{Payload: []byte(`{}`)}, nil
+	}
+	return Response{}, &Error{Code: "bad_request", HTTPStatus: http.StatusBadRequest}
+}
+
+func TestCachedContentID(t *testing.T) {
+	cases := map[string]string{
+		"cachedContents/abc":                        "abc",
+		"projects/p/locations/l/cachedContents/abc": "abc",
+		"/v1beta/cachedContents/abc/":               "abc",
+		"abc":                                       "abc",
+		"":                                          "",
+	}
+	for in, want := range cases {
+		if got := CachedContentID(in); got != want {
+			t.Errorf("CachedContentID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCachedContent_OwnerPinning(t *testing.T) {
+	exec := &fakeCacheExecutor{stubExecutor: stubExecutor{id: "gemini"}}
+	manager := newStubManager(t, exec, []string{"gemini-2.5-flash"}, "cache-gemini-a", "cache-gemini-b")
+	ctx := context.Background()
+
+	resp, err := manager.CreateCachedContent(ctx, []string{"gemini"}, "gemini-2.5-flash", []byte(`{"model":"models/gemini-2.5-flash"}`))
+	if err != nil {
+		t.Fatalf("CreateCachedContent: %v", err)
+	}
+	if len(resp.Payload) == 0 {
+		t.Fatal("expected cache resource in response")
+	}
+	owner, ok := manager.CachedContentOwner("cachedContents/c1")
+	if !ok {
+		t.Fatal("expected owner to be recorded")
+	}
+	if other, ok := manager.CachedContentOwner("projects/p/locations/l/cachedContents/c1"); !ok || other != owner {
+		t.Errorf("full resource name should resolve to the same owner, got %q", other)
+	}
+
+	pinned := WithPinnedAuth(ctx, owner)
+	for i := 0; i < 5; i++ {
+		out, err := manager.Execute(pinned, []string{"gemini"}, Request{Model: "gemini-2.5-flash"}, Options{})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if string(out.Payload) != owner {
+			t.Fatalf("request routed to %s, want pinned owner %s", out.Payload, owner)
+		}
+	}
+
+	if _, err := manager.CachedContent(ctx, CachedContentRequest{Method: http.MethodGet, Name: "cachedContents/c1"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if _, err := manager.CachedContent(ctx, CachedContentRequest{Method: http.MethodDelete, Name: "cachedContents/c1"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := manager.CachedContentOwner("cachedContents/c1"); ok {
+		t.Error("owner should be forgotten after delete")
+	}
+	_, err = manager.CachedContent(ctx, CachedContentRequest{Method: http.MethodGet, Name: "cachedContents/c1"})
+	var se StatusCodeError
+	if err == nil || !errors.As(err, &se) || se.StatusCode() != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown cache, got %v", err)
+	}
+
+	for _, call := range exec.calls {
+		if !strings.Contains(call, " "+owner+" ") {
+			t.Errorf("cache call %q did not go to owner %s", call, owner)
+		}
+	}
+}
+
+// clientContext returns a context carrying a request authenticated as key.
+func clientContext(key string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", key)
+	return context.WithValue(context.Background(), ginContextKey, c)
+}
+
+func TestCachedContent_OwnedByClientKey(t *testing.T) {
+	exec := &fakeCacheExecutor{stubExecutor: stubExecutor{id: "gemini"}}
+	manager := newStubManager(t, exec, []string{"gemini-2.5-flash"}, "cache-client-a")
+	alice, bob := clientContext("alice"), clientContext("bob")
+
+	if _, err := manager.CreateCachedContent(alice, []string{"gemini"}, "gemini-2.5-flash", []byte(`{"model":"models/gemini-2.5-flash"}`)); err != nil {
+		t.Fatalf("CreateCachedContent: %v", err)
+	}
+
+	resp, err := manager.ListCachedContents(alice, nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if names := gjson.GetBytes(resp.Payload, "cachedContents.#.name").String(); names != `["cachedContents/c1"]` {
+		t.Errorf("owner listing = %s, want only its own cache", names)
+	}
+	resp, err = manager.ListCachedContents(bob, nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if n := len(gjson.GetBytes(resp.Payload, "cachedContents").Array()); n != 0 {
+		t.Errorf("other client listed %d caches: %s", n, resp.Payload)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		_, err := manager.CachedContent(bob, CachedContentRequest{Method: method, Name: "cachedContents/c1", Body: []byte(`{"ttl":"60s"}`)})
+		var se StatusCodeError
+		if err == nil || !errors.As(err, &se) || se.StatusCode() != http.StatusNotFound {
+			t.Errorf("%s by another client: got %v, want 404", method, err)
+		}
+	}
+	if _, err := manager.CachedContent(alice, CachedContentRequest{Method: http.MethodGet, Name: "cachedContents/c1"}); err != nil {
+		t.Errorf("get by owner: %v", err)
+	}
+	if _, err := manager.CachedContent(alice, CachedContentRequest{Method: http.MethodGet, Name: "cachedContents/external"}); err == nil {
+		t.Error("cache created outside llm-mux should not be readable")
+	}
+}
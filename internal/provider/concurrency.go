@@ -0,0 +1,477 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+const (
+	defaultConcurrencyQueueSize = 100
+	defaultConcurrencyMaxWait   = 30 * time.Second
+	defaultKeepAliveInterval    = 15 * time.Second
+
+	// concurrencyPollInterval is how often a queued request re-checks for a
+	// slot on its own. A release wakes one waiter, which may not be able to use
+	// the freed slot (another model, an auth it already tried).
+	concurrencyPollInterval = 500 * time.Millisecond
+)
+
+// ConcurrencyConfig caps in-flight requests and configures how requests wait
+// for a slot or for a cooling down auth.
+type ConcurrencyConfig struct {
+	// MaxPerAuth caps in-flight requests per auth; the max_in_flight attribute
+	// overrides it. 0 means unlimited.
+	MaxPerAuth int
+	// MaxPerProvider caps in-flight requests per provider key.
+	MaxPerProvider map[string]int
+	// QueueSize bounds the requests waiting for a slot per provider.
+	QueueSize int
+	// MaxWait bounds how long a request waits for a slot.
+	MaxWait time.Duration
+	// CooldownWait is how far away the earliest recovery of a cooling down
+	// model may be for requests to wait for it. 0 fails them at once.
+	CooldownWait time.Duration
+	// KeepAlive is how often the WithWaitKeepAlive callback runs while waiting.
+	KeepAlive time.Duration
+}
+
+func (c ConcurrencyConfig) withDefaults() ConcurrencyConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultConcurrencyQueueSize
+	}
+	if c.MaxWait <= 0 {
+		c.MaxWait = defaultConcurrencyMaxWait
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = defaultKeepAliveInterval
+	}
+	providers := make(map[string]int, len(c.MaxPerProvider))
+	for k, v := range c.MaxPerProvider {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" && v > 0 {
+			providers[k] = v
+		}
+	}
+	c.MaxPerProvider = providers
+	return c
+}
+
+// SetConcurrencyConfig replaces the in-flight limits. Requests already
+// holding a slot keep it.
+func (m *Manager) SetConcurrencyConfig(cfg ConcurrencyConfig) {
+	if m == nil || m.limiter == nil {
+		return
+	}
+	m.limiter.configure(cfg.withDefaults())
+}
+
+type waitKeepAliveContextKey struct{}
+
+// WithWaitKeepAlive registers fn to run periodically, on the calling
+// goroutine, while a request waits for a concurrency slot or for an auth to
+// leave cooldown. Streaming handlers use it to send keep-alive comments.
+func WithWaitKeepAlive(ctx context.Context, fn func()) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, waitKeepAliveContextKey{}, fn)
+}
+
+func waitKeepAliveFromContext(ctx context.Context) func() {
+	fn, _ := ctx.Value(waitKeepAliveContextKey{}).(func())
+	return fn
+}
+
+// ClientKeyFromContext returns the principal the request authenticated as. It
+// keeps waiting requests fair across client keys and selects guardrail policies.
+func ClientKeyFromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(ginContextKey).(*gin.Context); ok && c != nil {
+		if v, exists := c.Get("apiKey"); exists {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+func newConcurrencyLimitError(message string) *Error {
+	return &Error{Code: "concurrency_limit", Message: message, HTTPStatus: 429, ErrCategory: CategoryQuotaError}
+}
+
+// isConcurrencyLimitError reports whether err came from the slot queue, which
+// already spent the request's wait budget.
+func isConcurrencyLimitError(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == "concurrency_limit"
+}
+
+// errSlotsBusy is returned by pickNextFromRegistry when the only usable auths
+// are at their in-flight limit.
+var errSlotsBusy = errors.New("all auths are at their concurrency limit")
+
+type authSlots struct {
+	active int
+	limit  int
+}
+
+type slotWaiter struct {
+	key      string
+	model    string
+	ready    chan struct{}
+	signaled bool
+}
+
+// slotQueue holds the waiters of one provider, FIFO per client key and
+// round-robin across keys.
+type slotQueue struct {
+	keys    []string
+	waiters map[string][]*slotWaiter
+	size    int
+}
+
+func (q *slotQueue) push(w *slotWaiter) {
+	if _, ok := q.waiters[w.key]; !ok {
+		q.keys = append(q.keys, w.key)
+	}
+	q.waiters[w.key] = append(q.waiters[w.key], w)
+	q.size++
+}
+
+func (q *slotQueue) remove(w *slotWaiter) {
+	list := q.waiters[w.key]
+	for i, x := range list {
+		if x != w {
+			continue
+		}
+		q.size--
+		if len(list) == 1 {
+			delete(q.waiters, w.key)
+			for j, k := range q.keys {
+				if k == w.key {
+					q.keys = append(q.keys[:j], q.keys[j+1:]...)
+					break
+				}
+			}
+			return
+		}
+		q.waiters[w.key] = append(list[:i], list[i+1:]...)
+		return
+	}
+}
+
+// next returns the oldest unsignaled waiter of the next client key in turn.
+func (q *slotQueue) next() *slotWaiter {
+	for range q.keys {
+		key := q.keys[0]
+		q.keys = append(q.keys[1:], key)
+		for _, w := range q.waiters[key] {
+			if !w.signaled {
+				return w
+			}
+		}
+	}
+	return nil
+}
+
+// concurrencyLimiter counts in-flight requests per auth and provider. It keeps
+// its own counts: AuthEntry.ActiveRequests is a selection hint and is not
+// released on every path.
+type concurrencyLimiter struct {
+	mu        sync.Mutex
+	cfg       ConcurrencyConfig
+	auths     map[string]*authSlots
+	providers map[string]int
+	queues    map[string]*slotQueue
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{
+		cfg:       ConcurrencyConfig{}.withDefaults(),
+		auths:     make(map[string]*authSlots),
+		providers: make(map[string]int),
+		queues:    make(map[string]*slotQueue),
+	}
+}
+
+func (l *concurrencyLimiter) configure(cfg ConcurrencyConfig) {
+	l.mu.Lock()
+	l.cfg = cfg
+	// Raised limits may free slots for queued requests.
+	for provider := range l.queues {
+		l.signalLocked(provider)
+	}
+	l.mu.Unlock()
+}
+
+func (l *concurrencyLimiter) config() ConcurrencyConfig {
+	if l == nil {
+		return ConcurrencyConfig{}.withDefaults()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cfg
+}
+
+func (l *concurrencyLimiter) authLimitLocked(auth *Auth) int {
+	if auth != nil && auth.Attributes != nil {
+		if v, err := strconv.Atoi(strings.TrimSpace(auth.Attributes["max_in_flight"])); err == nil && v > 0 {
+			return v
+		}
+	}
+	return l.cfg.MaxPerAuth
+}
+
+// atLimit reports whether the auth has no free slot as of its last acquire.
+func (l *concurrencyLimiter) atLimit(authID string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.auths[authID]
+	return s != nil && s.limit > 0 && s.active >= s.limit
+}
+
+// tryAcquire reserves a slot for auth. The returned release is idempotent.
+func (l *concurrencyLimiter) tryAcquire(provider string, auth *Auth) (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	authLimit := l.authLimitLocked(auth)
+	providerLimit := l.cfg.MaxPerProvider[provider]
+	s := l.auths[auth.ID]
+	if authLimit <= 0 && providerLimit <= 0 && s == nil {
+		return func() {}, true
+	}
+	if providerLimit > 0 && l.providers[provider] >= providerLimit {
+		return nil, false
+	}
+	if s == nil {
+		s = &authSlots{}
+		l.auths[auth.ID] = s
+	}
+	s.limit = authLimit
+	if authLimit > 0 && s.active >= authLimit {
+		return nil, false
+	}
+	s.active++
+	l.providers[provider]++
+	var once sync.Once
+	return func() { once.Do(func() { l.release(provider, auth.ID) }) }, true
+}
+
+func (l *concurrencyLimiter) release(provider, authID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.auths[authID]; s != nil {
+		if s.active--; s.active <= 0 {
+			delete(l.auths, authID)
+		}
+	}
+	if l.providers[provider]--; l.providers[provider] <= 0 {
+		delete(l.providers, provider)
+	}
+	l.signalLocked(provider)
+}
+
+func (l *concurrencyLimiter) signalLocked(provider string) {
+	q := l.queues[provider]
+	if q == nil {
+		return
+	}
+	if w := q.next(); w != nil {
+		w.signaled = true
+		select {
+		case w.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// contended reports whether a slot on authID is wanted by a queued request:
+// one whose model the auth serves or, under a provider limit, any of them.
+// Newcomers only overtake waiting requests with slots none of them can use.
+func (l *concurrencyLimiter) contended(provider, authID string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := l.queues[provider]
+	if q == nil || q.size == 0 {
+		return false
+	}
+	if l.cfg.MaxPerProvider[provider] > 0 {
+		return true
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, list := range q.waiters {
+		for _, w := range list {
+			if w.model == "" || reg.ClientSupportsModel(authID, w.model) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (l *concurrencyLimiter) enqueue(provider, key, model string) (*slotWaiter, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := l.queues[provider]
+	if q == nil {
+		q = &slotQueue{waiters: make(map[string][]*slotWaiter)}
+		l.queues[provider] = q
+	}
+	if q.size >= l.cfg.QueueSize {
+		return nil, newConcurrencyLimitError(fmt.Sprintf("too many requests waiting for provider %s", provider))
+	}
+	w := &slotWaiter{key: key, model: strings.TrimSpace(model), ready: make(chan struct{}, 1)}
+	q.push(w)
+	return w, nil
+}
+
+func (l *concurrencyLimiter) dequeue(provider string, w *slotWaiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := l.queues[provider]
+	if q == nil {
+		return
+	}
+	q.remove(w)
+	if w.signaled {
+		// Pass an unused wake-up on.
+		l.signalLocked(provider)
+	}
+	if q.size == 0 {
+		delete(l.queues, provider)
+	}
+}
+
+// pickWithSlot picks an auth like pickNextFromRegistry and reserves an
+// in-flight slot for it. When every usable auth, or the provider, is at its
+// limit the request waits in the provider's queue. release must be called
+// once the request, or its stream, ends.
+func (m *Manager) pickWithSlot(ctx context.Context, provider, model string, opts Options, tried map[string]struct{}) (auth *Auth, executor ProviderExecutor, release func(), err error) {
+	l := m.limiter
+	var (
+		w         *slotWaiter
+		timeout   <-chan time.Time
+		poll      <-chan time.Time
+		keepAlive <-chan time.Time
+	)
+	defer func() {
+		if w != nil {
+			l.dequeue(provider, w)
+		}
+	}()
+	onKeepAlive := waitKeepAliveFromContext(ctx)
+	for {
+		auth, executor, err = m.pickNextFromRegistry(ctx, provider, model, opts, tried)
+		if err == nil {
+			// Newcomers queue behind waiting requests that could use the
+			// picked auth instead of overtaking them.
+			if w != nil || !l.contended(provider, auth.ID) {
+				if release, ok := l.tryAcquire(provider, auth); ok {
+					hedgeAttemptFromContext(ctx).record(provider, auth.ID)
+					return auth, executor, release, nil
+				}
+			}
+			if entry := m.registry.GetEntry(auth.ID); entry != nil {
+				entry.DecrementActiveRequests()
+			}
+		} else if !errors.Is(err, errSlotsBusy) {
+			return nil, nil, nil, err
+		}
+		if w == nil {
+			if w, err = l.enqueue(provider, ClientKeyFromContext(ctx), model); err != nil {
+				return nil, nil, nil, err
+			}
+			cfg := l.config()
+			timer := time.NewTimer(cfg.MaxWait)
+			defer timer.Stop()
+			timeout = timer.C
+			pollTicker := time.NewTicker(concurrencyPollInterval)
+			defer pollTicker.Stop()
+			poll = pollTicker.C
+			if onKeepAlive != nil {
+				keepAliveTicker := time.NewTicker(cfg.KeepAlive)
+				defer keepAliveTicker.Stop()
+				keepAlive = keepAliveTicker.C
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, nil, ctx.Err()
+		case <-timeout:
+			return nil, nil, nil, newConcurrencyLimitError(fmt.Sprintf("timed out waiting for a free slot on provider %s", provider))
+		case <-w.ready:
+			l.mu.Lock()
+			w.signaled = false
+			l.mu.Unlock()
+		case <-poll:
+		case <-keepAlive:
+			onKeepAlive()
+		}
+	}
+}
+
+// cooldownDeadline returns how long requests started now may wait for
+// cooling down auths, or the zero time when waiting is off.
+func (m *Manager) cooldownDeadline() time.Time {
+	if m == nil || m.limiter == nil {
+		return time.Time{}
+	}
+	if d := m.limiter.config().CooldownWait; d > 0 {
+		return time.Now().Add(d)
+	}
+	return time.Time{}
+}
+
+// waitForCooldown waits for the earliest recovery when err reports that every
+// auth for the model is cooling down and recovery comes before deadline. It
+// reports whether the request should be tried again.
+func (m *Manager) waitForCooldown(ctx context.Context, err error, deadline time.Time) bool {
+	var cooldown *modelCooldownError
+	if deadline.IsZero() || !errors.As(err, &cooldown) {
+		return false
+	}
+	wait := cooldown.resetIn
+	if wait < cooldownPollInterval {
+		wait = cooldownPollInterval
+	}
+	if time.Now().Add(wait).After(deadline) {
+		return false
+	}
+	return sleepWithKeepAlive(ctx, wait, waitKeepAliveFromContext(ctx), m.limiter.config().KeepAlive) == nil
+}
+
+// sleepWithKeepAlive sleeps for d, running keepAlive every interval.
+func sleepWithKeepAlive(ctx context.Context, d time.Duration, keepAlive func(), interval time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	var tick <-chan time.Time
+	if keepAlive != nil && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-tick:
+			keepAlive()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+type blockingExecutor struct {
+	stubExecutor
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, auth *Auth, req Request, opts Options) (Response, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		peak := e.peak.Load()
+		if n <= peak || e.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+	return Response{Payload: []byte(auth.ID)}, nil
+}
+
+func newConcurrencyTestManager(t *testing.T, cfg ConcurrencyConfig) (*Manager, *blockingExecutor) {
+	t.Helper()
+	exec := &blockingExecutor{stubExecutor: stubExecutor{id: "claude"}, release: make(chan struct{})}
+	manager := newStubManager(t, exec, []string{"claude-sonnet-4"}, "concurrency-claude-a")
+	manager.SetConcurrencyConfig(cfg)
+	return manager, exec
+}
+
+func TestConcurrencyLimit_WaitsForSlot(t *testing.T) {
+	manager, exec := newConcurrencyTestManager(t, ConcurrencyConfig{MaxPerAuth: 1, MaxWait: 5 * time.Second})
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := manager.Execute(context.Background(), []string{"claude"}, Request{Model: "claude-sonnet-4"}, Options{})
+			errs <- err
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(50 * time.Millisecond)
+		exec.release <- struct{}{}
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	if peak := exec.peak.Load(); peak != 1 {
+		t.Errorf("peak in-flight = %d, want 1", peak)
+	}
+}
+
+func TestConcurrencyLimit_QueueTimeout(t *testing.T) {
+	manager, exec := newConcurrencyTestManager(t, ConcurrencyConfig{MaxPerAuth: 1, MaxWait: 100 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = manager.Execute(context.Background(), []string{"claude"}, Request{Model: "claude-sonnet-4"}, Options{})
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := manager.Execute(context.Background(), []string{"claude"}, Request{Model: "claude-sonnet-4"}, Options{})
+	if !isConcurrencyLimitError(err) || statusCodeFromError(err) != 429 {
+		t.Errorf("err = %v, want a 429 concurrency_limit error", err)
+	}
+	exec.release <- struct{}{}
+	<-done
+}
+
+func TestConcurrencyLimit_NewcomerSkipsUnrelatedWaiters(t *testing.T) {
+	manager, exec := newConcurrencyTestManager(t, ConcurrencyConfig{MaxPerAuth: 1, MaxWait: 5 * time.Second})
+	// A second auth that only serves another model.
+	_, _ = manager.Register(context.Background(), &Auth{ID: "concurrency-claude-b", Provider: "claude", Status: StatusActive})
+	registry.GetGlobalRegistry().RegisterClient("concurrency-claude-b", "claude", []*registry.ModelInfo{{ID: "claude-haiku-4"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("concurrency-claude-b") })
+
+	errs := make(chan error, 3)
+	execute := func(model string) {
+		_, err := manager.Execute(context.Background(), []string{"claude"}, Request{Model: model}, Options{})
+		errs <- err
+	}
+	// Fill the first auth and queue a request behind it.
+	go execute("claude-sonnet-4")
+	go execute("claude-sonnet-4")
+	waitFor(t, 2*time.Second, func() bool {
+		return exec.inFlight.Load() == 1 && manager.limiter.contended("claude", "concurrency-claude-a")
+	})
+
+	// The waiter cannot use the second auth, so a request for its model
+	// starts before the queue's first poll instead of waiting behind it.
+	go execute("claude-haiku-4")
+	waitFor(t, concurrencyPollInterval/2, func() bool { return exec.inFlight.Load() == 2 })
+
+	for i := 0; i < 3; i++ {
+		exec.release <- struct{}{}
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSlotQueue_FairAcrossClientKeys(t *testing.T) {
+	q := &slotQueue{waiters: make(map[string][]*slotWaiter)}
+	waiters := map[string]*slotWaiter{}
+	for _, name := range []string{"a1", "a2", "a3", "b1", "c1"} {
+		w := &slotWaiter{key: name[:1], ready: make(chan struct{}, 1)}
+		waiters[name] = w
+		q.push(w)
+	}
+	var order []string
+	for i := 0; i < 5; i++ {
+		w := q.next()
+		w.signaled = true
+		for name, x := range waiters {
+			if x == w {
+				order = append(order, name)
+			}
+		}
+	}
+	want := []string{"a1", "b1", "c1", "a2", "a3"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	q.remove(waiters["b1"])
+	if q.size != 4 || len(q.keys) != 2 {
+		t.Errorf("after remove: size %d, keys %v", q.size, q.keys)
+	}
+}
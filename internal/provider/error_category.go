@@ -3,6 +3,8 @@ package provider
 import (
 	"net/http"
 	"strings"
+
+	"github.com/nghyane/llm-mux/internal/proxypool"
 )
 
 // ErrorCategory classifies errors for retry/fallback decisions
@@ -39,6 +41,10 @@ const (
 	// CategoryClientCanceled indicates client-side cancellation (context canceled, deadline exceeded)
 	// Should NOT affect provider status - this is client behavior, not provider failure
 	CategoryClientCanceled
+
+	// CategoryProxyError indicates the egress proxy failed (unreachable, refused CONNECT)
+	// Should fallback to another auth but NOT cool down the auth - the upstream never saw the request
+	CategoryProxyError
 )
 
 // String returns human-readable category name
@@ -58,6 +64,8 @@ func (c ErrorCategory) String() string {
 		return "not_found"
 	case CategoryClientCanceled:
 		return "client_canceled"
+	case CategoryProxyError:
+		return "proxy_error"
 	default:
 		return "unknown"
 	}
@@ -65,7 +73,7 @@ func (c ErrorCategory) String() string {
 
 // ShouldFallback returns true if should try another auth/provider
 func (c ErrorCategory) ShouldFallback() bool {
-	return c == CategoryQuotaError || c == CategoryTransient || c == CategoryAuthError
+	return c == CategoryQuotaError || c == CategoryTransient || c == CategoryAuthError || c == CategoryProxyError
 }
 
 // ShouldDisableAuth returns true if auth should be disabled
@@ -119,6 +127,11 @@ func CategorizeError(statusCode int, message string) ErrorCategory {
 		return CategoryClientCanceled
 	}
 
+	// Proxy failures say nothing about the auth or the upstream
+	if proxypool.IsProxyErrorMessage(message) {
+		return CategoryProxyError
+	}
+
 	// Check for OAuth revoked errors first (most specific)
 	if isOAuthRevokedError(message) {
 		return CategoryAuthRevoked
@@ -164,7 +177,8 @@ func isUserError(msg string) bool {
 		strings.Contains(lower, "please use a valid") ||
 		strings.Contains(lower, "not supported") ||
 		strings.Contains(lower, "must be non-empty") ||
-		strings.Contains(lower, "cannot be empty")
+		strings.Contains(lower, "cannot be empty") ||
+		strings.Contains(lower, "content management policy")
 }
 
 // isQuotaError checks if message indicates quota/rate limit error
@@ -37,7 +37,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNextFromRegistry(ctx, provider, req.Model, opts, tried)
+		auth, executor, release, errPick := m.pickWithSlot(ctx, provider, req.Model, opts, tried)
 		if errPick != nil {
 			telemetry.RecordError(span, errPick)
 			if lastErr != nil {
@@ -56,9 +56,11 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 
 		authCopy := auth
 		reqCopy := req
+		attemptStart := time.Now()
 		result, errBreaker := breaker.Execute(func() (any, error) {
 			return executor.Execute(execCtx, authCopy, reqCopy, opts)
 		})
+		release()
 
 		if errBreaker != nil {
 			telemetry.RecordError(span, errBreaker)
@@ -87,6 +89,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		}
 
 		resp := result.(Response)
+		m.recordFirstByte(provider, req.Model, false, time.Since(attemptStart))
 		m.MarkResult(execCtx, Result{AuthID: auth.ID, Provider: provider, Model: req.Model, Success: true})
 		return resp, nil
 	}
@@ -109,7 +112,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNextFromRegistry(ctx, provider, req.Model, opts, tried)
+		auth, executor, release, errPick := m.pickWithSlot(ctx, provider, req.Model, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return Response{}, lastErr
@@ -130,6 +133,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		result, errBreaker := breaker.Execute(func() (any, error) {
 			return executor.CountTokens(execCtx, authCopy, reqCopy, opts)
 		})
+		release()
 
 		if errBreaker != nil {
 			if errors.Is(errBreaker, context.Canceled) || errors.Is(errBreaker, context.DeadlineExceeded) {
@@ -181,7 +185,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNextFromRegistry(ctx, provider, req.Model, opts, tried)
+		auth, executor, release, errPick := m.pickWithSlot(ctx, provider, req.Model, opts, tried)
 		if errPick != nil {
 			done(false)
 			if lastErr != nil {
@@ -197,8 +201,10 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 		}
+		attemptStart := time.Now()
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, req, opts)
 		if errStream != nil {
+			release()
 			if errors.Is(errStream, context.Canceled) || errors.Is(errStream, context.DeadlineExceeded) {
 				done(false)
 				return nil, errStream
@@ -228,7 +234,8 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamModel string, streamChunks <-chan StreamChunk, cbDone func(bool)) {
 			defer close(out)
-			var failed bool
+			defer release()
+			var failed, started bool
 
 			for {
 				select {
@@ -249,6 +256,11 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 						return
 					}
 
+					if !started && chunk.Err == nil {
+						started = true
+						m.recordFirstByte(streamProvider, streamModel, true, time.Since(attemptStart))
+					}
+
 					// Check for errors in chunk
 					if chunk.Err != nil && !failed {
 						if errors.Is(chunk.Err, context.Canceled) || errors.Is(chunk.Err, context.DeadlineExceeded) {
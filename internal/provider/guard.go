@@ -0,0 +1,50 @@
+package provider
+
+import "context"
+
+// RequestGuard inspects requests before they are sent upstream, for example to
+// enforce content policies.
+type RequestGuard interface {
+	// Inspect returns the request to send, possibly rewritten, and a
+	// ResponseGuard for its response, or an error when the request is blocked.
+	// A nil ResponseGuard leaves the response untouched.
+	Inspect(ctx context.Context, req Request, opts Options) (Request, ResponseGuard, error)
+}
+
+// ResponseGuard post-processes the response to one inspected request.
+type ResponseGuard interface {
+	// Response checks and rewrites a non-streaming response payload.
+	Response(ctx context.Context, payload []byte) ([]byte, error)
+	// Stream checks and rewrites a stream; it must close the returned channel
+	// once chunks is closed.
+	Stream(ctx context.Context, chunks <-chan StreamChunk) <-chan StreamChunk
+}
+
+type requestGuardHolder struct {
+	guard RequestGuard
+}
+
+// SetRequestGuard installs g around Execute, ExecuteStream, ExecuteCount and
+// CreateCachedContent. A nil g removes the current guard.
+func (m *Manager) SetRequestGuard(g RequestGuard) {
+	if m == nil {
+		return
+	}
+	m.guard.Store(&requestGuardHolder{guard: g})
+}
+
+func (m *Manager) requestGuard() RequestGuard {
+	if h := m.guard.Load(); h != nil {
+		return h.guard
+	}
+	return nil
+}
+
+// inspectRequest runs the installed guard on req.
+func (m *Manager) inspectRequest(ctx context.Context, req Request, opts Options) (Request, ResponseGuard, error) {
+	g := m.requestGuard()
+	if g == nil {
+		return req, nil, nil
+	}
+	return g.Inspect(ctx, req, opts)
+}
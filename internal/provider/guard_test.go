@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// rewriteGuard replaces "secret" in payloads and blocks payloads containing
+// "blocked".
+type rewriteGuard struct{}
+
+func (rewriteGuard) Inspect(ctx context.Context, req Request, opts Options) (Request, ResponseGuard, error) {
+	if strings.Contains(string(req.Payload), "blocked") {
+		return req, nil, errors.New("blocked by guard")
+	}
+	req.Payload = []byte(strings.ReplaceAll(string(req.Payload), "secret", "[MASKED]"))
+	return req, nil, nil
+}
+
+func TestManagerExecuteCountRunsGuard(t *testing.T) {
+	exec := &stubExecutor{id: "guard-count"}
+	manager := newStubManager(t, exec, []string{"guard-count-model"}, "guard-count-auth")
+	manager.SetRequestGuard(rewriteGuard{})
+
+	_, err := manager.ExecuteCount(context.Background(), []string{"guard-count"}, Request{Model: "guard-count-model", Payload: []byte(`{"text":"a secret"}`)}, Options{})
+	if err != nil {
+		t.Fatalf("ExecuteCount: %v", err)
+	}
+	reqs := exec.recorded()
+	if len(reqs) != 1 || string(reqs[0].Payload) != `{"text":"a [MASKED]"}` {
+		t.Fatalf("expected the guarded payload upstream, got %+v", reqs)
+	}
+
+	_, err = manager.ExecuteCount(context.Background(), []string{"guard-count"}, Request{Model: "guard-count-model", Payload: []byte(`{"text":"blocked"}`)}, Options{})
+	if err == nil || err.Error() != "blocked by guard" {
+		t.Fatalf("expected the guard error, got %v", err)
+	}
+	if n := len(exec.recorded()); n != 1 {
+		t.Fatalf("blocked request reached the executor: %d requests", n)
+	}
+}
@@ -0,0 +1,440 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+const (
+	defaultHealthInterval         = 15 * time.Minute
+	defaultHealthRequestInterval  = time.Hour
+	defaultHealthTimeout          = 30 * time.Second
+	defaultHealthHistory          = 20
+	defaultHealthFailureThreshold = 2
+	defaultHealthConcurrency      = 4
+)
+
+// HealthProber is implemented by executors that have a cheaper way to verify
+// an auth than a one-token completion, e.g. a user or quota endpoint.
+type HealthProber interface {
+	ProbeHealth(ctx context.Context, auth *Auth) error
+}
+
+// HealthStatus summarises the recent health checks of an auth.
+type HealthStatus string
+
+const (
+	HealthUnknown   HealthStatus = ""
+	HealthHealthy   HealthStatus = "healthy"
+	HealthDegraded  HealthStatus = "degraded"
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+// Health check methods recorded in HealthCheck.Method.
+const (
+	HealthMethodProbe   = "probe"
+	HealthMethodQuota   = "quota"
+	HealthMethodRequest = "request"
+	HealthMethodToken   = "token"
+)
+
+// HealthCheck is the outcome of one probe.
+type HealthCheck struct {
+	Time      time.Time     `json:"time"`
+	OK        bool          `json:"ok"`
+	Method    string        `json:"method"`
+	Model     string        `json:"model,omitempty"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs int64         `json:"latency_ms"`
+	Status    int           `json:"status,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuthHealth is an immutable snapshot of an auth's probe history.
+type AuthHealth struct {
+	Status              HealthStatus  `json:"status"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastCheck           time.Time     `json:"last_check"`
+	History             []HealthCheck `json:"history"`
+}
+
+// HealthConfig configures the periodic health prober.
+type HealthConfig struct {
+	Interval time.Duration
+	// RequestInterval is the minimum time between scheduled request probes of
+	// an auth; each one is a billed completion.
+	RequestInterval time.Duration
+	Timeout         time.Duration
+	// History is the number of checks kept per auth.
+	History int
+	// FailureThreshold is the number of consecutive transient failures before an
+	// auth is taken out of rotation. Auth and quota errors apply immediately.
+	FailureThreshold int
+	// Concurrency bounds probes running at the same time.
+	Concurrency int
+	// Providers limits probing to these providers. Empty means all.
+	Providers []string
+	// Models overrides the model used for request probes, keyed by provider.
+	Models map[string]string
+}
+
+func (c HealthConfig) withDefaults() HealthConfig {
+	if c.Interval <= 0 {
+		c.Interval = defaultHealthInterval
+	}
+	if c.RequestInterval <= 0 {
+		c.RequestInterval = defaultHealthRequestInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultHealthTimeout
+	}
+	if c.History <= 0 {
+		c.History = defaultHealthHistory
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultHealthFailureThreshold
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultHealthConcurrency
+	}
+	return c
+}
+
+func (c HealthConfig) covers(provider string) bool {
+	if len(c.Providers) == 0 {
+		return true
+	}
+	for _, p := range c.Providers {
+		if strings.EqualFold(strings.TrimSpace(p), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// Health returns the latest health snapshot, or nil when the auth was never probed.
+func (e *AuthEntry) Health() *AuthHealth {
+	return e.health.Load()
+}
+
+// recordHealth appends check to the history and returns the previous and new snapshots.
+func (e *AuthEntry) recordHealth(check HealthCheck, cfg HealthConfig, immediate bool) (prev, next *AuthHealth) {
+	for {
+		prev = e.health.Load()
+		next = &AuthHealth{LastCheck: check.Time}
+		if prev != nil {
+			next.ConsecutiveFailures = prev.ConsecutiveFailures
+			next.History = append(next.History, prev.History...)
+		}
+		next.History = append(next.History, check)
+		if over := len(next.History) - cfg.History; over > 0 {
+			next.History = next.History[over:]
+		}
+		switch {
+		case check.OK:
+			next.ConsecutiveFailures = 0
+			next.Status = HealthHealthy
+		default:
+			next.ConsecutiveFailures++
+			next.Status = HealthDegraded
+			if immediate || next.ConsecutiveFailures >= cfg.FailureThreshold {
+				next.Status = HealthUnhealthy
+			}
+		}
+		if e.health.CompareAndSwap(prev, next) {
+			return prev, next
+		}
+	}
+}
+
+type healthProbe struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	cfg    HealthConfig
+}
+
+// StartHealthProbe launches the periodic health prober, replacing a running one.
+func (m *Manager) StartHealthProbe(parent context.Context, cfg HealthConfig) {
+	cfg = cfg.withDefaults()
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+	if m.health.cancel != nil {
+		m.health.cancel()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.health.cancel = cancel
+	m.health.cfg = cfg
+	log.Infof("health prober started (interval=%s, timeout=%s)", cfg.Interval, cfg.Timeout)
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.probeAll(ctx, cfg)
+			}
+		}
+	}()
+}
+
+// StopHealthProbe stops the periodic health prober, if running.
+func (m *Manager) StopHealthProbe() {
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+	if m.health.cancel != nil {
+		m.health.cancel()
+		m.health.cancel = nil
+	}
+}
+
+func (m *Manager) healthConfig() HealthConfig {
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+	return m.health.cfg.withDefaults()
+}
+
+func (m *Manager) probeAll(ctx context.Context, cfg HealthConfig) {
+	if m.registry == nil {
+		return
+	}
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for _, entry := range m.registry.ListEntries() {
+		if entry.IsDisabled() || !cfg.covers(entry.Provider()) || m.requestProbeThrottled(entry, cfg) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(entry *AuthEntry) {
+			defer func() { <-sem; wg.Done() }()
+			m.probeEntry(ctx, entry, cfg)
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// ProbeAuthHealth runs a health check for the auth with the given ID or
+// FileName immediately and returns the updated snapshot.
+func (m *Manager) ProbeAuthHealth(ctx context.Context, id string) (*AuthHealth, error) {
+	auth, ok := m.GetByID(id)
+	if !ok {
+		return nil, fmt.Errorf("auth not found: %s", id)
+	}
+	entry := m.GetAuthEntry(auth.ID)
+	if entry == nil {
+		return nil, fmt.Errorf("auth not found: %s", id)
+	}
+	if _, recorded := m.probeEntry(ctx, entry, m.healthConfig()); !recorded {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return entry.Health(), nil
+}
+
+// probeEntry checks one auth and applies the outcome. recorded is false when
+// the probe was inconclusive (e.g. ctx was cancelled) and nothing was stored.
+func (m *Manager) probeEntry(ctx context.Context, entry *AuthEntry, cfg HealthConfig) (HealthCheck, bool) {
+	check := m.runHealthCheck(ctx, entry, cfg)
+	if !check.OK && ctx.Err() != nil {
+		return check, false
+	}
+	check.LatencyMs = check.Latency.Milliseconds()
+
+	category := CategoryUnknown
+	if !check.OK {
+		category = CategorizeError(check.Status, check.Error)
+	}
+	immediate := category == CategoryAuthError || category == CategoryAuthRevoked || category == CategoryQuotaError
+	prev, next := entry.recordHealth(check, cfg, immediate)
+
+	switch {
+	case check.OK && prev != nil && prev.Status == HealthUnhealthy:
+		log.Infof("health: auth %s (provider=%s) recovered", entry.ID(), entry.Provider())
+		m.markProbeResult(ctx, Result{AuthID: entry.ID(), Provider: entry.Provider(), Success: true})
+	case next.Status == HealthUnhealthy:
+		log.Warnf("health: auth %s (provider=%s) unhealthy: %s", entry.ID(), entry.Provider(), check.Error)
+		m.markProbeResult(ctx, Result{
+			AuthID:   entry.ID(),
+			Provider: entry.Provider(),
+			Error:    &Error{Code: "health_check_failed", Message: check.Error, HTTPStatus: check.Status},
+		})
+		// Transient failures do not set a cooldown in MarkResult; keep the
+		// auth out of rotation until the next probe instead.
+		if until := check.Time.Add(cfg.Interval); !entry.IsDisabled() && entry.Quota.GetCooldownUntil().Before(until) {
+			entry.Quota.SetCooldownUntil(until)
+			entry.SetUnavailable(true)
+		}
+	}
+	return check, true
+}
+
+// markProbeResult applies a probe outcome like MarkResult, without releasing
+// an in-flight slot the probe never took.
+func (m *Manager) markProbeResult(ctx context.Context, result Result) {
+	m.registry.MarkProbeResult(ctx, result)
+	m.syncDisabled(result.AuthID)
+}
+
+// requestProbeThrottled reports whether a scheduled probe of the auth would be
+// a one-token completion within RequestInterval of the previous one. On-demand
+// probes are never throttled.
+func (m *Manager) requestProbeThrottled(entry *AuthEntry, cfg HealthConfig) bool {
+	if healthMethodFor(m.executorFor(entry.Provider()), entry.ToAuth()) != HealthMethodRequest {
+		return false
+	}
+	health := entry.Health()
+	if health == nil {
+		return false
+	}
+	for i := len(health.History) - 1; i >= 0; i-- {
+		if check := health.History[i]; check.Method == HealthMethodRequest {
+			return time.Since(check.Time) < cfg.RequestInterval
+		}
+	}
+	return false
+}
+
+// healthMethodFor returns how runHealthCheck verifies an auth, apart from the
+// token refresh that may come first.
+func healthMethodFor(exec ProviderExecutor, auth *Auth) string {
+	switch {
+	case exec == nil || isHealthProber(exec):
+		return HealthMethodProbe
+	case auth.Provider == "antigravity" && extractAccessToken(auth) != "":
+		return HealthMethodQuota
+	default:
+		return HealthMethodRequest
+	}
+}
+
+func (m *Manager) runHealthCheck(ctx context.Context, entry *AuthEntry, cfg HealthConfig) HealthCheck {
+	check := HealthCheck{Time: time.Now()}
+	fail := func(err error) HealthCheck {
+		check.Latency = time.Since(check.Time)
+		check.Error = err.Error()
+		check.Status = statusOf(err)
+		return check
+	}
+
+	// Proactive token expiry: refresh an expired token now rather than on the
+	// first user request, and report the auth if that does not help.
+	if exp := entry.Token.GetExpiresAt(); !exp.IsZero() && !exp.After(check.Time) && m.registry.needsRefresh(entry) {
+		check.Method = HealthMethodToken
+		_ = m.RefreshAuthByID(ctx, entry.ID())
+		if exp = entry.Token.GetExpiresAt(); !exp.After(time.Now()) {
+			return fail(fmt.Errorf("access token expired at %s and refresh did not renew it", exp.UTC().Format(time.RFC3339)))
+		}
+	}
+
+	exec := m.executorFor(entry.Provider())
+	if exec == nil {
+		check.Method = HealthMethodProbe
+		return fail(&Error{Code: "executor_not_found", Message: "executor not registered"})
+	}
+	auth := entry.ToAuth()
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	if rt := m.roundTripperFor(auth); rt != nil {
+		probeCtx = context.WithValue(probeCtx, roundTripperContextKey{}, rt)
+	}
+
+	var err error
+	check.Method = healthMethodFor(exec, auth)
+	switch check.Method {
+	case HealthMethodProbe:
+		err = exec.(HealthProber).ProbeHealth(probeCtx, auth)
+	case HealthMethodQuota:
+		var snapshot *RealQuotaSnapshot
+		if snapshot, err = queryAntigravityQuota(probeCtx, extractAccessToken(auth)); err == nil {
+			if qm, ok := m.selector.(*QuotaManager); ok {
+				state := qm.getOrCreateState(auth.ID)
+				state.SetRealQuota(snapshot)
+				qm.handleQuotaSnapshotUpdate(state, snapshot)
+			}
+		}
+	default:
+		check.Model = healthProbeModel(auth, cfg)
+		if check.Model == "" {
+			return fail(&Error{Code: "no_probe_model", Message: "no model registered for auth"})
+		}
+		err = executeHealthRequest(probeCtx, exec, auth, check.Model)
+	}
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		err = fmt.Errorf("health check timed out after %s", cfg.Timeout)
+	}
+	if err != nil {
+		if CategorizeError(statusOf(err), err.Error()) == CategoryUserError {
+			// The upstream accepted the credentials and rejected the tiny
+			// probe payload itself; that still proves the account works.
+			check.OK = true
+			check.Status = statusOf(err)
+			check.Error = err.Error()
+			check.Latency = time.Since(check.Time)
+			return check
+		}
+		return fail(err)
+	}
+	check.OK = true
+	check.Latency = time.Since(check.Time)
+	return check
+}
+
+func isHealthProber(exec ProviderExecutor) bool {
+	_, ok := exec.(HealthProber)
+	return ok
+}
+
+func statusOf(err error) int {
+	var se StatusCodeError
+	if errors.As(err, &se) && se != nil {
+		return se.StatusCode()
+	}
+	return 0
+}
+
+// healthProbeModel picks the configured probe model for the provider, or the
+// first model registered for the auth.
+func healthProbeModel(auth *Auth, cfg HealthConfig) string {
+	if model := strings.TrimSpace(cfg.Models[auth.Provider]); model != "" {
+		return model
+	}
+	if models := registry.GetGlobalRegistry().ClientModels(auth.ID); len(models) > 0 {
+		return models[0]
+	}
+	return ""
+}
+
+// executeHealthRequest sends a one-token OpenAI-format completion through the
+// executor, which translates it like any client request.
+func executeHealthRequest(ctx context.Context, exec ProviderExecutor, auth *Auth, model string) error {
+	payload, err := json.Marshal(map[string]any{
+		"model":      model,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		"max_tokens": 1,
+		"stream":     false,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = exec.Execute(ctx, auth, Request{Model: model, Payload: payload, Format: FormatOpenAI}, Options{
+		OriginalRequest: payload,
+		SourceFormat:    FormatOpenAI,
+	})
+	return err
+}
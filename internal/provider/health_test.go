@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type fakeProbingExecutor struct {
+	stubExecutor
+	probeErr error
+}
+
+func (e *fakeProbingExecutor) ProbeHealth(ctx context.Context, auth *Auth) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.probeErr
+}
+
+func (e *fakeProbingExecutor) setErr(err error) {
+	e.mu.Lock()
+	e.probeErr = err
+	e.mu.Unlock()
+}
+
+func TestHealthProbeMarksUnhealthyAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	exec := &fakeProbingExecutor{stubExecutor: stubExecutor{id: "probe-test"}}
+	m := newStubManager(t, exec, nil, "flaky", "revoked")
+	cfg := HealthConfig{Interval: time.Hour, History: 3, FailureThreshold: 2}.withDefaults()
+	flaky := m.GetAuthEntry("flaky")
+	revoked := m.GetAuthEntry("revoked")
+
+	exec.setErr(&Error{Message: "upstream overloaded", HTTPStatus: http.StatusServiceUnavailable})
+	m.probeEntry(ctx, flaky, cfg)
+	if h := flaky.Health(); h.Status != HealthDegraded || h.ConsecutiveFailures != 1 {
+		t.Fatalf("after one failure: %+v, want degraded", h)
+	}
+	if blocked, _, _ := flaky.IsBlockedForModel("", time.Now()); blocked {
+		t.Fatal("a single transient failure should not remove the auth from rotation")
+	}
+	// A user request in flight keeps its slot while probes fail.
+	flaky.IncrementActiveRequests()
+	m.probeEntry(ctx, flaky, cfg)
+	if h := flaky.Health(); h.Status != HealthUnhealthy {
+		t.Fatalf("after threshold: %+v, want unhealthy", h)
+	}
+	if n := flaky.Quota.ActiveRequests.Load(); n != 1 {
+		t.Fatalf("probe changed the in-flight count to %d", n)
+	}
+	flaky.DecrementActiveRequests()
+	if blocked, _, _ := flaky.IsBlockedForModel("", time.Now()); !blocked {
+		t.Fatal("unhealthy auth should be blocked until the next probe")
+	}
+
+	exec.setErr(nil)
+	m.probeEntry(ctx, flaky, cfg)
+	if h := flaky.Health(); h.Status != HealthHealthy || len(h.History) != 3 || !h.History[2].OK {
+		t.Fatalf("after recovery: %+v", h)
+	}
+	if blocked, _, _ := flaky.IsBlockedForModel("", time.Now()); blocked {
+		t.Fatal("recovered auth should be back in rotation")
+	}
+	m.probeEntry(ctx, flaky, cfg)
+	if h := flaky.Health(); len(h.History) != 3 {
+		t.Fatalf("history not capped: %d entries", len(h.History))
+	}
+
+	exec.setErr(&Error{Message: "seat removed", HTTPStatus: http.StatusUnauthorized})
+	m.probeEntry(ctx, revoked, cfg)
+	if h := revoked.Health(); h.Status != HealthUnhealthy {
+		t.Fatalf("auth error should be unhealthy immediately: %+v", h)
+	}
+	if !revoked.IsDisabled() {
+		t.Fatal("401 from the probe should disable the auth")
+	}
+}
+
+func TestHealthProbeSendsMinimalRequest(t *testing.T) {
+	ctx := context.Background()
+	exec := &stubExecutor{id: "probe-request"}
+	m := newStubManager(t, exec, []string{"tiny-model", "big-model"}, "probe-request-1")
+
+	h, err := m.ProbeAuthHealth(ctx, "probe-request-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != HealthHealthy || h.History[0].Method != HealthMethodRequest || h.History[0].Model != "tiny-model" {
+		t.Fatalf("unexpected health %+v", h)
+	}
+	if reqs := exec.recorded(); len(reqs) != 1 || gjson.GetBytes(reqs[0].Payload, "max_tokens").Int() != 1 {
+		t.Fatalf("unexpected probe requests %+v", reqs)
+	}
+}
+
+func TestHealthProbeThrottlesRequestProbes(t *testing.T) {
+	ctx := context.Background()
+	exec := &stubExecutor{id: "probe-throttle"}
+	m := newStubManager(t, exec, []string{"tiny-model"}, "probe-throttle-1")
+
+	if _, err := m.ProbeAuthHealth(ctx, "probe-throttle-1"); err != nil {
+		t.Fatal(err)
+	}
+	m.probeAll(ctx, HealthConfig{RequestInterval: time.Hour}.withDefaults())
+	if n := len(exec.recorded()); n != 1 {
+		t.Fatalf("scheduled round sent %d completions within request-interval, want none", n-1)
+	}
+	m.probeAll(ctx, HealthConfig{RequestInterval: time.Nanosecond}.withDefaults())
+	if n := len(exec.recorded()); n != 2 {
+		t.Fatalf("scheduled round after request-interval sent %d completions, want 1", n-1)
+	}
+}
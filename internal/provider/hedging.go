@@ -0,0 +1,327 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/sseutil"
+)
+
+const (
+	defaultHedgePercentile = 95
+	defaultHedgeMinDelay   = 250 * time.Millisecond
+	defaultHedgeMaxDelay   = 10 * time.Second
+)
+
+// HedgeConfig configures request hedging: when the first attempt has not
+// answered within a latency percentile, a second attempt starts on another
+// auth or provider and the first to answer wins.
+type HedgeConfig struct {
+	Enabled bool
+	// Models limits hedging to matching models (wildcards). Empty means all.
+	Models []string
+	// Percentile of recent time-to-first-byte after which the second attempt
+	// starts (0-100).
+	Percentile float64
+	// MinDelay and MaxDelay bound the computed delay. MaxDelay is also used
+	// until enough latency samples exist.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+func (c HedgeConfig) withDefaults() HedgeConfig {
+	if c.Percentile <= 0 || c.Percentile > 100 {
+		c.Percentile = defaultHedgePercentile
+	}
+	if c.MinDelay <= 0 {
+		c.MinDelay = defaultHedgeMinDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = defaultHedgeMaxDelay
+	}
+	if c.MaxDelay < c.MinDelay {
+		c.MaxDelay = c.MinDelay
+	}
+	return c
+}
+
+// SetHedgeConfig replaces the hedging policy.
+func (m *Manager) SetHedgeConfig(cfg HedgeConfig) {
+	if m == nil {
+		return
+	}
+	cfg = cfg.withDefaults()
+	m.hedge.Store(&cfg)
+}
+
+// hedgeDelay returns how long to wait for the first attempt before hedging,
+// and false when the request is not hedged.
+func (m *Manager) hedgeDelay(providers []string, model string, stream bool) (time.Duration, bool) {
+	cfg := m.hedge.Load()
+	if cfg == nil || !cfg.Enabled || len(providers) == 0 {
+		return 0, false
+	}
+	if len(cfg.Models) > 0 {
+		matched := false
+		for _, pattern := range cfg.Models {
+			if sseutil.MatchModelPattern(pattern, model) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return 0, false
+		}
+	}
+	provider := providers[0]
+	upstream := registry.GetGlobalRegistry().GetModelIDForProvider(model, provider)
+	delay, ok := m.providerStats.FirstByteLatency(provider, upstream, stream, cfg.Percentile)
+	if !ok && !stream {
+		delay = m.providerStats.GetAvgLatency(provider, model)
+	}
+	if delay <= 0 {
+		delay = cfg.MaxDelay
+	}
+	if delay < cfg.MinDelay {
+		delay = cfg.MinDelay
+	}
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return delay, true
+}
+
+// hedgeGroup tracks the auths taken by the attempts of one hedged request so
+// they never share an auth.
+type hedgeGroup struct {
+	mu     sync.Mutex
+	owners map[string]*hedgeAttempt
+}
+
+type hedgeAttempt struct {
+	group    *hedgeGroup
+	provider string
+	authID   string
+}
+
+type hedgeAttemptContextKey struct{}
+
+func hedgeAttemptFromContext(ctx context.Context) *hedgeAttempt {
+	a, _ := ctx.Value(hedgeAttemptContextKey{}).(*hedgeAttempt)
+	return a
+}
+
+// takenByOther reports whether another attempt of the request uses authID.
+func (a *hedgeAttempt) takenByOther(authID string) bool {
+	if a == nil {
+		return false
+	}
+	a.group.mu.Lock()
+	defer a.group.mu.Unlock()
+	owner := a.group.owners[authID]
+	return owner != nil && owner != a
+}
+
+func (a *hedgeAttempt) record(provider, authID string) {
+	if a == nil {
+		return
+	}
+	a.group.mu.Lock()
+	defer a.group.mu.Unlock()
+	a.group.owners[authID] = a
+	a.provider, a.authID = provider, authID
+}
+
+func (a *hedgeAttempt) selected() (provider, authID string) {
+	a.group.mu.Lock()
+	defer a.group.mu.Unlock()
+	return a.provider, a.authID
+}
+
+// hedgeProviders orders providers for the second attempt, preferring a
+// different provider than the first.
+func hedgeProviders(providers []string) []string {
+	if len(providers) <= 1 {
+		return providers
+	}
+	out := make([]string, 0, len(providers))
+	out = append(out, providers[1:]...)
+	return append(out, providers[0])
+}
+
+type hedgeResult struct {
+	attempt *hedgeAttempt
+	resp    Response
+	chunks  <-chan StreamChunk
+	first   *StreamChunk
+	err     error
+}
+
+// hedgeRun starts attempts and collects their results. The second attempt
+// starts after delay if the first is still pending and the retry budget has
+// room.
+type hedgeRun struct {
+	m       *Manager
+	ctx     context.Context
+	group   *hedgeGroup
+	results chan hedgeResult
+	cancels map[*hedgeAttempt]context.CancelFunc
+	budget  bool
+}
+
+func (m *Manager) newHedgeRun(ctx context.Context) *hedgeRun {
+	return &hedgeRun{
+		m:       m,
+		ctx:     ctx,
+		group:   &hedgeGroup{owners: make(map[string]*hedgeAttempt)},
+		results: make(chan hedgeResult, 2),
+		cancels: make(map[*hedgeAttempt]context.CancelFunc, 2),
+	}
+}
+
+func (h *hedgeRun) launch(run func(context.Context) hedgeResult) *hedgeAttempt {
+	attempt := &hedgeAttempt{group: h.group}
+	attemptCtx, cancel := context.WithCancel(context.WithValue(h.ctx, hedgeAttemptContextKey{}, attempt))
+	h.cancels[attempt] = cancel
+	go func() {
+		res := run(attemptCtx)
+		res.attempt = attempt
+		h.results <- res
+	}()
+	return attempt
+}
+
+// finish cancels every attempt but keep and returns the hedge budget.
+func (h *hedgeRun) finish(keep *hedgeAttempt) {
+	for attempt, cancel := range h.cancels {
+		if attempt != keep {
+			cancel()
+		}
+	}
+	if h.budget {
+		h.m.retryBudget.Release()
+		h.budget = false
+	}
+}
+
+// wait returns the first successful result, or the primary's error when every
+// started attempt failed. A failure of the primary before the delay ends the
+// request without hedging, leaving the retry to the caller.
+func (h *hedgeRun) wait(delay time.Duration, primary *hedgeAttempt, hedge func() *hedgeAttempt) hedgeResult {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	var primaryErr, hedgeErr error
+	for {
+		select {
+		case <-h.ctx.Done():
+			return hedgeResult{err: h.ctx.Err()}
+		case <-timer.C:
+			if !hedged && h.m.retryBudget.TryAcquire() {
+				h.budget, hedged = true, true
+				hedge()
+				pending++
+			}
+		case res := <-h.results:
+			pending--
+			if res.err == nil {
+				return res
+			}
+			if res.attempt == primary {
+				primaryErr = res.err
+			} else {
+				hedgeErr = res.err
+			}
+			if pending == 0 {
+				if primaryErr != nil {
+					return hedgeResult{err: primaryErr}
+				}
+				return hedgeResult{err: hedgeErr}
+			}
+		}
+	}
+}
+
+// executeHedged runs one non-streaming execution round with hedging.
+func (m *Manager) executeHedged(ctx context.Context, providers []string, delay time.Duration, run func(context.Context, []string) (Response, error)) (Response, string, error) {
+	h := m.newHedgeRun(ctx)
+	attempt := func(list []string) func() *hedgeAttempt {
+		return func() *hedgeAttempt {
+			return h.launch(func(attemptCtx context.Context) hedgeResult {
+				resp, err := run(attemptCtx, list)
+				return hedgeResult{resp: resp, err: err}
+			})
+		}
+	}
+	primary := attempt(providers)()
+	res := h.wait(delay, primary, attempt(hedgeProviders(providers)))
+	h.finish(nil)
+	if res.err != nil {
+		return Response{}, "", res.err
+	}
+	provider, authID := res.attempt.selected()
+	setSelectedAuth(ctx, authID)
+	return res.resp, provider, nil
+}
+
+// executeStreamHedged runs one streaming execution round with hedging. An
+// attempt answers with its first chunk; the winner's stream is forwarded and
+// the other attempt is cancelled.
+func (m *Manager) executeStreamHedged(ctx context.Context, providers []string, delay time.Duration, run func(context.Context, []string) (<-chan StreamChunk, error)) (<-chan StreamChunk, error) {
+	h := m.newHedgeRun(ctx)
+	attempt := func(list []string) func() *hedgeAttempt {
+		return func() *hedgeAttempt {
+			return h.launch(func(attemptCtx context.Context) hedgeResult {
+				chunks, err := run(attemptCtx, list)
+				if err != nil {
+					return hedgeResult{err: err}
+				}
+				select {
+				case chunk, ok := <-chunks:
+					if !ok {
+						return hedgeResult{chunks: chunks}
+					}
+					if chunk.Err != nil {
+						return hedgeResult{err: chunk.Err}
+					}
+					return hedgeResult{chunks: chunks, first: &chunk}
+				case <-attemptCtx.Done():
+					return hedgeResult{err: attemptCtx.Err()}
+				}
+			})
+		}
+	}
+	primary := attempt(providers)()
+	res := h.wait(delay, primary, attempt(hedgeProviders(providers)))
+	if res.err != nil {
+		h.finish(nil)
+		return nil, res.err
+	}
+	h.finish(res.attempt)
+	_, authID := res.attempt.selected()
+	setSelectedAuth(ctx, authID)
+
+	cancel := h.cancels[res.attempt]
+	out := make(chan StreamChunk, 128)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if res.first != nil {
+			select {
+			case out <- *res.first:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for chunk := range res.chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowFirstExecutor blocks its first call until cancelled and answers every
+// later call at once.
+type slowFirstExecutor struct {
+	stubExecutor
+	calls     atomic.Int32
+	cancelled chan string
+}
+
+func (e *slowFirstExecutor) Execute(ctx context.Context, auth *Auth, req Request, opts Options) (Response, error) {
+	if e.calls.Add(1) == 1 {
+		<-ctx.Done()
+		e.cancelled <- auth.ID
+		return Response{}, ctx.Err()
+	}
+	return Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestHedging_SecondAttemptWins(t *testing.T) {
+	exec := &slowFirstExecutor{stubExecutor: stubExecutor{id: "claude"}, cancelled: make(chan string, 1)}
+	manager := newStubManager(t, exec, []string{"claude-sonnet-4"}, "hedge-claude-a", "hedge-claude-b")
+	manager.SetHedgeConfig(HedgeConfig{Enabled: true, MinDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond})
+
+	start := time.Now()
+	resp, err := manager.Execute(context.Background(), []string{"claude"}, Request{Model: "claude-sonnet-4"}, Options{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hedged request took %v", elapsed)
+	}
+	select {
+	case loser := <-exec.cancelled:
+		if loser == string(resp.Payload) {
+			t.Errorf("winner and cancelled attempt share auth %s", loser)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow attempt was not cancelled")
+	}
+}
+
+func TestLatencyRing_Percentile(t *testing.T) {
+	var r latencyRing
+	for i := 1; i < minLatencySamples; i++ {
+		r.add(time.Duration(i) * time.Millisecond)
+	}
+	if _, ok := r.percentile(95); ok {
+		t.Fatal("percentile reported with too few samples")
+	}
+	for i := minLatencySamples; i <= 100; i++ {
+		r.add(time.Duration(i) * time.Millisecond)
+	}
+	if got, _ := r.percentile(50); got != 51*time.Millisecond {
+		t.Errorf("p50 = %v, want 51ms", got)
+	}
+	if got, _ := r.percentile(100); got != 100*time.Millisecond {
+		t.Errorf("p100 = %v, want 100ms", got)
+	}
+}
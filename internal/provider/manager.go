@@ -23,7 +23,7 @@ func init() {
 			return true
 		}
 		cat := CategorizeError(0, err.Error())
-		return cat.IsUserFault()
+		return cat.IsUserFault() || cat == CategoryProxyError
 	}
 }
 
@@ -72,6 +72,15 @@ type Hook interface {
 	OnResult(ctx context.Context, result Result)
 }
 
+// LifecycleHook is an optional Hook extension for events the manager does not
+// otherwise surface: failed token refreshes and circuit breaker transitions.
+type LifecycleHook interface {
+	// OnRefreshFailed fires when refreshing an auth's token fails.
+	OnRefreshFailed(ctx context.Context, auth *Auth, err error)
+	// OnBreakerStateChange fires when a provider circuit breaker changes state.
+	OnBreakerStateChange(provider string, streaming bool, from, to string)
+}
+
 // NoopHook provides optional hook defaults.
 type NoopHook struct{}
 
@@ -103,16 +112,26 @@ type Manager struct {
 	refreshCancel context.CancelFunc
 	refreshSem    *semaphore.Weighted
 
+	health healthProbe
+
+	quotaPollMu     sync.Mutex
+	quotaPollCancel context.CancelFunc
+
 	breakerMu         sync.RWMutex
 	breakers          map[string]*resilience.CircuitBreaker
 	streamingBreakers map[string]*resilience.StreamingCircuitBreaker
 
 	retryBudget *resilience.RetryBudget
+	limiter     *concurrencyLimiter
+	hedge       atomic.Pointer[HedgeConfig]
+	guard       atomic.Pointer[requestGuardHolder]
 
 	registry *AuthRegistry
 
 	localStateMu    sync.RWMutex
 	localStateCache *localAuthState
+
+	cachedContents *cachedContentOwners
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -134,7 +153,9 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		breakers:          make(map[string]*resilience.CircuitBreaker),
 		streamingBreakers: make(map[string]*resilience.StreamingCircuitBreaker),
 		retryBudget:       resilience.NewRetryBudget(100),
+		limiter:           newConcurrencyLimiter(),
 		refreshSem:        newRefreshSemaphore(),
+		cachedContents:    newCachedContentOwners(),
 	}
 	m.registry = NewAuthRegistry(store, hook)
 	m.registry.SetExecutorProvider(m.executorFor)
@@ -151,6 +172,8 @@ func (m *Manager) Stop() {
 	if m.refreshCancel != nil {
 		m.refreshCancel()
 	}
+	m.StopHealthProbe()
+	m.StopQuotaPolling()
 	if m.registry != nil {
 		m.registry.Stop()
 	}
@@ -285,7 +308,23 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model with weighted selection based on performance.
+// The request guard, when set, inspects the request first and then the response.
 func (m *Manager) Execute(ctx context.Context, providers []string, req Request, opts Options) (Response, error) {
+	req, guard, err := m.inspectRequest(ctx, req, opts)
+	if err != nil {
+		return Response{}, err
+	}
+	resp, err := m.execute(ctx, providers, req, opts)
+	if err != nil || guard == nil {
+		return resp, err
+	}
+	if resp.Payload, err = guard.Response(ctx, resp.Payload); err != nil {
+		return Response{}, err
+	}
+	return resp, nil
+}
+
+func (m *Manager) execute(ctx context.Context, providers []string, req Request, opts Options) (Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -293,6 +332,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req Request,
 	selected := m.selectProviders(req.Model, normalized)
 
 	retryTimes, maxWait := m.retrySettings()
+	cooldownDeadline := m.cooldownDeadline()
 	attempts := retryTimes + 1
 	if attempts < 1 {
 		attempts = 1
@@ -310,10 +350,23 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req Request,
 		}
 
 		start := time.Now()
-		resp, errExec := m.executeProvidersOnce(ctx, selected, func(execCtx context.Context, provider string) (Response, error) {
-			lastProvider = provider
-			return m.executeWithProvider(execCtx, provider, req, opts)
-		})
+		var resp Response
+		var errExec error
+		if delay, ok := m.hedgeDelay(selected, req.Model, false); ok && attempt == 0 {
+			resp, lastProvider, errExec = m.executeHedged(ctx, selected, delay, func(execCtx context.Context, providers []string) (Response, error) {
+				return m.executeProvidersOnce(execCtx, providers, func(execCtx context.Context, provider string) (Response, error) {
+					return m.executeWithProvider(execCtx, provider, req, opts)
+				})
+			})
+			if errExec != nil {
+				lastProvider = selected[0]
+			}
+		} else {
+			resp, errExec = m.executeProvidersOnce(ctx, selected, func(execCtx context.Context, provider string) (Response, error) {
+				lastProvider = provider
+				return m.executeWithProvider(execCtx, provider, req, opts)
+			})
+		}
 		latency := time.Since(start)
 
 		if errExec == nil {
@@ -333,6 +386,11 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req Request,
 			m.retryBudget.Release()
 		}
 
+		// Waiting out a cooldown does not use up a retry.
+		if m.waitForCooldown(ctx, errExec, cooldownDeadline) {
+			attempt--
+			continue
+		}
 		if !m.shouldRetryAfterError(errExec, attempt, attempts, selected, req.Model) {
 			break
 		}
@@ -348,7 +406,17 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req Request,
 
 // ExecuteCount performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model with weighted selection based on performance.
+// The request guard, when set, inspects the request first: counted prompts are
+// sent upstream too.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req Request, opts Options) (Response, error) {
+	req, _, err := m.inspectRequest(ctx, req, opts)
+	if err != nil {
+		return Response{}, err
+	}
+	return m.executeCount(ctx, providers, req, opts)
+}
+
+func (m *Manager) executeCount(ctx context.Context, providers []string, req Request, opts Options) (Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -356,6 +424,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req Requ
 	selected := m.selectProviders(req.Model, normalized)
 
 	retryTimes, maxWait := m.retrySettings()
+	cooldownDeadline := m.cooldownDeadline()
 	attempts := retryTimes + 1
 	if attempts < 1 {
 		attempts = 1
@@ -394,6 +463,11 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req Requ
 			m.retryBudget.Release()
 		}
 
+		// Waiting out a cooldown does not use up a retry.
+		if m.waitForCooldown(ctx, errExec, cooldownDeadline) {
+			attempt--
+			continue
+		}
 		if !m.shouldRetryAfterError(errExec, attempt, attempts, selected, req.Model) {
 			break
 		}
@@ -410,7 +484,20 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req Requ
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model with weighted selection based on performance.
 // Stats tracking is now consolidated in executeStreamWithProvider to reduce wrapper overhead.
+// The request guard, when set, inspects the request first and then the stream.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req Request, opts Options) (<-chan StreamChunk, error) {
+	req, guard, err := m.inspectRequest(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := m.executeStream(ctx, providers, req, opts)
+	if err != nil || guard == nil {
+		return chunks, err
+	}
+	return guard.Stream(ctx, chunks), nil
+}
+
+func (m *Manager) executeStream(ctx context.Context, providers []string, req Request, opts Options) (<-chan StreamChunk, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -418,6 +505,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req Req
 	selected := m.selectProviders(req.Model, normalized)
 
 	retryTimes, maxWait := m.retrySettings()
+	cooldownDeadline := m.cooldownDeadline()
 	attempts := retryTimes + 1
 	if attempts < 1 {
 		attempts = 1
@@ -434,9 +522,18 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req Req
 		}
 
 		// Stats are now tracked inside executeStreamWithProvider - no need for wrapStreamForStats
-		chunks, errStream := m.executeStreamProvidersOnce(ctx, selected, func(execCtx context.Context, provider string) (<-chan StreamChunk, error) {
-			return m.executeStreamWithProvider(execCtx, provider, req, opts)
-		})
+		runOnce := func(execCtx context.Context, providers []string) (<-chan StreamChunk, error) {
+			return m.executeStreamProvidersOnce(execCtx, providers, func(execCtx context.Context, provider string) (<-chan StreamChunk, error) {
+				return m.executeStreamWithProvider(execCtx, provider, req, opts)
+			})
+		}
+		var chunks <-chan StreamChunk
+		var errStream error
+		if delay, ok := m.hedgeDelay(selected, req.Model, true); ok && attempt == 0 {
+			chunks, errStream = m.executeStreamHedged(ctx, selected, delay, runOnce)
+		} else {
+			chunks, errStream = runOnce(ctx, selected)
+		}
 
 		if errStream == nil {
 			if acquiredBudget {
@@ -452,6 +549,11 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req Req
 			m.retryBudget.Release()
 		}
 
+		// Waiting out a cooldown does not use up a retry.
+		if m.waitForCooldown(ctx, errStream, cooldownDeadline) {
+			attempt--
+			continue
+		}
 		if !m.shouldRetryAfterError(errStream, attempt, attempts, selected, req.Model) {
 			break
 		}
@@ -479,31 +581,42 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				qm.RecordQuotaHit(result.AuthID, result.Provider, result.Model, result.RetryAfter)
 			}
 		}
-		// Circuit breaker: sync disabled state from registry back to m.auths.
-		// The registry may have disabled the auth on 401/403; keep m.auths consistent.
-		if entry := m.registry.GetEntry(result.AuthID); entry != nil && entry.IsDisabled() {
-			m.mu.Lock()
-			if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
-				auth.Disabled = true
-				auth.Status = StatusDisabled
-				meta := entry.Metadata()
-				auth.StatusMessage = meta.StatusMessage
-				auth.UpdatedAt = meta.UpdatedAt
-			}
-			m.mu.Unlock()
-		}
+		m.syncDisabled(result.AuthID)
 		return
 	}
 	// Fallback to sync processing when registry is not available (legacy mode)
 	m.markResultSync(ctx, result)
 }
 
+// syncDisabled copies a disabled state from the registry back to m.auths.
+// The registry may have disabled the auth on 401/403 (circuit breaker).
+func (m *Manager) syncDisabled(authID string) {
+	entry := m.registry.GetEntry(authID)
+	if entry == nil || !entry.IsDisabled() {
+		return
+	}
+	m.mu.Lock()
+	if auth, ok := m.auths[authID]; ok && auth != nil {
+		auth.Disabled = true
+		auth.Status = StatusDisabled
+		meta := entry.Metadata()
+		auth.StatusMessage = meta.StatusMessage
+		auth.UpdatedAt = meta.UpdatedAt
+	}
+	m.mu.Unlock()
+}
+
 // markResultSync is the synchronous fallback for MarkResult.
 // Used when async worker is not available or queue is full.
 func (m *Manager) markResultSync(ctx context.Context, result Result) {
 	if result.AuthID == "" {
 		return
 	}
+	// Proxy failures never reached the upstream, so they say nothing about the auth.
+	if !result.Success && result.Error != nil && CategorizeError(0, result.Error.Message) == CategoryProxyError {
+		m.hook.OnResult(ctx, result)
+		return
+	}
 
 	shouldResumeModel := false
 	shouldSuspendModel := false
@@ -732,10 +845,14 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts Opt
 	// Collect candidate pointers under lock (cheap - no cloning yet)
 	candidatePtrs := make([]*Auth, 0, len(m.auths))
 	registryRef := registry.GetGlobalRegistry()
+	pinned := PinnedAuthFromContext(ctx)
 	for _, candidate := range m.auths {
 		if candidate.Provider != provider || candidate.Disabled {
 			continue
 		}
+		if pinned != "" && candidate.ID != pinned {
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
@@ -799,26 +916,46 @@ func (m *Manager) pickNextFromRegistry(ctx context.Context, provider, model stri
 	allEntries := m.registry.ListByProvider(provider)
 
 	var entries []*AuthEntry
+	busy := 0
 	registryRef := registry.GetGlobalRegistry()
+	pinned := PinnedAuthFromContext(ctx)
+	hedge := hedgeAttemptFromContext(ctx)
 	for _, entry := range allEntries {
 		if entry.IsDisabled() {
 			continue
 		}
+		if pinned != "" && entry.ID() != pinned {
+			continue
+		}
+		if hedge.takenByOther(entry.ID()) {
+			continue
+		}
 		if _, used := tried[entry.ID()]; used {
 			continue
 		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(entry.ID(), modelKey) {
 			continue
 		}
+		if m.limiter.atLimit(entry.ID()) {
+			busy++
+			continue
+		}
 		entries = append(entries, entry)
 	}
 
 	if len(entries) == 0 {
+		if busy > 0 {
+			return nil, nil, errSlotsBusy
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 
 	selected, errPick := m.registry.Pick(ctx, provider, model, opts, entries)
 	if errPick != nil {
+		// A busy auth frees up sooner than the others recover.
+		if busy > 0 {
+			return nil, nil, errSlotsBusy
+		}
 		return nil, nil, errPick
 	}
 	if selected == nil {
@@ -893,6 +1030,9 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated, err := exec.Refresh(ctx, cloned)
 	now := time.Now()
 	if err != nil {
+		if lh, ok := m.hook.(LifecycleHook); ok && ctx.Err() == nil {
+			lh.OnRefreshFailed(ctx, cloned, err)
+		}
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil && current.UpdatedAt == authUpdatedAt {
 			errMsg := err.Error()
@@ -1063,6 +1203,9 @@ func (m *Manager) getOrCreateBreaker(provider string) *resilience.CircuitBreaker
 	cfg := resilience.DefaultBreakerConfig("provider:" + provider)
 	cfg.OnStateChange = func(name string, from, to gobreaker.State) {
 		log.Infof("circuit breaker %s: %s -> %s", name, from, to)
+		if lh, ok := m.hook.(LifecycleHook); ok {
+			lh.OnBreakerStateChange(provider, false, from.String(), to.String())
+		}
 	}
 	cb := resilience.NewCircuitBreaker(cfg)
 	m.breakers[provider] = cb
@@ -1086,6 +1229,9 @@ func (m *Manager) getOrCreateStreamingBreaker(provider string) *resilience.Strea
 	cfg := resilience.DefaultBreakerConfig("streaming:" + provider)
 	cfg.OnStateChange = func(name string, from, to gobreaker.State) {
 		log.Infof("circuit breaker %s: %s -> %s", name, from, to)
+		if lh, ok := m.hook.(LifecycleHook); ok {
+			lh.OnBreakerStateChange(provider, true, from.String(), to.String())
+		}
 	}
 	cb := resilience.NewStreamingCircuitBreaker(cfg)
 	m.streamingBreakers[provider] = cb
@@ -1127,6 +1273,30 @@ func (m *Manager) AuthRegistry() *AuthRegistry {
 	return m.registry
 }
 
+// ProviderExhausted reports whether every auth registered for provider is
+// disabled or cooling down for model, and the earliest time one recovers.
+func (m *Manager) ProviderExhausted(provider, model string) (bool, time.Time) {
+	if m.registry == nil || provider == "" {
+		return false, time.Time{}
+	}
+	entries := m.registry.ListByProvider(provider)
+	if len(entries) == 0 {
+		return false, time.Time{}
+	}
+	now := time.Now()
+	var earliest time.Time
+	for _, entry := range entries {
+		blocked, _, retryAt := entry.IsBlockedForModel(model, now)
+		if !blocked {
+			return false, time.Time{}
+		}
+		if !retryAt.IsZero() && (earliest.IsZero() || retryAt.Before(earliest)) {
+			earliest = retryAt
+		}
+	}
+	return true, earliest
+}
+
 func (m *Manager) GetAuthEntry(id string) *AuthEntry {
 	if m.registry == nil {
 		return nil
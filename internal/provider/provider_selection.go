@@ -66,3 +66,11 @@ func (m *Manager) recordProviderResult(provider, model string, success bool, lat
 		stats.RecordFailure(provider, model)
 	}
 }
+
+// recordFirstByte records the time an attempt took to start answering.
+func (m *Manager) recordFirstByte(provider, model string, stream bool, latency time.Duration) {
+	if m.providerStats == nil {
+		return
+	}
+	m.providerStats.RecordFirstByte(provider, model, stream, latency)
+}
@@ -1,11 +1,20 @@
 package provider
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	// latencySamples is the number of recent first-byte latencies kept per
+	// provider:model for percentiles.
+	latencySamples = 100
+	// minLatencySamples is the number of samples a percentile needs.
+	minLatencySamples = 10
+)
+
 // ProviderStats tracks performance metrics for intelligent load balancing.
 // Uses lock-free atomic operations for high-concurrency scenarios.
 type ProviderStats struct {
@@ -19,6 +28,49 @@ type providerMetrics struct {
 	totalLatencyNs atomic.Int64 // cumulative latency in nanoseconds
 	lastUsed       atomic.Int64 // unix nano timestamp
 	lastSuccess    atomic.Int64 // unix nano timestamp
+
+	// firstByte holds recent time-to-response latencies, non-streaming at
+	// index 0 and time-to-first-chunk of streams at index 1.
+	firstByte [2]latencyRing
+}
+
+// latencyRing keeps the most recent latency samples.
+type latencyRing struct {
+	mu      sync.Mutex
+	samples [latencySamples]time.Duration
+	n       int
+	next    int
+}
+
+func (r *latencyRing) add(d time.Duration) {
+	r.mu.Lock()
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % latencySamples
+	if r.n < latencySamples {
+		r.n++
+	}
+	r.mu.Unlock()
+}
+
+// percentile returns the p-th percentile (0-100) of the samples, or false
+// when there are fewer than minLatencySamples.
+func (r *latencyRing) percentile(p float64) (time.Duration, bool) {
+	r.mu.Lock()
+	if r.n < minLatencySamples {
+		r.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, r.n)
+	copy(sorted, r.samples[:r.n])
+	r.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(p/100*float64(len(sorted)-1) + 0.5)
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx], true
 }
 
 // NewProviderStats creates a new stats tracker.
@@ -124,6 +176,32 @@ func (ps *ProviderStats) GetAvgLatency(provider, model string) time.Duration {
 	return time.Duration(m.totalLatencyNs.Load() / success)
 }
 
+// RecordFirstByte records how long an attempt took to return its response,
+// or for streams its first chunk.
+func (ps *ProviderStats) RecordFirstByte(provider, model string, stream bool, latency time.Duration) {
+	m := ps.getOrCreate(provider + ":" + model)
+	m.firstByte[streamIndex(stream)].add(latency)
+}
+
+// FirstByteLatency returns the p-th percentile (0-100) of recent first-byte
+// latencies, or false when there are too few samples.
+func (ps *ProviderStats) FirstByteLatency(provider, model string, stream bool, p float64) (time.Duration, bool) {
+	ps.mu.RLock()
+	m := ps.stats[provider+":"+model]
+	ps.mu.RUnlock()
+	if m == nil {
+		return 0, false
+	}
+	return m.firstByte[streamIndex(stream)].percentile(p)
+}
+
+func streamIndex(stream bool) int {
+	if stream {
+		return 1
+	}
+	return 0
+}
+
 // SortByScore sorts providers by score (highest first), preserving order for equal scores.
 func (ps *ProviderStats) SortByScore(providers []string, model string) []string {
 	if len(providers) <= 1 {
@@ -35,6 +35,10 @@ type RealQuotaSnapshot struct {
 	RemainingTokens   int64     // Absolute remaining
 	WindowResetAt     time.Time // When quota window resets
 	FetchedAt         time.Time // When this was fetched
+
+	Source string        // Where the data came from (QuotaSource* constants)
+	Tier   string        // Subscription tier or plan, when the upstream reports it
+	Groups []QuotaWindow // Per model group or rate-limit window details
 }
 
 // DefaultStrategy is used for providers without specific strategy.
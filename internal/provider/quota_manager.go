@@ -191,12 +191,14 @@ func (m *QuotaManager) getShard(authID string) *quotaShard {
 }
 
 func (m *QuotaManager) Start() {
+	activeQuotaManagers.Store(m, struct{}{})
 	m.sticky.Start()
 	m.wg.Add(1)
 	go m.cleanupLoop()
 }
 
 func (m *QuotaManager) Stop() {
+	activeQuotaManagers.Delete(m)
 	m.stopOnce.Do(func() {
 		close(m.stopChan)
 	})
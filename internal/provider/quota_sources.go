@@ -0,0 +1,294 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/nghyane/llm-mux/internal/logging"
+)
+
+// Quota sources recorded in RealQuotaSnapshot.Source.
+const (
+	QuotaSourceAntigravity      = "antigravity-api"
+	QuotaSourceCopilot          = "copilot-api"
+	QuotaSourceGeminiCLI        = "gemini-cli-api"
+	QuotaSourceCodexHeaders     = "codex-headers"
+	QuotaSourceAnthropicHeaders = "anthropic-headers"
+)
+
+const defaultQuotaPollInterval = 5 * time.Minute
+
+// QuotaWindow is one limit reported by the upstream: a model group, a rate
+// limit window or a request/token budget.
+type QuotaWindow struct {
+	Name              string    `json:"name"`
+	RemainingFraction float64   `json:"remaining_fraction"`
+	Remaining         int64     `json:"remaining,omitempty"`
+	Limit             int64     `json:"limit,omitempty"`
+	Unlimited         bool      `json:"unlimited,omitempty"`
+	ResetAt           time.Time `json:"reset_at,omitempty"`
+}
+
+// QuotaFetcher is implemented by executors that can query the upstream for
+// the remaining quota of an auth.
+type QuotaFetcher interface {
+	FetchQuota(ctx context.Context, auth *Auth) (*RealQuotaSnapshot, error)
+}
+
+// Summarize sets the top-level fields from the tightest limited window, the
+// one that blocks requests first when every window applies to every request.
+func (s *RealQuotaSnapshot) Summarize() {
+	first := true
+	for _, w := range s.Groups {
+		if w.Unlimited {
+			continue
+		}
+		if first || w.RemainingFraction < s.RemainingFraction {
+			s.RemainingFraction = w.RemainingFraction
+			s.RemainingTokens = w.Remaining
+			s.WindowResetAt = w.ResetAt
+			first = false
+		}
+	}
+	if first {
+		s.RemainingFraction = 1
+	}
+}
+
+var activeQuotaManagers sync.Map // *QuotaManager -> struct{}
+
+// ReportRealQuota records a snapshot for authID on every running QuotaManager.
+// Executors call it with quota data observed outside the polling loop.
+func ReportRealQuota(authID string, snapshot *RealQuotaSnapshot) {
+	if authID == "" || snapshot == nil {
+		return
+	}
+	activeQuotaManagers.Range(func(key, _ any) bool {
+		qm := key.(*QuotaManager)
+		state := qm.getOrCreateState(authID)
+		state.SetRealQuota(snapshot)
+		qm.handleQuotaSnapshotUpdate(state, snapshot)
+		return true
+	})
+}
+
+// ReportQuotaHeaders parses the rate-limit headers of an upstream response
+// for providers that send them (Claude, Codex) and records the result.
+func ReportQuotaHeaders(auth *Auth, h http.Header) {
+	if auth == nil || len(h) == 0 {
+		return
+	}
+	now := time.Now()
+	var snapshot *RealQuotaSnapshot
+	switch auth.Provider {
+	case "claude":
+		snapshot = ParseAnthropicRateLimitHeaders(h, now)
+	case "codex":
+		snapshot = ParseCodexRateLimitHeaders(h, now)
+	}
+	ReportRealQuota(auth.ID, snapshot)
+}
+
+// ParseAnthropicRateLimitHeaders reads the unified subscription headers
+// (anthropic-ratelimit-unified-<window>-utilization/-reset) sent to OAuth
+// clients, and the request/token budget headers sent to API keys.
+func ParseAnthropicRateLimitHeaders(h http.Header, now time.Time) *RealQuotaSnapshot {
+	const unified = "Anthropic-Ratelimit-Unified-"
+	snapshot := &RealQuotaSnapshot{Source: QuotaSourceAnthropicHeaders, FetchedAt: now}
+	for key, values := range h {
+		key = http.CanonicalHeaderKey(key)
+		if !strings.HasPrefix(key, unified) || !strings.HasSuffix(key, "-Utilization") || len(values) == 0 {
+			continue
+		}
+		window := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(key, unified), "-Utilization"))
+		used, err := strconv.ParseFloat(strings.TrimSpace(values[0]), 64)
+		if err != nil {
+			continue
+		}
+		w := QuotaWindow{Name: window, RemainingFraction: clampFraction(1 - used)}
+		if reset := h.Get(unified + window + "-Reset"); reset != "" {
+			w.ResetAt = parseResetTime(reset, now)
+		}
+		snapshot.Groups = append(snapshot.Groups, w)
+	}
+	for _, kind := range []string{"requests", "tokens", "input-tokens", "output-tokens"} {
+		prefix := "Anthropic-Ratelimit-" + kind + "-"
+		limit, errLimit := strconv.ParseInt(h.Get(prefix+"Limit"), 10, 64)
+		remaining, errRemaining := strconv.ParseInt(h.Get(prefix+"Remaining"), 10, 64)
+		if errLimit != nil || errRemaining != nil || limit <= 0 {
+			continue
+		}
+		snapshot.Groups = append(snapshot.Groups, QuotaWindow{
+			Name:              kind,
+			Limit:             limit,
+			Remaining:         remaining,
+			RemainingFraction: clampFraction(float64(remaining) / float64(limit)),
+			ResetAt:           parseResetTime(h.Get(prefix+"Reset"), now),
+		})
+	}
+	if len(snapshot.Groups) == 0 {
+		return nil
+	}
+	sortQuotaWindows(snapshot.Groups)
+	snapshot.Summarize()
+	return snapshot
+}
+
+// ParseCodexRateLimitHeaders reads the x-codex-primary-* and
+// x-codex-secondary-* usage windows sent by the ChatGPT backend.
+func ParseCodexRateLimitHeaders(h http.Header, now time.Time) *RealQuotaSnapshot {
+	snapshot := &RealQuotaSnapshot{Source: QuotaSourceCodexHeaders, FetchedAt: now}
+	for _, slot := range []string{"primary", "secondary"} {
+		prefix := "X-Codex-" + slot + "-"
+		used, err := strconv.ParseFloat(strings.TrimSpace(h.Get(prefix+"Used-Percent")), 64)
+		if err != nil {
+			continue
+		}
+		w := QuotaWindow{Name: slot, RemainingFraction: clampFraction(1 - used/100)}
+		if minutes, err := strconv.Atoi(h.Get(prefix + "Window-Minutes")); err == nil && minutes > 0 {
+			w.Name = formatWindow(time.Duration(minutes) * time.Minute)
+		}
+		if secs, err := strconv.ParseInt(h.Get(prefix+"Reset-After-Seconds"), 10, 64); err == nil {
+			w.ResetAt = now.Add(time.Duration(secs) * time.Second)
+		} else if at := h.Get(prefix + "Reset-At"); at != "" {
+			w.ResetAt = parseResetTime(at, now)
+		}
+		snapshot.Groups = append(snapshot.Groups, w)
+	}
+	if len(snapshot.Groups) == 0 {
+		return nil
+	}
+	snapshot.Summarize()
+	return snapshot
+}
+
+// parseResetTime accepts RFC 3339 timestamps, Unix seconds and relative seconds.
+func parseResetTime(v string, now time.Time) time.Time {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		// Values below a year are relative ("reset in N seconds").
+		if n < 365*24*3600 {
+			return now.Add(time.Duration(n) * time.Second)
+		}
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
+
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+func clampFraction(f float64) float64 {
+	switch {
+	case f < 0:
+		return 0
+	case f > 1:
+		return 1
+	}
+	return f
+}
+
+func sortQuotaWindows(ws []QuotaWindow) {
+	sort.Slice(ws, func(i, j int) bool { return ws[i].Name < ws[j].Name })
+}
+
+// StartQuotaPolling periodically refreshes quota for auths whose executor
+// implements QuotaFetcher. Starting it again replaces the previous loop.
+func (m *Manager) StartQuotaPolling(parent context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultQuotaPollInterval
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.quotaPollMu.Lock()
+	if m.quotaPollCancel != nil {
+		m.quotaPollCancel()
+	}
+	m.quotaPollCancel = cancel
+	m.quotaPollMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		m.RefreshQuotas(ctx, "")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.RefreshQuotas(ctx, "")
+			}
+		}
+	}()
+}
+
+// StopQuotaPolling stops the quota polling loop, if running.
+func (m *Manager) StopQuotaPolling() {
+	m.quotaPollMu.Lock()
+	defer m.quotaPollMu.Unlock()
+	if m.quotaPollCancel != nil {
+		m.quotaPollCancel()
+		m.quotaPollCancel = nil
+	}
+}
+
+// RefreshQuotas fetches quota now for every enabled auth (or only those of
+// providerName) whose executor implements QuotaFetcher, and asks background
+// refreshers such as Antigravity's to poll early.
+func (m *Manager) RefreshQuotas(ctx context.Context, providerName string) {
+	qm := m.GetQuotaManager()
+	if m.registry == nil || qm == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRefreshes)
+	for _, entry := range m.registry.ListEntries() {
+		if entry.IsDisabled() || (providerName != "" && entry.Provider() != providerName) {
+			continue
+		}
+		fetcher, ok := m.executorFor(entry.Provider()).(QuotaFetcher)
+		if !ok {
+			if state := qm.getState(entry.ID()); state != nil {
+				state.TriggerRefresh()
+			}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(auth *Auth) {
+			defer func() { <-sem; wg.Done() }()
+			fetchCtx, cancel := context.WithTimeout(ctx, quotaFetchTimeout)
+			defer cancel()
+			if rt := m.roundTripperFor(auth); rt != nil {
+				fetchCtx = context.WithValue(fetchCtx, roundTripperContextKey{}, rt)
+			}
+			snapshot, err := fetcher.FetchQuota(fetchCtx, auth)
+			if err != nil {
+				log.Debugf("quota: fetch failed for %s (provider=%s): %v", auth.ID, auth.Provider, err)
+				return
+			}
+			state := qm.getOrCreateState(auth.ID)
+			state.SetRealQuota(snapshot)
+			qm.handleQuotaSnapshotUpdate(state, snapshot)
+		}(entry.ToAuth())
+	}
+	wg.Wait()
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseAnthropicRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-unified-5h-utilization", "0.25")
+	h.Set("anthropic-ratelimit-unified-5h-reset", "1700003600")
+	h.Set("anthropic-ratelimit-unified-7d-utilization", "0.9")
+	h.Set("anthropic-ratelimit-unified-7d-reset", "1700500000")
+
+	s := ParseAnthropicRateLimitHeaders(h, now)
+	if s == nil || len(s.Groups) != 2 || s.Source != QuotaSourceAnthropicHeaders {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	if s.Groups[0].Name != "5h" || s.Groups[0].RemainingFraction != 0.75 {
+		t.Fatalf("unexpected 5h window %+v", s.Groups[0])
+	}
+	if got := s.RemainingFraction; got < 0.099 || got > 0.101 {
+		t.Fatalf("remaining fraction = %v, want the tighter 7d window", got)
+	}
+	if !s.WindowResetAt.Equal(time.Unix(1700500000, 0)) {
+		t.Fatalf("reset = %v", s.WindowResetAt)
+	}
+
+	h = http.Header{}
+	h.Set("anthropic-ratelimit-requests-limit", "50")
+	h.Set("anthropic-ratelimit-requests-remaining", "10")
+	h.Set("anthropic-ratelimit-requests-reset", "2023-11-14T22:14:00Z")
+	s = ParseAnthropicRateLimitHeaders(h, now)
+	if s == nil || s.RemainingFraction != 0.2 || s.RemainingTokens != 10 || s.WindowResetAt.IsZero() {
+		t.Fatalf("unexpected request budget snapshot %+v", s)
+	}
+
+	if ParseAnthropicRateLimitHeaders(http.Header{"Content-Type": {"application/json"}}, now) != nil {
+		t.Fatal("expected nil snapshot without rate-limit headers")
+	}
+}
+
+func TestParseCodexRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	h := http.Header{}
+	h.Set("x-codex-primary-used-percent", "40")
+	h.Set("x-codex-primary-window-minutes", "300")
+	h.Set("x-codex-primary-reset-after-seconds", "600")
+	h.Set("x-codex-secondary-used-percent", "12.5")
+	h.Set("x-codex-secondary-window-minutes", "10080")
+
+	s := ParseCodexRateLimitHeaders(h, now)
+	if s == nil || len(s.Groups) != 2 {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	if s.Groups[0].Name != "5h" || s.Groups[1].Name != "7d" {
+		t.Fatalf("unexpected window names %q, %q", s.Groups[0].Name, s.Groups[1].Name)
+	}
+	if s.RemainingFraction != 0.6 || !s.WindowResetAt.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("summary = %v reset %v, want the primary window", s.RemainingFraction, s.WindowResetAt)
+	}
+}
@@ -89,6 +89,11 @@ func (m *Manager) shouldRetryAfterError(err error, attempt, maxAttempts int, pro
 		return false
 	}
 
+	// The request already waited its budget for a concurrency slot.
+	if isConcurrencyLimitError(err) {
+		return false
+	}
+
 	category := categoryFromError(err)
 	if !category.ShouldFallback() {
 		return false
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/registry"
+)
+
+// stubExecutor is a ProviderExecutor for manager tests. Execute and
+// CountTokens record the request and answer with the auth ID; tests embed it
+// and override the methods they exercise.
+type stubExecutor struct {
+	id string
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+func (e *stubExecutor) Identifier() string { return e.id }
+
+func (e *stubExecutor) Execute(ctx context.Context, auth *Auth, req Request, opts Options) (Response, error) {
+	e.record(req)
+	return Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *stubExecutor) ExecuteStream(ctx context.Context, auth *Auth, req Request, opts Options) (<-chan StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (e *stubExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *stubExecutor) CountTokens(ctx context.Context, auth *Auth, req Request, opts Options) (Response, error) {
+	e.record(req)
+	return Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *stubExecutor) record(req Request) {
+	e.mu.Lock()
+	e.requests = append(e.requests, req)
+	e.mu.Unlock()
+}
+
+// recorded returns the requests seen so far.
+func (e *stubExecutor) recorded() []Request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Request(nil), e.requests...)
+}
+
+// newStubManager returns a manager with exec registered and one active auth
+// per ID serving models. The manager and registry entries are cleaned up with
+// the test.
+func newStubManager(t *testing.T, exec ProviderExecutor, models []string, authIDs ...string) *Manager {
+	t.Helper()
+	manager := NewManager(nil, nil, nil)
+	t.Cleanup(manager.Stop)
+	manager.RegisterExecutor(exec)
+	infos := make([]*registry.ModelInfo, 0, len(models))
+	for _, model := range models {
+		infos = append(infos, &registry.ModelInfo{ID: model})
+	}
+	for _, id := range authIDs {
+		_, _ = manager.Register(context.Background(), &Auth{ID: id, Provider: exec.Identifier(), Status: StatusActive})
+		if len(infos) > 0 {
+			registry.GetGlobalRegistry().RegisterClient(id, exec.Identifier(), infos)
+			t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+		}
+	}
+	return manager
+}
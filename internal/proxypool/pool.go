@@ -0,0 +1,481 @@
+// Package proxypool manages named pools of egress proxies. Auths reference a
+// pool with a proxy URL of the form "pool:<name>"; each request is routed
+// through a healthy member chosen by the auth's key, and members that fail are
+// skipped until a background health check sees them recover.
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"golang.org/x/net/proxy"
+)
+
+// Scheme prefixes proxy URLs that reference a pool.
+const Scheme = "pool:"
+
+const (
+	defaultCheckInterval = 30 * time.Second
+	defaultCheckTimeout  = 5 * time.Second
+)
+
+// Config describes one pool.
+type Config struct {
+	Name string
+	// URLs are the members: http://, https:// or socks5:// proxy URLs.
+	URLs []string
+	// CheckURL is fetched through each member by the health check. Empty
+	// checks that the proxy accepts connections.
+	CheckURL      string
+	CheckInterval time.Duration
+	CheckTimeout  time.Duration
+}
+
+// MemberStatus is the health of one pool member.
+type MemberStatus struct {
+	URL                 string    `json:"url"`
+	Healthy             bool      `json:"healthy"`
+	LastCheck           time.Time `json:"last_check,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	LatencyMs           int64     `json:"latency_ms,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Requests            int64     `json:"requests"`
+	ProxyErrors         int64     `json:"proxy_errors"`
+}
+
+// PoolStatus is the state of one pool.
+type PoolStatus struct {
+	Name     string         `json:"name"`
+	CheckURL string         `json:"check_url,omitempty"`
+	Healthy  int            `json:"healthy"`
+	Members  []MemberStatus `json:"members"`
+}
+
+type member struct {
+	url string
+
+	mu        sync.Mutex
+	healthy   bool
+	lastCheck time.Time
+	lastErr   string
+	latency   time.Duration
+	failures  int
+
+	requests    atomic.Int64
+	proxyErrors atomic.Int64
+}
+
+func (m *member) isHealthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy
+}
+
+func (m *member) record(err error, latency time.Duration, checked bool) (changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	was := m.healthy
+	if checked {
+		m.lastCheck = time.Now()
+	}
+	if err != nil {
+		m.healthy = false
+		m.lastErr = err.Error()
+		m.failures++
+	} else {
+		m.healthy = true
+		m.lastErr = ""
+		m.failures = 0
+		if latency > 0 {
+			m.latency = latency
+		}
+	}
+	return was != m.healthy
+}
+
+func (m *member) status() MemberStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MemberStatus{
+		URL:                 Redact(m.url),
+		Healthy:             m.healthy,
+		LastCheck:           m.lastCheck,
+		LastError:           m.lastErr,
+		LatencyMs:           m.latency.Milliseconds(),
+		ConsecutiveFailures: m.failures,
+		Requests:            m.requests.Load(),
+		ProxyErrors:         m.proxyErrors.Load(),
+	}
+}
+
+type pool struct {
+	cfg     Config
+	members []*member
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Manager holds the configured pools and runs their health checks.
+type Manager struct {
+	mu    sync.RWMutex
+	pools map[string]*pool
+}
+
+// NewManager returns an empty Manager.
+func NewManager() *Manager {
+	return &Manager{pools: make(map[string]*pool)}
+}
+
+var defaultManager = NewManager()
+
+// Default returns the process-wide pool manager.
+func Default() *Manager {
+	return defaultManager
+}
+
+// PoolName returns the pool referenced by a proxy URL such as "pool:egress".
+func PoolName(proxyURL string) (string, bool) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if len(proxyURL) <= len(Scheme) || !strings.EqualFold(proxyURL[:len(Scheme)], Scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(proxyURL[len(Scheme):]), true
+}
+
+// Configure replaces the pools. Members that keep their URL keep their health
+// state; health checks restart with the new settings.
+func (m *Manager) Configure(cfgs []Config) {
+	next := make(map[string]*pool, len(cfgs))
+	m.mu.Lock()
+	old := m.pools
+	for _, cfg := range cfgs {
+		name := strings.TrimSpace(cfg.Name)
+		if name == "" {
+			continue
+		}
+		if cfg.CheckInterval <= 0 {
+			cfg.CheckInterval = defaultCheckInterval
+		}
+		if cfg.CheckTimeout <= 0 {
+			cfg.CheckTimeout = defaultCheckTimeout
+		}
+		prev := make(map[string]*member)
+		if p := old[name]; p != nil {
+			for _, mem := range p.members {
+				prev[mem.url] = mem
+			}
+		}
+		p := &pool{cfg: cfg, stop: make(chan struct{}), done: make(chan struct{})}
+		for _, raw := range cfg.URLs {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			mem := prev[raw]
+			if mem == nil {
+				// Members start healthy so traffic flows before the first check.
+				mem = &member{url: raw, healthy: true}
+			}
+			p.members = append(p.members, mem)
+		}
+		next[name] = p
+	}
+	m.pools = next
+	m.mu.Unlock()
+
+	for _, p := range old {
+		close(p.stop)
+		<-p.done
+	}
+	for _, p := range next {
+		go m.runChecks(p)
+	}
+}
+
+// Stop ends all health checks.
+func (m *Manager) Stop() {
+	m.Configure(nil)
+}
+
+func (m *Manager) pool(name string) *pool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pools[name]
+}
+
+// Resolve returns the member of pool name to use for key. The same key keeps
+// the same member while it is healthy; when it fails, the key moves to the
+// next healthy member. When no member is healthy, the one with the fewest
+// consecutive failures is returned so requests still have a chance.
+func (m *Manager) Resolve(name, key string) (string, bool) {
+	p := m.pool(name)
+	if p == nil || len(p.members) == 0 {
+		return "", false
+	}
+	order := rendezvous(p.members, key)
+	for _, mem := range order {
+		if mem.isHealthy() {
+			return mem.url, true
+		}
+	}
+	best := order[0]
+	bestFailures := -1
+	for _, mem := range order {
+		mem.mu.Lock()
+		f := mem.failures
+		mem.mu.Unlock()
+		if bestFailures < 0 || f < bestFailures {
+			best, bestFailures = mem, f
+		}
+	}
+	return best.url, true
+}
+
+// ResolveURL resolves pool references and returns other proxy URLs
+// unchanged. It reports false for a pool that is not configured or empty.
+func (m *Manager) ResolveURL(proxyURL, key string) (string, bool) {
+	name, ok := PoolName(proxyURL)
+	if !ok {
+		return proxyURL, true
+	}
+	return m.Resolve(name, key)
+}
+
+// UnavailableError is returned for requests whose proxy pool has no members.
+// IsProxyError reports true for it.
+func UnavailableError(name string) error {
+	return &net.OpError{Op: "proxyconnect", Net: "tcp", Err: fmt.Errorf("proxy pool %q is not configured or empty", name)}
+}
+
+// UnavailableTransport fails every request with UnavailableError, so that a
+// client whose pool cannot be resolved fails closed rather than connecting
+// directly.
+func UnavailableTransport(name string) http.RoundTripper {
+	return unavailableTransport{name: name}
+}
+
+type unavailableTransport struct {
+	name string
+}
+
+func (t unavailableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, UnavailableError(t.name)
+}
+
+// ReportResult records the outcome of a request sent through member url of
+// pool name. A proxy error takes the member out of rotation at once.
+func (m *Manager) ReportResult(name, memberURL string, err error) {
+	p := m.pool(name)
+	if p == nil {
+		return
+	}
+	for _, mem := range p.members {
+		if mem.url != memberURL {
+			continue
+		}
+		mem.requests.Add(1)
+		if err == nil || !IsProxyError(err) {
+			return
+		}
+		mem.proxyErrors.Add(1)
+		if mem.record(err, 0, false) {
+			log.Warnf("proxy pool %s: member %s failed, failing over: %v", name, Redact(memberURL), err)
+		}
+		return
+	}
+}
+
+// Status returns the state of every pool, sorted by name.
+func (m *Manager) Status() []PoolStatus {
+	m.mu.RLock()
+	pools := make([]*pool, 0, len(m.pools))
+	for _, p := range m.pools {
+		pools = append(pools, p)
+	}
+	m.mu.RUnlock()
+	sort.Slice(pools, func(i, j int) bool { return pools[i].cfg.Name < pools[j].cfg.Name })
+	out := make([]PoolStatus, 0, len(pools))
+	for _, p := range pools {
+		st := PoolStatus{Name: p.cfg.Name, CheckURL: p.cfg.CheckURL, Members: make([]MemberStatus, 0, len(p.members))}
+		for _, mem := range p.members {
+			ms := mem.status()
+			if ms.Healthy {
+				st.Healthy++
+			}
+			st.Members = append(st.Members, ms)
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+func (m *Manager) runChecks(p *pool) {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		m.checkPool(p)
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) checkPool(p *pool) {
+	var wg sync.WaitGroup
+	for _, mem := range p.members {
+		wg.Add(1)
+		go func(mem *member) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), p.cfg.CheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := Check(ctx, mem.url, p.cfg.CheckURL)
+			if mem.record(err, time.Since(start), true) {
+				if err != nil {
+					log.Warnf("proxy pool %s: member %s unhealthy: %v", p.cfg.Name, Redact(mem.url), err)
+				} else {
+					log.Infof("proxy pool %s: member %s recovered", p.cfg.Name, Redact(mem.url))
+				}
+			}
+		}(mem)
+	}
+	wg.Wait()
+}
+
+// Check probes one proxy. With a checkURL it fetches the URL through the
+// proxy and accepts any HTTP response; otherwise it only connects to the
+// proxy.
+func Check(ctx context.Context, proxyURL, checkURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+	if checkURL == "" {
+		var d net.Dialer
+		conn, errDial := d.DialContext(ctx, "tcp", hostPort(u))
+		if errDial != nil {
+			return errDial
+		}
+		return conn.Close()
+	}
+	t := &http.Transport{DisableKeepAlives: true}
+	switch u.Scheme {
+	case "http", "https":
+		t.Proxy = http.ProxyURL(u)
+	case "socks5":
+		var auth *proxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+		dialer, errSOCKS := proxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{})
+		if errSOCKS != nil {
+			return errSOCKS
+		}
+		cd, ok := dialer.(proxy.ContextDialer)
+		if !ok {
+			return fmt.Errorf("socks5 dialer does not support contexts")
+		}
+		t.DialContext = cd.DialContext
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	t.OnProxyConnectResponse = OnProxyConnectResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, checkURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: t}).Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// OnProxyConnectResponse turns a refused CONNECT into an error recognised by
+// IsProxyError. Use it as http.Transport.OnProxyConnectResponse.
+func OnProxyConnectResponse(_ context.Context, proxyURL *url.URL, _ *http.Request, resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return &net.OpError{Op: "proxyconnect", Net: "tcp", Err: fmt.Errorf("proxy %s refused CONNECT: %s", proxyURL.Host, resp.Status)}
+}
+
+// IsProxyError reports whether err was caused by the proxy rather than the
+// upstream: the proxy could not be reached, refused the tunnel or failed the
+// SOCKS handshake.
+func IsProxyError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || opErr.Op == "socks connect") {
+		return true
+	}
+	return err != nil && IsProxyErrorMessage(err.Error())
+}
+
+// IsProxyErrorMessage is IsProxyError for error text that lost its type.
+func IsProxyErrorMessage(msg string) bool {
+	return strings.Contains(msg, "proxyconnect ") || strings.Contains(msg, "socks connect ")
+}
+
+// Redact hides the password of a proxy URL.
+func Redact(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.User == nil {
+		return proxyURL
+	}
+	return u.Redacted()
+}
+
+func hostPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443")
+	case "socks5":
+		return net.JoinHostPort(u.Hostname(), "1080")
+	default:
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+}
+
+// rendezvous orders members by highest-random-weight hashing of key, so a
+// key keeps its member and only the keys of a failed member move.
+func rendezvous(members []*member, key string) []*member {
+	type scored struct {
+		m     *member
+		score uint64
+	}
+	s := make([]scored, len(members))
+	for i, mem := range members {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(mem.url))
+		s[i] = scored{mem, h.Sum64()}
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].score > s[j].score })
+	out := make([]*member, len(s))
+	for i := range s {
+		out[i] = s[i].m
+	}
+	return out
+}
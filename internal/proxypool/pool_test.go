@@ -0,0 +1,102 @@
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func waitChecked(t *testing.T, m *Manager) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		checked := true
+		for _, p := range m.Status() {
+			for _, mem := range p.Members {
+				checked = checked && !mem.LastCheck.IsZero()
+			}
+		}
+		if checked {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("health checks did not run")
+}
+
+func TestManager_FailoverOnProxyError(t *testing.T) {
+	a := httptest.NewServer(http.NotFoundHandler())
+	defer a.Close()
+	b := httptest.NewServer(http.NotFoundHandler())
+	defer b.Close()
+
+	m := NewManager()
+	defer m.Stop()
+	m.Configure([]Config{{Name: "egress", URLs: []string{a.URL, b.URL}, CheckInterval: time.Hour}})
+	waitChecked(t, m)
+
+	first, ok := m.Resolve("egress", "auth-1")
+	if !ok {
+		t.Fatal("pool not resolved")
+	}
+	if again, _ := m.Resolve("egress", "auth-1"); again != first {
+		t.Fatalf("member changed without failure: %s -> %s", first, again)
+	}
+
+	m.ReportResult("egress", first, errors.New("upstream said no"))
+	if again, _ := m.Resolve("egress", "auth-1"); again != first {
+		t.Fatal("non-proxy error caused failover")
+	}
+
+	m.ReportResult("egress", first, &net.OpError{Op: "proxyconnect", Net: "tcp", Err: errors.New("connection refused")})
+	second, _ := m.Resolve("egress", "auth-1")
+	if second == first {
+		t.Fatal("proxy error did not fail over")
+	}
+	st := m.Status()[0]
+	if st.Healthy != 1 || st.Members[0].ProxyErrors+st.Members[1].ProxyErrors != 1 {
+		t.Errorf("status = %+v", st)
+	}
+
+	if _, ok := m.Resolve("missing", "auth-1"); ok {
+		t.Error("unknown pool resolved")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := "http://" + ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Check(ctx, proxy.URL, ""); err != nil {
+		t.Errorf("connect check: %v", err)
+	}
+	if err := Check(ctx, proxy.URL, "http://upstream.invalid/"); err != nil {
+		t.Errorf("fetch check: %v", err)
+	}
+	if err := Check(ctx, dead, "http://upstream.invalid/"); err == nil || !IsProxyError(err) {
+		t.Errorf("dead proxy: err = %v, want a proxy error", err)
+	}
+}
+
+func TestPoolName(t *testing.T) {
+	if name, ok := PoolName(" pool:egress "); !ok || name != "egress" {
+		t.Errorf("PoolName = %q, %v", name, ok)
+	}
+	if _, ok := PoolName("http://proxy:3128"); ok {
+		t.Error("plain proxy URL treated as pool")
+	}
+}
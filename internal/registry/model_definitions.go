@@ -155,3 +155,32 @@ func GetKiroModels() []*ModelInfo {
 		Kiro("claude-3-5-haiku-20241022").Display("Claude 3.5 Haiku").Desc("Claude 3.5 Haiku via Kiro/Amazon Q").Created(1729555200).B(),
 	}
 }
+
+// StaticModelIDs returns the IDs and canonical IDs of every built-in model
+// definition, for validating model references in the configuration.
+func StaticModelIDs() []string {
+	lists := [][]*ModelInfo{
+		GetClaudeModels(), GetOpenAIModels(), GetQwenModels(), GetIFlowModels(),
+		GetClineModels(), GetGitHubCopilotModels(), GetKiroModels(),
+		GetGeminiModelsForProvider("gemini-cli"),
+	}
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, list := range lists {
+		for _, m := range list {
+			for _, id := range []string{m.ID, m.CanonicalID} {
+				if _, ok := seen[id]; id != "" && !ok {
+					seen[id] = struct{}{}
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// KnownModelIDs returns StaticModelIDs plus every model currently registered
+// in the global registry, including models discovered from upstream APIs.
+func KnownModelIDs() []string {
+	return append(StaticModelIDs(), GetGlobalRegistry().ModelIDs()...)
+}
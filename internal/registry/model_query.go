@@ -152,6 +152,25 @@ func (r *ModelRegistry) GetModelInfo(modelID string) *ModelInfo {
 	return nil
 }
 
+// ClientModels returns the model IDs registered for a client, in registration order.
+func (r *ModelRegistry) ClientModels(clientID string) []string {
+	s := r.snapshot()
+	return append([]string(nil), s.clientModels[strings.TrimSpace(clientID)]...)
+}
+
+// ModelIDs returns the IDs of all registered models, available or not.
+func (r *ModelRegistry) ModelIDs() []string {
+	s := r.snapshot()
+	ids := make([]string, 0, len(s.models))
+	for _, reg := range s.models {
+		if reg != nil && reg.Info != nil && reg.Info.ID != "" {
+			ids = append(ids, reg.Info.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (r *ModelRegistry) GetAvailableProviders() []string {
 	s := r.snapshot()
 
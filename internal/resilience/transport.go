@@ -11,14 +11,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nghyane/llm-mux/internal/proxypool"
 	"github.com/nghyane/llm-mux/internal/transport"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/proxy"
 )
 
 var sharedTransport = sync.OnceValue(func() *http.Transport {
-	t := newBaseTransport()
-	t.DialContext = newDialer().DialContext
+	t, _ := newBaseTransport(transport.Settings{})
+	t.DialContext = newDialer(transport.Settings{}).DialContext
 	return t
 })
 
@@ -26,15 +27,15 @@ func SharedTransport() *http.Transport {
 	return sharedTransport()
 }
 
-func newDialer() *net.Dialer {
+func newDialer(s transport.Settings) *net.Dialer {
 	return &net.Dialer{
-		Timeout:   transport.Config.DialTimeout,
+		Timeout:   s.DialTimeoutOrDefault(),
 		KeepAlive: transport.Config.KeepAlive,
 		DualStack: true,
 	}
 }
 
-func newBaseTransport() *http.Transport {
+func newBaseTransport(s transport.Settings) (*http.Transport, error) {
 	t := &http.Transport{
 		MaxIdleConns:        transport.Config.MaxIdleConns,
 		MaxIdleConnsPerHost: transport.Config.MaxIdleConnsPerHost,
@@ -63,8 +64,13 @@ func newBaseTransport() *http.Transport {
 		WriteBufferSize: 64 * 1024,
 		ReadBufferSize:  64 * 1024,
 	}
-	configureHTTP2(t)
-	return t
+	if err := s.Apply(t); err != nil {
+		return nil, err
+	}
+	if s.HTTP2Enabled() {
+		configureHTTP2(t)
+	}
+	return t, nil
 }
 
 func configureHTTP2(t *http.Transport) {
@@ -78,21 +84,38 @@ func configureHTTP2(t *http.Transport) {
 	h2Transport.AllowHTTP = transport.Config.H2AllowHTTP
 }
 
-func ProxyTransport(proxyURL *url.URL) *http.Transport {
-	t := newBaseTransport()
+func DirectTransport(s transport.Settings) (*http.Transport, error) {
+	t, err := newBaseTransport(s)
+	if err != nil {
+		return nil, err
+	}
+	t.DialContext = newDialer(s).DialContext
+	return t, nil
+}
+
+func ProxyTransport(proxyURL *url.URL, s transport.Settings) (*http.Transport, error) {
+	t, err := newBaseTransport(s)
+	if err != nil {
+		return nil, err
+	}
 	t.Proxy = http.ProxyURL(proxyURL)
-	t.DialContext = newDialer().DialContext
-	return t
+	t.OnProxyConnectResponse = proxypool.OnProxyConnectResponse
+	t.DialContext = newDialer(s).DialContext
+	return t, nil
 }
 
-func SOCKS5Transport(dialFunc func(network, addr string) (net.Conn, error)) *http.Transport {
-	t := newBaseTransport()
+func SOCKS5Transport(dialFunc func(network, addr string) (net.Conn, error), s transport.Settings) (*http.Transport, error) {
+	t, err := newBaseTransport(s)
+	if err != nil {
+		return nil, err
+	}
 	t.DialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
 		return dialFunc(network, addr)
 	}
-	return t
+	return t, nil
 }
 
+// TransportCache caches transports by proxy URL and transport settings.
 type TransportCache struct {
 	mu    sync.RWMutex
 	cache map[string]*http.Transport
@@ -110,18 +133,37 @@ func (c *TransportCache) Get(proxyURL string) *http.Transport {
 	return c.cache[proxyURL]
 }
 
+// GetOrCreate returns the transport for proxyURLStr with the global settings.
 func (c *TransportCache) GetOrCreate(proxyURLStr string) (*http.Transport, error) {
-	if proxyURLStr == "" {
+	return c.GetOrCreateWith(proxyURLStr, transport.Defaults())
+}
+
+// GetOrCreateWith returns the transport for proxyURLStr and settings,
+// building and caching it on first use.
+func (c *TransportCache) GetOrCreateWith(proxyURLStr string, settings transport.Settings) (*http.Transport, error) {
+	if proxyURLStr == "" && settings.IsZero() {
 		return SharedTransport(), nil
 	}
+	key := proxyURLStr
+	if !settings.IsZero() {
+		key = proxyURLStr + "#" + settings.Key()
+	}
 
 	c.mu.RLock()
-	if t := c.cache[proxyURLStr]; t != nil {
+	if t := c.cache[key]; t != nil {
 		c.mu.RUnlock()
 		return t, nil
 	}
 	c.mu.RUnlock()
 
+	if proxyURLStr == "" {
+		t, err := DirectTransport(settings)
+		if err != nil {
+			return nil, err
+		}
+		return c.store(key, t), nil
+	}
+
 	proxyURL, err := url.Parse(proxyURLStr)
 	if err != nil {
 		return nil, err
@@ -136,22 +178,47 @@ func (c *TransportCache) GetOrCreate(proxyURLStr string) (*http.Transport, error
 			password, _ := proxyURL.User.Password()
 			proxyAuth = &proxy.Auth{User: username, Password: password}
 		}
-		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, proxyAuth, proxy.Direct)
+		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, proxyAuth, newDialer(settings))
+		if err != nil {
+			return nil, err
+		}
+		t, err = SOCKS5Transport(dialer.Dial, settings)
 		if err != nil {
 			return nil, err
 		}
-		t = SOCKS5Transport(dialer.Dial)
 	case "http", "https":
-		t = ProxyTransport(proxyURL)
+		t, err = ProxyTransport(proxyURL, settings)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return SharedTransport(), nil
 	}
 
+	return c.store(key, t), nil
+}
+
+func (c *TransportCache) store(key string, t *http.Transport) *http.Transport {
 	c.mu.Lock()
-	c.cache[proxyURLStr] = t
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+	if existing := c.cache[key]; existing != nil {
+		t.CloseIdleConnections()
+		return existing
+	}
+	c.cache[key] = t
+	return t
+}
 
-	return t, nil
+// Retire drops every cached transport and closes its idle connections.
+// Requests in flight finish on the transport they started with.
+func (c *TransportCache) Retire() {
+	c.mu.Lock()
+	old := c.cache
+	c.cache = make(map[string]*http.Transport)
+	c.mu.Unlock()
+	for _, t := range old {
+		t.CloseIdleConnections()
+	}
 }
 
 func NewHTTPClient(proxyURL string, timeout time.Duration) (*http.Client, error) {
@@ -169,3 +236,9 @@ func NewHTTPClient(proxyURL string, timeout time.Duration) (*http.Client, error)
 var globalTransportCache = sync.OnceValue(func() *TransportCache {
 	return NewTransportCache()
 })
+
+// RetireTransports retires the transports cached by NewHTTPClient, for
+// example after the transport configuration was reloaded.
+func RetireTransports() {
+	globalTransportCache().Retire()
+}
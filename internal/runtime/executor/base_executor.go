@@ -43,6 +43,12 @@ func (b *BaseExecutor) ApplyPayloadConfig(model string, payload []byte) []byte {
 	return sseutil.ApplyPayloadConfig(b.Cfg, model, payload)
 }
 
+// ApplyPromptCache inserts automatic Anthropic cache breakpoints into a Claude payload
+// when the prompt-cache policy is enabled for model.
+func (b *BaseExecutor) ApplyPromptCache(model string, payload []byte) []byte {
+	return sseutil.ApplyPromptCachePolicy(b.Cfg, model, payload)
+}
+
 func (b *BaseExecutor) RefreshNoOp(_ context.Context, auth *provider.Auth) (*provider.Auth, error) {
 	return auth, nil
 }
@@ -41,6 +41,7 @@ const (
 	CopilotIntegrationID        = "vscode-chat"
 	CopilotOpenAIIntent         = "conversation-panel"
 	KiroDefaultBaseURL          = "https://codewhisperer.us-east-1.amazonaws.com/generateAssistantResponse"
+	OllamaDefaultBaseURL        = "http://localhost:11434"
 	IFlowDefaultEndpoint        = "/chat/completions"
 )
 
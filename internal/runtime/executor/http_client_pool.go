@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/nghyane/llm-mux/internal/transport"
 )
 
 const (
@@ -57,21 +59,26 @@ func ReleaseHTTPClient(c *http.Client) {
 	httpClientPool.Put(c)
 }
 
-// getCachedTransport returns a cached transport for the given proxy URL,
-// creating one if it doesn't exist. Returns nil for empty proxyURL.
-func getCachedTransport(proxyURL string) *http.Transport {
-	if proxyURL == "" {
-		return nil
+// getCachedTransport returns a cached transport for the given proxy URL and
+// transport settings, creating one if it doesn't exist. Returns nil when both
+// are empty, and an error when the transport cannot be built.
+func getCachedTransport(proxyURL string, settings transport.Settings) (*http.Transport, error) {
+	if proxyURL == "" && settings.IsZero() {
+		return nil, nil
 	}
 
 	initCleanup()
+	key := proxyURL
+	if !settings.IsZero() {
+		key = proxyURL + "#" + settings.Key()
+	}
 
 	// Fast path: read lock
 	transportCacheMu.RLock()
-	if cached, ok := transportCache[proxyURL]; ok {
+	if cached, ok := transportCache[key]; ok {
 		cached.lastUsed = time.Now()
 		transportCacheMu.RUnlock()
-		return cached.transport
+		return cached.transport, nil
 	}
 	transportCacheMu.RUnlock()
 
@@ -80,9 +87,9 @@ func getCachedTransport(proxyURL string) *http.Transport {
 	defer transportCacheMu.Unlock()
 
 	// Double-check after acquiring write lock
-	if cached, ok := transportCache[proxyURL]; ok {
+	if cached, ok := transportCache[key]; ok {
 		cached.lastUsed = time.Now()
-		return cached.transport
+		return cached.transport, nil
 	}
 
 	// Evict LRU entry if cache is full
@@ -91,18 +98,20 @@ func getCachedTransport(proxyURL string) *http.Transport {
 	}
 
 	// Build and cache the transport
-	t := buildProxyTransport(proxyURL)
-	if t != nil {
-		transportCache[proxyURL] = &cachedTransport{
-			transport: t,
-			lastUsed:  time.Now(),
-		}
+	t, err := buildProxyTransport(proxyURL, settings)
+	if err != nil {
+		return nil, err
+	}
+	transportCache[key] = &cachedTransport{
+		transport: t,
+		lastUsed:  time.Now(),
 	}
-	return t
+	return t, nil
 }
 
-// ClearTransportCache clears all cached proxy transports.
-// Useful for testing or when proxy configuration changes.
+// ClearTransportCache retires all cached transports. Requests in flight keep
+// their transport; new requests build fresh ones from the current settings.
+// Called when proxy or transport configuration changes.
 func ClearTransportCache() {
 	transportCacheMu.Lock()
 	defer transportCacheMu.Unlock()
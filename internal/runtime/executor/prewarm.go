@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/nghyane/llm-mux/internal/transport"
 )
 
 var antigravityEndpoints = []string{
@@ -55,8 +57,14 @@ func prewarmHTTP(ctx context.Context, baseURL string, timeout time.Duration) boo
 		return false
 	}
 
+	var rt http.RoundTripper = SharedTransport
+	if settings := transport.Defaults(); !settings.IsZero() {
+		if t, err := getCachedTransport("", settings); err == nil && t != nil {
+			rt = t
+		}
+	}
 	client := &http.Client{
-		Transport: SharedTransport,
+		Transport: rt,
 		Timeout:   timeout,
 	}
 
@@ -47,6 +47,9 @@ func (e *AIStudioExecutor) Execute(ctx context.Context, auth *provider.Auth, req
 	if err != nil {
 		return resp, err
 	}
+	if body.action != "countTokens" {
+		body.payload = applyGeminiAutoCache(ctx, e.Cfg, e, auth, "models/"+req.Model, body.payload)
+	}
 	endpoint := e.buildEndpoint(req.Model, body.action, opts.Alt)
 	wsReq := &wsrelay.HTTPRequest{
 		Method:  http.MethodPost,
@@ -90,6 +93,9 @@ func (e *AIStudioExecutor) ExecuteStream(ctx context.Context, auth *provider.Aut
 	if err != nil {
 		return nil, err
 	}
+	if body.action != "countTokens" {
+		body.payload = applyGeminiAutoCache(ctx, e.Cfg, e, auth, "models/"+req.Model, body.payload)
+	}
 
 	endpoint := e.buildEndpoint(req.Model, body.action, opts.Alt)
 	wsReq := &wsrelay.HTTPRequest{
@@ -277,6 +283,34 @@ func (e *AIStudioExecutor) CountTokens(ctx context.Context, auth *provider.Auth,
 	return provider.Response{Payload: resp.Body}, nil
 }
 
+// CachedContent proxies a cachedContents API call through the AI Studio relay.
+func (e *AIStudioExecutor) CachedContent(ctx context.Context, auth *provider.Auth, req provider.CachedContentRequest) (provider.Response, error) {
+	endpoint := executor.GeminiDefaultBaseURL + "/" + executor.GeminiGLAPIVersion + "/" + cachedContentPath(req.Name)
+	if len(req.Query) > 0 {
+		endpoint += "?" + req.Query.Encode()
+	}
+	var authID string
+	if auth != nil {
+		authID = auth.ID
+	}
+	resp, err := e.relay.NonStream(ctx, authID, &wsrelay.HTTPRequest{
+		Method:  req.Method,
+		URL:     endpoint,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    req.Body,
+	})
+	if err != nil {
+		return provider.Response{}, err
+	}
+	if resp.Status < 200 || resp.Status >= 300 {
+		return provider.Response{}, executor.NewStatusError(resp.Status, string(resp.Body), nil)
+	}
+	if len(bytes.TrimSpace(resp.Body)) == 0 {
+		return provider.Response{Payload: []byte("{}")}, nil
+	}
+	return provider.Response{Payload: resp.Body}, nil
+}
+
 func (e *AIStudioExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
 	_ = ctx
 	return auth, nil
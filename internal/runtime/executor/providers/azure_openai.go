@@ -0,0 +1,412 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/nghyane/llm-mux/internal/runtime/executor/stream"
+	"github.com/nghyane/llm-mux/internal/sseutil"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/nghyane/llm-mux/internal/translator/to_ir"
+	"github.com/nghyane/llm-mux/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	azureChatAPIVersion      = "2024-10-21"
+	azureResponsesAPIVersion = "2025-04-01-preview"
+	azureEntraScope          = "https://cognitiveservices.azure.com/.default"
+	azureEntraAuthority      = "https://login.microsoftonline.com"
+)
+
+// AzureOpenAIExecutor serves Azure OpenAI deployments. Model names map to
+// deployments through the provider's models list, and requests go to either
+// chat completions or the Responses API depending on wire-api.
+type AzureOpenAIExecutor struct {
+	executor.BaseExecutor
+}
+
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{BaseExecutor: executor.BaseExecutor{Cfg: cfg}}
+}
+
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+// PrepareRequest sets the api-key header, or an Entra ID bearer token when
+// the provider has no key.
+func (e *AzureOpenAIExecutor) PrepareRequest(req *http.Request, auth *provider.Auth) error {
+	if apiKey := executor.AttrStringValue(authAttrs(auth), "api_key"); apiKey != "" {
+		req.Header.Set("api-key", apiKey)
+	} else if prov := e.resolveConfig(auth); prov != nil && prov.EntraID != nil {
+		token, err := e.entraToken(req.Context(), auth, prov.EntraID)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	util.ApplyCustomHeadersFromAttrs(req, authAttrs(auth))
+	return nil
+}
+
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (resp provider.Response, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	target, body, err := e.buildRequest(auth, from, req, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.do(ctx, auth, target, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure-openai executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, executor.ExtractUsageFromOpenAIResponse(data))
+	reporter.EnsurePublished(ctx)
+
+	var translated []byte
+	if e.usesResponses(auth) {
+		translated, err = stream.TranslateResponseNonStream(e.Cfg, provider.FromString("openai-response"), from, data, req.Model)
+	} else {
+		translated, err = e.translateChatResponse(from, data, req.Model)
+	}
+	if err != nil {
+		return resp, err
+	}
+	if translated == nil {
+		translated = data
+	}
+	return provider.Response{Payload: translated}, nil
+}
+
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	target, body, err := e.buildRequest(auth, from, req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.do(ctx, auth, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.usesResponses(auth) {
+		translator := stream.NewStreamTranslator(e.Cfg, from, from.String(), req.Model, "resp-"+req.Model, stream.NewStreamContext())
+		return stream.RunSSEStream(ctx, httpResp.Body, reporter, &codexStreamProcessor{translator: translator}, stream.StreamConfig{
+			ExecutorName:   "azure-openai",
+			SkipEmptyLines: true,
+		}), nil
+	}
+	processor := &azureChatStreamProcessor{
+		translator: stream.NewStreamTranslator(e.Cfg, provider.FromString("openai"), from.String(), req.Model, "chatcmpl-"+req.Model, stream.NewStreamContext()),
+	}
+	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
+		ExecutorName:     "azure-openai",
+		Preprocessor:     stream.DataTagPreprocessor(),
+		HandleDoneSignal: true,
+		EnsurePublished:  true,
+	}), nil
+}
+
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
+	translated, err := stream.TranslateToOpenAI(e.Cfg, opts.SourceFormat, req.Model, req.Payload, false, nil)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	enc, err := executor.TokenizerForModel(req.Model)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("azure-openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := executor.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("azure-openai executor: token counting failed: %w", err)
+	}
+	return provider.Response{Payload: executor.BuildOpenAIUsageJSON(count)}, nil
+}
+
+func (e *AzureOpenAIExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
+
+// buildRequest returns the upstream URL and body. Classic endpoints address
+// the deployment in the path; the v1 API (base URL ending in /openai/v1) and
+// the Responses API take it as the model.
+func (e *AzureOpenAIExecutor) buildRequest(auth *provider.Auth, from provider.Format, req provider.Request, streaming bool) (string, []byte, error) {
+	prov := e.resolveConfig(auth)
+	deployment := configModelName(prov, req.Model)
+	base := strings.TrimSuffix(executor.AttrStringValue(authAttrs(auth), "base_url"), "/")
+	if base == "" {
+		return "", nil, executor.NewStatusError(http.StatusUnauthorized, "missing provider baseURL", nil)
+	}
+	v1 := strings.HasSuffix(base, "/openai/v1")
+	base = strings.TrimSuffix(base, "/openai")
+
+	var body []byte
+	var err error
+	var target, version string
+	if prov != nil {
+		version = prov.APIVersion
+	}
+	if e.usesResponses(auth) {
+		body, err = stream.TranslateToCodex(e.Cfg, from, req.Model, req.Payload, streaming, req.Metadata)
+		if err != nil {
+			return "", nil, err
+		}
+		body = sseutil.ApplyPayloadConfigWithRoot(e.Cfg, req.Model, "codex", "", body)
+		body, _ = sjson.SetBytes(body, "stream", streaming)
+		body, _ = sjson.SetBytes(body, "model", deployment)
+		if v1 {
+			target = base + "/responses"
+		} else {
+			target = base + "/openai/responses"
+			if version == "" {
+				version = azureResponsesAPIVersion
+			}
+		}
+	} else {
+		body, err = stream.TranslateToOpenAI(e.Cfg, from, req.Model, req.Payload, streaming, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		body = sseutil.ApplyPayloadConfigWithRoot(e.Cfg, req.Model, "openai", "", body)
+		body, _ = sjson.SetBytes(body, "stream", streaming)
+		if streaming {
+			body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+		}
+		if v1 {
+			body, _ = sjson.SetBytes(body, "model", deployment)
+			target = base + "/chat/completions"
+		} else {
+			body, _ = sjson.DeleteBytes(body, "model")
+			target = base + "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions"
+			if version == "" {
+				version = azureChatAPIVersion
+			}
+		}
+	}
+	if version != "" {
+		target += "?api-version=" + url.QueryEscape(version)
+	}
+	return target, body, nil
+}
+
+func (e *AzureOpenAIExecutor) do(ctx context.Context, auth *provider.Auth, target string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	executor.SetCommonHeaders(httpReq, "application/json")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpResp, err := e.NewHTTPClient(ctx, auth, 0).Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, executor.NewTimeoutError("request timed out")
+		}
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		result := executor.HandleHTTPError(httpResp, "azure-openai executor")
+		_ = httpResp.Body.Close()
+		return nil, result.Error
+	}
+	return httpResp, nil
+}
+
+// translateChatResponse converts a chat completion, carrying the prompt and
+// completion content filter annotations into the IR.
+func (e *AzureOpenAIExecutor) translateChatResponse(from provider.Format, data []byte, model string) ([]byte, error) {
+	if to := from.String(); to == "openai" || to == "cline" {
+		return data, nil
+	}
+	messages, usage, err := to_ir.ParseOpenAIResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	root := gjson.ParseBytes(data)
+	candidate := ir.CandidateResult{
+		Messages:      messages,
+		FinishReason:  ir.MapOpenAIFinishReason(root.Get("choices.0.finish_reason").String()),
+		ContentFilter: azureContentFilter(root),
+	}
+	return stream.NewResponseTranslator(e.Cfg, from.String(), model).Translate([]ir.CandidateResult{candidate}, usage, nil)
+}
+
+func (e *AzureOpenAIExecutor) usesResponses(auth *provider.Auth) bool {
+	prov := e.resolveConfig(auth)
+	return prov != nil && prov.WireAPI == "responses"
+}
+
+func (e *AzureOpenAIExecutor) resolveConfig(auth *provider.Auth) *config.Provider {
+	return ResolveConfigProvider(e.Cfg, auth, config.ProviderTypeAzureOpenAI)
+}
+
+// azureContentFilter merges the prompt and first choice annotations of a
+// chat completion or stream chunk.
+func azureContentFilter(root gjson.Result) *ir.ContentFilterResult {
+	var result *ir.ContentFilterResult
+	for _, p := range root.Get("prompt_filter_results").Array() {
+		result = result.Merge(ir.ParseAzureContentFilter(p.Get("content_filter_results")))
+	}
+	return result.Merge(ir.ParseAzureContentFilter(root.Get("choices.0.content_filter_results")))
+}
+
+// azureChatStreamProcessor is the OpenAI chat stream processor plus content
+// filter tracking: Azure annotates the prompt in a first chunk without
+// choices and each completion chunk separately, and the merged result is
+// attached to the finish event.
+type azureChatStreamProcessor struct {
+	translator *stream.StreamTranslator
+	filter     *ir.ContentFilterResult
+}
+
+func (p *azureChatStreamProcessor) ProcessLine(line []byte) ([][]byte, *ir.Usage, error) {
+	p.filter = p.filter.Merge(azureContentFilter(gjson.ParseBytes(line)))
+	events, err := to_ir.ParseOpenAIChunk(line)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil, nil
+	}
+	for i := range events {
+		if events[i].Type == ir.EventTypeFinish && p.filter != nil {
+			events[i].ContentFilter = p.filter
+		}
+	}
+	result, err := p.translator.Translate(events)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Chunks, result.Usage, nil
+}
+
+func (p *azureChatStreamProcessor) ProcessDone() ([][]byte, error) {
+	return p.translator.Flush()
+}
+
+// entraTokens caches client credential tokens per app registration. Requests
+// for the same registration share one token request, and the lock is never
+// held across it.
+var entraTokens = struct {
+	sync.Mutex
+	m       map[string]entraToken
+	refresh *executor.TokenRefreshGroup
+}{m: make(map[string]entraToken), refresh: executor.NewTokenRefreshGroup()}
+
+type entraToken struct {
+	value   string
+	expires time.Time
+}
+
+// entraToken returns a cached bearer token for cred, requesting a new one
+// with the client credentials flow when it is missing or about to expire.
+func (e *AzureOpenAIExecutor) entraToken(ctx context.Context, auth *provider.Auth, cred *config.EntraIDCredential) (string, error) {
+	authority, scope := cred.Authority, cred.Scope
+	if authority == "" {
+		authority = azureEntraAuthority
+	}
+	if scope == "" {
+		scope = azureEntraScope
+	}
+	sum := sha256.Sum256([]byte(authority + "|" + cred.TenantID + "|" + cred.ClientID + "|" + cred.ClientSecret + "|" + scope))
+	key := hex.EncodeToString(sum[:])
+
+	cached := func() (string, bool) {
+		entraTokens.Lock()
+		defer entraTokens.Unlock()
+		tok, ok := entraTokens.m[key]
+		return tok.value, ok && time.Until(tok.expires) > time.Minute
+	}
+	if value, ok := cached(); ok {
+		return value, nil
+	}
+	result, err := entraTokens.refresh.Do(key, func(tokenCtx context.Context) (any, error) {
+		if value, ok := cached(); ok {
+			return value, nil
+		}
+		return e.requestEntraToken(ctx, tokenCtx, auth, cred, authority, scope, key)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// requestEntraToken requests a token with the client credentials flow and
+// caches it under key. ctx selects the transport; the request runs on
+// tokenCtx, so that one caller giving up does not fail the others waiting on
+// it.
+func (e *AzureOpenAIExecutor) requestEntraToken(ctx, tokenCtx context.Context, auth *provider.Auth, cred *config.EntraIDCredential, authority, scope, key string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {cred.ClientID},
+		"client_secret": {cred.ClientSecret},
+		"scope":         {scope},
+	}
+	tokenURL := authority + "/" + url.PathEscape(cred.TenantID) + "/oauth2/v2.0/token"
+	httpReq, err := http.NewRequestWithContext(tokenCtx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpResp, err := e.NewHTTPClient(ctx, auth, 0).Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("azure-openai: entra id token request: %w", err)
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", err
+	}
+	root := gjson.ParseBytes(data)
+	if httpResp.StatusCode != http.StatusOK || root.Get("access_token").String() == "" {
+		msg := root.Get("error_description").String()
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+		}
+		status := httpResp.StatusCode
+		if status == http.StatusBadRequest {
+			// Wrong secrets and unknown clients come back as 400 invalid_client.
+			status = http.StatusUnauthorized
+		}
+		return "", executor.NewStatusError(status, "azure-openai: entra id token: "+msg, nil)
+	}
+	tok := entraToken{
+		value:   root.Get("access_token").String(),
+		expires: time.Now().Add(time.Duration(root.Get("expires_in").Int()) * time.Second),
+	}
+	entraTokens.Lock()
+	entraTokens.m[key] = tok
+	entraTokens.Unlock()
+	return tok.value, nil
+}
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor/stream"
+	"github.com/tidwall/gjson"
+)
+
+func TestAzureOpenAIExecutor_DeploymentURLWithEntraID(t *testing.T) {
+	var tokenCalls int
+	var gotPath, gotQuery, gotAuth, gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+			tokenCalls++
+			if err := r.ParseForm(); err != nil || r.Form.Get("client_secret") != "s3cret" {
+				http.Error(w, `{"error":"invalid_client"}`, http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"entra-token","expires_in":3600}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		gotModel = gjson.GetBytes(body, "model").String()
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"},"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: []config.Provider{{
+		Type:    config.ProviderTypeAzureOpenAI,
+		Name:    "azure-eu",
+		BaseURL: srv.URL,
+		EntraID: &config.EntraIDCredential{TenantID: "tenant", ClientID: "app", ClientSecret: "s3cret", Authority: srv.URL},
+		Models:  []config.ProviderModel{{Name: "gpt4o-prod", Alias: "gpt-4o"}},
+	}}}
+	auth := &provider.Auth{ID: "azure-1", Provider: "azure-openai", Label: "azure-eu", Attributes: map[string]string{"base_url": srv.URL}}
+	exec := NewAzureOpenAIExecutor(cfg)
+
+	for i := 0; i < 2; i++ {
+		resp, err := exec.Execute(context.Background(), auth, provider.Request{
+			Model:   "gpt-4o",
+			Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`),
+		}, provider.Options{SourceFormat: provider.FromString("openai")})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if !strings.Contains(string(resp.Payload), "content_filter_results") {
+			t.Errorf("payload = %s, want the annotations kept for openai clients", resp.Payload)
+		}
+	}
+	if gotPath != "/openai/deployments/gpt4o-prod/chat/completions" || gotQuery != "api-version="+azureChatAPIVersion {
+		t.Errorf("request = %s?%s", gotPath, gotQuery)
+	}
+	if gotAuth != "Bearer entra-token" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if gotModel != "" {
+		t.Errorf("model = %q, want it left to the deployment path", gotModel)
+	}
+	if tokenCalls != 1 {
+		t.Errorf("token requests = %d, want the token cached", tokenCalls)
+	}
+}
+
+func TestAzureOpenAIExecutor_EntraTokenNotSerializedAcrossTenants(t *testing.T) {
+	release := make(chan struct{})
+	var slowCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/slow/") {
+			slowCalls.Add(1)
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	slow := &config.EntraIDCredential{TenantID: "slow", ClientID: "app", ClientSecret: "a", Authority: srv.URL}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = exec.entraToken(context.Background(), nil, slow)
+		}()
+	}
+	defer func() {
+		close(release)
+		wg.Wait()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for slowCalls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := exec.entraToken(context.Background(), nil, &config.EntraIDCredential{TenantID: "fast", ClientID: "app", ClientSecret: "b", Authority: srv.URL})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("entraToken: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("token request for one tenant waited on another tenant's request")
+	}
+	if n := slowCalls.Load(); n != 1 {
+		t.Errorf("token requests for the slow tenant = %d, want concurrent callers to share one", n)
+	}
+}
+
+func TestAzureChatStreamProcessor_ContentFilter(t *testing.T) {
+	processor := &azureChatStreamProcessor{
+		translator: stream.NewStreamTranslator(nil, provider.FromString("openai"), "openai", "gpt-4o", "chatcmpl-1", stream.NewStreamContext()),
+	}
+	lines := []string{
+		`{"choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{"jailbreak":{"filtered":false,"detected":false},"hate":{"filtered":false,"severity":"safe"}}}]}`,
+		`{"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":"I can't"},"content_filter_results":{"self_harm":{"filtered":false,"severity":"low"}}}]}`,
+		`{"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"content_filter","content_filter_results":{"self_harm":{"filtered":true,"severity":"high"}}}]}`,
+	}
+	var out []byte
+	for _, line := range lines {
+		chunks, _, err := processor.ProcessLine([]byte(line))
+		if err != nil {
+			t.Fatalf("ProcessLine: %v", err)
+		}
+		for _, c := range chunks {
+			out = append(out, c...)
+		}
+	}
+
+	var finish gjson.Result
+	for _, line := range strings.Split(string(out), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok && gjson.Get(data, "choices.0.finish_reason").String() != "" {
+			finish = gjson.Get(data, "choices.0")
+		}
+	}
+	if finish.Get("finish_reason").String() != "content_filter" {
+		t.Fatalf("no content_filter finish chunk in:\n%s", out)
+	}
+	cf := finish.Get("content_filter_results")
+	if !cf.Get("self_harm.filtered").Bool() || cf.Get("self_harm.severity").String() != "high" {
+		t.Errorf("self_harm = %s, want the blocking rating", cf.Get("self_harm").Raw)
+	}
+	if !cf.Get("hate").Exists() || !cf.Get("jailbreak").Exists() {
+		t.Errorf("content_filter_results = %s, want prompt annotations merged", cf.Raw)
+	}
+}
@@ -0,0 +1,258 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/nghyane/llm-mux/internal/runtime/executor/stream"
+	"github.com/nghyane/llm-mux/internal/sseutil"
+	"github.com/nghyane/llm-mux/internal/translator"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/nghyane/llm-mux/internal/translator/to_ir"
+	"github.com/nghyane/llm-mux/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// BedrockExecutor calls the AWS Bedrock Converse and ConverseStream APIs,
+// signing requests with SigV4 or sending a Bedrock API key.
+type BedrockExecutor struct {
+	executor.BaseExecutor
+}
+
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor {
+	return &BedrockExecutor{BaseExecutor: executor.BaseExecutor{Cfg: cfg}}
+}
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// PrepareRequest sends the api-key as a Bedrock API key, or signs the request
+// with the provider's AWS credentials. Signing covers the body, so it must
+// be replayable (GetBody set, as for bytes readers).
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *provider.Auth) error {
+	util.ApplyCustomHeadersFromAttrs(req, authAttrs(auth))
+	if apiKey := executor.AttrStringValue(authAttrs(auth), "api_key"); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		return nil
+	}
+	prov := e.resolveConfig(auth)
+	if prov == nil {
+		return executor.NewStatusError(http.StatusUnauthorized, "bedrock: provider config not found", nil)
+	}
+	creds, err := resolveAWSCredentials(req.Context(), executor.NewProxyAwareHTTPClient(req.Context(), e.Cfg, auth, 30*time.Second), prov)
+	if err != nil {
+		return executor.NewStatusError(http.StatusUnauthorized, err.Error(), nil)
+	}
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		body, err = io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	signAWSRequest(req, body, creds, prov.Region, "bedrock", time.Now())
+	return nil
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (resp provider.Response, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	target, body, err := e.buildRequest(auth, from, req, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.do(ctx, auth, target, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, err
+	}
+	_, usage, err := to_ir.ParseBedrockResponse(data)
+	if err != nil {
+		return resp, executor.NewStatusError(http.StatusBadGateway, err.Error(), nil)
+	}
+	reporter.Publish(ctx, usage)
+	reporter.EnsurePublished(ctx)
+
+	translated, err := stream.TranslateResponseNonStream(e.Cfg, provider.FromString("bedrock"), from, data, req.Model)
+	if err != nil {
+		return resp, err
+	}
+	if translated == nil {
+		translated = data
+	}
+	return provider.Response{Payload: translated}, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	target, body, err := e.buildRequest(auth, from, req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.do(ctx, auth, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &to_ir.BedrockStreamState{}
+	translator := stream.NewStreamTranslator(e.Cfg, from, from.String(), req.Model, "chatcmpl-"+req.Model, stream.NewStreamContext())
+	processor := stream.NewBaseStreamProcessor(translator, func(frame []byte) ([]ir.UnifiedEvent, error) {
+		headers, payload, err := parseEventFrame(frame)
+		if err != nil {
+			return nil, nil
+		}
+		if headers[":message-type"] == "exception" {
+			msg := gjson.GetBytes(payload, "message").String()
+			if msg == "" {
+				msg = string(payload)
+			}
+			return nil, fmt.Errorf("bedrock %s: %s", headers[":exception-type"], msg)
+		}
+		return to_ir.ParseBedrockStreamEvent(headers[":event-type"], payload, state)
+	})
+	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
+		ExecutorName:    "bedrock",
+		SplitFunc:       splitAWSEventStream,
+		EnsurePublished: true,
+	}), nil
+}
+
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
+	translated, err := stream.TranslateToOpenAI(e.Cfg, opts.SourceFormat, req.Model, req.Payload, false, nil)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	enc, err := executor.TokenizerForModel(req.Model)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("bedrock executor: tokenizer init failed: %w", err)
+	}
+	count, err := executor.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("bedrock executor: token counting failed: %w", err)
+	}
+	return provider.Response{Payload: executor.BuildOpenAIUsageJSON(count)}, nil
+}
+
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
+
+// buildRequest returns the Converse (or ConverseStream) URL for the model's
+// upstream ID and the translated body.
+func (e *BedrockExecutor) buildRequest(auth *provider.Auth, from provider.Format, req provider.Request, streaming bool) (string, []byte, error) {
+	prov := e.resolveConfig(auth)
+	if prov == nil {
+		return "", nil, executor.NewStatusError(http.StatusUnauthorized, "bedrock: provider config not found", nil)
+	}
+	irReq, err := stream.ConvertRequestToIR(from, req.Model, req.Payload, req.Metadata)
+	if err != nil {
+		return "", nil, err
+	}
+	modelID := BedrockUpstreamModel(prov, req.Model)
+	irReq.Model = modelID
+	body, err := translator.ConvertRequest("bedrock", irReq)
+	if err != nil {
+		return "", nil, err
+	}
+	body = sseutil.ApplyPayloadConfigWithRoot(e.Cfg, req.Model, "bedrock", "", body)
+
+	base := strings.TrimSuffix(executor.AttrStringValue(authAttrs(auth), "base_url"), "/")
+	if base == "" {
+		base = "https://bedrock-runtime." + prov.Region + ".amazonaws.com"
+	}
+	action := "/converse"
+	if streaming {
+		action = "/converse-stream"
+	}
+	return base + "/model/" + awsURIEncode(modelID) + action, body, nil
+}
+
+func (e *BedrockExecutor) do(ctx context.Context, auth *provider.Auth, target string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	executor.SetCommonHeaders(httpReq, "application/json")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.NewHTTPClient(ctx, auth, 0).Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, executor.NewTimeoutError("request timed out")
+		}
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		result := executor.HandleHTTPError(httpResp, "bedrock executor")
+		_ = httpResp.Body.Close()
+		return nil, result.Error
+	}
+	return httpResp, nil
+}
+
+func (e *BedrockExecutor) resolveConfig(auth *provider.Auth) *config.Provider {
+	return ResolveConfigProvider(e.Cfg, auth, config.ProviderTypeBedrock)
+}
+
+// bedrockProfilePrefixes are the inference profile prefixes model IDs may
+// already carry.
+var bedrockProfilePrefixes = []string{"us.", "us-gov.", "eu.", "apac.", "jp.", "au.", "ca.", "global."}
+
+// BedrockUpstreamModel maps a client-facing alias to the Bedrock model ID,
+// adding the cross-region inference profile prefix the provider asks for.
+// ARNs and IDs that already name a profile are used unchanged.
+func BedrockUpstreamModel(prov *config.Provider, alias string) string {
+	id := configModelName(prov, alias)
+	if prov == nil || prov.InferenceProfile == "" || strings.HasPrefix(id, "arn:") {
+		return id
+	}
+	for _, p := range bedrockProfilePrefixes {
+		if strings.HasPrefix(id, p) {
+			return id
+		}
+	}
+	if prov.InferenceProfile == "global" {
+		return "global." + id
+	}
+	switch region := prov.Region; {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "us-gov." + id
+	case strings.HasPrefix(region, "us-"):
+		return "us." + id
+	case strings.HasPrefix(region, "eu-"):
+		return "eu." + id
+	case strings.HasPrefix(region, "ap-"):
+		return "apac." + id
+	}
+	return id
+}
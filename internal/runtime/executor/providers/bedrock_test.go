@@ -0,0 +1,127 @@
+package providers
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/tidwall/gjson"
+)
+
+// AWS SigV4 test suite, get-vanilla.
+func TestSignAWSRequest_Vanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBedrockExecutor_ConverseStream(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotBody = r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for _, ev := range [][2]string{
+			{"messageStart", `{"role":"assistant"}`},
+			{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Checking."}}`},
+			{"contentBlockStop", `{"contentBlockIndex":0}`},
+			{"contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tooluse_1","name":"get_weather"}}}`},
+			{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"city\":"}}}`},
+			{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"Paris\"}"}}}`},
+			{"contentBlockStop", `{"contentBlockIndex":1}`},
+			{"messageStop", `{"stopReason":"tool_use"}`},
+			{"metadata", `{"usage":{"inputTokens":20,"outputTokens":9,"totalTokens":29},"metrics":{"latencyMs":100}}`},
+		} {
+			_, _ = w.Write(eventFrame(ev[0], ev[1]))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: []config.Provider{{
+		Type:             config.ProviderTypeBedrock,
+		Name:             "bedrock-us",
+		BaseURL:          srv.URL,
+		Region:           "us-west-2",
+		InferenceProfile: "geo",
+		AWS:              &config.AWSCredential{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		Models:           []config.ProviderModel{{Name: "anthropic.claude-sonnet-4-20250514-v1:0", Alias: "claude-sonnet-4"}},
+	}}}
+	auth := &provider.Auth{ID: "bedrock-1", Provider: "bedrock", Label: "bedrock-us", Attributes: map[string]string{"base_url": srv.URL}}
+	ch, err := NewBedrockExecutor(cfg).ExecuteStream(context.Background(), auth, provider.Request{
+		Model:   "claude-sonnet-4",
+		Payload: []byte(`{"model":"claude-sonnet-4","stream":true,"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Weather in Paris?"}]}`),
+	}, provider.Options{SourceFormat: provider.FromString("openai")})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var out strings.Builder
+	for chunk := range ch {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+
+	if gotPath != "/model/us.anthropic.claude-sonnet-4-20250514-v1%3A0/converse-stream" {
+		t.Errorf("path = %s, want the geo inference profile", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(gotAuth, "/us-west-2/bedrock/aws4_request") {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if gjson.Get(gotBody, "system.0.text").String() != "Be brief." || gjson.Get(gotBody, "messages.0.content.0.text").String() != "Weather in Paris?" {
+		t.Errorf("body = %s", gotBody)
+	}
+
+	text := out.String()
+	for _, want := range []string{`"content":"Checking."`, `"name":"get_weather"`, `{\"city\":\"Paris\"}`, `"finish_reason":"tool_calls"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("stream missing %s:\n%s", want, text)
+		}
+	}
+}
+
+func TestBedrockUpstreamModel(t *testing.T) {
+	prov := &config.Provider{Region: "eu-central-1", InferenceProfile: "geo", Models: []config.ProviderModel{{Name: "anthropic.claude-3-haiku-20240307-v1:0", Alias: "haiku"}}}
+	tests := map[string]string{
+		"haiku":                    "eu.anthropic.claude-3-haiku-20240307-v1:0",
+		"us.amazon.nova-pro-v1:0":  "us.amazon.nova-pro-v1:0",
+		"arn:aws:bedrock:x:y:z/id": "arn:aws:bedrock:x:y:z/id",
+	}
+	for alias, want := range tests {
+		if got := BedrockUpstreamModel(prov, alias); got != want {
+			t.Errorf("BedrockUpstreamModel(%q) = %q, want %q", alias, got, want)
+		}
+	}
+}
+
+// eventFrame encodes an AWS event-stream event frame.
+func eventFrame(eventType, payload string) []byte {
+	var headers []byte
+	for _, h := range [][2]string{{":message-type", "event"}, {":event-type", eventType}, {":content-type", "application/json"}} {
+		headers = append(headers, byte(len(h[0])))
+		headers = append(headers, h[0]...)
+		headers = append(headers, 7)
+		headers = binary.BigEndian.AppendUint16(headers, uint16(len(h[1])))
+		headers = append(headers, h[1]...)
+	}
+	total := 12 + len(headers) + len(payload) + 4
+	frame := binary.BigEndian.AppendUint32(nil, uint32(total))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(headers)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+	frame = append(frame, headers...)
+	frame = append(frame, payload...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
@@ -115,6 +115,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *provider.Auth, req p
 		body = checkSystemInstructions(body)
 	}
 	body = e.ApplyPayloadConfig(req.Model, body)
+	body = e.ApplyPromptCache(req.Model, body)
 
 	body = ensureMaxTokensForThinking(req.Model, body)
 
@@ -141,6 +142,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *provider.Auth, req p
 		}
 		return resp, err
 	}
+	provider.ReportQuotaHeaders(auth, httpResp.Header)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		executor.LogUpstreamError("claude executor", httpResp.StatusCode, executor.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
@@ -221,6 +223,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth,
 		body = checkSystemInstructions(body)
 	}
 	body = e.ApplyPayloadConfig(req.Model, body)
+	body = e.ApplyPromptCache(req.Model, body)
 
 	body = ensureMaxTokensForThinking(req.Model, body)
 
@@ -249,6 +252,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth,
 		}
 		return nil, err
 	}
+	provider.ReportQuotaHeaders(auth, httpResp.Header)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		executor.LogUpstreamError("claude executor", httpResp.StatusCode, executor.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
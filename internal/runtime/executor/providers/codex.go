@@ -76,6 +76,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *provider.Auth, req pr
 		}
 		return resp, err
 	}
+	provider.ReportQuotaHeaders(auth, httpResp.Header)
 	defer func() {
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("codex executor: close response body error: %v", errClose)
@@ -157,6 +158,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth,
 		}
 		return nil, err
 	}
+	provider.ReportQuotaHeaders(auth, httpResp.Header)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		data, readErr := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
@@ -100,7 +100,9 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *provider.Auth, req
 		resp = provider.Response{Payload: data}
 	}
 	reporter.EnsurePublished(ctx)
-	return resp, nil
+	return executor.RepairToolCalls(ctx, e.Cfg, e.Identifier(), from, req, opts, resp, func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error) {
+		return e.Execute(ctx, auth, req, opts)
+	}), nil
 }
 
 func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
@@ -145,6 +147,7 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth
 
 	messageID := uuid.NewString()
 	processor := stream.NewOpenAIStreamProcessor(e.Cfg, from, req.Model, messageID)
+	processor.EnableToolRepair(e.Identifier(), req.Payload)
 
 	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
 		ExecutorName:    "github-copilot executor",
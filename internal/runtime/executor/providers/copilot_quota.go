@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/tidwall/gjson"
+)
+
+const copilotQuotaURL = "https://api.github.com/copilot_internal/user"
+
+// FetchQuota implements provider.QuotaFetcher using the Copilot entitlement
+// endpoint, which reports chat, completions and premium request quotas.
+func (e *CopilotExecutor) FetchQuota(ctx context.Context, auth *provider.Auth) (*provider.RealQuotaSnapshot, error) {
+	accessToken := executor.MetaStringValue(auth.Metadata, "access_token")
+	if accessToken == "" {
+		return nil, executor.NewStatusError(http.StatusUnauthorized, "missing github access token", nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, copilotQuotaURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	resp, err := e.NewHTTPClient(ctx, auth, 0).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, executor.NewStatusError(resp.StatusCode, string(body), nil)
+	}
+	return parseCopilotQuota(body, time.Now()), nil
+}
+
+func parseCopilotQuota(body []byte, now time.Time) *provider.RealQuotaSnapshot {
+	root := gjson.ParseBytes(body)
+	snapshot := &provider.RealQuotaSnapshot{
+		Source:    provider.QuotaSourceCopilot,
+		Tier:      root.Get("copilot_plan").String(),
+		FetchedAt: now,
+	}
+	var resetAt time.Time
+	if v := root.Get("quota_reset_date").String(); v != "" {
+		if t, err := time.Parse(time.DateOnly, v); err == nil {
+			resetAt = t
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			resetAt = t
+		}
+	}
+	root.Get("quota_snapshots").ForEach(func(key, q gjson.Result) bool {
+		w := provider.QuotaWindow{
+			Name:      key.String(),
+			Unlimited: q.Get("unlimited").Bool(),
+			Limit:     q.Get("entitlement").Int(),
+			Remaining: q.Get("remaining").Int(),
+			ResetAt:   resetAt,
+		}
+		if pct := q.Get("percent_remaining"); pct.Exists() {
+			w.RemainingFraction = pct.Float() / 100
+		} else if w.Limit > 0 {
+			w.RemainingFraction = float64(w.Remaining) / float64(w.Limit)
+		}
+		if w.Unlimited {
+			w.RemainingFraction = 1
+		}
+		snapshot.Groups = append(snapshot.Groups, w)
+		return true
+	})
+	sort.Slice(snapshot.Groups, func(i, j int) bool { return snapshot.Groups[i].Name < snapshot.Groups[j].Name })
+
+	// Premium requests only gate premium models; chat availability decides
+	// whether the auth can serve requests at all.
+	snapshot.RemainingFraction, snapshot.WindowResetAt = 1, resetAt
+	for _, w := range snapshot.Groups {
+		if strings.EqualFold(w.Name, "chat") && !w.Unlimited {
+			snapshot.RemainingFraction = w.RemainingFraction
+			snapshot.RemainingTokens = w.Remaining
+		}
+	}
+	return snapshot
+}
+
+var _ provider.QuotaFetcher = (*CopilotExecutor)(nil)
@@ -87,6 +87,9 @@ func (e *GeminiExecutor) Execute(ctx context.Context, auth *provider.Auth, req p
 			action = "countTokens"
 		}
 	}
+	if action == "generateContent" {
+		body = applyGeminiAutoCache(ctx, e.Cfg, e, auth, "models/"+req.Model, body)
+	}
 	baseURL := resolveGeminiBaseURL(auth)
 	ub := executor.GetURLBuilder()
 	defer ub.Release()
@@ -176,6 +179,7 @@ func (e *GeminiExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth,
 	}
 	body = util.StripThinkingConfigIfUnsupported(req.Model, body)
 	body = e.ApplyPayloadConfig(req.Model, body)
+	body = applyGeminiAutoCache(ctx, e.Cfg, e, auth, "models/"+req.Model, body)
 
 	baseURL := resolveGeminiBaseURL(auth)
 	ub := executor.GetURLBuilder()
@@ -378,6 +382,23 @@ func (e *GeminiExecutor) CountTokens(ctx context.Context, auth *provider.Auth, r
 	return provider.Response{Payload: data}, nil
 }
 
+// CachedContent proxies a cachedContents API call to the Gemini API using auth.
+func (e *GeminiExecutor) CachedContent(ctx context.Context, auth *provider.Auth, req provider.CachedContentRequest) (provider.Response, error) {
+	apiKey, bearer := geminiCreds(auth)
+	url := resolveGeminiBaseURL(auth) + "/" + executor.GeminiGLAPIVersion + "/" + cachedContentPath(req.Name)
+	if len(req.Query) > 0 {
+		url += "?" + req.Query.Encode()
+	}
+	return doCachedContentHTTP(ctx, e.NewHTTPClient(ctx, auth, 0), req.Method, url, req.Body, func(httpReq *http.Request) {
+		if apiKey != "" {
+			httpReq.Header.Set("x-goog-api-key", apiKey)
+		} else if bearer != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		applyGeminiHeaders(httpReq, auth)
+	}, "gemini executor")
+}
+
 func (e *GeminiExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
 	if auth == nil {
 		return nil, fmt.Errorf("gemini executor: auth is nil")
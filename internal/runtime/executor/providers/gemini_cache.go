@@ -0,0 +1,261 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	geminiAutoCacheDefaultMinTokens  = 4096
+	geminiAutoCacheDefaultMinRepeats = 2
+	geminiAutoCacheDefaultTTL        = time.Hour
+	geminiAutoCacheDefaultRefresh    = 10 * time.Minute
+	// geminiAutoCacheFailureBackoff suppresses re-creation attempts after a failure.
+	geminiAutoCacheFailureBackoff = 10 * time.Minute
+	// geminiAutoCacheExpiryMargin avoids referencing a cache that expires mid-request.
+	geminiAutoCacheExpiryMargin = 30 * time.Second
+	geminiAutoCacheMaxEntries   = 4096
+)
+
+// cachedContentPath returns the collection or resource path for a cache name.
+func cachedContentPath(name string) string {
+	if id := provider.CachedContentID(name); id != "" {
+		return "cachedContents/" + id
+	}
+	return "cachedContents"
+}
+
+// doCachedContentHTTP sends a cachedContents request and returns the upstream body.
+func doCachedContentHTTP(ctx context.Context, client *http.Client, method, url string, body []byte, applyAuth func(*http.Request), executorName string) (provider.Response, error) {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	executor.SetCommonHeaders(httpReq, "application/json")
+	applyAuth(httpReq)
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return provider.Response{}, executor.NewTimeoutError("request timed out")
+		}
+		return provider.Response{}, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s: close response body error: %v", executorName, errClose)
+		}
+	}()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return provider.Response{}, executor.HandleHTTPError(httpResp, executorName).Error
+	}
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	return provider.Response{Payload: data}, nil
+}
+
+// geminiAutoCacheEntry is a cache created automatically for one auth and prefix.
+type geminiAutoCacheEntry struct {
+	name       string
+	expireTime time.Time
+	refreshing bool
+	// retryAfter is set instead of name when creation failed.
+	retryAfter time.Time
+}
+
+type geminiAutoCacheState struct {
+	mu       sync.Mutex
+	entries  map[string]*geminiAutoCacheEntry
+	seen     map[string]int
+	creating map[string]struct{}
+}
+
+var geminiAutoCaches = &geminiAutoCacheState{
+	entries:  make(map[string]*geminiAutoCacheEntry),
+	seen:     make(map[string]int),
+	creating: make(map[string]struct{}),
+}
+
+// applyGeminiAutoCache replaces a large, repeated systemInstruction/tools prefix
+// with a reference to an automatically created cachedContent owned by auth.
+// The first sightings of a prefix are sent unchanged while a cache is created in
+// the background; caches close to expiry get their TTL extended on use.
+// modelResource is the model name as the upstream expects it in a create call.
+func applyGeminiAutoCache(ctx context.Context, cfg *config.Config, exec provider.CachedContentExecutor, auth *provider.Auth, modelResource string, body []byte) []byte {
+	if cfg == nil || !cfg.GeminiCache.Auto || auth == nil || exec == nil {
+		return body
+	}
+	if gjson.GetBytes(body, "cachedContent").String() != "" {
+		return body
+	}
+	system := gjson.GetBytes(body, "systemInstruction")
+	tools := gjson.GetBytes(body, "tools")
+	toolConfig := gjson.GetBytes(body, "toolConfig")
+	if !system.Exists() && !tools.Exists() {
+		return body
+	}
+	gc := &cfg.GeminiCache
+	minTokens := gc.MinTokens
+	if minTokens <= 0 {
+		minTokens = geminiAutoCacheDefaultMinTokens
+	}
+	if (len(system.Raw)+len(tools.Raw)+3)/4 < minTokens {
+		return body
+	}
+
+	sum := sha256.Sum256([]byte(system.Raw + "\x00" + tools.Raw + "\x00" + toolConfig.Raw))
+	key := auth.ID + "|" + modelResource + "|" + hex.EncodeToString(sum[:])
+	now := time.Now()
+	ttl := parseDurationOr(gc.TTL, geminiAutoCacheDefaultTTL)
+	refreshBefore := parseDurationOr(gc.RefreshBefore, geminiAutoCacheDefaultRefresh)
+	minRepeats := gc.MinRepeats
+	if minRepeats <= 0 {
+		minRepeats = geminiAutoCacheDefaultMinRepeats
+	}
+
+	s := geminiAutoCaches
+	s.mu.Lock()
+	entry := s.entries[key]
+	if entry != nil && entry.name != "" && now.Add(geminiAutoCacheExpiryMargin).Before(entry.expireTime) {
+		name := entry.name
+		if !entry.refreshing && entry.expireTime.Sub(now) < refreshBefore {
+			entry.refreshing = true
+			go s.refresh(context.WithoutCancel(ctx), exec, auth, key, name, ttl)
+		}
+		s.mu.Unlock()
+		out, _ := sjson.DeleteBytes(body, "systemInstruction")
+		out, _ = sjson.DeleteBytes(out, "tools")
+		out, _ = sjson.DeleteBytes(out, "toolConfig")
+		out, _ = sjson.SetBytes(out, "cachedContent", name)
+		return out
+	}
+	if entry != nil && now.Before(entry.retryAfter) {
+		s.mu.Unlock()
+		return body
+	}
+	s.seen[key]++
+	_, pending := s.creating[key]
+	if s.seen[key] >= minRepeats && !pending {
+		s.creating[key] = struct{}{}
+		createBody := []byte(`{}`)
+		createBody, _ = sjson.SetBytes(createBody, "model", modelResource)
+		if system.Exists() {
+			createBody, _ = sjson.SetRawBytes(createBody, "systemInstruction", []byte(system.Raw))
+		}
+		if tools.Exists() {
+			createBody, _ = sjson.SetRawBytes(createBody, "tools", []byte(tools.Raw))
+		}
+		if toolConfig.Exists() {
+			createBody, _ = sjson.SetRawBytes(createBody, "toolConfig", []byte(toolConfig.Raw))
+		}
+		createBody, _ = sjson.SetBytes(createBody, "ttl", formatGeminiTTL(ttl))
+		go s.create(context.WithoutCancel(ctx), exec, auth, key, createBody)
+	}
+	s.mu.Unlock()
+	return body
+}
+
+func (s *geminiAutoCacheState) create(ctx context.Context, exec provider.CachedContentExecutor, auth *provider.Auth, key string, body []byte) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	resp, err := exec.CachedContent(ctx, auth, provider.CachedContentRequest{Method: http.MethodPost, Body: body})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.creating, key)
+	delete(s.seen, key)
+	s.pruneLocked()
+	if err != nil {
+		log.Debugf("gemini auto-cache: create failed for auth %s: %v", auth.ID, err)
+		s.entries[key] = &geminiAutoCacheEntry{retryAfter: time.Now().Add(geminiAutoCacheFailureBackoff)}
+		return
+	}
+	name := gjson.GetBytes(resp.Payload, "name").String()
+	expire, _ := time.Parse(time.RFC3339Nano, gjson.GetBytes(resp.Payload, "expireTime").String())
+	if name == "" || expire.IsZero() {
+		s.entries[key] = &geminiAutoCacheEntry{retryAfter: time.Now().Add(geminiAutoCacheFailureBackoff)}
+		return
+	}
+	log.Debugf("gemini auto-cache: created %s for auth %s (expires %s)", name, auth.ID, expire.Format(time.RFC3339))
+	s.entries[key] = &geminiAutoCacheEntry{name: name, expireTime: expire}
+}
+
+func (s *geminiAutoCacheState) refresh(ctx context.Context, exec provider.CachedContentExecutor, auth *provider.Auth, key, name string, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	body, _ := sjson.SetBytes([]byte(`{}`), "ttl", formatGeminiTTL(ttl))
+	resp, err := exec.CachedContent(ctx, auth, provider.CachedContentRequest{
+		Method: http.MethodPatch,
+		Name:   name,
+		Query:  map[string][]string{"updateMask": {"ttl"}},
+		Body:   body,
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	if entry == nil || entry.name != name {
+		return
+	}
+	entry.refreshing = false
+	if err != nil {
+		log.Debugf("gemini auto-cache: refresh of %s failed: %v", name, err)
+		return
+	}
+	if expire, errParse := time.Parse(time.RFC3339Nano, gjson.GetBytes(resp.Payload, "expireTime").String()); errParse == nil {
+		entry.expireTime = expire
+	}
+}
+
+// pruneLocked drops expired entries once the table grows large. Caller holds s.mu.
+func (s *geminiAutoCacheState) pruneLocked() {
+	if len(s.entries) < geminiAutoCacheMaxEntries && len(s.seen) < geminiAutoCacheMaxEntries {
+		return
+	}
+	now := time.Now()
+	for k, e := range s.entries {
+		if now.After(e.expireTime) && now.After(e.retryAfter) {
+			delete(s.entries, k)
+		}
+	}
+	if len(s.seen) >= geminiAutoCacheMaxEntries {
+		s.seen = make(map[string]int)
+	}
+}
+
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// formatGeminiTTL renders a duration in the protobuf Duration JSON form ("3600s").
+func formatGeminiTTL(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d/time.Second))
+}
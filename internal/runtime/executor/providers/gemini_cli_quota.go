@@ -0,0 +1,108 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/tidwall/gjson"
+)
+
+// FetchQuota implements provider.QuotaFetcher with the Code Assist
+// loadCodeAssist (tier) and retrieveUserQuota (per-model buckets) calls.
+func (e *GeminiCLIExecutor) FetchQuota(ctx context.Context, auth *provider.Auth) (*provider.RealQuotaSnapshot, error) {
+	tokenSource, baseTokenData, err := prepareGeminiCLITokenSource(ctx, e.Cfg, auth)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := tokenSource.Token()
+	if err != nil {
+		return nil, wrapTokenError(err)
+	}
+	updateGeminiCLITokenMetadata(auth, baseTokenData, tok)
+	httpClient := executor.NewProxyAwareHTTPClient(ctx, e.Cfg, auth, 0)
+
+	call := func(method string, payload any) ([]byte, error) {
+		body, errMarshal := json.Marshal(payload)
+		if errMarshal != nil {
+			return nil, errMarshal
+		}
+		url := fmt.Sprintf("%s/%s:%s", codeAssistEndpoint, codeAssistVersion, method)
+		req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if errReq != nil {
+			return nil, errReq
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+		applyGeminiCLIHeaders(req)
+		resp, errDo := httpClient.Do(req)
+		if errDo != nil {
+			return nil, errDo
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, errRead := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if errRead != nil {
+			return nil, errRead
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, newGeminiStatusErr(resp.StatusCode, data)
+		}
+		return data, nil
+	}
+
+	projectID := resolveGeminiProjectID(auth)
+	quota, err := call("retrieveUserQuota", map[string]string{"project": projectID})
+	if err != nil {
+		return nil, err
+	}
+	snapshot := parseGeminiCLIQuota(quota, time.Now())
+	metadata := map[string]string{"ideType": "IDE_UNSPECIFIED", "platform": "PLATFORM_UNSPECIFIED", "pluginType": "GEMINI"}
+	if tier, errTier := call("loadCodeAssist", map[string]any{"cloudaicompanionProject": projectID, "metadata": metadata}); errTier == nil {
+		snapshot.Tier = gjson.GetBytes(tier, "currentTier.name").String()
+		if snapshot.Tier == "" {
+			snapshot.Tier = gjson.GetBytes(tier, "currentTier.id").String()
+		}
+	}
+	return snapshot, nil
+}
+
+func parseGeminiCLIQuota(body []byte, now time.Time) *provider.RealQuotaSnapshot {
+	snapshot := &provider.RealQuotaSnapshot{Source: provider.QuotaSourceGeminiCLI, FetchedAt: now}
+	gjson.GetBytes(body, "buckets").ForEach(func(_, b gjson.Result) bool {
+		name := b.Get("modelId").String()
+		if tt := b.Get("tokenType").String(); tt != "" && tt != "REQUESTS" {
+			name += " (" + tt + ")"
+		}
+		w := provider.QuotaWindow{
+			Name:              name,
+			RemainingFraction: b.Get("remainingFraction").Float(),
+			Remaining:         b.Get("remainingAmount").Int(),
+		}
+		if t, err := time.Parse(time.RFC3339, b.Get("resetTime").String()); err == nil {
+			w.ResetAt = t
+		}
+		snapshot.Groups = append(snapshot.Groups, w)
+		return true
+	})
+	sort.Slice(snapshot.Groups, func(i, j int) bool { return snapshot.Groups[i].Name < snapshot.Groups[j].Name })
+
+	// Buckets are per model, so the auth stays usable while any model has
+	// quota left; per-model exhaustion is handled by 429 cooldowns.
+	snapshot.RemainingFraction = 1
+	for i, w := range snapshot.Groups {
+		if i == 0 || w.RemainingFraction > snapshot.RemainingFraction {
+			snapshot.RemainingFraction = w.RemainingFraction
+			snapshot.WindowResetAt = w.ResetAt
+		}
+	}
+	return snapshot
+}
+
+var _ provider.QuotaFetcher = (*GeminiCLIExecutor)(nil)
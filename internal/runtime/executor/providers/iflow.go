@@ -97,7 +97,9 @@ func (e *IFlowExecutor) Execute(ctx context.Context, auth *provider.Auth, req pr
 	} else {
 		resp = provider.Response{Payload: data}
 	}
-	return resp, nil
+	return executor.RepairToolCalls(ctx, e.Cfg, e.Identifier(), from, req, opts, resp, func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error) {
+		return e.Execute(ctx, auth, req, opts)
+	}), nil
 }
 
 func (e *IFlowExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
@@ -149,6 +151,7 @@ func (e *IFlowExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth,
 
 	messageID := "chatcmpl-" + req.Model
 	processor := stream.NewOpenAIStreamProcessor(e.Cfg, from, req.Model, messageID)
+	processor.EnableToolRepair(e.Identifier(), req.Payload)
 
 	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
 		ExecutorName:    "iflow executor",
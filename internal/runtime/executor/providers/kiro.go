@@ -175,10 +175,18 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *provider.Auth, req pro
 		return provider.Response{}, fmt.Errorf("upstream error %d: %s", resp.StatusCode, string(body))
 	}
 
+	var result provider.Response
 	if hasEventStreamContentType(resp.Header.Get("Content-Type")) {
-		return e.handleEventStreamResponse(resp.Body, req.Model)
+		result, err = e.handleEventStreamResponse(resp.Body, req.Model)
+	} else {
+		result, err = e.handleJSONResponse(resp.Body, req.Model)
 	}
-	return e.handleJSONResponse(resp.Body, req.Model)
+	if err != nil {
+		return result, err
+	}
+	return executor.RepairToolCalls(ctx, e.Cfg, e.Identifier(), provider.FormatOpenAI, req, opts, result, func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error) {
+		return e.Execute(ctx, auth, req, opts)
+	}), nil
 }
 
 func hasEventStreamContentType(contentType string) bool {
@@ -254,11 +262,12 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, r
 	}
 
 	out := make(chan provider.StreamChunk, 32)
-	go e.processStream(ctx, resp, req.Model, out)
+	buffer := stream.NewToolRepairEventBuffer(e.Cfg, e.Identifier(), req.Payload)
+	go e.processStream(ctx, resp, req.Model, buffer, out)
 	return out, nil
 }
 
-func (e *KiroExecutor) processStream(ctx context.Context, resp *http.Response, model string, out chan<- provider.StreamChunk) {
+func (e *KiroExecutor) processStream(ctx context.Context, resp *http.Response, model string, buffer stream.EventBufferStrategy, out chan<- provider.StreamChunk) {
 	defer resp.Body.Close()
 	defer close(out)
 	defer func() {
@@ -276,6 +285,19 @@ func (e *KiroExecutor) processStream(ctx context.Context, resp *http.Response, m
 	state := to_ir.NewKiroStreamState()
 	messageID := "chatcmpl-" + uuid.New().String()
 	idx := 0
+	send := func(ev *ir.UnifiedEvent) bool {
+		for _, buffered := range buffer.Process(ev) {
+			if chunk, _ := from_ir.ToOpenAIChunk(*buffered, model, messageID, idx); len(chunk) > 0 {
+				select {
+				case out <- provider.StreamChunk{Payload: chunk}:
+					idx++
+				case <-ctx.Done():
+					return false
+				}
+			}
+		}
+		return true
+	}
 
 	for scanner.Scan() {
 		select {
@@ -289,25 +311,14 @@ func (e *KiroExecutor) processStream(ctx context.Context, resp *http.Response, m
 			continue
 		}
 		events, _ := state.ProcessChunk(payload)
-		for _, ev := range events {
-			if chunk, _ := from_ir.ToOpenAIChunk(ev, model, messageID, idx); len(chunk) > 0 {
-				select {
-				case out <- provider.StreamChunk{Payload: chunk}:
-					idx++
-				case <-ctx.Done():
-					return
-				}
+		for i := range events {
+			if !send(&events[i]) {
+				return
 			}
 		}
 	}
 
-	finish := ir.UnifiedEvent{Type: ir.EventTypeFinish, FinishReason: state.DetermineFinishReason()}
-	if chunk, _ := from_ir.ToOpenAIChunk(finish, model, messageID, idx); len(chunk) > 0 {
-		select {
-		case out <- provider.StreamChunk{Payload: chunk}:
-		case <-ctx.Done():
-		}
-	}
+	send(&ir.UnifiedEvent{Type: ir.EventTypeFinish, FinishReason: state.DetermineFinishReason()})
 }
 
 func (e *KiroExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
@@ -368,17 +379,76 @@ func splitAWSEventStream(data []byte, atEOF bool) (int, []byte, error) {
 }
 
 func parseEventPayload(frame []byte) ([]byte, error) {
+	_, payload, err := parseEventFrame(frame)
+	if err == nil && len(payload) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+	return payload, err
+}
+
+// parseEventFrame validates an AWS event-stream frame and returns its string
+// headers (such as :event-type and :message-type) and payload.
+func parseEventFrame(frame []byte) (map[string]string, []byte, error) {
 	if len(frame) < 16 {
-		return nil, fmt.Errorf("short frame")
+		return nil, nil, fmt.Errorf("short frame")
 	}
 	if binary.BigEndian.Uint32(frame[8:12]) != crc32.ChecksumIEEE(frame[0:8]) {
-		return nil, fmt.Errorf("crc mismatch")
+		return nil, nil, fmt.Errorf("crc mismatch")
 	}
 	totalLen := int(binary.BigEndian.Uint32(frame[0:4]))
 	headersLen := int(binary.BigEndian.Uint32(frame[4:8]))
 	start, end := 12+headersLen, totalLen-4
-	if start >= end || end > len(frame) {
-		return nil, fmt.Errorf("bounds")
+	if start > end || end > len(frame) {
+		return nil, nil, fmt.Errorf("bounds")
+	}
+	return parseEventHeaders(frame[12:start]), frame[start:end], nil
+}
+
+// parseEventHeaders decodes the string-typed headers of a frame; headers of
+// other types are skipped.
+func parseEventHeaders(b []byte) map[string]string {
+	headers := make(map[string]string, 3)
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			break
+		}
+		name := string(b[1 : 1+nameLen])
+		typ := b[1+nameLen]
+		b = b[2+nameLen:]
+		var n int
+		switch typ {
+		case 0, 1: // bool
+			n = 0
+		case 2: // byte
+			n = 1
+		case 3: // int16
+			n = 2
+		case 4: // int32
+			n = 4
+		case 5, 8: // int64, timestamp
+			n = 8
+		case 9: // uuid
+			n = 16
+		case 6, 7: // bytes, string
+			if len(b) < 2 {
+				return headers
+			}
+			l := int(binary.BigEndian.Uint16(b[0:2]))
+			if len(b) < 2+l {
+				return headers
+			}
+			if typ == 7 {
+				headers[name] = string(b[2 : 2+l])
+			}
+			n = 2 + l
+		default:
+			return headers
+		}
+		if len(b) < n {
+			break
+		}
+		b = b[n:]
 	}
-	return frame[start:end], nil
+	return headers
 }
@@ -0,0 +1,272 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/registry"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+	"github.com/nghyane/llm-mux/internal/runtime/executor/stream"
+	"github.com/nghyane/llm-mux/internal/sseutil"
+	"github.com/nghyane/llm-mux/internal/translator"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/nghyane/llm-mux/internal/translator/to_ir"
+	"github.com/nghyane/llm-mux/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// OllamaExecutor talks to Ollama (and llama.cpp servers exposing the Ollama
+// API) through the native /api/chat endpoint, which streams NDJSON.
+type OllamaExecutor struct {
+	executor.BaseExecutor
+}
+
+func NewOllamaExecutor(cfg *config.Config) *OllamaExecutor {
+	return &OllamaExecutor{BaseExecutor: executor.BaseExecutor{Cfg: cfg}}
+}
+
+func (e *OllamaExecutor) Identifier() string { return "ollama" }
+
+func (e *OllamaExecutor) PrepareRequest(req *http.Request, auth *provider.Auth) error {
+	if apiKey := executor.AttrStringValue(authAttrs(auth), "api_key"); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	util.ApplyCustomHeadersFromAttrs(req, authAttrs(auth))
+	return nil
+}
+
+func (e *OllamaExecutor) Execute(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (resp provider.Response, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	body, err := e.buildChatRequest(auth, from, req, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.doChat(ctx, auth, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, err
+	}
+	_, usage, err := to_ir.ParseOllamaResponse(data)
+	if err != nil {
+		return resp, executor.NewStatusError(http.StatusBadGateway, err.Error(), nil)
+	}
+	reporter.Publish(ctx, usage)
+	reporter.EnsurePublished(ctx)
+
+	translated, err := stream.TranslateResponseNonStream(e.Cfg, provider.FromString("ollama"), from, data, req.Model)
+	if err != nil {
+		return resp, err
+	}
+	if translated == nil {
+		translated = data
+	}
+	return provider.Response{Payload: translated}, nil
+}
+
+func (e *OllamaExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
+	reporter := e.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	body, err := e.buildChatRequest(auth, from, req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.doChat(ctx, auth, body)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &to_ir.OllamaStreamState{}
+	translator := stream.NewStreamTranslator(e.Cfg, from, from.String(), req.Model, "chatcmpl-"+req.Model, stream.NewStreamContext())
+	processor := stream.NewBaseStreamProcessor(translator, func(line []byte) ([]ir.UnifiedEvent, error) {
+		return to_ir.ParseOllamaChunk(line, state)
+	})
+	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
+		ExecutorName:    "ollama",
+		SkipEmptyLines:  true,
+		EnsurePublished: true,
+	}), nil
+}
+
+func (e *OllamaExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
+	translated, err := stream.TranslateToOpenAI(e.Cfg, opts.SourceFormat, req.Model, req.Payload, false, nil)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	enc, err := executor.TokenizerForModel(e.resolveUpstreamModel(req.Model, auth))
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("ollama executor: tokenizer init failed: %w", err)
+	}
+	count, err := executor.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("ollama executor: token counting failed: %w", err)
+	}
+	return provider.Response{Payload: executor.BuildOpenAIUsageJSON(count)}, nil
+}
+
+func (e *OllamaExecutor) Refresh(ctx context.Context, auth *provider.Auth) (*provider.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
+
+// ProbeHealth lists the server's models, which fails fast when the server is
+// down without loading a model into memory.
+func (e *OllamaExecutor) ProbeHealth(ctx context.Context, auth *provider.Auth) error {
+	data, err := e.getTags(ctx, auth, executor.NewProxyAwareHTTPClient(ctx, e.Cfg, auth, 0))
+	if err != nil {
+		return err
+	}
+	if !gjson.GetBytes(data, "models").IsArray() {
+		return executor.NewStatusError(http.StatusBadGateway, "ollama: unexpected /api/tags response", nil)
+	}
+	return nil
+}
+
+// buildChatRequest translates the client payload into an /api/chat body,
+// filling keep_alive and num_ctx from the provider config when the client
+// did not set them.
+func (e *OllamaExecutor) buildChatRequest(auth *provider.Auth, from provider.Format, req provider.Request, streaming bool) ([]byte, error) {
+	irReq, err := stream.ConvertRequestToIR(from, req.Model, req.Payload, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if irReq.Metadata == nil {
+		irReq.Metadata = make(map[string]any)
+	}
+	// Requests from /api/generate clients are sent as chats too.
+	delete(irReq.Metadata, "ollama_endpoint")
+	irReq.Metadata["stream"] = streaming
+	irReq.Model = e.resolveUpstreamModel(req.Model, auth)
+	if prov := e.resolveConfig(auth); prov != nil {
+		if _, ok := irReq.Metadata["ollama_keep_alive"]; !ok && prov.KeepAlive != "" {
+			irReq.Metadata["ollama_keep_alive"] = prov.KeepAlive
+		}
+		if _, ok := irReq.Metadata["ollama_num_ctx"]; !ok && prov.NumCtx > 0 {
+			irReq.Metadata["ollama_num_ctx"] = int64(prov.NumCtx)
+		}
+	}
+	body, err := translator.ConvertRequest("ollama", irReq)
+	if err != nil {
+		return nil, err
+	}
+	return sseutil.ApplyPayloadConfigWithRoot(e.Cfg, req.Model, "ollama", "", body), nil
+}
+
+func (e *OllamaExecutor) doChat(ctx context.Context, auth *provider.Auth, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ollamaBaseURL(auth)+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	executor.SetCommonHeaders(httpReq, "application/json")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.NewHTTPClient(ctx, auth, 0).Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, executor.NewTimeoutError("request timed out")
+		}
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		result := executor.HandleHTTPError(httpResp, "ollama executor")
+		_ = httpResp.Body.Close()
+		return nil, result.Error
+	}
+	return httpResp, nil
+}
+
+func (e *OllamaExecutor) getTags(ctx context.Context, auth *provider.Auth, httpClient *http.Client) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, ollamaBaseURL(auth)+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, executor.HandleHTTPError(httpResp, "ollama executor").Error
+	}
+	return io.ReadAll(httpResp.Body)
+}
+
+// resolveUpstreamModel maps a configured alias to the server's model name.
+func (e *OllamaExecutor) resolveUpstreamModel(alias string, auth *provider.Auth) string {
+	return configModelName(e.resolveConfig(auth), alias)
+}
+
+func (e *OllamaExecutor) resolveConfig(auth *provider.Auth) *config.Provider {
+	return ResolveConfigProvider(e.Cfg, auth, config.ProviderTypeOllama)
+}
+
+// FetchOllamaModels lists the models installed on the server (/api/tags).
+func FetchOllamaModels(ctx context.Context, auth *provider.Auth, cfg *config.Config) []*registry.ModelInfo {
+	e := NewOllamaExecutor(cfg)
+	data, err := e.getTags(ctx, auth, executor.NewProxyAwareHTTPClient(ctx, cfg, auth, 0))
+	if err != nil {
+		log.Warnf("ollama: models request to %s failed: %v", ollamaBaseURL(auth), err)
+		return nil
+	}
+	now := time.Now().Unix()
+	var models []*registry.ModelInfo
+	for _, m := range gjson.GetBytes(data, "models").Array() {
+		name := m.Get("name").String()
+		if name == "" {
+			name = m.Get("model").String()
+		}
+		if name == "" {
+			continue
+		}
+		models = append(models, &registry.ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     "ollama",
+			Type:        "ollama",
+			DisplayName: name,
+			Description: strings.TrimSpace(m.Get("details.parameter_size").String() + " " + m.Get("details.quantization_level").String()),
+		})
+	}
+	return models
+}
+
+func ollamaBaseURL(auth *provider.Auth) string {
+	if base := executor.AttrStringValue(authAttrs(auth), "base_url"); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	return executor.OllamaDefaultBaseURL
+}
+
+func authAttrs(auth *provider.Auth) map[string]string {
+	if auth == nil {
+		return nil
+	}
+	return auth.Attributes
+}
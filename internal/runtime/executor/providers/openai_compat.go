@@ -102,7 +102,9 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *provider.Auth,
 	} else {
 		resp = provider.Response{Payload: body}
 	}
-	return resp, nil
+	return executor.RepairToolCalls(ctx, e.Cfg, e.Identifier(), from, req, opts, resp, func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error) {
+		return e.Execute(ctx, auth, req, opts)
+	}), nil
 }
 
 func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
@@ -158,6 +160,7 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *provider
 
 	messageID := "chatcmpl-" + req.Model
 	processor := stream.NewOpenAIStreamProcessor(e.Cfg, from, req.Model, messageID)
+	processor.EnableToolRepair(e.Identifier(), req.Payload)
 	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
 		ExecutorName:     "openai-compat",
 		Preprocessor:     stream.DataTagPreprocessor(),
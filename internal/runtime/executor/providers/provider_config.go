@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor"
+)
+
+// ResolveConfigProvider finds the providers entry of type typ that a config
+// auth was built from. Keyless providers (local Ollama servers, Azure with
+// Entra ID) cannot be told apart by key, so entries are matched by base URL
+// and display name as well.
+func ResolveConfigProvider(cfg *config.Config, auth *provider.Auth, typ config.ProviderType) *config.Provider {
+	if cfg == nil || auth == nil {
+		return nil
+	}
+	base := executor.AttrStringValue(auth.Attributes, "base_url")
+	key := executor.AttrStringValue(auth.Attributes, "api_key")
+	for i := range cfg.Providers {
+		p := &cfg.Providers[i]
+		if p.Type != typ || p.BaseURL != base || p.GetDisplayName() != auth.Label {
+			continue
+		}
+		keys := p.GetAPIKeys()
+		if key == "" && len(keys) == 0 {
+			return p
+		}
+		for _, k := range keys {
+			if k.Key == key {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// configModelName maps a client-facing alias to the upstream name configured
+// for it, or returns alias unchanged.
+func configModelName(prov *config.Provider, alias string) string {
+	if prov != nil {
+		for _, m := range prov.Models {
+			if m.Alias != "" && strings.EqualFold(m.Alias, alias) {
+				return m.Name
+			}
+		}
+	}
+	return alias
+}
@@ -87,7 +87,9 @@ func (e *QwenExecutor) Execute(ctx context.Context, auth *provider.Auth, req pro
 	} else {
 		resp = provider.Response{Payload: data}
 	}
-	return resp, nil
+	return executor.RepairToolCalls(ctx, e.Cfg, e.Identifier(), from, req, opts, resp, func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error) {
+		return e.Execute(ctx, auth, req, opts)
+	}), nil
 }
 
 func (e *QwenExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (streamChan <-chan provider.StreamChunk, err error) {
@@ -135,6 +137,7 @@ func (e *QwenExecutor) ExecuteStream(ctx context.Context, auth *provider.Auth, r
 
 	messageID := "chatcmpl-" + req.Model
 	processor := stream.NewOpenAIStreamProcessor(e.Cfg, from, req.Model, messageID)
+	processor.EnableToolRepair(e.Identifier(), req.Payload)
 
 	return stream.RunSSEStream(ctx, httpResp.Body, reporter, processor, stream.StreamConfig{
 		ExecutorName:     "qwen executor",
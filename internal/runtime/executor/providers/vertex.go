@@ -22,6 +22,7 @@ import (
 	"github.com/nghyane/llm-mux/internal/translator/ir"
 	"github.com/nghyane/llm-mux/internal/translator/to_ir"
 	"github.com/nghyane/llm-mux/internal/util"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -156,6 +157,10 @@ func (e *VertexExecutor) executeWithStrategy(ctx context.Context, auth *provider
 		}
 	}
 
+	if sa, ok := strategy.(*serviceAccountStrategy); ok && action != "countTokens" {
+		body = e.applyCachedContent(ctx, auth, sa, req.Model, body)
+	}
+
 	url := strategy.BuildURL(req.Model, action, opts)
 	if opts.Alt != "" && action != "countTokens" {
 		url = url + "?$alt=" + opts.Alt
@@ -233,6 +238,9 @@ func (e *VertexExecutor) executeStreamWithStrategy(ctx context.Context, auth *pr
 	}
 	body := translation.Payload
 	body = util.StripThinkingConfigIfUnsupported(req.Model, body)
+	if sa, ok := strategy.(*serviceAccountStrategy); ok {
+		body = e.applyCachedContent(ctx, auth, sa, req.Model, body)
+	}
 
 	url := strategy.BuildURL(req.Model, "streamGenerateContent", opts)
 	if opts.Alt == "" {
@@ -293,6 +301,66 @@ func (e *VertexExecutor) executeStreamWithStrategy(ctx context.Context, auth *pr
 	}), nil
 }
 
+// CachedContent proxies a cachedContents API call to Vertex AI. Only service
+// account credentials are supported since caches are scoped to a project and location.
+func (e *VertexExecutor) CachedContent(ctx context.Context, auth *provider.Auth, req provider.CachedContentRequest) (provider.Response, error) {
+	strategy, err := e.resolveStrategy(auth)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	sa, ok := strategy.(*serviceAccountStrategy)
+	if !ok {
+		return provider.Response{}, executor.NewStatusError(http.StatusNotImplemented, "cached content requires vertex service account credentials", nil)
+	}
+	body := req.Body
+	if req.Method == http.MethodPost {
+		if model := gjson.GetBytes(body, "model").String(); model != "" {
+			body, _ = sjson.SetBytes(body, "model", sa.modelResource(model))
+		}
+	}
+	url := vertexBaseURL(sa.location) + "/" + vertexAPIVersion + "/" + sa.parent() + "/" + cachedContentPath(req.Name)
+	if len(req.Query) > 0 {
+		url += "?" + req.Query.Encode()
+	}
+	token, errTok := sa.GetToken(ctx, e.Cfg, auth)
+	if errTok != nil {
+		log.Errorf("vertex executor: access token error: %v", errTok)
+		return provider.Response{}, executor.NewStatusError(500, "internal server error", nil)
+	}
+	return doCachedContentHTTP(ctx, e.NewHTTPClient(ctx, auth, 0), req.Method, url, body, func(httpReq *http.Request) {
+		sa.ApplyAuth(httpReq, token)
+		applyGeminiHeaders(httpReq, auth)
+	}, "gemini-vertex executor")
+}
+
+// applyCachedContent expands short cache names to full Vertex resource names
+// and applies automatic context caching.
+func (e *VertexExecutor) applyCachedContent(ctx context.Context, auth *provider.Auth, sa *serviceAccountStrategy, model string, body []byte) []byte {
+	if name := gjson.GetBytes(body, "cachedContent").String(); name != "" {
+		if !strings.HasPrefix(name, "projects/") {
+			body, _ = sjson.SetBytes(body, "cachedContent", sa.parent()+"/"+cachedContentPath(name))
+		}
+		return body
+	}
+	return applyGeminiAutoCache(ctx, e.Cfg, e, auth, sa.modelResource(model), body)
+}
+
+func (s *serviceAccountStrategy) parent() string {
+	return "projects/" + s.projectID + "/locations/" + s.location
+}
+
+// modelResource returns the fully qualified publisher model name Vertex expects
+// in cachedContents bodies.
+func (s *serviceAccountStrategy) modelResource(model string) string {
+	if strings.HasPrefix(model, "projects/") {
+		return model
+	}
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+	}
+	return s.parent() + "/publishers/google/models/" + model
+}
+
 func (e *VertexExecutor) CountTokens(ctx context.Context, auth *provider.Auth, req provider.Request, opts provider.Options) (provider.Response, error) {
 	strategy, err := e.resolveStrategy(auth)
 	if err != nil {
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -10,6 +11,8 @@ import (
 	"github.com/nghyane/llm-mux/internal/config"
 	log "github.com/nghyane/llm-mux/internal/logging"
 	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/proxypool"
+	"github.com/nghyane/llm-mux/internal/transport"
 	"golang.org/x/net/proxy"
 )
 
@@ -20,22 +23,49 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *prov
 	}
 
 	var proxyURL string
+	settings := transport.Defaults()
 	if auth != nil {
 		proxyURL = strings.TrimSpace(auth.ProxyURL)
+		settings = transport.For(auth.Provider, auth.Attributes, auth.Metadata)
 	}
 
 	if proxyURL == "" && cfg != nil {
 		proxyURL = strings.TrimSpace(cfg.ProxyURL)
 	}
 
-	if proxyURL != "" {
-		// Use cached transport for proxy URLs to enable connection pooling
-		transport := getCachedTransport(proxyURL)
-		if transport != nil {
-			httpClient.Transport = transport
+	pool, isPool := proxypool.PoolName(proxyURL)
+	if isPool {
+		key := ""
+		if auth != nil {
+			key = auth.ID
+		}
+		member, ok := proxypool.Default().Resolve(pool, key)
+		if !ok {
+			// Fail closed rather than bypass the egress proxy.
+			httpClient.Transport = errorRoundTripper{err: proxypool.UnavailableError(pool)}
+			return httpClient
+		}
+		proxyURL = member
+	}
+
+	if proxyURL != "" || !settings.IsZero() {
+		// Use cached transport for proxy URLs and custom settings to enable connection pooling
+		t, err := getCachedTransport(proxyURL, settings)
+		if err != nil {
+			// Fail closed rather than send requests without the configured
+			// proxy, CA or client certificate.
+			log.Errorf("failed to set up transport (proxy %q): %v", proxyURL, err)
+			httpClient.Transport = errorRoundTripper{err: err}
+			return httpClient
+		}
+		if t != nil {
+			if isPool {
+				httpClient.Transport = &poolRoundTripper{base: t, pool: pool, member: proxyURL}
+			} else {
+				httpClient.Transport = t
+			}
 			return httpClient
 		}
-		log.Debugf("failed to setup proxy from URL: %s, falling back to context transport", proxyURL)
 	}
 
 	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
@@ -47,15 +77,45 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *prov
 	return httpClient
 }
 
-func buildProxyTransport(proxyURLStr string) *http.Transport {
+// poolRoundTripper reports the outcome of requests through a proxy pool
+// member so a failing member is taken out of rotation.
+type poolRoundTripper struct {
+	base   http.RoundTripper
+	pool   string
+	member string
+}
+
+func (p *poolRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := p.base.RoundTrip(req)
+	if err == nil || req.Context().Err() == nil {
+		proxypool.Default().ReportResult(p.pool, p.member, err)
+	}
+	return resp, err
+}
+
+type errorRoundTripper struct {
+	err error
+}
+
+func (e errorRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, e.err
+}
+
+func buildProxyTransport(proxyURLStr string, settings transport.Settings) (*http.Transport, error) {
 	if proxyURLStr == "" {
-		return nil
+		t, err := DirectTransport(settings)
+		if err != nil {
+			return nil, fmt.Errorf("build transport: %w", err)
+		}
+		return t, nil
 	}
 
 	parsedURL, errParse := url.Parse(proxyURLStr)
 	if errParse != nil {
-		log.Errorf("parse proxy URL failed: %v", errParse)
-		return nil
+		return nil, fmt.Errorf("parse proxy URL: %w", errParse)
 	}
 
 	switch parsedURL.Scheme {
@@ -66,16 +126,22 @@ func buildProxyTransport(proxyURLStr string) *http.Transport {
 			password, _ := parsedURL.User.Password()
 			proxyAuth = &proxy.Auth{User: username, Password: password}
 		}
-		dialer, errSOCKS5 := proxy.SOCKS5("tcp", parsedURL.Host, proxyAuth, proxy.Direct)
+		dialer, errSOCKS5 := proxy.SOCKS5("tcp", parsedURL.Host, proxyAuth, newDialer(settings))
 		if errSOCKS5 != nil {
-			log.Errorf("create SOCKS5 dialer failed: %v", errSOCKS5)
-			return nil
+			return nil, fmt.Errorf("create SOCKS5 dialer: %w", errSOCKS5)
 		}
-		return SOCKS5Transport(dialer.Dial)
+		t, errBuild := SOCKS5Transport(dialer.Dial, settings)
+		if errBuild != nil {
+			return nil, fmt.Errorf("build transport: %w", errBuild)
+		}
+		return t, nil
 	case "http", "https":
-		return ProxyTransport(parsedURL)
+		t, errBuild := ProxyTransport(parsedURL, settings)
+		if errBuild != nil {
+			return nil, fmt.Errorf("build transport: %w", errBuild)
+		}
+		return t, nil
 	default:
-		log.Errorf("unsupported proxy scheme: %s", parsedURL.Scheme)
-		return nil
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", parsedURL.Scheme)
 	}
 }
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/transport"
+)
+
+func TestNewProxyAwareHTTPClient_TrustsConfiguredCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Cleanup(ClearTransportCache)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, block, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(auth *provider.Auth) error {
+		client := NewProxyAwareHTTPClient(context.Background(), nil, auth, 5*time.Second)
+		defer ReleaseHTTPClient(client)
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(&provider.Auth{Provider: "claude"}); err == nil {
+		t.Fatal("request without the CA succeeded, want a verification error")
+	}
+	auth := &provider.Auth{
+		Provider:   "claude",
+		Attributes: transport.Settings{CACert: caPath}.Attributes(),
+	}
+	if err := get(auth); err != nil {
+		t.Fatalf("request with the CA: %v", err)
+	}
+}
+
+func TestNewProxyAwareHTTPClient_FailsClosedWithoutCA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Cleanup(ClearTransportCache)
+
+	auth := &provider.Auth{
+		Provider:   "claude",
+		Attributes: transport.Settings{CACert: filepath.Join(t.TempDir(), "missing.pem")}.Attributes(),
+	}
+	client := NewProxyAwareHTTPClient(context.Background(), nil, auth, 5*time.Second)
+	defer ReleaseHTTPClient(client)
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request without the configured CA succeeded, want an error")
+	}
+}
+
+func TestTransportSettings_HTTP2Off(t *testing.T) {
+	off := false
+	tr, err := DirectTransport(transport.Settings{HTTP2: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 {
+		t.Errorf("HTTP/2 still enabled: force=%v next=%v", tr.ForceAttemptHTTP2, tr.TLSNextProto)
+	}
+	tr, err = DirectTransport(transport.Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tr.TLSNextProto["h2"]; !ok {
+		t.Error("HTTP/2 not configured by default")
+	}
+}
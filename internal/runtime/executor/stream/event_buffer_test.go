@@ -41,3 +41,36 @@ func TestPassthroughEventBuffer_FlushReturnsNil(t *testing.T) {
 		t.Error("passthrough buffer flush should return nil")
 	}
 }
+
+func TestToolRepairBuffer_MergesAndRepairsStreamedCalls(t *testing.T) {
+	req := []byte(`{"tools":[{"type":"function","function":{"name":"read_file","parameters":{"type":"object","properties":{"path":{"type":"string"},"limit":{"type":"integer"}},"required":["path"]}}}]}`)
+	buf := NewToolRepairBuffer("qwen", req, true)
+
+	events := []*ir.UnifiedEvent{
+		{Type: ir.EventTypeToken, Content: "Reading"},
+		{Type: ir.EventTypeToolCall, ToolCall: &ir.ToolCall{ID: "call_1", Name: "readFile", Args: `{"path":`}},
+		{Type: ir.EventTypeToolCall, ToolCall: &ir.ToolCall{Args: `"a.go","limit":"5"`}},
+		{Type: ir.EventTypeToolCall, ToolCall: &ir.ToolCall{ID: "call_2", Name: "read_file", Args: `{'path': 'b.go'}`}, ToolCallIndex: 1},
+		{Type: ir.EventTypeFinish, FinishReason: ir.FinishReasonToolCalls},
+	}
+
+	var emitted []*ir.UnifiedEvent
+	for _, ev := range events {
+		emitted = append(emitted, buf.Process(ev)...)
+	}
+	emitted = append(emitted, buf.Flush()...)
+
+	if len(emitted) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(emitted))
+	}
+	if emitted[0].Type != ir.EventTypeToken || emitted[3].Type != ir.EventTypeFinish {
+		t.Errorf("unexpected order: %v ... %v", emitted[0].Type, emitted[3].Type)
+	}
+	first, second := emitted[1].ToolCall, emitted[2].ToolCall
+	if first.ID != "call_1" || first.Name != "read_file" || first.Args != `{"path":"a.go","limit":5}` {
+		t.Errorf("first call = %+v", first)
+	}
+	if second.ID != "call_2" || second.Args != `{"path": "b.go"}` || emitted[2].ToolCallIndex != 1 {
+		t.Errorf("second call = %+v", second)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/nghyane/llm-mux/internal/translator/from_ir"
 	"github.com/nghyane/llm-mux/internal/translator/ir"
 	"github.com/nghyane/llm-mux/internal/translator/to_ir"
+	"github.com/tidwall/gjson"
 )
 
 // =============================================================================
@@ -111,6 +112,33 @@ func parseGeminiResponse(response []byte) (*ParsedResponse, error) {
 	return &ParsedResponse{Candidates: candidates, Usage: usage, Meta: meta}, nil
 }
 
+// parseOllamaResponse parses an Ollama /api/chat response to IR.
+func parseOllamaResponse(response []byte) (*ParsedResponse, error) {
+	messages, usage, err := to_ir.ParseOllamaResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	finish := ir.FinishReasonStop
+	if gjson.GetBytes(response, "done_reason").String() == "length" {
+		finish = ir.FinishReasonMaxTokens
+	} else if len(messages) > 0 && len(messages[0].ToolCalls) > 0 {
+		finish = ir.FinishReasonToolCalls
+	}
+	candidates := []ir.CandidateResult{{Index: 0, Messages: messages, FinishReason: finish}}
+	return &ParsedResponse{Candidates: candidates, Usage: usage}, nil
+}
+
+// parseBedrockResponse parses a Bedrock Converse response to IR.
+func parseBedrockResponse(response []byte) (*ParsedResponse, error) {
+	messages, usage, err := to_ir.ParseBedrockResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	finish := to_ir.BedrockFinishReason(gjson.GetBytes(response, "stopReason").String())
+	candidates := []ir.CandidateResult{{Index: 0, Messages: messages, FinishReason: finish}}
+	return &ParsedResponse{Candidates: candidates, Usage: usage}, nil
+}
+
 // parseSourceResponse parses response based on source format.
 func parseSourceResponse(from string, response []byte) (*ParsedResponse, error) {
 	switch {
@@ -120,6 +148,10 @@ func parseSourceResponse(from string, response []byte) (*ParsedResponse, error)
 		return parseClaudeResponse(response)
 	case provider.IsGeminiFormat(from):
 		return parseGeminiResponse(response)
+	case from == "ollama":
+		return parseOllamaResponse(response)
+	case from == "bedrock":
+		return parseBedrockResponse(response)
 	default:
 		return nil, nil
 	}
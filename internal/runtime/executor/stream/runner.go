@@ -62,6 +62,9 @@ type StreamConfig struct {
 	HandleDoneSignal   bool
 	SkipDoneInData     bool
 	IdleTimeout        time.Duration
+	// SplitFunc replaces line splitting for binary framings such as the AWS
+	// event stream; each token is then passed to the processor as a line.
+	SplitFunc bufio.SplitFunc
 }
 
 func GeminiPreprocessor() StreamPreprocessor {
@@ -166,6 +169,9 @@ func RunSSEStream(
 			maxBufferSize = DefaultStreamBufferSize
 		}
 		scanner.Buffer(*bufPtr, maxBufferSize)
+		if cfg.SplitFunc != nil {
+			scanner.Split(cfg.SplitFunc)
+		}
 
 		for scanner.Scan() {
 			select {
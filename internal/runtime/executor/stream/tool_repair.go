@@ -0,0 +1,141 @@
+package stream
+
+import (
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+)
+
+// ToolRepairBuffer holds streamed tool calls until they are complete, then
+// emits each as a single repaired ToolCall event. Other events pass through;
+// buffered calls are released before the finish or error event.
+type ToolRepairBuffer struct {
+	schema     *ir.ToolSchemaContext
+	fuzzyNames bool
+	provider   string
+	pending    []*ir.UnifiedEvent
+	byIndex    map[int]*ir.UnifiedEvent
+}
+
+// NewToolRepairBuffer creates a buffer that repairs tool calls against the
+// tools declared in originalRequest.
+func NewToolRepairBuffer(providerName string, originalRequest []byte, fuzzyNames bool) *ToolRepairBuffer {
+	b := &ToolRepairBuffer{
+		fuzzyNames: fuzzyNames,
+		provider:   providerName,
+		byIndex:    make(map[int]*ir.UnifiedEvent),
+	}
+	tools := gjson.GetBytes(originalRequest, "tools")
+	if !tools.Exists() {
+		tools = gjson.GetBytes(originalRequest, "request.tools")
+	}
+	b.schema = ir.NewToolSchemaContextFromGJSON(tools.Array())
+	return b
+}
+
+func (b *ToolRepairBuffer) Process(event *ir.UnifiedEvent) []*ir.UnifiedEvent {
+	switch event.Type {
+	case ir.EventTypeToolCall, ir.EventTypeToolCallDelta:
+		if event.ToolCall == nil {
+			return nil
+		}
+		b.add(event)
+		return nil
+	case ir.EventTypeFinish, ir.EventTypeError:
+		return append(b.Flush(), event)
+	default:
+		return []*ir.UnifiedEvent{event}
+	}
+}
+
+func (b *ToolRepairBuffer) Flush() []*ir.UnifiedEvent {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	out := make([]*ir.UnifiedEvent, 0, len(b.pending))
+	for _, ev := range b.pending {
+		out = append(out, b.repair(ev))
+	}
+	b.pending = nil
+	clear(b.byIndex)
+	return out
+}
+
+// add merges a streamed fragment into the call it continues. A ToolCall with
+// a new ID starts a call; one repeating the ID with a name carries the full
+// arguments (Responses API "done" events) and replaces what was accumulated.
+func (b *ToolRepairBuffer) add(event *ir.UnifiedEvent) {
+	tc := event.ToolCall
+	cur := b.byIndex[event.ToolCallIndex]
+	if cur != nil && tc.ID != "" && tc.ID != cur.ToolCall.ID {
+		cur = nil
+	}
+	if cur == nil {
+		if event.Type != ir.EventTypeToolCall {
+			return
+		}
+		call := *tc
+		cur = &ir.UnifiedEvent{Type: ir.EventTypeToolCall, ToolCall: &call, ToolCallIndex: event.ToolCallIndex}
+		b.byIndex[event.ToolCallIndex] = cur
+		b.pending = append(b.pending, cur)
+		return
+	}
+	switch {
+	case event.Type == ir.EventTypeToolCall && tc.ID != "" && tc.Name != "":
+		cur.ToolCall.Name = tc.Name
+		if tc.Args != "" {
+			cur.ToolCall.Args = tc.Args
+		}
+	default:
+		cur.ToolCall.Args += tc.Args
+		if tc.Name != "" && cur.ToolCall.Name == "" {
+			cur.ToolCall.Name = tc.Name
+		}
+	}
+	if len(tc.ThoughtSignature) > 0 {
+		cur.ToolCall.ThoughtSignature = tc.ThoughtSignature
+	}
+}
+
+func (b *ToolRepairBuffer) repair(ev *ir.UnifiedEvent) *ir.UnifiedEvent {
+	tc := ev.ToolCall
+	if b.schema == nil {
+		if fixed, ok := ir.RepairJSON(tc.Args); ok {
+			tc.Args = fixed
+		}
+		return ev
+	}
+	r := b.schema.RepairToolCall(tc.Name, tc.Args, b.fuzzyNames)
+	if r.Changed {
+		log.Debugf("tool repair: %s repaired streamed call %q", b.provider, tc.Name)
+	}
+	if r.Problem != "" {
+		log.Warnf("tool repair: %s returned an unrepairable call to %q: %s", b.provider, tc.Name, r.Problem)
+	}
+	tc.Name, tc.Args = r.Name, r.Args
+	return ev
+}
+
+// NewToolRepairEventBuffer returns a ToolRepairBuffer when cfg.ToolRepair
+// applies to providerName and a passthrough buffer otherwise.
+func NewToolRepairEventBuffer(cfg *config.Config, providerName string, originalRequest []byte) EventBufferStrategy {
+	if cfg == nil || !cfg.ToolRepair.AppliesTo(providerName) {
+		return NewPassthroughEventBuffer()
+	}
+	return NewToolRepairBuffer(providerName, originalRequest, cfg.ToolRepair.FuzzyNames())
+}
+
+// EnableToolRepair buffers and repairs tool calls when cfg.ToolRepair applies
+// to providerName. Arguments are then sent as one chunk per call instead of
+// incrementally.
+func (t *StreamTranslator) EnableToolRepair(providerName string, originalRequest []byte) {
+	if t.cfg != nil && t.cfg.ToolRepair.AppliesTo(providerName) {
+		t.eventBuffer = NewToolRepairEventBuffer(t.cfg, providerName, originalRequest)
+	}
+}
+
+// EnableToolRepair enables tool call repair on the underlying translator.
+func (p *OpenAIStreamProcessor) EnableToolRepair(providerName string, originalRequest []byte) {
+	p.translator.EnableToolRepair(providerName, originalRequest)
+}
@@ -171,6 +171,7 @@ func TranslateToClaude(cfg *config.Config, from provider.Format, model string, p
 	if err != nil {
 		return nil, err
 	}
+	irReq.DeferCacheBreakpoints = sseutil.PromptCacheActive(cfg, model)
 	return translator.ConvertRequest("claude", irReq)
 }
 
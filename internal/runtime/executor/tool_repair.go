@@ -0,0 +1,319 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/json"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// toolRepairAttemptKey counts re-prompts in Options.Metadata so a retried
+// request does not re-prompt again once the budget is spent.
+const toolRepairAttemptKey = "tool_repair_attempt"
+
+// ToolRepairExecuteFunc re-executes a request, typically the executor's own Execute.
+type ToolRepairExecuteFunc func(ctx context.Context, req provider.Request, opts provider.Options) (provider.Response, error)
+
+// RepairToolCalls validates the tool calls in a non-streaming response in
+// format against the tools declared in the request and repairs them in place. When a call is
+// still invalid and cfg.ToolRepair.Reprompt allows it, the request is sent
+// again with a message describing the problems. The best response available
+// is returned; a failed re-prompt never fails the request.
+func RepairToolCalls(ctx context.Context, cfg *config.Config, providerName string, format provider.Format, req provider.Request, opts provider.Options, resp provider.Response, execute ToolRepairExecuteFunc) provider.Response {
+	if cfg == nil || !cfg.ToolRepair.AppliesTo(providerName) || len(resp.Payload) == 0 {
+		return resp
+	}
+	repaired, problems := RepairResponseToolCalls(format, req.Payload, resp.Payload, cfg.ToolRepair.FuzzyNames())
+	resp.Payload = repaired
+	if len(problems) == 0 {
+		return resp
+	}
+	log.Warnf("tool repair: %s returned unrepairable tool calls: %s", providerName, strings.Join(problems, "; "))
+
+	attempt, _ := opts.Metadata[toolRepairAttemptKey].(int)
+	// The failed turn is replayed in the request, so it must be in the
+	// request's format.
+	if execute == nil || attempt >= cfg.ToolRepair.Reprompt || format != opts.SourceFormat {
+		return resp
+	}
+	payload, ok := AppendRepairFeedback(format, req.Payload, resp.Payload, problems)
+	if !ok {
+		return resp
+	}
+	metadata := make(map[string]any, len(opts.Metadata)+1)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata[toolRepairAttemptKey] = attempt + 1
+	opts.Metadata = metadata
+	req.Payload = payload
+
+	retried, err := execute(ctx, req, opts)
+	if err != nil || len(retried.Payload) == 0 {
+		log.Debugf("tool repair: %s re-prompt failed: %v", providerName, err)
+		return resp
+	}
+	return retried
+}
+
+// toolCallSite locates the name and arguments of one tool call in a response.
+// Arguments are either a JSON-encoded string or an embedded object.
+type toolCallSite struct {
+	namePath string
+	argsPath string
+	object   bool
+}
+
+// RepairResponseToolCalls repairs the tool calls of a response in the given
+// client format and returns the updated payload with one message per call that
+// could not be repaired.
+func RepairResponseToolCalls(format provider.Format, originalRequest, payload []byte, fuzzyNames bool) ([]byte, []string) {
+	sites := responseToolCallSites(format, payload)
+	if len(sites) == 0 {
+		return payload, nil
+	}
+	tools := gjson.GetBytes(originalRequest, "tools")
+	if !tools.Exists() {
+		tools = gjson.GetBytes(originalRequest, "request.tools")
+	}
+	schema := ir.NewToolSchemaContextFromGJSON(tools.Array())
+
+	var problems []string
+	for _, site := range sites {
+		name := gjson.GetBytes(payload, site.namePath).String()
+		args := gjson.GetBytes(payload, site.argsPath)
+		raw := args.String()
+		if site.object {
+			raw = args.Raw
+		}
+
+		var r ir.ToolCallRepair
+		if schema != nil {
+			r = schema.RepairToolCall(name, raw, fuzzyNames)
+		} else {
+			r = ir.ToolCallRepair{Name: name, Args: raw}
+			if fixed, ok := ir.RepairJSON(raw); !ok {
+				r.Problem = "arguments are not valid JSON"
+			} else if fixed != raw {
+				r.Args, r.Changed = fixed, true
+			}
+		}
+		if r.Problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, r.Problem))
+		}
+		if !r.Changed {
+			continue
+		}
+		payload, _ = sjson.SetBytes(payload, site.namePath, r.Name)
+		if site.object {
+			payload, _ = sjson.SetRawBytes(payload, site.argsPath, []byte(r.Args))
+		} else {
+			payload, _ = sjson.SetBytes(payload, site.argsPath, r.Args)
+		}
+	}
+	return payload, problems
+}
+
+func responseToolCallSites(format provider.Format, payload []byte) []toolCallSite {
+	root := gjson.ParseBytes(payload)
+	var sites []toolCallSite
+	switch format {
+	case provider.FormatOpenAI, "cline":
+		root.Get("choices").ForEach(func(ci, choice gjson.Result) bool {
+			choice.Get("message.tool_calls").ForEach(func(ti, _ gjson.Result) bool {
+				base := fmt.Sprintf("choices.%d.message.tool_calls.%d.function", ci.Int(), ti.Int())
+				sites = append(sites, toolCallSite{namePath: base + ".name", argsPath: base + ".arguments"})
+				return true
+			})
+			return true
+		})
+	case provider.FormatClaude:
+		root.Get("content").ForEach(func(i, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_use" {
+				base := fmt.Sprintf("content.%d", i.Int())
+				sites = append(sites, toolCallSite{namePath: base + ".name", argsPath: base + ".input", object: true})
+			}
+			return true
+		})
+	case provider.FormatCodex, "openai-response":
+		root.Get("output").ForEach(func(i, item gjson.Result) bool {
+			if item.Get("type").String() == "function_call" {
+				base := fmt.Sprintf("output.%d", i.Int())
+				sites = append(sites, toolCallSite{namePath: base + ".name", argsPath: base + ".arguments"})
+			}
+			return true
+		})
+	case provider.FormatGemini, "gemini-cli":
+		prefix := ""
+		if root.Get("response.candidates").Exists() {
+			prefix = "response."
+		}
+		root.Get(prefix + "candidates").ForEach(func(ci, candidate gjson.Result) bool {
+			candidate.Get("content.parts").ForEach(func(pi, part gjson.Result) bool {
+				if part.Get("functionCall").Exists() {
+					base := fmt.Sprintf("%scandidates.%d.content.parts.%d.functionCall", prefix, ci.Int(), pi.Int())
+					sites = append(sites, toolCallSite{namePath: base + ".name", argsPath: base + ".args", object: true})
+				}
+				return true
+			})
+			return true
+		})
+	case provider.FormatOllama:
+		root.Get("message.tool_calls").ForEach(func(i, _ gjson.Result) bool {
+			base := fmt.Sprintf("message.tool_calls.%d.function", i.Int())
+			sites = append(sites, toolCallSite{namePath: base + ".name", argsPath: base + ".arguments", object: true})
+			return true
+		})
+	}
+	return sites
+}
+
+// toolRepairSkipped is the result reported for each call of the replayed turn.
+const toolRepairSkipped = "Not executed: the call does not match the declared tools."
+
+// AppendRepairFeedback appends the assistant turn of response to a request in
+// the given client format, followed by a result for each of its tool calls
+// reporting that it was not executed and a user message listing problems. It
+// reports false for formats it cannot extend.
+func AppendRepairFeedback(format provider.Format, payload, response []byte, problems []string) ([]byte, bool) {
+	text := "Your previous response contained tool calls that do not match the declared tools:\n- " +
+		strings.Join(problems, "\n- ") +
+		"\nCall the tools again using only declared tool names and arguments that match their schemas."
+
+	// Turns are kept as raw JSON so the replayed response is sent back as
+	// the model produced it.
+	var (
+		path  string
+		turns []string
+	)
+	add := func(v any) {
+		if b, err := json.Marshal(v); err == nil {
+			turns = append(turns, string(b))
+		}
+	}
+	resp := gjson.ParseBytes(response)
+	switch format {
+	case provider.FormatOpenAI, "cline":
+		path = "messages"
+		msg := resp.Get("choices.0.message")
+		if !msg.Exists() {
+			return payload, false
+		}
+		assistant := `{"role":"assistant","content":null}`
+		if content := msg.Get("content"); content.Exists() {
+			assistant, _ = sjson.SetRaw(assistant, "content", content.Raw)
+		}
+		if calls := msg.Get("tool_calls"); calls.IsArray() {
+			assistant, _ = sjson.SetRaw(assistant, "tool_calls", calls.Raw)
+		}
+		turns = append(turns, assistant)
+		msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			add(map[string]any{"role": "tool", "tool_call_id": call.Get("id").String(), "content": toolRepairSkipped})
+			return true
+		})
+		add(map[string]any{"role": "user", "content": text})
+	case provider.FormatOllama:
+		path = "messages"
+		msg := resp.Get("message")
+		if !msg.Exists() {
+			return payload, false
+		}
+		turns = append(turns, msg.Raw)
+		msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			add(map[string]any{"role": "tool", "tool_name": call.Get("function.name").String(), "content": toolRepairSkipped})
+			return true
+		})
+		add(map[string]any{"role": "user", "content": text})
+	case provider.FormatClaude:
+		path = "messages"
+		content := resp.Get("content")
+		if !content.IsArray() {
+			return payload, false
+		}
+		assistant, _ := sjson.SetRaw(`{"role":"assistant"}`, "content", content.Raw)
+		turns = append(turns, assistant)
+		var blocks []any
+		content.ForEach(func(_, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_use" {
+				blocks = append(blocks, map[string]any{"type": "tool_result", "tool_use_id": block.Get("id").String(), "content": toolRepairSkipped, "is_error": true})
+			}
+			return true
+		})
+		blocks = append(blocks, map[string]any{"type": "text", "text": text})
+		add(map[string]any{"role": "user", "content": blocks})
+	case provider.FormatGemini, "gemini-cli":
+		path = "contents"
+		if gjson.GetBytes(payload, "request.contents").Exists() {
+			path = "request.contents"
+		}
+		content := resp.Get("candidates.0.content")
+		if !content.Exists() {
+			content = resp.Get("response.candidates.0.content")
+		}
+		if !content.Get("parts").IsArray() {
+			return payload, false
+		}
+		model, _ := sjson.SetRaw(`{"role":"model"}`, "parts", content.Get("parts").Raw)
+		turns = append(turns, model)
+		var parts []any
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			call := part.Get("functionCall")
+			if !call.Exists() {
+				return true
+			}
+			fr := map[string]any{"name": call.Get("name").String(), "response": map[string]any{"error": toolRepairSkipped}}
+			if id := call.Get("id"); id.Exists() {
+				fr["id"] = id.String()
+			}
+			parts = append(parts, map[string]any{"functionResponse": fr})
+			return true
+		})
+		parts = append(parts, map[string]any{"text": text})
+		add(map[string]any{"role": "user", "parts": parts})
+	case provider.FormatCodex, "openai-response":
+		path = "input"
+		if input := gjson.GetBytes(payload, "input"); input.Type == gjson.String {
+			payload, _ = sjson.SetBytes(payload, "input", []any{map[string]any{"type": "message", "role": "user", "content": input.String()}})
+		}
+		var outputs []map[string]any
+		resp.Get("output").ForEach(func(_, item gjson.Result) bool {
+			switch item.Get("type").String() {
+			case "message", "function_call":
+				// Item IDs only resolve when the response was stored upstream.
+				raw, _ := sjson.Delete(item.Raw, "id")
+				turns = append(turns, raw)
+				if item.Get("type").String() == "function_call" {
+					outputs = append(outputs, map[string]any{"type": "function_call_output", "call_id": item.Get("call_id").String(), "output": toolRepairSkipped})
+				}
+			}
+			return true
+		})
+		if len(turns) == 0 {
+			return payload, false
+		}
+		for _, o := range outputs {
+			add(o)
+		}
+		add(map[string]any{"type": "message", "role": "user", "content": text})
+	default:
+		return payload, false
+	}
+	if !gjson.GetBytes(payload, path).IsArray() {
+		return payload, false
+	}
+	out := payload
+	for _, turn := range turns {
+		var err error
+		if out, err = sjson.SetRawBytes(out, path+".-1", []byte(turn)); err != nil {
+			return payload, false
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/tidwall/gjson"
+)
+
+func TestAppendRepairFeedback_ReplaysFailedTurn(t *testing.T) {
+	problems := []string{"read_fiel: unknown tool"}
+
+	req := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`)
+	resp := []byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_fiel","arguments":"{\"n\":12345678901234567890}"}}]}}]}`)
+	out, ok := AppendRepairFeedback(provider.FormatOpenAI, req, resp, problems)
+	if !ok {
+		t.Fatal("openai feedback not appended")
+	}
+	msgs := gjson.GetBytes(out, "messages")
+	if n := len(msgs.Array()); n != 4 {
+		t.Fatalf("expected 4 messages, got %d: %s", n, out)
+	}
+	if msgs.Get("1.role").String() != "assistant" || msgs.Get("1.tool_calls.0.function.arguments").String() != `{"n":12345678901234567890}` {
+		t.Errorf("assistant turn not replayed: %s", msgs.Get("1").Raw)
+	}
+	if msgs.Get("2.role").String() != "tool" || msgs.Get("2.tool_call_id").String() != "call_1" {
+		t.Errorf("missing tool result: %s", msgs.Get("2").Raw)
+	}
+	if msgs.Get("3.role").String() != "user" {
+		t.Errorf("feedback is not last: %s", msgs.Get("3").Raw)
+	}
+
+	req = []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`)
+	resp = []byte(`{"content":[{"type":"text","text":"ok"},{"type":"tool_use","id":"toolu_1","name":"read_fiel","input":{"n":12345678901234567890}}]}`)
+	out, ok = AppendRepairFeedback(provider.FormatClaude, req, resp, problems)
+	if !ok {
+		t.Fatal("claude feedback not appended")
+	}
+	msgs = gjson.GetBytes(out, "messages")
+	if n := len(msgs.Array()); n != 3 {
+		t.Fatalf("expected 3 messages, got %d: %s", n, out)
+	}
+	if msgs.Get("1.role").String() != "assistant" || msgs.Get("1.content.1.input.n").Raw != "12345678901234567890" {
+		t.Errorf("assistant turn not replayed: %s", msgs.Get("1").Raw)
+	}
+	user := msgs.Get("2.content")
+	if msgs.Get("2.role").String() != "user" || user.Get("0.type").String() != "tool_result" || user.Get("0.tool_use_id").String() != "toolu_1" || user.Get("1.type").String() != "text" {
+		t.Errorf("unexpected feedback turn: %s", msgs.Get("2").Raw)
+	}
+}
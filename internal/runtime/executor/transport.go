@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/nghyane/llm-mux/internal/proxypool"
 	"github.com/nghyane/llm-mux/internal/transport"
 	"golang.org/x/net/http2"
 )
@@ -22,15 +23,15 @@ func configureHTTP2(t *http.Transport) {
 	h2Transport.AllowHTTP = transport.Config.H2AllowHTTP
 }
 
-func newDialer() *net.Dialer {
+func newDialer(s transport.Settings) *net.Dialer {
 	return &net.Dialer{
-		Timeout:   transport.Config.DialTimeout,
+		Timeout:   s.DialTimeoutOrDefault(),
 		KeepAlive: transport.Config.KeepAlive,
 		DualStack: true,
 	}
 }
 
-func baseTransport() *http.Transport {
+func baseTransport(s transport.Settings) (*http.Transport, error) {
 	t := &http.Transport{
 		MaxIdleConns:        transport.Config.MaxIdleConns,
 		MaxIdleConnsPerHost: transport.Config.MaxIdleConnsPerHost,
@@ -51,29 +52,54 @@ func baseTransport() *http.Transport {
 		WriteBufferSize: 64 * 1024,
 		ReadBufferSize:  64 * 1024,
 	}
-	configureHTTP2(t)
-	return t
+	if err := s.Apply(t); err != nil {
+		return nil, err
+	}
+	if s.HTTP2Enabled() {
+		configureHTTP2(t)
+	}
+	return t, nil
 }
 
-var SharedTransport = baseTransport()
+var SharedTransport = func() *http.Transport {
+	t, _ := baseTransport(transport.Settings{})
+	return t
+}()
 
 func init() {
-	SharedTransport.DialContext = newDialer().DialContext
+	SharedTransport.DialContext = newDialer(transport.Settings{}).DialContext
+}
+
+// DirectTransport builds a transport without a proxy for the given settings.
+func DirectTransport(s transport.Settings) (*http.Transport, error) {
+	t, err := baseTransport(s)
+	if err != nil {
+		return nil, err
+	}
+	t.DialContext = newDialer(s).DialContext
+	return t, nil
 }
 
-func ProxyTransport(proxyURL *url.URL) *http.Transport {
-	t := baseTransport()
+func ProxyTransport(proxyURL *url.URL, s transport.Settings) (*http.Transport, error) {
+	t, err := baseTransport(s)
+	if err != nil {
+		return nil, err
+	}
 	t.Proxy = http.ProxyURL(proxyURL)
-	t.DialContext = newDialer().DialContext
-	return t
+	t.OnProxyConnectResponse = proxypool.OnProxyConnectResponse
+	t.DialContext = newDialer(s).DialContext
+	return t, nil
 }
 
-func SOCKS5Transport(dialFunc func(network, addr string) (net.Conn, error)) *http.Transport {
-	t := baseTransport()
+func SOCKS5Transport(dialFunc func(network, addr string) (net.Conn, error), s transport.Settings) (*http.Transport, error) {
+	t, err := baseTransport(s)
+	if err != nil {
+		return nil, err
+	}
 	t.DialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
 		return dialFunc(network, addr)
 	}
-	return t
+	return t, nil
 }
 
 func CloseIdleConnections() {
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/events"
+	"github.com/nghyane/llm-mux/internal/provider"
+)
+
+// The ServiceHook methods below translate provider lifecycle callbacks into
+// notifications on events.Default().
+
+// OnResult implements provider.Hook. Failed results can disable an auth or
+// leave every auth of the provider cooling down.
+func (h *ServiceHook) OnResult(_ context.Context, result provider.Result) {
+	if result.Success || result.AuthID == "" {
+		return
+	}
+	svc := h.service()
+	if svc == nil || svc.coreManager == nil {
+		return
+	}
+	if entry := svc.coreManager.GetAuthEntry(result.AuthID); entry != nil && entry.IsDisabled() {
+		h.trackDisabled(entry.ToAuth(), true)
+	}
+	exhausted, retryAt := svc.coreManager.ProviderExhausted(result.Provider, result.Model)
+	if !exhausted {
+		return
+	}
+	e := events.Event{
+		Type:     events.ProviderExhausted,
+		Severity: events.SeverityCritical,
+		Provider: result.Provider,
+		Model:    result.Model,
+		Message:  fmt.Sprintf("all %s auths are disabled or cooling down", result.Provider),
+	}
+	if !retryAt.IsZero() {
+		e.Details = map[string]any{"next_recovery": retryAt.UTC().Format(time.RFC3339)}
+		e.Message += "; next recovery at " + retryAt.Format(time.Kitchen)
+	}
+	events.Default().Emit(e)
+}
+
+// OnRefreshFailed implements provider.LifecycleHook.
+func (h *ServiceHook) OnRefreshFailed(_ context.Context, auth *provider.Auth, err error) {
+	if auth == nil || err == nil {
+		return
+	}
+	events.Default().Emit(events.Event{
+		Type:     events.RefreshFailed,
+		Severity: events.SeverityWarning,
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Label:    auth.Label,
+		Message:  "token refresh failed: " + err.Error(),
+	})
+}
+
+// OnBreakerStateChange implements provider.LifecycleHook.
+func (h *ServiceHook) OnBreakerStateChange(providerName string, streaming bool, from, to string) {
+	kind := "request"
+	if streaming {
+		kind = "streaming"
+	}
+	e := events.Event{
+		Provider: providerName,
+		Details:  map[string]any{"breaker": kind, "from": from, "to": to},
+		Key:      providerName + "|" + kind,
+	}
+	switch to {
+	case "open":
+		e.Type, e.Severity = events.BreakerOpened, events.SeverityWarning
+		e.Message = fmt.Sprintf("%s circuit breaker for %s opened after repeated failures", kind, providerName)
+	case "closed":
+		e.Type, e.Severity = events.BreakerClosed, events.SeverityInfo
+		e.Message = fmt.Sprintf("%s circuit breaker for %s closed; provider recovered", kind, providerName)
+	default:
+		return
+	}
+	events.Default().Emit(e)
+}
+
+// trackDisabled emits auth.disabled or auth.revoked when an auth becomes
+// disabled. Auths already disabled when first seen are recorded silently.
+func (h *ServiceHook) trackDisabled(auth *provider.Auth, notify bool) {
+	if auth == nil || auth.ID == "" {
+		return
+	}
+	if !auth.Disabled {
+		h.disabled.Delete(auth.ID)
+		return
+	}
+	if _, seen := h.disabled.LoadOrStore(auth.ID, struct{}{}); seen || !notify {
+		return
+	}
+	e := events.Event{
+		Type:     events.AuthDisabled,
+		Severity: events.SeverityWarning,
+		Provider: auth.Provider,
+		AuthID:   auth.ID,
+		Label:    auth.Label,
+		Message:  "auth disabled",
+	}
+	if auth.StatusMessage != "" {
+		e.Message += ": " + auth.StatusMessage
+	}
+	if strings.Contains(auth.StatusMessage, "revoked") {
+		e.Type, e.Severity = events.AuthRevoked, events.SeverityCritical
+		e.Message = "refresh token revoked; re-login required: " + auth.StatusMessage
+	}
+	events.Default().Emit(e)
+}
+
+func (h *ServiceHook) service() *Service {
+	h.svcMu.RLock()
+	defer h.svcMu.RUnlock()
+	return h.svc
+}
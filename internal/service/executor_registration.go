@@ -65,6 +65,12 @@ func registerProviderExecutor(a *provider.Auth, cfg *config.Config, coreManager
 		coreManager.RegisterExecutor(providers.NewKiroExecutor(cfg))
 	case "github-copilot":
 		coreManager.RegisterExecutor(providers.NewCopilotExecutor(cfg))
+	case "ollama":
+		coreManager.RegisterExecutor(providers.NewOllamaExecutor(cfg))
+	case "azure-openai":
+		coreManager.RegisterExecutor(providers.NewAzureOpenAIExecutor(cfg))
+	case "bedrock":
+		coreManager.RegisterExecutor(providers.NewBedrockExecutor(cfg))
 	default:
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
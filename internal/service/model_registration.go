@@ -143,6 +143,42 @@ func registerModelsForAuth(a *provider.Auth, cfg *config.Config, wsGateway *wsre
 	case "github-copilot":
 		models = registry.GetGitHubCopilotModels()
 		models = applyExcludedModels(models, excluded)
+	case "ollama":
+		// Configured models win; otherwise what the server has installed is
+		// listed in the background and registered once found. Until then the
+		// auth registers the last listing, or nothing.
+		entry := providers.ResolveConfigProvider(cfg, a, config.ProviderTypeOllama)
+		if entry != nil {
+			excluded = entry.ExcludedModels
+		}
+		if entry != nil && len(entry.Models) > 0 {
+			ollamaModels.stop(a.ID)
+			models = buildConfigModels(entry, "ollama")
+		} else {
+			id := a.ID
+			models = ollamaModels.watch(a, cfg, func(found []*ModelInfo) {
+				found = applyProviderPriority(applyExcludedModels(found, excluded), "ollama", cfg)
+				if len(found) == 0 {
+					GlobalModelRegistry().UnregisterClient(id)
+					return
+				}
+				GlobalModelRegistry().RegisterClient(id, "ollama", found)
+			})
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Models are deployments, which cannot be listed with a data-plane key.
+		if entry := providers.ResolveConfigProvider(cfg, a, config.ProviderTypeAzureOpenAI); entry != nil {
+			models = applyExcludedModels(buildConfigModels(entry, "azure-openai"), entry.ExcludedModels)
+		}
+	case "bedrock":
+		if entry := providers.ResolveConfigProvider(cfg, a, config.ProviderTypeBedrock); entry != nil {
+			models = buildConfigModels(entry, "bedrock")
+			for _, m := range models {
+				m.UpstreamName = providers.BedrockUpstreamModel(entry, m.ID)
+			}
+			models = applyExcludedModels(models, entry.ExcludedModels)
+		}
 	default:
 		handleOpenAICompatProvider(a, compatProviderKey, compatDisplayName, compatDetected, cfg)
 		return
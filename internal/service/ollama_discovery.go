@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/runtime/executor/providers"
+)
+
+const (
+	// ollamaDiscoveryInterval is how often installed models are listed again,
+	// to pick up models pulled or removed on the server.
+	ollamaDiscoveryInterval = 5 * time.Minute
+	// ollamaDiscoveryRetry is how soon a server that could not be listed is
+	// tried again.
+	ollamaDiscoveryRetry   = 30 * time.Second
+	ollamaDiscoveryTimeout = 15 * time.Second
+)
+
+// ollamaModels discovers the models of ollama auths without configured models.
+var ollamaModels = &ollamaDiscovery{
+	models:   make(map[string][]*ModelInfo),
+	watchers: make(map[string]*ollamaWatcher),
+}
+
+// ollamaDiscovery lists the models installed on ollama servers in the
+// background, so auth registration never waits on a server, and lists them
+// again periodically so that new models and servers that come back are
+// registered without an auth update.
+type ollamaDiscovery struct {
+	mu sync.Mutex
+	// models is the last successful listing per auth ID.
+	models   map[string][]*ModelInfo
+	watchers map[string]*ollamaWatcher
+}
+
+// ollamaWatcher is the discovery loop of one auth and what it was started for.
+type ollamaWatcher struct {
+	cancel  context.CancelFunc
+	version int64
+	cfg     *config.Config
+}
+
+// watch starts discovery for an auth, or restarts it when the auth or config
+// changed, and returns the models found by the last listing, if any. publish
+// is called with every listing that differs from the previous one until the
+// auth is stopped.
+func (d *ollamaDiscovery) watch(a *provider.Auth, cfg *config.Config, publish func([]*ModelInfo)) []*ModelInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w, ok := d.watchers[a.ID]; ok {
+		if w.version == a.MaterialVersion && w.cfg == cfg {
+			return d.models[a.ID]
+		}
+		w.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.watchers[a.ID] = &ollamaWatcher{cancel: cancel, version: a.MaterialVersion, cfg: cfg}
+	go d.run(ctx, a.ID, a.Clone(), cfg, publish)
+	return d.models[a.ID]
+}
+
+func (d *ollamaDiscovery) run(ctx context.Context, id string, a *provider.Auth, cfg *config.Config, publish func([]*ModelInfo)) {
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, ollamaDiscoveryTimeout)
+		models := providers.FetchOllamaModels(fetchCtx, a, cfg)
+		cancel()
+
+		wait := ollamaDiscoveryInterval
+		if models == nil {
+			wait = ollamaDiscoveryRetry
+		} else {
+			d.mu.Lock()
+			// Checked under the lock so that a stopped auth is never
+			// registered again.
+			if ctx.Err() != nil {
+				d.mu.Unlock()
+				return
+			}
+			if !sameModelIDs(d.models[id], models) {
+				log.Debugf("ollama: discovered %d models for %s", len(models), id)
+				d.models[id] = models
+				publish(models)
+			}
+			d.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// stop ends discovery for an auth and forgets its models.
+func (d *ollamaDiscovery) stop(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w, ok := d.watchers[id]; ok {
+		w.cancel()
+		delete(d.watchers, id)
+	}
+	delete(d.models, id)
+}
+
+// stopAll ends discovery for every auth.
+func (d *ollamaDiscovery) stopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, w := range d.watchers {
+		w.cancel()
+		delete(d.watchers, id)
+	}
+}
+
+func sameModelIDs(a, b []*ModelInfo) bool {
+	return slices.EqualFunc(a, b, func(x, y *ModelInfo) bool { return x.ID == y.ID })
+}
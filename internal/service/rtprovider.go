@@ -12,6 +12,7 @@ import (
 
 	"github.com/nghyane/llm-mux/internal/provider"
 	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/proxypool"
 	"golang.org/x/net/proxy"
 )
 
@@ -76,6 +77,13 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *provider.Auth) http.
 		return nil
 	}
 	proxyStr := strings.TrimSpace(auth.ProxyURL)
+	if resolved, ok := proxypool.Default().ResolveURL(proxyStr, auth.ID); ok {
+		proxyStr = resolved
+	} else {
+		// Fail closed rather than fall back to a direct connection.
+		pool, _ := proxypool.PoolName(proxyStr)
+		return proxypool.UnavailableTransport(pool)
+	}
 	if proxyStr == "" {
 		return nil
 	}
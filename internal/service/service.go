@@ -16,8 +16,13 @@ import (
 	"github.com/nghyane/llm-mux/internal/api"
 	"github.com/nghyane/llm-mux/internal/auth/login"
 	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/events"
+	"github.com/nghyane/llm-mux/internal/guardrail"
+	"github.com/nghyane/llm-mux/internal/json"
 	log "github.com/nghyane/llm-mux/internal/logging"
 	"github.com/nghyane/llm-mux/internal/provider"
+	"github.com/nghyane/llm-mux/internal/proxypool"
+	"github.com/nghyane/llm-mux/internal/resilience"
 	"github.com/nghyane/llm-mux/internal/runtime/executor"
 	"github.com/nghyane/llm-mux/internal/transport"
 	"github.com/nghyane/llm-mux/internal/usage"
@@ -53,6 +58,16 @@ type Service struct {
 
 	shutdownOnce sync.Once
 	wsGateway    *wsrelay.Manager
+
+	// healthKey is the health-check section the prober was last started with.
+	healthMu  sync.Mutex
+	healthKey string
+
+	// transportKey and proxyPoolsKey are the transport and proxy-pools
+	// sections last applied.
+	transportMu   sync.Mutex
+	transportKey  string
+	proxyPoolsKey string
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -64,6 +79,15 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// Server returns the HTTP server built by Run, or nil before startup.
+// Hooks.OnAfterStart callbacks can use it to reach the shared routing pipeline.
+func (s *Service) Server() *api.Server {
+	if s == nil {
+		return nil
+	}
+	return s.server
+}
+
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *login.Manager {
 	return login.NewManager(
@@ -278,6 +302,7 @@ func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
 	if s.coreManager == nil {
 		return
 	}
+	ollamaModels.stop(id)
 	GlobalModelRegistry().UnregisterClient(id)
 	if existing, ok := s.coreManager.GetByID(id); ok && existing != nil {
 		existing.Disabled = true
@@ -294,10 +319,205 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	}
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
+}
+
+// applyTransportConfig installs the outbound transport settings and retires
+// cached transports when they change. stream-timeout is the default
+// response-header-timeout.
+func (s *Service) applyTransportConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	global := cfg.Transport.TransportSettings.Settings()
+	if global.ResponseHeaderTimeout == 0 && cfg.StreamTimeout > 0 {
+		global.ResponseHeaderTimeout = time.Duration(cfg.StreamTimeout) * time.Second
+	}
+	providers := make(map[string]transport.Settings, len(cfg.Transport.Providers))
+	for name, ts := range cfg.Transport.Providers {
+		providers[name] = ts.Settings()
+	}
+	raw, _ := json.Marshal(struct {
+		Global    string
+		Providers map[string]string
+	}{global.Key(), settingsKeys(providers)})
+	s.transportMu.Lock()
+	defer s.transportMu.Unlock()
+	if string(raw) == s.transportKey {
+		return
+	}
+	first := s.transportKey == ""
+	s.transportKey = string(raw)
+	for name, ps := range providers {
+		if err := global.Merge(ps).Check(); err != nil {
+			log.Errorf("transport: provider %s: %v", name, err)
+		}
+	}
+	if err := global.Check(); err != nil {
+		log.Errorf("transport: %v", err)
+	}
+	transport.SetDefaults(global, providers)
+	if !first {
+		executor.ClearTransportCache()
+		resilience.RetireTransports()
+		log.Info("transport settings changed, retired cached transports")
+	}
+}
+
+// applyProxyPools configures the egress proxy pools and their health checks
+// when the proxy-pools section changes.
+func (s *Service) applyProxyPools(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	raw, _ := json.Marshal(cfg.ProxyPools)
+	s.transportMu.Lock()
+	defer s.transportMu.Unlock()
+	if string(raw) == s.proxyPoolsKey {
+		return
+	}
+	s.proxyPoolsKey = string(raw)
+	parse := func(name, v string) time.Duration {
+		if strings.TrimSpace(v) == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			log.Warnf("proxy-pools: invalid %s %q, using default", name, v)
+			return 0
+		}
+		return d
+	}
+	pools := make([]proxypool.Config, 0, len(cfg.ProxyPools))
+	for _, p := range cfg.ProxyPools {
+		pools = append(pools, proxypool.Config{
+			Name:          strings.TrimSpace(p.Name),
+			URLs:          p.URLs,
+			CheckURL:      strings.TrimSpace(p.CheckURL),
+			CheckInterval: parse("check-interval", p.CheckInterval),
+			CheckTimeout:  parse("check-timeout", p.CheckTimeout),
+		})
+	}
+	proxypool.Default().Configure(pools)
+}
+
+func settingsKeys(m map[string]transport.Settings) map[string]string {
+	out := make(map[string]string, len(m))
+	for name, s := range m {
+		out[name] = s.Key()
+	}
+	return out
+}
+
+// applyConcurrencyConfig updates the in-flight limits and wait budgets.
+func (s *Service) applyConcurrencyConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	cc := cfg.Concurrency
+	parse := func(name, v string) time.Duration {
+		if strings.TrimSpace(v) == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			log.Warnf("concurrency: invalid %s %q, using default", name, v)
+			return 0
+		}
+		return d
+	}
+	s.coreManager.SetConcurrencyConfig(provider.ConcurrencyConfig{
+		MaxPerAuth:     cc.MaxPerAuth,
+		MaxPerProvider: cc.MaxPerProvider,
+		QueueSize:      cc.QueueSize,
+		MaxWait:        parse("max-queue-wait", cc.MaxQueueWait),
+		CooldownWait:   parse("cooldown-wait", cc.CooldownWait),
+		KeepAlive:      parse("keep-alive-interval", cc.KeepAliveInterval),
+	})
+}
+
+// applyGuardrailsConfig installs the content policies enforced around every
+// request. An invalid configuration keeps the previous policies.
+func (s *Service) applyGuardrailsConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	g, err := guardrail.New(cfg.Guardrails)
+	if err != nil {
+		log.Errorf("guardrails: %v, keeping previous policies", err)
+		return
+	}
+	if g == nil {
+		s.coreManager.SetRequestGuard(nil)
+		return
+	}
+	s.coreManager.SetRequestGuard(g)
+}
 
-	if cfg.StreamTimeout > 0 {
-		transport.Config.ResponseHeaderTimeout = time.Duration(cfg.StreamTimeout) * time.Second
+// applyHedgingConfig updates the request hedging policy.
+func (s *Service) applyHedgingConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
 	}
+	hc := cfg.Hedging
+	parse := func(name, v string) time.Duration {
+		if strings.TrimSpace(v) == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			log.Warnf("hedging: invalid %s %q, using default", name, v)
+			return 0
+		}
+		return d
+	}
+	s.coreManager.SetHedgeConfig(provider.HedgeConfig{
+		Enabled:    hc.Enable,
+		Models:     hc.Models,
+		Percentile: hc.Percentile,
+		MinDelay:   parse("min-delay", hc.MinDelay),
+		MaxDelay:   parse("max-delay", hc.MaxDelay),
+	})
+}
+
+// applyHealthCheckConfig starts, restarts or stops the auth health prober
+// when the health-check section changes.
+func (s *Service) applyHealthCheckConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	raw, _ := json.Marshal(cfg.HealthCheck)
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	if string(raw) == s.healthKey {
+		return
+	}
+	s.healthKey = string(raw)
+	hc := cfg.HealthCheck
+	if !hc.Enable {
+		s.coreManager.StopHealthProbe()
+		return
+	}
+	parse := func(name, v string) time.Duration {
+		if strings.TrimSpace(v) == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			log.Warnf("health-check: invalid %s %q, using default", name, v)
+			return 0
+		}
+		return d
+	}
+	s.coreManager.StartHealthProbe(context.Background(), provider.HealthConfig{
+		Interval:         parse("interval", hc.Interval),
+		RequestInterval:  parse("request-interval", hc.RequestInterval),
+		Timeout:          parse("timeout", hc.Timeout),
+		History:          hc.History,
+		FailureThreshold: hc.FailureThreshold,
+		Concurrency:      hc.Concurrency,
+		Providers:        hc.Providers,
+		Models:           hc.Models,
+	})
 }
 
 func openAICompatInfoFromAuth(a *provider.Auth) (providerKey string, compatName string, ok bool) {
@@ -361,6 +581,9 @@ func (s *Service) Run(ctx context.Context) error {
 
 	usage.StartDefault(ctx)
 
+	events.Default().Configure(s.cfg)
+	usage.RegisterPlugin(events.NewUsageWatcher(events.Default()))
+
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 	defer func() {
@@ -374,6 +597,11 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applyConcurrencyConfig(s.cfg)
+	s.applyHedgingConfig(s.cfg)
+	s.applyTransportConfig(s.cfg)
+	s.applyProxyPools(s.cfg)
+	s.applyGuardrailsConfig(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -446,7 +674,9 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 	case <-time.After(100 * time.Millisecond):
 	}
-	fmt.Printf("API server started successfully on: %d\n", s.cfg.Port)
+	if s.server.Listening() {
+		fmt.Printf("API server started successfully on: %d\n", s.cfg.Port)
+	}
 
 	if s.hooks.OnAfterStart != nil {
 		s.hooks.OnAfterStart(s)
@@ -463,6 +693,13 @@ func (s *Service) Run(ctx context.Context) error {
 			return
 		}
 		s.applyRetryConfig(newCfg)
+		s.applyConcurrencyConfig(newCfg)
+		s.applyHedgingConfig(newCfg)
+		s.applyTransportConfig(newCfg)
+		s.applyProxyPools(newCfg)
+		s.applyGuardrailsConfig(newCfg)
+		s.applyHealthCheckConfig(newCfg)
+		events.Default().Configure(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -501,7 +738,9 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartQuotaPolling(context.Background(), 0)
 	}
+	s.applyHealthCheckConfig(s.cfg)
 
 	select {
 	case <-ctx.Done():
@@ -536,10 +775,13 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopQuotaPolling()
 			if qm := s.coreManager.GetQuotaManager(); qm != nil {
 				qm.Stop()
 			}
 		}
+		proxypool.Default().Stop()
+		ollamaModels.stopAll()
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
 				log.Errorf("failed to stop file watcher: %v", err)
@@ -571,6 +813,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		events.Default().Close()
 	})
 	return shutdownErr
 }
@@ -760,3 +1003,31 @@ func buildClaudeConfigModels(entry *config.Provider) []*ModelInfo {
 	}
 	return out
 }
+
+// buildConfigModels lists the models of a provider entry whose names are
+// upstream names (Ollama models, Azure deployments) and aliases the IDs clients use.
+func buildConfigModels(entry *config.Provider, modelType string) []*ModelInfo {
+	now := time.Now().Unix()
+	out := make([]*ModelInfo, 0, len(entry.Models))
+	seen := make(map[string]struct{}, len(entry.Models))
+	for _, model := range entry.Models {
+		id := model.Alias
+		if id == "" {
+			id = model.Name
+		}
+		key := strings.ToLower(id)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, &ModelInfo{
+			ID:          id,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     entry.GetDisplayName(),
+			Type:        modelType,
+			DisplayName: model.Name,
+		})
+	}
+	return out
+}
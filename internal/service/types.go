@@ -147,6 +147,8 @@ type ServiceHook struct {
 	provider.NoopHook
 	svc   *Service
 	svcMu sync.RWMutex
+	// disabled holds IDs of auths known to be disabled, so transitions are notified once.
+	disabled sync.Map
 }
 
 func NewServiceHook() *ServiceHook {
@@ -160,10 +162,12 @@ func (h *ServiceHook) SetService(svc *Service) {
 }
 
 func (h *ServiceHook) OnAuthRegistered(ctx context.Context, auth *provider.Auth) {
+	h.trackDisabled(auth, false)
 	h.registerModels(auth)
 }
 
 func (h *ServiceHook) OnAuthUpdated(ctx context.Context, auth *provider.Auth) {
+	h.trackDisabled(auth, true)
 	h.registerModels(auth)
 }
 
@@ -0,0 +1,275 @@
+package sseutil
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxCacheBreakpoints is the number of cache_control blocks Anthropic accepts per request.
+	maxCacheBreakpoints = 4
+	// defaultCacheRecentTurns is how many trailing user turns are marked by default.
+	defaultCacheRecentTurns = 2
+	// extendedCacheTTLBeta enables the 1h cache lifetime.
+	extendedCacheTTLBeta = "extended-cache-ttl-2025-04-11"
+)
+
+// defaultCacheMinTokens lists Anthropic's minimum cacheable prompt lengths.
+// Models not listed here use 1024 tokens.
+var defaultCacheMinTokens = []config.PromptCacheMinTokens{
+	{Model: "claude-3-haiku*", Tokens: 2048},
+	{Model: "claude-3-5-haiku*", Tokens: 2048},
+	{Model: "claude-haiku-4-5*", Tokens: 4096},
+	{Model: "claude-opus-4-5*", Tokens: 4096},
+}
+
+// ApplyPromptCachePolicy inserts Anthropic cache_control breakpoints into a Claude
+// Messages payload according to cfg.PromptCache. Breakpoints are placed, in order of
+// priority, on the tool definitions, the system prompt and the last N user turns,
+// skipping any position whose cumulative prefix is shorter than the model's minimum
+// cacheable length. Client-supplied breakpoints are kept and count toward the limit.
+// model is the client-facing model; when empty the payload's model is used.
+func ApplyPromptCachePolicy(cfg *config.Config, model string, payload []byte) []byte {
+	if len(payload) == 0 {
+		return payload
+	}
+	if model == "" {
+		model = gjson.GetBytes(payload, "model").String()
+	}
+	if !PromptCacheActive(cfg, model) {
+		return payload
+	}
+	pc := &cfg.PromptCache
+
+	limit := pc.MaxBreakpoints
+	if limit <= 0 || limit > maxCacheBreakpoints {
+		limit = maxCacheBreakpoints
+	}
+	budget := limit - countCacheBreakpoints(payload)
+	if budget <= 0 {
+		return payload
+	}
+
+	// Minimum lengths are Anthropic's, so they follow the upstream model name.
+	minTokens := PromptCacheMinTokens(cfg, gjson.GetBytes(payload, "model").String())
+	cacheControl := `{"type":"ephemeral"}`
+	extendedTTL := strings.EqualFold(strings.TrimSpace(pc.TTL), "1h")
+	if extendedTTL {
+		cacheControl = `{"type":"ephemeral","ttl":"1h"}`
+	}
+
+	out := payload
+	added := 0
+	prefix := 0
+
+	// Tools come first in Anthropic's cache prefix order: tools, system, messages.
+	if tools := gjson.GetBytes(out, "tools"); tools.IsArray() {
+		items := tools.Array()
+		prefix += estimatePromptTokens(tools.Raw)
+		if len(items) > 0 && !pc.DisableTools && added < budget && prefix >= minTokens && !containsCacheControl(tools) {
+			path := "tools." + strconv.Itoa(len(items)-1) + ".cache_control"
+			if updated, err := sjson.SetRawBytes(out, path, []byte(cacheControl)); err == nil {
+				out = updated
+				added++
+			}
+		}
+	}
+
+	if system := gjson.GetBytes(out, "system"); system.Exists() {
+		prefix += estimatePromptTokens(system.Raw)
+		if !pc.DisableSystem && added < budget && prefix >= minTokens && !containsCacheControl(system) {
+			if updated, ok := setLastBlockCacheControl(out, "system", system, cacheControl); ok {
+				out = updated
+				added++
+			}
+		}
+	}
+
+	recentTurns := pc.RecentTurns
+	if recentTurns == 0 {
+		recentTurns = defaultCacheRecentTurns
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if recentTurns > 0 && added < budget && len(messages) > 0 {
+		// prefixAt[i] is the estimated prompt length up to and including message i.
+		prefixAt := make([]int, len(messages))
+		running := prefix
+		for i, msg := range messages {
+			running += estimatePromptTokens(msg.Get("content").Raw)
+			prefixAt[i] = running
+		}
+		turns := 0
+		for i := len(messages) - 1; i >= 0 && turns < recentTurns && added < budget; i-- {
+			msg := messages[i]
+			if msg.Get("role").String() != "user" {
+				continue
+			}
+			turns++
+			if prefixAt[i] < minTokens || containsCacheControl(msg) {
+				continue
+			}
+			path := "messages." + strconv.Itoa(i) + ".content"
+			if updated, ok := setLastBlockCacheControl(out, path, msg.Get("content"), cacheControl); ok {
+				out = updated
+				added++
+			}
+		}
+	}
+
+	if added > 0 && extendedTTL {
+		out = appendBeta(out, extendedCacheTTLBeta)
+	}
+	return out
+}
+
+// PromptCacheActive reports whether the prompt-cache policy applies to model.
+func PromptCacheActive(cfg *config.Config, model string) bool {
+	if cfg == nil || !cfg.PromptCache.Enabled {
+		return false
+	}
+	return len(cfg.PromptCache.Models) == 0 || matchAnyModelPattern(cfg.PromptCache.Models, model)
+}
+
+// PromptCacheMinTokens returns the minimum cacheable prefix length for model,
+// preferring configured overrides over the built-in table.
+func PromptCacheMinTokens(cfg *config.Config, model string) int {
+	if cfg != nil {
+		for _, rule := range cfg.PromptCache.MinTokens {
+			if rule.Tokens > 0 && MatchModelPattern(rule.Model, model) {
+				return rule.Tokens
+			}
+		}
+	}
+	for _, rule := range defaultCacheMinTokens {
+		if MatchModelPattern(rule.Model, model) {
+			return rule.Tokens
+		}
+	}
+	return 1024
+}
+
+// setLastBlockCacheControl marks the last cacheable block of a content value at path.
+// String content is converted to a single text block first.
+func setLastBlockCacheControl(payload []byte, path string, content gjson.Result, cacheControl string) ([]byte, bool) {
+	if content.Type == gjson.String {
+		if content.String() == "" {
+			return payload, false
+		}
+		block := `{"type":"text","text":` + strconv.Quote(content.String()) + `,"cache_control":` + cacheControl + `}`
+		updated, err := sjson.SetRawBytes(payload, path, []byte("["+block+"]"))
+		return updated, err == nil
+	}
+	if !content.IsArray() {
+		return payload, false
+	}
+	blocks := content.Array()
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if !isCacheableBlock(blocks[i]) {
+			continue
+		}
+		updated, err := sjson.SetRawBytes(payload, path+"."+strconv.Itoa(i)+".cache_control", []byte(cacheControl))
+		return updated, err == nil
+	}
+	return payload, false
+}
+
+// isCacheableBlock reports whether Anthropic accepts cache_control on block.
+// Thinking blocks cannot be marked directly and empty text blocks are rejected.
+func isCacheableBlock(block gjson.Result) bool {
+	switch block.Get("type").String() {
+	case "thinking", "redacted_thinking", "":
+		return false
+	case "text":
+		return block.Get("text").String() != ""
+	default:
+		return true
+	}
+}
+
+// countCacheBreakpoints counts cache_control markers already present in the payload.
+func countCacheBreakpoints(payload []byte) int {
+	count := 0
+	countIn := func(r gjson.Result) {
+		r.ForEach(func(_, item gjson.Result) bool {
+			if item.Get("cache_control").Exists() {
+				count++
+			}
+			return true
+		})
+	}
+	countIn(gjson.GetBytes(payload, "tools"))
+	if system := gjson.GetBytes(payload, "system"); system.IsArray() {
+		countIn(system)
+	}
+	gjson.GetBytes(payload, "messages").ForEach(func(_, msg gjson.Result) bool {
+		if msg.Get("cache_control").Exists() {
+			count++
+		}
+		if content := msg.Get("content"); content.IsArray() {
+			countIn(content)
+		}
+		return true
+	})
+	return count
+}
+
+// containsCacheControl reports whether r or any of its direct children carries cache_control.
+func containsCacheControl(r gjson.Result) bool {
+	if r.IsObject() && r.Get("cache_control").Exists() {
+		return true
+	}
+	if r.IsObject() {
+		if content := r.Get("content"); content.IsArray() {
+			return containsCacheControl(content)
+		}
+		return false
+	}
+	found := false
+	if r.IsArray() {
+		r.ForEach(func(_, item gjson.Result) bool {
+			found = item.Get("cache_control").Exists()
+			return !found
+		})
+	}
+	return found
+}
+
+// estimatePromptTokens approximates the token count of a raw JSON fragment
+// using the common four-characters-per-token heuristic.
+func estimatePromptTokens(raw string) int {
+	return (len(raw) + 3) / 4
+}
+
+func appendBeta(payload []byte, beta string) []byte {
+	betas := gjson.GetBytes(payload, "betas")
+	if betas.IsArray() {
+		for _, b := range betas.Array() {
+			if b.String() == beta {
+				return payload
+			}
+		}
+	} else if betas.Exists() && betas.String() != "" {
+		if betas.String() == beta {
+			return payload
+		}
+		payload, _ = sjson.SetBytes(payload, "betas", []string{betas.String()})
+	}
+	updated, err := sjson.SetBytes(payload, "betas.-1", beta)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+func matchAnyModelPattern(patterns []string, model string) bool {
+	for _, p := range patterns {
+		if MatchModelPattern(p, model) {
+			return true
+		}
+	}
+	return false
+}
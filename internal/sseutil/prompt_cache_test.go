@@ -0,0 +1,106 @@
+package sseutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func promptCacheConfig(pc config.PromptCacheConfig) *config.Config {
+	pc.Enabled = true
+	return &config.Config{PromptCache: pc}
+}
+
+func TestApplyPromptCachePolicy_Disabled(t *testing.T) {
+	payload := []byte(`{"model":"claude-sonnet-4-5","system":"x","messages":[{"role":"user","content":"hi"}]}`)
+	out := ApplyPromptCachePolicy(&config.Config{}, "", payload)
+	if string(out) != string(payload) {
+		t.Fatalf("payload changed while policy disabled: %s", out)
+	}
+}
+
+func TestApplyPromptCachePolicy_PlacesBreakpoints(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 800) // ~2400 estimated tokens
+	payload := []byte(`{"model":"claude-sonnet-4-5",` +
+		`"tools":[{"name":"a","input_schema":{}},{"name":"b","input_schema":{}}],` +
+		`"system":"` + long + `",` +
+		`"messages":[` +
+		`{"role":"user","content":"first"},` +
+		`{"role":"assistant","content":[{"type":"thinking","thinking":"t","signature":"s"},{"type":"text","text":"ok"}]},` +
+		`{"role":"user","content":[{"type":"text","text":"second"}]},` +
+		`{"role":"assistant","content":"reply"},` +
+		`{"role":"user","content":"third"}]}`)
+
+	out := ApplyPromptCachePolicy(promptCacheConfig(config.PromptCacheConfig{}), "", payload)
+
+	if gjson.GetBytes(out, "tools.1.cache_control").Exists() {
+		t.Error("tools prefix is below the minimum length and should not be marked")
+	}
+	if !gjson.GetBytes(out, "system.0.cache_control").Exists() {
+		t.Error("system prompt should be marked")
+	}
+	if !gjson.GetBytes(out, "messages.4.content.0.cache_control").Exists() {
+		t.Error("last user turn should be marked")
+	}
+	if !gjson.GetBytes(out, "messages.2.content.0.cache_control").Exists() {
+		t.Error("previous user turn should be marked")
+	}
+	if gjson.GetBytes(out, "messages.0.content.0.cache_control").Exists() {
+		t.Error("only the last two user turns should be marked by default")
+	}
+	if gjson.GetBytes(out, "messages.1.content.0.cache_control").Exists() {
+		t.Error("thinking blocks must never be marked")
+	}
+	if got := countCacheBreakpoints(out); got != 3 {
+		t.Errorf("breakpoints = %d, want 3", got)
+	}
+}
+
+func TestApplyPromptCachePolicy_RespectsLimit(t *testing.T) {
+	long := strings.Repeat("x", 20000)
+	payload := []byte(`{"model":"claude-sonnet-4-5","system":[` +
+		`{"type":"text","text":"a","cache_control":{"type":"ephemeral"}},` +
+		`{"type":"text","text":"b","cache_control":{"type":"ephemeral"}},` +
+		`{"type":"text","text":"` + long + `","cache_control":{"type":"ephemeral"}}],` +
+		`"messages":[{"role":"user","content":"one"},{"role":"assistant","content":"two"},{"role":"user","content":"three"}]}`)
+
+	out := ApplyPromptCachePolicy(promptCacheConfig(config.PromptCacheConfig{}), "", payload)
+	if got := countCacheBreakpoints(out); got != maxCacheBreakpoints {
+		t.Fatalf("breakpoints = %d, want %d", got, maxCacheBreakpoints)
+	}
+	if !gjson.GetBytes(out, "messages.2.content.0.cache_control").Exists() {
+		t.Error("remaining slot should go to the most recent user turn")
+	}
+}
+
+func TestApplyPromptCachePolicy_ExtendedTTLAddsBeta(t *testing.T) {
+	long := strings.Repeat("x", 20000)
+	payload := []byte(`{"model":"claude-opus-4-1","system":"` + long + `","messages":[{"role":"user","content":"hi"}]}`)
+
+	out := ApplyPromptCachePolicy(promptCacheConfig(config.PromptCacheConfig{TTL: "1h"}), "", payload)
+	if got := gjson.GetBytes(out, "system.0.cache_control.ttl").String(); got != "1h" {
+		t.Fatalf("ttl = %q, want 1h", got)
+	}
+	if got := gjson.GetBytes(out, "betas.0").String(); got != extendedCacheTTLBeta {
+		t.Fatalf("betas = %s, want %s", gjson.GetBytes(out, "betas").Raw, extendedCacheTTLBeta)
+	}
+}
+
+func TestPromptCacheMinTokens(t *testing.T) {
+	cfg := promptCacheConfig(config.PromptCacheConfig{
+		MinTokens: []config.PromptCacheMinTokens{{Model: "claude-sonnet-*", Tokens: 3000}},
+	})
+	cases := map[string]int{
+		"claude-sonnet-4-5":         3000,
+		"claude-3-5-haiku-20241022": 2048,
+		"claude-haiku-4-5-20251001": 4096,
+		"claude-opus-4-1-20250805":  1024,
+	}
+	for model, want := range cases {
+		if got := PromptCacheMinTokens(cfg, model); got != want {
+			t.Errorf("PromptCacheMinTokens(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
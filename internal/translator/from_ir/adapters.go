@@ -85,10 +85,27 @@ func (kiroConverter) ToChunk(event ir.UnifiedEvent, model string) ([]byte, error
 
 func (kiroConverter) Provider() string { return "kiro" }
 
+type bedrockConverter struct{}
+
+func (bedrockConverter) ConvertRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
+	return ToBedrockConverseRequest(req)
+}
+
+func (bedrockConverter) ToResponse(messages []ir.Message, usage *ir.Usage, model string) ([]byte, error) {
+	return ToOpenAIChatCompletion(messages, usage, model, "")
+}
+
+func (bedrockConverter) ToChunk(event ir.UnifiedEvent, model string) ([]byte, error) {
+	return ToOpenAIChunk(event, model, "", 0)
+}
+
+func (bedrockConverter) Provider() string { return "bedrock" }
+
 func init() {
 	translator.RegisterFromIR("gemini", geminiConverter{})
 	translator.RegisterFromIR("claude", claudeConverter{})
 	translator.RegisterFromIR("openai", openaiConverter{})
 	translator.RegisterFromIR("ollama", ollamaConverter{})
 	translator.RegisterFromIR("kiro", kiroConverter{})
+	translator.RegisterFromIR("bedrock", bedrockConverter{})
 }
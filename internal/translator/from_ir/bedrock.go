@@ -0,0 +1,279 @@
+package from_ir
+
+import (
+	"path"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+)
+
+// ToBedrockConverseRequest converts an IR request to a Bedrock Converse (and
+// ConverseStream) request body. The model is addressed in the URL, not the body.
+func ToBedrockConverseRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
+	root := map[string]any{}
+
+	var system []any
+	if req.Instructions != "" {
+		system = append(system, map[string]any{"text": req.Instructions})
+	}
+	var msgs []map[string]any
+	for _, m := range req.Messages {
+		var role string
+		var content []any
+		switch m.Role {
+		case ir.RoleSystem:
+			if text := ir.CombineTextParts(m); text != "" {
+				system = append(system, map[string]any{"text": text})
+			}
+			continue
+		case ir.RoleAssistant:
+			role, content = "assistant", bedrockAssistantContent(m)
+		default:
+			// Tool results are sent in user turns.
+			role, content = "user", bedrockUserContent(m)
+		}
+		if len(content) == 0 {
+			continue
+		}
+		// Converse requires alternating roles, so consecutive turns of the same
+		// role (a tool result followed by user text) are merged.
+		if n := len(msgs); n > 0 && msgs[n-1]["role"] == role {
+			msgs[n-1]["content"] = append(msgs[n-1]["content"].([]any), content...)
+			continue
+		}
+		msgs = append(msgs, map[string]any{"role": role, "content": content})
+	}
+	root["messages"] = msgs
+	if len(system) > 0 {
+		root["system"] = system
+	}
+
+	inference := map[string]any{}
+	if req.MaxTokens != nil {
+		inference["maxTokens"] = *req.MaxTokens
+	}
+	if req.Temperature != nil {
+		inference["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		inference["topP"] = *req.TopP
+	}
+	if len(req.StopSequences) > 0 {
+		inference["stopSequences"] = req.StopSequences
+	}
+	if len(inference) > 0 {
+		root["inferenceConfig"] = inference
+	}
+
+	if toolConfig := bedrockToolConfig(req); toolConfig != nil {
+		root["toolConfig"] = toolConfig
+	}
+
+	// Model-specific parameters outside the Converse schema.
+	extra := map[string]any{}
+	if req.TopK != nil {
+		extra["top_k"] = *req.TopK
+	}
+	if req.Thinking != nil && req.Thinking.IncludeThoughts && req.Thinking.ThinkingBudget != nil && *req.Thinking.ThinkingBudget > 0 {
+		extra["thinking"] = map[string]any{"type": "enabled", "budget_tokens": *req.Thinking.ThinkingBudget}
+	}
+	if len(extra) > 0 {
+		root["additionalModelRequestFields"] = extra
+	}
+
+	return json.Marshal(root)
+}
+
+func bedrockUserContent(m ir.Message) []any {
+	var content []any
+	for _, part := range m.Content {
+		switch part.Type {
+		case ir.ContentTypeText:
+			if part.Text != "" {
+				content = append(content, map[string]any{"text": part.Text})
+			}
+		case ir.ContentTypeImage:
+			if img := bedrockImage(part.Image); img != nil {
+				content = append(content, img)
+			}
+		case ir.ContentTypeFile:
+			if doc := bedrockDocument(part.File); doc != nil {
+				content = append(content, doc)
+			}
+		case ir.ContentTypeToolResult:
+			if part.ToolResult != nil {
+				content = append(content, bedrockToolResult(part.ToolResult))
+			}
+		}
+	}
+	return content
+}
+
+func bedrockAssistantContent(m ir.Message) []any {
+	var content []any
+	for _, part := range m.Content {
+		switch part.Type {
+		case ir.ContentTypeReasoning:
+			if part.Reasoning == "" {
+				continue
+			}
+			text := map[string]any{"text": part.Reasoning}
+			if len(part.ThoughtSignature) > 0 {
+				text["signature"] = string(part.ThoughtSignature)
+			}
+			content = append(content, map[string]any{"reasoningContent": map[string]any{"reasoningText": text}})
+		case ir.ContentTypeRedactedThinking:
+			if part.RedactedData != "" {
+				content = append(content, map[string]any{"reasoningContent": map[string]any{"redactedContent": part.RedactedData}})
+			}
+		case ir.ContentTypeText:
+			if part.Text != "" {
+				content = append(content, map[string]any{"text": part.Text})
+			}
+		}
+	}
+	for _, tc := range m.ToolCalls {
+		content = append(content, map[string]any{"toolUse": map[string]any{
+			"toolUseId": tc.ID, "name": tc.Name, "input": ir.ArgsAsRaw(tc.Args),
+		}})
+	}
+	return content
+}
+
+func bedrockToolResult(tr *ir.ToolResultPart) map[string]any {
+	var content []any
+	if tr.Result != "" {
+		content = append(content, map[string]any{"text": tr.Result})
+	}
+	for _, img := range tr.Images {
+		if b := bedrockImage(img); b != nil {
+			content = append(content, b)
+		}
+	}
+	for _, f := range tr.Files {
+		if b := bedrockDocument(f); b != nil {
+			content = append(content, b)
+		}
+	}
+	if len(content) == 0 {
+		content = append(content, map[string]any{"text": ""})
+	}
+	result := map[string]any{"toolUseId": tr.ToolCallID, "content": content}
+	if tr.IsError {
+		result["status"] = "error"
+	}
+	return map[string]any{"toolResult": result}
+}
+
+// bedrockImage returns an image block for inline or S3 images; Converse
+// cannot fetch http URLs.
+func bedrockImage(img *ir.ImagePart) map[string]any {
+	if img == nil {
+		return nil
+	}
+	var source map[string]any
+	switch {
+	case img.Data != "":
+		source = map[string]any{"bytes": img.Data}
+	case strings.HasPrefix(img.URL, "s3://"):
+		source = map[string]any{"s3Location": map[string]any{"uri": img.URL}}
+	default:
+		return nil
+	}
+	format := strings.TrimPrefix(strings.ToLower(img.MimeType), "image/")
+	switch format {
+	case "jpg", "":
+		format = "jpeg"
+	}
+	return map[string]any{"image": map[string]any{"format": format, "source": source}}
+}
+
+var bedrockDocumentFormats = map[string]string{
+	"application/pdf":    "pdf",
+	"text/csv":           "csv",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+	"text/html":     "html",
+	"text/plain":    "txt",
+	"text/markdown": "md",
+}
+
+// bedrockDocument returns a document block for inline files in a format
+// Converse accepts, or nil.
+func bedrockDocument(f *ir.FilePart) map[string]any {
+	if f == nil || f.FileData == "" {
+		return nil
+	}
+	format := bedrockDocumentFormats[strings.ToLower(f.MimeType)]
+	if format == "" {
+		ext := strings.TrimPrefix(strings.ToLower(path.Ext(f.Filename)), ".")
+		for _, v := range bedrockDocumentFormats {
+			if v == ext {
+				format = ext
+				break
+			}
+		}
+	}
+	if format == "" {
+		return nil
+	}
+	return map[string]any{"document": map[string]any{
+		"format": format,
+		"name":   bedrockDocumentName(f.Filename),
+		"source": map[string]any{"bytes": f.FileData},
+	}}
+}
+
+// bedrockDocumentName strips the extension and any characters Converse
+// rejects in document names (it allows letters, digits, single spaces,
+// hyphens, parentheses and square brackets).
+func bedrockDocumentName(filename string) string {
+	name := strings.TrimSuffix(filename, path.Ext(filename))
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("-()[]", r):
+			b.WriteRune(r)
+			space = false
+		case !space && b.Len() > 0:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if out := strings.TrimSpace(b.String()); out != "" {
+		return out
+	}
+	return "document"
+}
+
+func bedrockToolConfig(req *ir.UnifiedChatRequest) map[string]any {
+	if len(req.Tools) == 0 {
+		return nil
+	}
+	tools := make([]any, 0, len(req.Tools))
+	for _, t := range req.Tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		spec := map[string]any{"name": t.Name, "inputSchema": map[string]any{"json": schema}}
+		if t.Description != "" {
+			spec["description"] = t.Description
+		}
+		tools = append(tools, map[string]any{"toolSpec": spec})
+	}
+	toolConfig := map[string]any{"tools": tools}
+	switch {
+	case req.ToolChoiceFunction != "":
+		toolConfig["toolChoice"] = map[string]any{"tool": map[string]any{"name": req.ToolChoiceFunction}}
+	case req.ToolChoice == "required" || req.ToolChoice == "any":
+		toolConfig["toolChoice"] = map[string]any{"any": map[string]any{}}
+	case req.ToolChoice == "auto":
+		toolConfig["toolChoice"] = map[string]any{"auto": map[string]any{}}
+	}
+	return toolConfig
+}
@@ -0,0 +1,66 @@
+package from_ir
+
+import (
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+)
+
+func TestToBedrockConverseRequest(t *testing.T) {
+	req := &ir.UnifiedChatRequest{
+		Model: "anthropic.claude-sonnet-4-20250514-v1:0",
+		Messages: []ir.Message{
+			{Role: ir.RoleSystem, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: "Be brief."}}},
+			{Role: ir.RoleUser, Content: []ir.ContentPart{
+				{Type: ir.ContentTypeText, Text: "Summarize this"},
+				{Type: ir.ContentTypeImage, Image: &ir.ImagePart{MimeType: "image/jpg", Data: "aW1n"}},
+				{Type: ir.ContentTypeFile, File: &ir.FilePart{Filename: "Q3 report (final).pdf", FileData: "cGRm"}},
+			}},
+			{
+				Role: ir.RoleAssistant,
+				Content: []ir.ContentPart{
+					{Type: ir.ContentTypeReasoning, Reasoning: "Need the weather.", ThoughtSignature: []byte("sig")},
+				},
+				ToolCalls: []ir.ToolCall{{ID: "tooluse_1", Name: "get_weather", Args: `{"city":"Paris"}`}},
+			},
+			{Role: ir.RoleTool, Content: []ir.ContentPart{{Type: ir.ContentTypeToolResult, ToolResult: &ir.ToolResultPart{ToolCallID: "tooluse_1", Result: "sunny"}}}},
+			{Role: ir.RoleUser, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: "Thanks"}}},
+		},
+		Tools:      []ir.ToolDefinition{{Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+		ToolChoice: "required",
+		MaxTokens:  ir.Ptr(2048),
+		Thinking:   &ir.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: ir.Ptr(int32(1024))},
+	}
+
+	out, err := ToBedrockConverseRequest(req)
+	if err != nil {
+		t.Fatalf("ToBedrockConverseRequest: %v", err)
+	}
+	root := gjson.ParseBytes(out)
+
+	if root.Get("system.0.text").String() != "Be brief." {
+		t.Errorf("system = %s", root.Get("system").Raw)
+	}
+	if n := len(root.Get("messages").Array()); n != 3 {
+		t.Fatalf("got %d messages, want the tool result merged with the next user turn: %s", n, root.Get("messages").Raw)
+	}
+	user := root.Get("messages.0.content")
+	if user.Get("1.image.format").String() != "jpeg" || user.Get("2.document.format").String() != "pdf" || user.Get("2.document.name").String() != "Q3 report (final)" {
+		t.Errorf("user content = %s", user.Raw)
+	}
+	assistant := root.Get("messages.1.content")
+	if assistant.Get("0.reasoningContent.reasoningText.signature").String() != "sig" || assistant.Get("1.toolUse.input.city").String() != "Paris" {
+		t.Errorf("assistant content = %s", assistant.Raw)
+	}
+	last := root.Get("messages.2")
+	if last.Get("role").String() != "user" || last.Get("content.0.toolResult.toolUseId").String() != "tooluse_1" || last.Get("content.1.text").String() != "Thanks" {
+		t.Errorf("last message = %s", last.Raw)
+	}
+	if !root.Get("toolConfig.toolChoice.any").Exists() || root.Get("toolConfig.tools.0.toolSpec.name").String() != "get_weather" {
+		t.Errorf("toolConfig = %s", root.Get("toolConfig").Raw)
+	}
+	if root.Get("inferenceConfig.maxTokens").Int() != 2048 || root.Get("additionalModelRequestFields.thinking.budget_tokens").Int() != 1024 {
+		t.Errorf("inferenceConfig = %s, additionalModelRequestFields = %s", root.Get("inferenceConfig").Raw, root.Get("additionalModelRequestFields").Raw)
+	}
+}
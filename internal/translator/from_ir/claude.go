@@ -170,7 +170,8 @@ func (p *ClaudeProvider) ConvertRequest(req *ir.UnifiedChatRequest) ([]byte, err
 	// Auto-inject cache_control on the second-to-last message (conversation prefix cache breakpoint).
 	// This enables Anthropic to cache the conversation history up to the latest exchange,
 	// so only the newest user message is uncached on each request.
-	if len(msgs) >= 2 {
+	// When the prompt-cache policy is active it places turn breakpoints instead.
+	if len(msgs) >= 2 && !req.DeferCacheBreakpoints {
 		target := msgs[len(msgs)-2]
 		if msgMap, ok := target.(map[string]any); ok {
 			if _, hasCC := msgMap["cache_control"]; !hasCC {
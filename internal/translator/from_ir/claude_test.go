@@ -116,3 +116,34 @@ func TestClaudeProvider_NormalMessagesKeepCacheControl(t *testing.T) {
 		t.Error("assistant message without thinking should have cache_control")
 	}
 }
+
+func TestClaudeProvider_ConversationCacheBreakpoint(t *testing.T) {
+	newReq := func() *ir.UnifiedChatRequest {
+		return &ir.UnifiedChatRequest{
+			Model: "claude-sonnet-4-20250514",
+			Messages: []ir.Message{
+				{Role: ir.RoleUser, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: "Hello"}}},
+				{Role: ir.RoleAssistant, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: "Hi"}}},
+				{Role: ir.RoleUser, Content: []ir.ContentPart{{Type: ir.ContentTypeText, Text: "Follow up"}}},
+			},
+		}
+	}
+
+	payload, err := (&ClaudeProvider{}).ConvertRequest(newReq())
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+	if !gjson.GetBytes(payload, "messages.1.cache_control").Exists() {
+		t.Errorf("second-to-last message should get the default breakpoint: %s", payload)
+	}
+
+	req := newReq()
+	req.DeferCacheBreakpoints = true
+	payload, err = (&ClaudeProvider{}).ConvertRequest(req)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+	if gjson.GetBytes(payload, "messages.1.cache_control").Exists() {
+		t.Errorf("breakpoint should be left to the prompt-cache policy: %s", payload)
+	}
+}
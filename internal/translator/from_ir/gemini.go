@@ -2,6 +2,8 @@ package from_ir
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -80,8 +82,19 @@ func (p *GeminiProvider) applyGenerationConfig(root map[string]any, req *ir.Unif
 	if len(req.ResponseModality) > 0 {
 		gc["responseModalities"] = req.ResponseModality
 	}
+	if req.AudioConfig != nil && req.AudioConfig.Voice != "" && slices.ContainsFunc(req.ResponseModality, func(m string) bool {
+		return strings.EqualFold(m, ir.ResponseModalityAudio)
+	}) {
+		gc["speechConfig"] = map[string]any{
+			"voiceConfig": map[string]any{"prebuiltVoiceConfig": map[string]any{"voiceName": req.AudioConfig.Voice}},
+		}
+	}
 	if req.ImageConfig != nil && req.ImageConfig.AspectRatio != "" && req.Model != "gemini-2.5-flash-image-preview" {
-		gc["imageConfig"] = map[string]any{"aspectRatio": req.ImageConfig.AspectRatio, "imageSize": req.ImageConfig.ImageSize}
+		ic := map[string]any{"aspectRatio": req.ImageConfig.AspectRatio}
+		if req.ImageConfig.ImageSize != "" {
+			ic["imageSize"] = req.ImageConfig.ImageSize
+		}
+		gc["imageConfig"] = ic
 	}
 	if req.ResponseSchema != nil {
 		gc["responseMimeType"] = "application/json"
@@ -105,11 +105,20 @@ func ToOllamaRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
 
 func convertToOllamaChatRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
 	m := map[string]any{"model": req.Model, "messages": []any{}, "stream": req.Metadata["stream"] == true, "options": buildOllamaOptions(req)}
+	// Ollama matches tool results to calls by function name.
+	toolNames := make(map[string]string)
 	for _, msg := range req.Messages {
+		for _, tc := range msg.ToolCalls {
+			toolNames[tc.ID] = tc.Name
+		}
 		if msg.Role == ir.RoleTool {
 			for _, p := range msg.Content {
 				if p.Type == ir.ContentTypeToolResult && p.ToolResult != nil {
-					m["messages"] = append(m["messages"].([]any), map[string]any{"role": "tool", "tool_call_id": p.ToolResult.ToolCallID, "content": p.ToolResult.Result})
+					tm := map[string]any{"role": "tool", "tool_call_id": p.ToolResult.ToolCallID, "content": p.ToolResult.Result}
+					if name := toolNames[p.ToolResult.ToolCallID]; name != "" {
+						tm["tool_name"] = name
+					}
+					m["messages"] = append(m["messages"].([]any), tm)
 				}
 			}
 			continue
@@ -134,12 +143,36 @@ func convertToOllamaChatRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
 	} else if fmt, ok := req.Metadata["ollama_format"].(string); ok && fmt != "" {
 		m["format"] = fmt
 	}
-	if ka, ok := req.Metadata["ollama_keep_alive"].(string); ok && ka != "" {
+	if ka := req.Metadata["ollama_keep_alive"]; ka != nil && ka != "" {
 		m["keep_alive"] = ka
 	}
+	if think := ollamaThink(req); think != nil {
+		m["think"] = think
+	}
 	return json.Marshal(m)
 }
 
+// ollamaThink returns the think value for a chat request: the client's own
+// value for Ollama clients, otherwise one derived from the thinking config
+// (an effort level for models such as gpt-oss, a bool for the rest).
+func ollamaThink(req *ir.UnifiedChatRequest) any {
+	if v, ok := req.Metadata["ollama_think"]; ok {
+		return v
+	}
+	tc := req.Thinking
+	if tc == nil {
+		return nil
+	}
+	switch tc.Effort {
+	case ir.ReasoningEffortLow, ir.ReasoningEffortMedium, ir.ReasoningEffortHigh:
+		return string(tc.Effort)
+	}
+	if tc.ThinkingBudget != nil && *tc.ThinkingBudget == 0 && !tc.IncludeThoughts {
+		return false
+	}
+	return true
+}
+
 func convertToOllamaGenerateRequest(req *ir.UnifiedChatRequest) ([]byte, error) {
 	m := map[string]any{"model": req.Model, "prompt": "", "stream": req.Metadata["stream"] == true, "options": buildOllamaOptions(req)}
 	var sp, up string
@@ -198,7 +231,10 @@ func buildOllamaOptions(req *ir.UnifiedChatRequest) map[string]any {
 		if v, ok := req.Metadata["ollama_seed"].(int64); ok {
 			o["seed"] = v
 		}
-		if v, ok := req.Metadata["ollama_num_ctx"].(int64); ok {
+		switch v := req.Metadata["ollama_num_ctx"].(type) {
+		case int64:
+			o["num_ctx"] = v
+		case int:
 			o["num_ctx"] = v
 		}
 	}
@@ -257,7 +293,12 @@ func buildOllamaAssistantMessage(m ir.Message) map[string]any {
 	if len(m.ToolCalls) > 0 {
 		tcs := make([]any, len(m.ToolCalls))
 		for i, tc := range m.ToolCalls {
-			tcs[i] = map[string]any{"id": tc.ID, "type": "function", "function": map[string]any{"name": tc.Name, "arguments": tc.Args}}
+			// Ollama expects the arguments as an object, not a JSON string.
+			var args map[string]any
+			if json.Unmarshal([]byte(tc.Args), &args) != nil || args == nil {
+				args = map[string]any{}
+			}
+			tcs[i] = map[string]any{"id": tc.ID, "type": "function", "function": map[string]any{"name": tc.Name, "arguments": args}}
 		}
 		res["tool_calls"] = tcs
 	}
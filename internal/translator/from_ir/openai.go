@@ -121,6 +121,18 @@ func convertToChatCompletionsRequest(req *ir.UnifiedChatRequest) ([]byte, error)
 	if len(req.ResponseModality) > 0 {
 		m["modalities"] = req.ResponseModality
 	}
+	if req.ImageConfig != nil {
+		ic := map[string]any{}
+		if req.ImageConfig.AspectRatio != "" {
+			ic["aspect_ratio"] = req.ImageConfig.AspectRatio
+		}
+		if req.ImageConfig.ImageSize != "" {
+			ic["image_size"] = req.ImageConfig.ImageSize
+		}
+		if len(ic) > 0 {
+			m["image_config"] = ic
+		}
+	}
 	if req.AudioConfig != nil {
 		ac := map[string]any{}
 		if req.AudioConfig.Voice != "" {
@@ -410,7 +422,13 @@ func ToOpenAIChatCompletionCandidates(cs []ir.CandidateResult, us *ir.Usage, mod
 		if tcs != nil {
 			mc["tool_calls"] = tcs
 		}
+		if imgs := buildOpenAIImages(*m); imgs != nil {
+			mc["images"] = imgs
+		}
 		co := map[string]any{"index": c.Index, "finish_reason": ir.MapFinishReasonToOpenAI(c.FinishReason), "message": mc}
+		if c.ContentFilter != nil {
+			co["content_filter_results"] = c.ContentFilter.ToAzureMap()
+		}
 		if c.Logprobs != nil {
 			co["logprobs"] = c.Logprobs
 		}
@@ -462,6 +480,9 @@ func ToOpenAIChatCompletionMeta(ms []ir.Message, us *ir.Usage, model, mid string
 		if tcs != nil {
 			mc["tool_calls"] = tcs
 		}
+		if imgs := buildOpenAIImages(*m); imgs != nil {
+			mc["images"] = imgs
+		}
 		if ap := findAudioContent(*m); ap != nil {
 			ao := map[string]any{}
 			if ap.ID != "" {
@@ -650,7 +671,9 @@ func ToOpenAIChunkMeta(ev ir.UnifiedEvent, model, mid string, ci int, meta *ir.O
 		if ev.Logprobs != nil {
 			c["logprobs"] = ev.Logprobs
 		}
-		if ev.ContentFilter != nil {
+		if cf, ok := ev.ContentFilter.(*ir.ContentFilterResult); ok {
+			c["content_filter_results"] = cf.ToAzureMap()
+		} else if ev.ContentFilter != nil {
 			c["content_filter_results"] = ev.ContentFilter
 		}
 		if ev.Usage != nil {
@@ -1014,3 +1037,16 @@ func findAudioContent(m ir.Message) *ir.AudioPart {
 	}
 	return nil
 }
+
+// buildOpenAIImages returns generated images in the same shape as streaming
+// image deltas, or nil when the message has none.
+func buildOpenAIImages(m ir.Message) []any {
+	var imgs []any
+	for _, p := range m.Content {
+		if p.Type != ir.ContentTypeImage || p.Image == nil || p.Image.Data == "" {
+			continue
+		}
+		imgs = append(imgs, map[string]any{"type": "image_url", "image_url": map[string]string{"url": fmt.Sprintf("data:%s;base64,%s", p.Image.MimeType, p.Image.Data)}})
+	}
+	return imgs
+}
@@ -60,9 +60,13 @@ func BuildAudioPart(audio *ir.AudioPart) map[string]any {
 		}
 	}
 	if audio.Data != "" {
+		mimeType := audio.MimeType
+		if mimeType == "" {
+			mimeType = AudioMimeType(audio.Format)
+		}
 		return map[string]any{
 			"inlineData": map[string]any{
-				"mimeType": audio.MimeType,
+				"mimeType": mimeType,
 				"data":     audio.Data,
 			},
 		}
@@ -70,6 +74,23 @@ func BuildAudioPart(audio *ir.AudioPart) map[string]any {
 	return nil
 }
 
+// AudioMimeType maps an OpenAI input_audio format or file extension to the
+// MIME type Gemini expects.
+func AudioMimeType(format string) string {
+	switch f := strings.ToLower(strings.TrimPrefix(format, ".")); f {
+	case "mp3", "mpga", "mpeg":
+		return "audio/mp3"
+	case "m4a", "mp4":
+		return "audio/mp4"
+	case "pcm":
+		return "audio/pcm"
+	case "":
+		return "audio/wav"
+	default:
+		return "audio/" + f
+	}
+}
+
 // BuildVideoPart creates a video content part from IR.
 func BuildVideoPart(video *ir.VideoPart) map[string]any {
 	if video == nil {
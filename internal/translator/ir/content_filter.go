@@ -1,5 +1,11 @@
 package ir
 
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
 // ContentFilterResult contains content safety filtering results.
 // This replaces the `any` type for type safety.
 type ContentFilterResult struct {
@@ -123,3 +129,117 @@ func (c *ContentFilterResult) IsBlocked() bool {
 	}
 	return false
 }
+
+// azureFilterCategories maps Azure OpenAI content filter keys to the
+// category constants; other keys (jailbreak, protected_material_*, profanity)
+// are kept as is.
+var azureFilterCategories = map[string]string{
+	"hate":      FilterCategoryHateSpeech,
+	"self_harm": FilterCategorySelfHarm,
+	"sexual":    FilterCategorySexual,
+	"violence":  FilterCategoryViolence,
+}
+
+// ParseAzureContentFilter converts the content_filter_results object Azure
+// OpenAI attaches to choices and prompts, e.g.
+// {"hate":{"filtered":false,"severity":"safe"},"jailbreak":{"filtered":true,"detected":true}}.
+// Detection-only filters report "detected" as their severity.
+func ParseAzureContentFilter(v gjson.Result) *ContentFilterResult {
+	if !v.IsObject() {
+		return nil
+	}
+	result := &ContentFilterResult{}
+	v.ForEach(func(key, value gjson.Result) bool {
+		if !value.IsObject() {
+			return true
+		}
+		category := key.String()
+		if mapped, ok := azureFilterCategories[category]; ok {
+			category = mapped
+		}
+		rating := &SafetyRating{
+			Category: category,
+			Blocked:  value.Get("filtered").Bool(),
+			Severity: value.Get("severity").String(),
+		}
+		if value.Get("detected").Bool() {
+			rating.Severity = "detected"
+		}
+		result.Ratings = append(result.Ratings, rating)
+		if rating.Blocked {
+			result.Filtered = true
+			if result.BlockedBy == "" {
+				result.BlockedBy = category
+			}
+		}
+		return true
+	})
+	if len(result.Ratings) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Merge folds other into c, e.g. the per-chunk results of a stream, keeping
+// the first blocking category. A nil c returns other.
+func (c *ContentFilterResult) Merge(other *ContentFilterResult) *ContentFilterResult {
+	if c == nil {
+		return other
+	}
+	if other == nil {
+		return c
+	}
+	for _, r := range other.Ratings {
+		replaced := false
+		for i, existing := range c.Ratings {
+			if existing.Category == r.Category {
+				if r.Blocked || !existing.Blocked {
+					c.Ratings[i] = r
+				}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.Ratings = append(c.Ratings, r)
+		}
+	}
+	if c.BlockedBy == "" {
+		c.BlockedBy = other.BlockedBy
+	}
+	if c.BlockReason == "" {
+		c.BlockReason = other.BlockReason
+	}
+	c.Filtered = c.Filtered || other.Filtered
+	return c
+}
+
+// ToAzureMap renders c in the content_filter_results shape of OpenAI-format
+// responses, the inverse of ParseAzureContentFilter.
+func (c *ContentFilterResult) ToAzureMap() map[string]any {
+	if c == nil {
+		return nil
+	}
+	result := make(map[string]any, len(c.Ratings))
+	for _, r := range c.Ratings {
+		if r == nil || r.Category == "" {
+			continue
+		}
+		key := r.Category
+		for azure, category := range azureFilterCategories {
+			if category == r.Category {
+				key = azure
+				break
+			}
+		}
+		entry := map[string]any{"filtered": r.Blocked}
+		switch {
+		case r.Severity == "detected":
+			entry["detected"] = true
+		case r.Severity != "":
+			entry["severity"] = strings.ToLower(r.Severity)
+		}
+		result[key] = entry
+	}
+	return result
+}
@@ -0,0 +1,656 @@
+package ir
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/json"
+	"github.com/tidwall/sjson"
+)
+
+// ToolCallRepair is the outcome of RepairToolCall.
+type ToolCallRepair struct {
+	Name    string
+	Args    string
+	Changed bool
+	// Problem describes what could not be repaired. Empty when the call is
+	// valid against the declared schema.
+	Problem string
+}
+
+// RepairToolCall validates a complete tool call against the tools of the
+// request and repairs what it can: loose or truncated JSON arguments,
+// parameter names (see NormalizeToolCallArgs), values of the wrong type and,
+// when fuzzyNames is set, misspelled tool names.
+func (ctx *ToolSchemaContext) RepairToolCall(name, args string, fuzzyNames bool) ToolCallRepair {
+	r := ToolCallRepair{Name: name, Args: args}
+	if ctx == nil {
+		return r
+	}
+
+	fixed, ok := RepairJSON(args)
+	if !ok {
+		r.Problem = "arguments are not valid JSON"
+	} else if fixed != args {
+		r.Args, r.Changed = fixed, true
+	}
+
+	if _, known := ctx.Tools[name]; !known {
+		match := ""
+		if fuzzyNames {
+			match = ctx.MatchToolName(name)
+		}
+		if match == "" {
+			if r.Problem == "" {
+				r.Problem = fmt.Sprintf("unknown tool %q", name)
+			}
+			return r
+		}
+		r.Name, r.Changed = match, true
+	}
+	if r.Problem != "" {
+		return r
+	}
+
+	if normalized := ctx.NormalizeToolCallArgs(r.Name, r.Args); normalized != r.Args {
+		r.Args, r.Changed = normalized, true
+	}
+	schema := ctx.Schemas[r.Name]
+	if schema == nil {
+		return r
+	}
+	var value any
+	if err := json.Unmarshal([]byte(r.Args), &value); err != nil {
+		r.Problem = "arguments are not valid JSON"
+		return r
+	}
+	if _, isObject := value.(map[string]any); !isObject {
+		r.Problem = "arguments must be a JSON object"
+		return r
+	}
+	var patches []argPatch
+	coerced := coerceArgs(value, schema, "", &patches)
+	if problem := checkSchema(coerced, schema, ""); problem != "" {
+		r.Problem = problem
+	}
+	// Patch only the coerced values, so the rest of the arguments keeps its
+	// key order and number precision.
+	out := r.Args
+	for _, p := range patches {
+		patched, err := sjson.Set(out, p.path, p.value)
+		if err != nil {
+			return r
+		}
+		out = patched
+	}
+	if out != r.Args {
+		r.Args, r.Changed = out, true
+	}
+	return r
+}
+
+// argPatch replaces the value at an sjson path of the tool call arguments.
+type argPatch struct {
+	path  string
+	value any
+}
+
+// coerceArgs is coerceToSchema for decoded arguments. It descends into
+// objects and arrays that already have the declared type and records a patch
+// for each value it converts, returning the coerced value for validation.
+func coerceArgs(value any, schema map[string]any, path string, patches *[]argPatch) any {
+	if value == nil || schema == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if schemaType(schema) != "object" || len(props) == 0 {
+			break
+		}
+		for key, item := range v {
+			propSchema, _ := props[key].(map[string]any)
+			v[key] = coerceArgs(item, propSchema, joinArgPath(path, escapeArgKey(key)), patches)
+		}
+		return v
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		if schemaType(schema) != "array" || items == nil {
+			break
+		}
+		for i, item := range v {
+			v[i] = coerceArgs(item, items, joinArgPath(path, strconv.Itoa(i)), patches)
+		}
+		return v
+	}
+	coerced, changed := coerceToSchema(value, schema)
+	if changed && path != "" {
+		*patches = append(*patches, argPatch{path: path, value: coerced})
+	}
+	return coerced
+}
+
+func joinArgPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// escapeArgKey escapes the characters sjson treats as path syntax.
+func escapeArgKey(key string) string {
+	var b strings.Builder
+	for _, c := range key {
+		switch c {
+		case '.', '*', '?', '|', '#', '@', '\\', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// MatchToolName returns the declared tool that name most likely refers to, or
+// "" when there is no clear match. It ignores case, separators and namespace
+// prefixes such as "functions." and then allows a small edit distance.
+func (ctx *ToolSchemaContext) MatchToolName(name string) string {
+	if ctx == nil || name == "" {
+		return ""
+	}
+	if _, ok := ctx.Tools[name]; ok {
+		return name
+	}
+	want := toolNameKey(name)
+	best, bestDist, tie := "", math.MaxInt, false
+	for _, candidate := range slices.Sorted(maps.Keys(ctx.Tools)) {
+		key := toolNameKey(candidate)
+		if key == want {
+			return candidate
+		}
+		dist := editDistance(want, key)
+		switch {
+		case dist < bestDist:
+			best, bestDist, tie = candidate, dist, false
+		case dist == bestDist:
+			tie = true
+		}
+	}
+	limit := len(want) / 4
+	if limit < 1 {
+		limit = 1
+	}
+	if best == "" || tie || bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// toolNameKey lowercases name, drops a namespace prefix and removes
+// separators, so "functions.Read_File" and "readFile" compare equal.
+func toolNameKey(name string) string {
+	if i := strings.LastIndexAny(name, ".:/"); i >= 0 && i < len(name)-1 {
+		name = name[i+1:]
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r != '_' && r != '-' && r != ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// RepairJSON turns loose or truncated JSON into valid JSON. It strips code
+// fences and leading prose, quotes bare keys and words, converts single
+// quotes and Python literals, drops trailing commas and closes unterminated
+// strings, objects and arrays. It reports false when the result is still
+// invalid; the input is then returned unchanged.
+func RepairJSON(raw string) (string, bool) {
+	s := strings.TrimSpace(raw)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], "{[") {
+			s = s[i+1:]
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+	if s == "" {
+		return "{}", true
+	}
+	if json.Valid([]byte(s)) {
+		return s, true
+	}
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return raw, false
+	}
+	s = s[start:]
+
+	var (
+		out        []byte
+		stack      []byte
+		last       byte // last structural byte written outside strings; 'v' after a value
+		keyPending bool // a key was written and its colon has not been seen
+		inString   bool
+		quote      byte
+		escaped    bool
+	)
+	atKey := func() bool {
+		return len(stack) > 0 && stack[len(stack)-1] == '{' && (last == '{' || last == ',')
+	}
+	// closeValue completes a dangling key or colon before a separator or a
+	// closing bracket.
+	closeValue := func() {
+		if keyPending {
+			out = append(out, ":null"...)
+			keyPending = false
+		} else if last == ':' {
+			out = append(out, "null"...)
+		}
+		if last == ',' {
+			out = trimTrailingComma(out)
+		}
+	}
+	closeContainer := func() {
+		closeValue()
+		if stack[len(stack)-1] == '{' {
+			out = append(out, '}')
+		} else {
+			out = append(out, ']')
+		}
+		stack = stack[:len(stack)-1]
+		last = 'v'
+	}
+
+scan:
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+				if c == '\'' {
+					out[len(out)-1] = '\''
+				} else {
+					out = append(out, c)
+				}
+			case c == '\\':
+				escaped = true
+				out = append(out, c)
+			case c == quote:
+				inString = false
+				out = append(out, '"')
+				last = 'v'
+			case c == '"':
+				out = append(out, '\\', '"')
+			case c == '\n':
+				out = append(out, '\\', 'n')
+			case c == '\r':
+				out = append(out, '\\', 'r')
+			case c == '\t':
+				out = append(out, '\\', 't')
+			default:
+				out = append(out, c)
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			if atKey() {
+				keyPending = true
+			}
+			inString, quote = true, c
+			out = append(out, '"')
+		case '{', '[':
+			stack = append(stack, c)
+			out = append(out, c)
+			last = c
+		case '}', ']':
+			if len(stack) == 0 {
+				break scan
+			}
+			closeContainer()
+			if len(stack) == 0 {
+				break scan
+			}
+		case ':':
+			keyPending = false
+			out = append(out, c)
+			last = ':'
+		case ',':
+			if last == ',' || last == '{' || last == '[' {
+				continue
+			}
+			closeValue()
+			out = append(out, c)
+			last = ','
+		case ' ', '\t', '\n', '\r':
+			out = append(out, c)
+		default:
+			if !isBareWordByte(c) {
+				continue
+			}
+			j := i
+			for j < len(s) && isBareWordByte(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			key := atKey()
+			if j == len(s) && !key {
+				// Truncated literal at the end of the input.
+				for _, lit := range []string{"true", "false", "null"} {
+					if strings.HasPrefix(lit, word) {
+						word = lit
+					}
+				}
+			}
+			i = j - 1
+			switch {
+			case key:
+				out = strconv.AppendQuote(out, word)
+				keyPending = true
+			case isNumberStart(word[0]):
+				for word != "" && !json.Valid([]byte(word)) {
+					word = word[:len(word)-1]
+				}
+				if word == "" {
+					word = "null"
+				}
+				out = append(out, word...)
+			default:
+				switch word {
+				case "true", "True":
+					out = append(out, "true"...)
+				case "false", "False":
+					out = append(out, "false"...)
+				case "null", "None", "undefined", "NaN", "Infinity":
+					out = append(out, "null"...)
+				default:
+					out = strconv.AppendQuote(out, word)
+				}
+			}
+			last = 'v'
+		}
+	}
+	if inString {
+		if escaped {
+			out = out[:len(out)-1]
+		}
+		out = append(out, '"')
+		last = 'v'
+	}
+	for len(stack) > 0 {
+		closeContainer()
+	}
+	if !json.Valid(out) {
+		return raw, false
+	}
+	return string(out), true
+}
+
+// trimTrailingComma removes a comma at the end of b, ignoring whitespace
+// after it.
+func trimTrailingComma(b []byte) []byte {
+	i := len(b) - 1
+	for i >= 0 && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i--
+	}
+	if i >= 0 && b[i] == ',' {
+		return b[:i]
+	}
+	return b
+}
+
+func isBareWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '-' || c == '+' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+func isNumberStart(c byte) bool {
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
+
+// schemaType returns the first non-null type of a JSON schema.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return strings.ToLower(t)
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return strings.ToLower(s)
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// coerceToSchema converts value to the types declared by schema where the
+// conversion is lossless, e.g. "3" to 3 for an integer or a scalar to a
+// one-element array.
+func coerceToSchema(value any, schema map[string]any) (any, bool) {
+	if value == nil || schema == nil {
+		return value, false
+	}
+	changed := false
+	switch schemaType(schema) {
+	case "string":
+		switch v := value.(type) {
+		case float64:
+			value, changed = strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			value, changed = strconv.FormatBool(v), true
+		case map[string]any, []any:
+			if out, err := json.Marshal(v); err == nil {
+				value, changed = string(out), true
+			}
+		}
+	case "integer":
+		switch v := value.(type) {
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				value, changed = n, true
+			} else if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f == math.Trunc(f) {
+				value, changed = int64(f), true
+			}
+		case bool:
+			value, changed = boolToInt(v), true
+		}
+	case "number":
+		switch v := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				value, changed = f, true
+			}
+		case bool:
+			value, changed = float64(boolToInt(v)), true
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes", "1":
+				value, changed = true, true
+			case "false", "no", "0":
+				value, changed = false, true
+			}
+		case float64:
+			if v == 0 || v == 1 {
+				value, changed = v == 1, true
+			}
+		}
+	case "array":
+		if s, ok := value.(string); ok {
+			var parsed []any
+			if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &parsed); err == nil {
+				value, changed = parsed, true
+			}
+		}
+		if _, ok := value.([]any); !ok {
+			value, changed = []any{value}, true
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			arr := value.([]any)
+			for i, item := range arr {
+				if v, c := coerceToSchema(item, items); c {
+					if !changed {
+						arr = slices.Clone(arr)
+					}
+					arr[i], changed = v, true
+				}
+			}
+			value = arr
+		}
+	case "object":
+		if s, ok := value.(string); ok {
+			var parsed map[string]any
+			if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &parsed); err == nil {
+				value, changed = parsed, true
+			}
+		}
+		obj, ok := value.(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		if !ok || len(props) == 0 {
+			break
+		}
+		for key, v := range obj {
+			propSchema, _ := props[key].(map[string]any)
+			if nv, c := coerceToSchema(v, propSchema); c {
+				obj[key], changed = nv, true
+			}
+		}
+	}
+	if s, ok := value.(string); ok {
+		if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, any(s)) {
+			for _, e := range enum {
+				if es, ok := e.(string); ok && strings.EqualFold(es, s) {
+					value, changed = es, true
+					break
+				}
+			}
+		}
+	}
+	return value, changed
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// checkSchema reports the first remaining violation of schema by value:
+// a missing required property, a value of the wrong type or a value outside
+// an enum. path prefixes property names in the message.
+func checkSchema(value any, schema map[string]any, path string) string {
+	if schema == nil {
+		return ""
+	}
+	name := path
+	if name == "" {
+		name = "arguments"
+	}
+	if value == nil {
+		return ""
+	}
+	switch schemaType(schema) {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Sprintf("%s must be a string", name)
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int64:
+		case float64:
+			if v != math.Trunc(v) {
+				return fmt.Sprintf("%s must be an integer", name)
+			}
+		default:
+			return fmt.Sprintf("%s must be an integer", name)
+		}
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+		default:
+			return fmt.Sprintf("%s must be a number", name)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("%s must be a boolean", name)
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Sprintf("%s must be an array", name)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range arr {
+				if problem := checkSchema(item, items, fmt.Sprintf("%s[%d]", name, i)); problem != "" {
+					return problem
+				}
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Sprintf("%s must be an object", name)
+		}
+		required, _ := schema["required"].([]any)
+		for _, r := range required {
+			if key, ok := r.(string); ok {
+				if v, present := obj[key]; !present || v == nil {
+					return fmt.Sprintf("missing required argument %q", joinPath(path, key))
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			propSchema, _ := props[key].(map[string]any)
+			if problem := checkSchema(obj[key], propSchema, joinPath(path, key)); problem != "" {
+				return problem
+			}
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		if s, isString := value.(string); isString && !slices.Contains(enum, any(s)) {
+			return fmt.Sprintf("%s must be one of %v", name, enum)
+		}
+	}
+	return ""
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,106 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid", `{"a":1}`, `{"a":1}`},
+		{"empty", ``, `{}`},
+		{"code fence", "```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"leading prose", `Here you go: {"a":1}`, `{"a":1}`},
+		{"truncated string", `{"path":"/tmp/fo`, `{"path":"/tmp/fo"}`},
+		{"truncated nested", `{"a":[1,2,{"b":tr`, `{"a":[1,2,{"b":true}]}`},
+		{"dangling key", `{"a":1,"b"`, `{"a":1,"b":null}`},
+		{"dangling colon", `{"a":`, `{"a":null}`},
+		{"trailing comma", `{"a":1,}`, `{"a":1}`},
+		{"single quotes", `{'a':'it\'s'}`, `{"a":"it's"}`},
+		{"bare keys", `{a: 1, b: True, c: None}`, `{"a": 1, "b": true, "c": null}`},
+		{"raw newline", "{\"a\":\"x\ny\"}", `{"a":"x\ny"}`},
+		{"truncated number", `{"a":1.`, `{"a":1}`},
+		{"trailing text", `{"a":1} thanks`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RepairJSON(tt.in)
+			if !ok {
+				t.Fatalf("RepairJSON(%q) failed", tt.in)
+			}
+			if got != tt.want {
+				t.Errorf("RepairJSON(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := RepairJSON("no json here"); ok {
+		t.Error("expected failure without an object or array")
+	}
+}
+
+func TestRepairToolCall(t *testing.T) {
+	ctx := NewToolSchemaContextFromGJSON(gjson.Parse(`[{"type":"function","function":{"name":"read_file","parameters":{
+		"type":"object",
+		"properties":{
+			"path":{"type":"string"},
+			"limit":{"type":"integer"},
+			"recursive":{"type":"boolean"},
+			"globs":{"type":"array","items":{"type":"string"}},
+			"mode":{"type":"string","enum":["text","binary"]}
+		},
+		"required":["path"]}}}]`).Array())
+
+	r := ctx.RepairToolCall("functions.readFile", `{"path":"a.go","limit":"20","recursive":"yes","globs":"*.go","mode":"TEXT"`, true)
+	if r.Problem != "" {
+		t.Fatalf("unexpected problem: %s", r.Problem)
+	}
+	if !r.Changed || r.Name != "read_file" {
+		t.Fatalf("name = %q, changed = %v", r.Name, r.Changed)
+	}
+	args := gjson.Parse(r.Args)
+	if args.Get("limit").Raw != "20" || args.Get("recursive").Raw != "true" {
+		t.Errorf("args = %s", r.Args)
+	}
+	if args.Get("globs").Raw != `["*.go"]` || args.Get("mode").String() != "text" {
+		t.Errorf("args = %s", r.Args)
+	}
+
+	if r := ctx.RepairToolCall("read_file", `{"limit":5}`, true); r.Problem == "" {
+		t.Error("expected missing required argument")
+	}
+	if r := ctx.RepairToolCall("read_fiel", `{"path":"a"}`, false); r.Problem == "" {
+		t.Error("expected unknown tool without fuzzy matching")
+	}
+	if r := ctx.RepairToolCall("read_fiel", `{"path":"a"}`, true); r.Name != "read_file" || r.Problem != "" {
+		t.Errorf("fuzzy match = %+v", r)
+	}
+	if r := ctx.RepairToolCall("delete_everything", `{}`, true); r.Problem == "" {
+		t.Error("expected unknown tool")
+	}
+	if r := ctx.RepairToolCall("read_file", `{"path":"a.go"}`, true); r.Changed || r.Problem != "" {
+		t.Errorf("valid call changed: %+v", r)
+	}
+}
+
+func TestRepairToolCall_PatchesInPlace(t *testing.T) {
+	ctx := NewToolSchemaContextFromGJSON(gjson.Parse(`[{"type":"function","function":{"name":"seek","parameters":{
+		"type":"object",
+		"properties":{
+			"offset":{"type":"integer"},
+			"opts":{"type":"object","properties":{"limit":{"type":"integer"},"a.b":{"type":"boolean"}}}
+		}}}}]`).Array())
+
+	r := ctx.RepairToolCall("seek", `{"zeta":1,"offset":12345678901234567890,"opts":{"limit":"5","a.b":"true"},"alpha":2}`, false)
+	if r.Problem != "" {
+		t.Fatalf("unexpected problem: %s", r.Problem)
+	}
+	if want := `{"zeta":1,"offset":12345678901234567890,"opts":{"limit":5,"a.b":true},"alpha":2}`; r.Args != want {
+		t.Errorf("args = %s, want %s", r.Args, want)
+	}
+}
@@ -20,8 +20,10 @@
 //   - Recursive: handles nested objects and arrays at any depth
 //
 // # Current Usage
-// Currently enabled only for the Antigravity provider, which exhibits this
-// parameter naming issue when proxying through Gemini CLI.
+// Always enabled for the Antigravity provider, which exhibits this parameter
+// naming issue when proxying through Gemini CLI. Other providers opt in through
+// the tool-repair config, which runs RepairToolCall (tool_repair.go): JSON
+// repair, fuzzy tool names and type coercion on top of this normalization.
 // # Potential Applications
 // This mechanism can be enabled for any provider to achieve:
 //  1. Client Compatibility: Different clients (Cursor, Copilot, Cline) may use
@@ -60,6 +62,8 @@ import (
 type ToolSchemaContext struct {
 	// Tools maps ToolName -> ParameterName -> ParameterType ("string", "array", "object", "boolean", "number", "integer")
 	Tools map[string]map[string]string
+	// Schemas maps ToolName -> full parameters JSON schema, used by RepairToolCall.
+	Schemas map[string]map[string]any
 }
 
 // NewToolSchemaContextFromGJSON creates a context from gjson tools array (fast, no full unmarshal).
@@ -68,12 +72,14 @@ type ToolSchemaContext struct {
 // - OpenAI format: tools[].type="function", tools[].function.name, tools[].function.parameters.properties
 // - Gemini format: tools[].functionDeclarations[].name, tools[].functionDeclarations[].parametersJsonSchema.properties
 // - Direct Gemini: tools[].name, tools[].parametersJsonSchema.properties
+// - Claude and Responses API: tools[].name, tools[].input_schema or tools[].parameters
 func NewToolSchemaContextFromGJSON(toolsJSON []gjson.Result) *ToolSchemaContext {
 	if len(toolsJSON) == 0 {
 		return nil
 	}
 	ctx := &ToolSchemaContext{
-		Tools: make(map[string]map[string]string),
+		Tools:   make(map[string]map[string]string),
+		Schemas: make(map[string]map[string]any),
 	}
 
 	for _, t := range toolsJSON {
@@ -91,6 +97,9 @@ func NewToolSchemaContextFromGJSON(toolsJSON []gjson.Result) *ToolSchemaContext
 				if !t.Get(propsPath).Exists() {
 					propsPath = "parameters.properties"
 				}
+				if !t.Get(propsPath).Exists() && t.Get("input_schema").Exists() {
+					propsPath = "input_schema.properties"
+				}
 			}
 		}
 
@@ -106,6 +115,9 @@ func NewToolSchemaContextFromGJSON(toolsJSON []gjson.Result) *ToolSchemaContext
 				return true
 			})
 			ctx.Tools[name] = params
+			if schema, ok := t.Get(strings.TrimSuffix(propsPath, ".properties")).Value().(map[string]any); ok {
+				ctx.Schemas[name] = schema
+			}
 		}
 
 		// Also check for Gemini nested format: tools[].functionDeclarations[]
@@ -131,6 +143,9 @@ func NewToolSchemaContextFromGJSON(toolsJSON []gjson.Result) *ToolSchemaContext
 					return true
 				})
 				ctx.Tools[fdName] = params
+				if schema, ok := fd.Get(strings.TrimSuffix(fdPropsPath, ".properties")).Value().(map[string]any); ok {
+					ctx.Schemas[fdName] = schema
+				}
 			}
 		}
 	}
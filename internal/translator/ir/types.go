@@ -229,12 +229,13 @@ type PromptFeedback struct {
 // CandidateResult holds the result of a single candidate/choice from the model.
 // Used when candidateCount/n > 1 to return multiple alternatives.
 type CandidateResult struct {
-	Index             int                // Candidate index (0-based)
-	Messages          []Message          // Messages from this candidate
-	FinishReason      FinishReason       // Why this candidate stopped
-	Logprobs          any                // Log probabilities for this candidate (OpenAI format)
-	GroundingMetadata *GroundingMetadata // Google Search grounding metadata for this candidate
-	SafetyRatings     []*SafetyRating    // Safety evaluation results
+	Index             int                  // Candidate index (0-based)
+	Messages          []Message            // Messages from this candidate
+	FinishReason      FinishReason         // Why this candidate stopped
+	Logprobs          any                  // Log probabilities for this candidate (OpenAI format)
+	GroundingMetadata *GroundingMetadata   // Google Search grounding metadata for this candidate
+	SafetyRatings     []*SafetyRating      // Safety evaluation results
+	ContentFilter     *ContentFilterResult // Content filter annotations (Azure OpenAI)
 }
 
 // ToolCall represents a request from the model to execute a tool.
@@ -509,6 +510,10 @@ type UnifiedChatRequest struct {
 	Metadata         map[string]any  // Additional provider-specific metadata
 	ServiceTier      ServiceTier
 
+	// DeferCacheBreakpoints leaves conversation cache breakpoints to the
+	// prompt-cache policy applied by the Claude executor.
+	DeferCacheBreakpoints bool
+
 	// Responses API specific fields
 	Instructions         string // System instructions (Responses API)
 	PreviousResponseID   string
@@ -60,11 +60,11 @@ func (ollamaParser) Parse(payload []byte) (*ir.UnifiedChatRequest, error) {
 }
 
 func (ollamaParser) ParseResponse(payload []byte) ([]ir.Message, *ir.Usage, error) {
-	return nil, nil, nil
+	return ParseOllamaResponse(payload)
 }
 
 func (ollamaParser) ParseChunk(payload []byte) ([]ir.UnifiedEvent, error) {
-	return nil, nil
+	return ParseOllamaChunk(payload, nil)
 }
 
 func (ollamaParser) Format() string { return "ollama" }
@@ -0,0 +1,168 @@
+package to_ir
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
+)
+
+// BedrockStreamState tracks tool use blocks across ConverseStream events:
+// a block's name and ID arrive in contentBlockStart and its input in
+// contentBlockDelta fragments.
+type BedrockStreamState struct {
+	ToolCalls  int
+	StopReason string
+	blocks     map[int64]*bedrockToolBlock
+}
+
+type bedrockToolBlock struct {
+	id, name string
+	input    strings.Builder
+}
+
+// ParseBedrockResponse parses a Converse API response.
+func ParseBedrockResponse(rawJSON []byte) ([]ir.Message, *ir.Usage, error) {
+	root, err := ir.ParseAndValidateJSON(rawJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e := root.Get("message").String(); e != "" && !root.Get("output").Exists() {
+		return nil, nil, errors.New(e)
+	}
+	usage := parseBedrockUsage(root.Get("usage"))
+	msg := ir.Message{Role: ir.RoleAssistant}
+	for _, block := range root.Get("output.message.content").Array() {
+		switch {
+		case block.Get("text").Exists():
+			if v := block.Get("text").String(); v != "" {
+				msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeText, Text: v})
+			}
+		case block.Get("reasoningContent").Exists():
+			rc := block.Get("reasoningContent")
+			if v := rc.Get("redactedContent").String(); v != "" {
+				msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeRedactedThinking, RedactedData: v})
+				continue
+			}
+			part := ir.ContentPart{Type: ir.ContentTypeReasoning, Reasoning: rc.Get("reasoningText.text").String()}
+			if sig := rc.Get("reasoningText.signature").String(); sig != "" {
+				part.ThoughtSignature = []byte(sig)
+			}
+			msg.Content = append(msg.Content, part)
+		case block.Get("toolUse").Exists():
+			tu := block.Get("toolUse")
+			call := ir.ToolCall{ID: tu.Get("toolUseId").String(), Name: tu.Get("name").String(), Args: "{}"}
+			if input := tu.Get("input"); input.IsObject() {
+				call.Args = input.Raw
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+	}
+	if len(msg.Content) == 0 && len(msg.ToolCalls) == 0 {
+		return nil, usage, nil
+	}
+	return []ir.Message{msg}, usage, nil
+}
+
+// ParseBedrockStreamEvent parses the JSON payload of one ConverseStream
+// event; eventType is the frame's :event-type header. Tool calls are emitted
+// whole when their block stops, and the finish event is emitted with the
+// metadata event that carries usage.
+func ParseBedrockStreamEvent(eventType string, payload []byte, state *BedrockStreamState) ([]ir.UnifiedEvent, error) {
+	if state == nil {
+		state = &BedrockStreamState{}
+	}
+	root := gjson.ParseBytes(payload)
+	switch eventType {
+	case "contentBlockStart":
+		if tu := root.Get("start.toolUse"); tu.Exists() {
+			if state.blocks == nil {
+				state.blocks = make(map[int64]*bedrockToolBlock)
+			}
+			state.blocks[root.Get("contentBlockIndex").Int()] = &bedrockToolBlock{id: tu.Get("toolUseId").String(), name: tu.Get("name").String()}
+		}
+	case "contentBlockDelta":
+		delta := root.Get("delta")
+		switch {
+		case delta.Get("text").Exists():
+			if v := delta.Get("text").String(); v != "" {
+				return []ir.UnifiedEvent{{Type: ir.EventTypeToken, Content: v}}, nil
+			}
+		case delta.Get("reasoningContent").Exists():
+			rc := delta.Get("reasoningContent")
+			switch {
+			case rc.Get("text").Exists():
+				return []ir.UnifiedEvent{{Type: ir.EventTypeReasoning, Reasoning: rc.Get("text").String()}}, nil
+			case rc.Get("signature").Exists():
+				return []ir.UnifiedEvent{{Type: ir.EventTypeReasoning, ThoughtSignature: []byte(rc.Get("signature").String())}}, nil
+			case rc.Get("redactedContent").Exists():
+				return []ir.UnifiedEvent{{Type: ir.EventTypeReasoning, RedactedData: rc.Get("redactedContent").String()}}, nil
+			}
+		case delta.Get("toolUse").Exists():
+			if b := state.blocks[root.Get("contentBlockIndex").Int()]; b != nil {
+				b.input.WriteString(delta.Get("toolUse.input").String())
+			}
+		}
+	case "contentBlockStop":
+		idx := root.Get("contentBlockIndex").Int()
+		b := state.blocks[idx]
+		if b == nil {
+			return nil, nil
+		}
+		delete(state.blocks, idx)
+		call := ir.ToolCall{ID: b.id, Name: b.name, Args: b.input.String()}
+		if call.ID == "" {
+			call.ID = ir.GenToolCallID()
+		}
+		if strings.TrimSpace(call.Args) == "" {
+			call.Args = "{}"
+		}
+		ev := ir.UnifiedEvent{Type: ir.EventTypeToolCall, ToolCall: &call, ToolCallIndex: state.ToolCalls}
+		state.ToolCalls++
+		return []ir.UnifiedEvent{ev}, nil
+	case "messageStop":
+		state.StopReason = root.Get("stopReason").String()
+	case "metadata":
+		return []ir.UnifiedEvent{{
+			Type:         ir.EventTypeFinish,
+			FinishReason: BedrockFinishReason(state.StopReason),
+			Usage:        parseBedrockUsage(root.Get("usage")),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// BedrockFinishReason maps a Converse stopReason to IR.
+func BedrockFinishReason(stopReason string) ir.FinishReason {
+	switch stopReason {
+	case "tool_use":
+		return ir.FinishReasonToolCalls
+	case "max_tokens", "model_context_window_exceeded":
+		return ir.FinishReasonMaxTokens
+	case "stop_sequence":
+		return ir.FinishReasonStopSequence
+	case "guardrail_intervened", "content_filtered":
+		return ir.FinishReasonContentFilter
+	default:
+		return ir.FinishReasonStop
+	}
+}
+
+func parseBedrockUsage(usage gjson.Result) *ir.Usage {
+	if !usage.Exists() {
+		return nil
+	}
+	input, output := usage.Get("inputTokens").Int(), usage.Get("outputTokens").Int()
+	u := &ir.Usage{
+		PromptTokens:             input,
+		CompletionTokens:         output,
+		TotalTokens:              input + output,
+		CacheReadInputTokens:     usage.Get("cacheReadInputTokens").Int(),
+		CacheCreationInputTokens: usage.Get("cacheWriteInputTokens").Int(),
+	}
+	if u.CacheReadInputTokens > 0 {
+		u.PromptTokensDetails = &ir.PromptTokensDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return u
+}
@@ -51,6 +51,9 @@ func ParseGeminiRequest(rawJSON []byte) (*ir.UnifiedChatRequest, error) {
 		for _, m := range gc.Get("responseModalities").Array() {
 			req.ResponseModality = append(req.ResponseModality, m.String())
 		}
+		if v := gc.Get("speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName"); v.Exists() {
+			req.AudioConfig = &ir.AudioConfig{Voice: v.String()}
+		}
 
 		rs := gc.Get("responseJsonSchema")
 		if !rs.Exists() {
@@ -405,6 +408,8 @@ func parseGeminiCandidate(candidate gjson.Result, schemaCtx *ir.ToolSchemaContex
 				},
 				ThoughtSignature: ts,
 			})
+		} else if audio := parseGeminiInlineAudio(part); audio != nil {
+			msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeAudio, Audio: audio, ThoughtSignature: ts})
 		} else if img := parseGeminiInlineImage(part); img != nil {
 			msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeImage, Image: img, ThoughtSignature: ts})
 		} else if len(ts) > 0 {
@@ -876,6 +881,23 @@ func convertGeminiLogprobsToOpenAI(lr gjson.Result) map[string]any {
 	return map[string]any{"content": content}
 }
 
+// parseGeminiInlineAudio returns generated audio (e.g. TTS output, typically
+// "audio/L16;codec=pcm;rate=24000"), or nil when the part is not audio.
+func parseGeminiInlineAudio(part gjson.Result) *ir.AudioPart {
+	data := part.Get("inlineData")
+	if !data.Exists() {
+		data = part.Get("inline_data")
+	}
+	mimeType := data.Get("mimeType").String()
+	if mimeType == "" {
+		mimeType = data.Get("mime_type").String()
+	}
+	if !strings.HasPrefix(mimeType, "audio/") {
+		return nil
+	}
+	return &ir.AudioPart{MimeType: mimeType, Data: data.Get("data").String()}
+}
+
 func parseGeminiInlineImage(part gjson.Result) *ir.ImagePart {
 	data := part.Get("inlineData")
 	if !data.Exists() {
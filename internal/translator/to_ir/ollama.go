@@ -1,10 +1,13 @@
 package to_ir
 
 import (
+	"bytes"
+	"errors"
 	"strings"
 
 	"github.com/nghyane/llm-mux/internal/json"
 	"github.com/nghyane/llm-mux/internal/translator/ir"
+	"github.com/tidwall/gjson"
 )
 
 func ParseOllamaRequest(rawJSON []byte) (*ir.UnifiedChatRequest, error) {
@@ -100,7 +103,7 @@ func ParseOllamaRequest(rawJSON []byte) (*ir.UnifiedChatRequest, error) {
 		}
 	}
 
-	for _, k := range []string{"format", "keep_alive"} {
+	for _, k := range []string{"format", "keep_alive", "think"} {
 		if v := root.Get(k); v.Exists() {
 			req.Metadata["ollama_"+k] = v.Value()
 		}
@@ -129,3 +132,107 @@ func parseOllamaImage(data string) *ir.ImagePart {
 	}
 	return &ir.ImagePart{MimeType: mime, Data: p[1]}
 }
+
+// OllamaStreamState carries tool call numbering across /api/chat stream chunks.
+type OllamaStreamState struct {
+	ToolCalls int
+}
+
+// ParseOllamaResponse parses a non-streaming /api/chat response.
+func ParseOllamaResponse(rawJSON []byte) ([]ir.Message, *ir.Usage, error) {
+	root, err := ir.ParseAndValidateJSON(rawJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e := root.Get("error").String(); e != "" {
+		return nil, nil, errors.New(e)
+	}
+	usage := parseOllamaUsage(root)
+	m := root.Get("message")
+	msg := ir.Message{Role: ir.RoleAssistant}
+	if v := m.Get("thinking").String(); v != "" {
+		msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeReasoning, Reasoning: v})
+	}
+	if v := m.Get("content").String(); v != "" {
+		msg.Content = append(msg.Content, ir.ContentPart{Type: ir.ContentTypeText, Text: v})
+	}
+	for _, tc := range m.Get("tool_calls").Array() {
+		msg.ToolCalls = append(msg.ToolCalls, parseOllamaToolCall(tc))
+	}
+	if len(msg.Content) == 0 && len(msg.ToolCalls) == 0 {
+		return nil, usage, nil
+	}
+	return []ir.Message{msg}, usage, nil
+}
+
+// ParseOllamaChunk parses one NDJSON line of a streaming /api/chat response.
+// Ollama sends complete tool calls in a single chunk and the token counts in
+// the final chunk, which has done set.
+func ParseOllamaChunk(rawJSON []byte, state *OllamaStreamState) ([]ir.UnifiedEvent, error) {
+	raw := bytes.TrimSpace(rawJSON)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if state == nil {
+		state = &OllamaStreamState{}
+	}
+	root := gjson.ParseBytes(raw)
+	if e := root.Get("error").String(); e != "" {
+		return nil, errors.New(e)
+	}
+	var evs []ir.UnifiedEvent
+	m := root.Get("message")
+	if v := m.Get("thinking").String(); v != "" {
+		evs = append(evs, ir.UnifiedEvent{Type: ir.EventTypeReasoning, Reasoning: v})
+	}
+	if v := m.Get("content").String(); v != "" {
+		evs = append(evs, ir.UnifiedEvent{Type: ir.EventTypeToken, Content: v})
+	}
+	for _, tc := range m.Get("tool_calls").Array() {
+		call := parseOllamaToolCall(tc)
+		evs = append(evs, ir.UnifiedEvent{Type: ir.EventTypeToolCall, ToolCall: &call, ToolCallIndex: state.ToolCalls})
+		state.ToolCalls++
+	}
+	if root.Get("done").Bool() {
+		evs = append(evs, ir.UnifiedEvent{
+			Type:         ir.EventTypeFinish,
+			FinishReason: mapOllamaDoneReason(root.Get("done_reason").String(), state.ToolCalls > 0),
+			Usage:        parseOllamaUsage(root),
+		})
+	}
+	return evs, nil
+}
+
+func parseOllamaToolCall(tc gjson.Result) ir.ToolCall {
+	call := ir.ToolCall{ID: tc.Get("id").String(), Name: tc.Get("function.name").String(), Args: "{}"}
+	if call.ID == "" {
+		call.ID = ir.GenToolCallID()
+	}
+	// Native Ollama sends arguments as an object; some servers send a JSON string.
+	switch args := tc.Get("function.arguments"); {
+	case args.IsObject():
+		call.Args = args.Raw
+	case args.Type == gjson.String && args.Str != "":
+		call.Args = args.Str
+	}
+	return call
+}
+
+func parseOllamaUsage(root gjson.Result) *ir.Usage {
+	prompt, completion := root.Get("prompt_eval_count").Int(), root.Get("eval_count").Int()
+	if prompt == 0 && completion == 0 {
+		return nil
+	}
+	return &ir.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+func mapOllamaDoneReason(reason string, toolCalls bool) ir.FinishReason {
+	switch {
+	case reason == "length":
+		return ir.FinishReasonMaxTokens
+	case toolCalls:
+		return ir.FinishReasonToolCalls
+	default:
+		return ir.FinishReasonStop
+	}
+}
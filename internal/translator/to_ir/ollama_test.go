@@ -0,0 +1,79 @@
+package to_ir
+
+import (
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/translator/ir"
+)
+
+// ==================== ParseOllamaChunk Tests ====================
+
+func TestParseOllamaChunk_Stream(t *testing.T) {
+	state := &OllamaStreamState{}
+	lines := []string{
+		`{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"Let me check."},"done":false}`,
+		`{"model":"qwen3","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":false}`,
+		``,
+		`{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":30}`,
+	}
+
+	var events []ir.UnifiedEvent
+	for _, line := range lines {
+		evs, err := ParseOllamaChunk([]byte(line), state)
+		if err != nil {
+			t.Fatalf("ParseOllamaChunk(%q) failed: %v", line, err)
+		}
+		events = append(events, evs...)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Type != ir.EventTypeReasoning || events[0].Reasoning != "Let me check." {
+		t.Errorf("event 0 = %+v, want reasoning", events[0])
+	}
+	tc := events[1].ToolCall
+	if events[1].Type != ir.EventTypeToolCall || tc == nil || tc.Name != "get_weather" || tc.Args != `{"city":"Paris"}` || tc.ID == "" {
+		t.Errorf("event 1 = %+v, want tool call with object arguments", events[1])
+	}
+	finish := events[2]
+	if finish.Type != ir.EventTypeFinish || finish.FinishReason != ir.FinishReasonToolCalls {
+		t.Errorf("finish = %+v, want tool_calls finish", finish)
+	}
+	if finish.Usage == nil || finish.Usage.PromptTokens != 12 || finish.Usage.CompletionTokens != 30 || finish.Usage.TotalTokens != 42 {
+		t.Errorf("usage = %+v", finish.Usage)
+	}
+}
+
+func TestParseOllamaChunk_Error(t *testing.T) {
+	if _, err := ParseOllamaChunk([]byte(`{"error":"model \"x\" not found"}`), nil); err == nil {
+		t.Fatal("expected the stream error to be returned")
+	}
+}
+
+// ==================== ParseOllamaResponse Tests ====================
+
+func TestParseOllamaResponse(t *testing.T) {
+	input := `{
+		"model": "llama3.2",
+		"message": {"role": "assistant", "content": "Hi there", "thinking": "greet"},
+		"done": true,
+		"done_reason": "length",
+		"prompt_eval_count": 5,
+		"eval_count": 7
+	}`
+
+	msgs, usage, err := ParseOllamaResponse([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseOllamaResponse failed: %v", err)
+	}
+	if len(msgs) != 1 || len(msgs[0].Content) != 2 {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	if msgs[0].Content[0].Reasoning != "greet" || msgs[0].Content[1].Text != "Hi there" {
+		t.Errorf("content = %+v", msgs[0].Content)
+	}
+	if usage == nil || usage.TotalTokens != 12 {
+		t.Errorf("usage = %+v, want 12 total tokens", usage)
+	}
+}
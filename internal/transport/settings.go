@@ -0,0 +1,262 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Settings customizes outbound transports: extra trusted CAs, a client
+// certificate for mTLS, timeouts and HTTP/2. Zero fields fall back to Config.
+type Settings struct {
+	// CACert is a PEM bundle trusted in addition to the system roots.
+	CACert string
+	// ClientCert and ClientKey are PEM files presented for mTLS.
+	ClientCert string
+	ClientKey  string
+
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// HTTP2 enables or disables HTTP/2. Nil keeps the default (enabled).
+	HTTP2 *bool
+}
+
+// Auth attribute keys carrying per-auth settings.
+const (
+	AttrCACert                = "transport_ca_cert"
+	AttrClientCert            = "transport_client_cert"
+	AttrClientKey             = "transport_client_key"
+	AttrDialTimeout           = "transport_dial_timeout"
+	AttrTLSHandshakeTimeout   = "transport_tls_handshake_timeout"
+	AttrResponseHeaderTimeout = "transport_response_header_timeout"
+	AttrHTTP2                 = "transport_http2"
+)
+
+type defaultSettings struct {
+	global    Settings
+	providers map[string]Settings
+}
+
+var defaults atomic.Pointer[defaultSettings]
+
+// SetDefaults replaces the global settings and the per-provider overrides,
+// keyed by provider name. Callers should retire cached transports afterwards.
+func SetDefaults(global Settings, providers map[string]Settings) {
+	d := &defaultSettings{global: global, providers: make(map[string]Settings, len(providers))}
+	for name, s := range providers {
+		d.providers[strings.ToLower(strings.TrimSpace(name))] = s
+	}
+	defaults.Store(d)
+}
+
+// Defaults returns the global settings.
+func Defaults() Settings {
+	if d := defaults.Load(); d != nil {
+		return d.global
+	}
+	return Settings{}
+}
+
+// For returns the settings of an auth: the global settings, then the
+// provider's overrides, then the auth's own attributes and the transport
+// object of its metadata (auth files).
+func For(provider string, attrs map[string]string, metadata map[string]any) Settings {
+	s := Defaults()
+	if d := defaults.Load(); d != nil {
+		if p, ok := d.providers[strings.ToLower(provider)]; ok {
+			s = s.Merge(p)
+		}
+	}
+	return s.Merge(FromAttributes(attrs)).Merge(FromMetadata(metadata))
+}
+
+// IsZero reports whether s changes nothing.
+func (s Settings) IsZero() bool {
+	return s == Settings{}
+}
+
+// Merge returns s with the non-zero fields of o applied on top.
+func (s Settings) Merge(o Settings) Settings {
+	if o.CACert != "" {
+		s.CACert = o.CACert
+	}
+	if o.ClientCert != "" {
+		s.ClientCert = o.ClientCert
+		s.ClientKey = o.ClientKey
+	}
+	if o.DialTimeout > 0 {
+		s.DialTimeout = o.DialTimeout
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		s.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	if o.ResponseHeaderTimeout > 0 {
+		s.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+	if o.HTTP2 != nil {
+		s.HTTP2 = o.HTTP2
+	}
+	return s
+}
+
+// Key identifies s for transport caches.
+func (s Settings) Key() string {
+	if s.IsZero() {
+		return ""
+	}
+	h2 := "-"
+	if s.HTTP2 != nil {
+		h2 = strconv.FormatBool(*s.HTTP2)
+	}
+	return fmt.Sprintf("%s|%s|%s|%d|%d|%d|%s", s.CACert, s.ClientCert, s.ClientKey,
+		s.DialTimeout, s.TLSHandshakeTimeout, s.ResponseHeaderTimeout, h2)
+}
+
+// Attributes encodes s as auth attributes, the inverse of FromAttributes.
+func (s Settings) Attributes() map[string]string {
+	attrs := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			attrs[key] = value
+		}
+	}
+	set(AttrCACert, s.CACert)
+	set(AttrClientCert, s.ClientCert)
+	set(AttrClientKey, s.ClientKey)
+	if s.DialTimeout > 0 {
+		attrs[AttrDialTimeout] = s.DialTimeout.String()
+	}
+	if s.TLSHandshakeTimeout > 0 {
+		attrs[AttrTLSHandshakeTimeout] = s.TLSHandshakeTimeout.String()
+	}
+	if s.ResponseHeaderTimeout > 0 {
+		attrs[AttrResponseHeaderTimeout] = s.ResponseHeaderTimeout.String()
+	}
+	if s.HTTP2 != nil {
+		attrs[AttrHTTP2] = strconv.FormatBool(*s.HTTP2)
+	}
+	return attrs
+}
+
+// FromAttributes reads per-auth settings from auth attributes.
+func FromAttributes(attrs map[string]string) Settings {
+	if len(attrs) == 0 {
+		return Settings{}
+	}
+	dur := func(key string) time.Duration {
+		d, err := time.ParseDuration(strings.TrimSpace(attrs[key]))
+		if err != nil || d < 0 {
+			return 0
+		}
+		return d
+	}
+	s := Settings{
+		CACert:                strings.TrimSpace(attrs[AttrCACert]),
+		ClientCert:            strings.TrimSpace(attrs[AttrClientCert]),
+		ClientKey:             strings.TrimSpace(attrs[AttrClientKey]),
+		DialTimeout:           dur(AttrDialTimeout),
+		TLSHandshakeTimeout:   dur(AttrTLSHandshakeTimeout),
+		ResponseHeaderTimeout: dur(AttrResponseHeaderTimeout),
+	}
+	if v, err := strconv.ParseBool(strings.TrimSpace(attrs[AttrHTTP2])); err == nil {
+		s.HTTP2 = &v
+	}
+	return s
+}
+
+// FromMetadata reads the transport object of auth metadata, which uses the
+// keys of the transport config section (ca-cert, client-cert, ...).
+func FromMetadata(metadata map[string]any) Settings {
+	raw, ok := metadata["transport"].(map[string]any)
+	if !ok || len(raw) == 0 {
+		return Settings{}
+	}
+	attrs := make(map[string]string, len(raw))
+	for key, attr := range map[string]string{
+		"ca-cert":                 AttrCACert,
+		"client-cert":             AttrClientCert,
+		"client-key":              AttrClientKey,
+		"dial-timeout":            AttrDialTimeout,
+		"tls-handshake-timeout":   AttrTLSHandshakeTimeout,
+		"response-header-timeout": AttrResponseHeaderTimeout,
+	} {
+		if v, ok := raw[key].(string); ok {
+			attrs[attr] = v
+		}
+	}
+	if v, ok := raw["http2"].(bool); ok {
+		attrs[AttrHTTP2] = strconv.FormatBool(v)
+	}
+	return FromAttributes(attrs)
+}
+
+// HTTP2Enabled reports whether transports built from s use HTTP/2.
+func (s Settings) HTTP2Enabled() bool {
+	return s.HTTP2 == nil || *s.HTTP2
+}
+
+// DialTimeoutOrDefault returns the dial timeout, falling back to Config.
+func (s Settings) DialTimeoutOrDefault() time.Duration {
+	if s.DialTimeout > 0 {
+		return s.DialTimeout
+	}
+	return Config.DialTimeout
+}
+
+// Apply sets the TLS material and timeouts of s on t. It must run before t
+// is configured for HTTP/2 and before first use.
+func (s Settings) Apply(t *http.Transport) error {
+	if s.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = s.TLSHandshakeTimeout
+	}
+	if s.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = s.ResponseHeaderTimeout
+	}
+	if !s.HTTP2Enabled() {
+		t.ForceAttemptHTTP2 = false
+		// A non-nil empty map turns off the automatic HTTP/2 upgrade.
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if s.CACert == "" && s.ClientCert == "" {
+		return nil
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if s.CACert != "" {
+		pem, err := os.ReadFile(s.CACert)
+		if err != nil {
+			return fmt.Errorf("read ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("ca-cert %s: no PEM certificates found", s.CACert)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if s.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(s.ClientCert, s.ClientKey)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
+// Check loads the CA bundle and client certificate of s and reports the
+// first error.
+func (s Settings) Check() error {
+	return s.Apply(&http.Transport{})
+}
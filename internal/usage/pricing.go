@@ -50,6 +50,22 @@ func CalculateCostUSD(model string, inputTokens, outputTokens, cachedTokens int6
 	return inputCost + outputCost + cachedCost
 }
 
+// cacheWritePremium is the surcharge on input price for writing a 5-minute cache entry.
+const cacheWritePremium = 0.25
+
+// CalculateCacheSavingsUSD estimates the net savings from prompt caching: cache reads
+// billed at the cached rate instead of the input rate, minus the premium paid for
+// cache writes. Models without cached pricing report zero.
+func CalculateCacheSavingsUSD(model string, cacheCreationTokens, cacheReadTokens int64) float64 {
+	pricing, ok := GetModelPricing(model)
+	if !ok || pricing.CachedPer1M == 0 {
+		return 0
+	}
+	readSavings := float64(cacheReadTokens) * (pricing.InputPer1M - pricing.CachedPer1M) / 1_000_000
+	writeCost := float64(cacheCreationTokens) * pricing.InputPer1M * cacheWritePremium / 1_000_000
+	return readSavings - writeCost
+}
+
 func matchPricingByPrefix(model string) ModelPricing {
 	prefixMap := map[string]ModelPricing{
 		"claude-3-5-haiku":  {InputPer1M: 0.80, OutputPer1M: 4.00, CachedPer1M: 0.08},
@@ -9,12 +9,23 @@ import (
 	"net/url"
 
 	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/proxypool"
 	"golang.org/x/net/proxy"
 )
 
+// SetProxy routes httpClient through the configured proxy. A pool reference
+// that cannot be resolved makes every request fail rather than bypass the
+// egress proxy.
 func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	var transport *http.Transport
-	proxyURL, errParse := url.Parse(cfg.ProxyURL)
+	raw, ok := proxypool.Default().ResolveURL(cfg.ProxyURL, "")
+	if !ok {
+		pool, _ := proxypool.PoolName(cfg.ProxyURL)
+		slog.Error(fmt.Sprintf("proxy pool %q is not configured or empty", pool))
+		httpClient.Transport = proxypool.UnavailableTransport(pool)
+		return httpClient
+	}
+	proxyURL, errParse := url.Parse(raw)
 	if errParse == nil {
 		switch proxyURL.Scheme {
 		case "socks5":
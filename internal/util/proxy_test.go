@@ -0,0 +1,31 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/proxypool"
+)
+
+func TestSetProxy_UnknownPoolFailsClosed(t *testing.T) {
+	var reached bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer srv.Close()
+
+	client := SetProxy(&config.SDKConfig{ProxyURL: "pool:missing"}, &http.Client{})
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected the request to fail")
+	}
+	if !proxypool.IsProxyError(err) {
+		t.Fatalf("expected a proxy error, got %v", err)
+	}
+	if reached {
+		t.Fatal("request bypassed the proxy")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/nghyane/llm-mux/internal/json"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -81,12 +82,26 @@ func (w *Watcher) SnapshotCoreAuths() []*provider.Auth {
 			case config.ProviderTypeVertexCompat:
 				pName = "vertex"
 				lbl = "vertex-apikey"
+			case config.ProviderTypeOllama:
+				pName = "ollama"
+				lbl = prov.GetDisplayName()
+			case config.ProviderTypeAzureOpenAI:
+				pName = "azure-openai"
+				lbl = prov.GetDisplayName()
+			case config.ProviderTypeBedrock:
+				pName = "bedrock"
+				lbl = prov.GetDisplayName()
 			default:
 				continue
 			}
-			for _, apiKey := range prov.GetAPIKeys() {
+			keys := prov.GetAPIKeys()
+			if len(keys) == 0 && prov.AllowsKeyless() {
+				// No key (local server, Entra ID, AWS credentials): one auth per configured provider.
+				keys = []config.ProviderAPIKey{{ProxyURL: prov.ProxyURL}}
+			}
+			for _, apiKey := range keys {
 				key := strings.TrimSpace(apiKey.Key)
-				if key == "" {
+				if key == "" && !prov.AllowsKeyless() {
 					continue
 				}
 				proxy := strings.TrimSpace(apiKey.ProxyURL)
@@ -94,6 +109,13 @@ func (w *Watcher) SnapshotCoreAuths() []*provider.Auth {
 					proxy = strings.TrimSpace(prov.ProxyURL)
 				}
 				auth := createProviderAuth(idGen, pName, lbl, key, strings.TrimSpace(prov.BaseURL), proxy, prov.Headers, prov.Models, prov.ExcludedModels, cfg, now)
+				if prov.MaxInFlight > 0 {
+					auth.Attributes["max_in_flight"] = strconv.Itoa(prov.MaxInFlight)
+				}
+				settings := prov.Transport.Settings().Merge(apiKey.Transport.Settings())
+				for k, v := range settings.Attributes() {
+					auth.Attributes[k] = v
+				}
 				out = append(out, auth)
 			}
 		}
@@ -223,6 +245,9 @@ func synthesizeGeminiVirtualAuths(primary *provider.Auth, metadata map[string]an
 		if proxy != "" {
 			metadataCopy["proxy_url"] = proxy
 		}
+		if t, ok := metadata["transport"]; ok {
+			metadataCopy["transport"] = t
+		}
 		virtual := &provider.Auth{
 			ID:         buildGeminiVirtualID(primary.ID, projectID),
 			Provider:   originalProvider,
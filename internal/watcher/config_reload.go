@@ -5,13 +5,16 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/nghyane/llm-mux/internal/config"
+	"github.com/nghyane/llm-mux/internal/events"
 	log "github.com/nghyane/llm-mux/internal/logging"
+	"github.com/nghyane/llm-mux/internal/registry"
 	"github.com/nghyane/llm-mux/internal/util"
 )
 
@@ -43,8 +46,9 @@ func (w *Watcher) doReloadConfigIfChanged() {
 		log.Debugf("ignoring empty config file write event")
 		return
 	}
-	sum := sha256.Sum256(data)
-	newHash := hex.EncodeToString(sum[:])
+	// Pick up include: files and drop-ins added by this change.
+	w.watchConfigSet()
+	newHash := w.configSetHash(data)
 
 	w.clientsMutex.RLock()
 	currentHash := w.lastConfigHash
@@ -58,8 +62,7 @@ func (w *Watcher) doReloadConfigIfChanged() {
 	if w.reloadConfig() {
 		finalHash := newHash
 		if updatedData, errRead := os.ReadFile(w.configPath); errRead == nil && len(updatedData) > 0 {
-			sumUpdated := sha256.Sum256(updatedData)
-			finalHash = hex.EncodeToString(sumUpdated[:])
+			finalHash = w.configSetHash(updatedData)
 		} else if errRead != nil {
 			log.WithError(errRead).Debug("failed to compute updated config hash after reload")
 		}
@@ -75,9 +78,19 @@ func (w *Watcher) reloadConfig() bool {
 	log.Debug("=========================== CONFIG RELOAD ============================")
 	log.Debugf("starting config reload from: %s", w.configPath)
 
-	newConfig, errLoadConfig := config.LoadConfig(w.configPath)
-	if errLoadConfig != nil {
-		log.Errorf("failed to reload config: %v", errLoadConfig)
+	data, errRead := os.ReadFile(w.configPath)
+	if errRead != nil {
+		log.Errorf("failed to reload config: %v", errRead)
+		return false
+	}
+	newConfig, issues := config.ValidateConfigFile(w.configPath, data, config.ValidateOptions{KnownModels: registry.KnownModelIDs()})
+	for _, issue := range issues {
+		if issue.Severity == config.IssueWarning {
+			log.Warnf("config: %s", issue)
+		}
+	}
+	if errValidate := config.ValidationErr(issues); errValidate != nil {
+		w.rejectConfig(errValidate)
 		return false
 	}
 
@@ -131,6 +144,88 @@ func (w *Watcher) reloadConfig() bool {
 	return true
 }
 
+// configSetHash hashes the main config content together with every file it
+// includes, so that editing a drop-in alone triggers a reload.
+func (w *Watcher) configSetHash(mainData []byte) string {
+	h := sha256.New()
+	set, err := config.ReadConfigSet(w.configPath, mainData)
+	if err != nil {
+		// Unreadable includes still count as a change; validation reports them.
+		h.Write(mainData)
+		h.Write([]byte(err.Error()))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	for _, f := range set.Fragments {
+		h.Write([]byte(f.Path))
+		h.Write([]byte{0})
+		h.Write(f.Data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// watchConfigSet adds the include: files of the config and the directories
+// that can contribute new ones (config.d and glob includes) to the watcher.
+func (w *Watcher) watchConfigSet() {
+	set, err := config.ReadConfigSet(w.configPath, nil)
+	if err != nil {
+		log.Debugf("config includes not resolved: %v", err)
+		return
+	}
+	w.clientsMutex.Lock()
+	defer w.clientsMutex.Unlock()
+	if w.configFiles == nil {
+		w.configFiles = make(map[string]bool)
+		w.configDirs = make(map[string]bool)
+	}
+	for _, path := range set.Files()[1:] {
+		if w.configFiles[path] {
+			continue
+		}
+		if errAdd := w.watcher.Add(path); errAdd != nil {
+			log.Warnf("failed to watch included config file %s: %v", path, errAdd)
+			continue
+		}
+		w.configFiles[path] = true
+		log.Debugf("watching included config file: %s", path)
+	}
+	for _, dir := range set.WatchDirs {
+		if w.configDirs[dir] {
+			continue
+		}
+		if errAdd := w.watcher.Add(dir); errAdd != nil {
+			log.Warnf("failed to watch config directory %s: %v", dir, errAdd)
+			continue
+		}
+		w.configDirs[dir] = true
+		log.Debugf("watching config directory: %s", dir)
+	}
+}
+
+// isConfigFragment reports whether path is an included config file or a
+// YAML file in a watched config directory.
+func (w *Watcher) isConfigFragment(path string) bool {
+	w.clientsMutex.RLock()
+	defer w.clientsMutex.RUnlock()
+	if w.configFiles[path] {
+		return true
+	}
+	ext := filepath.Ext(path)
+	return w.configDirs[filepath.Dir(path)] && (ext == ".yaml" || ext == ".yml")
+}
+
+// rejectConfig reports a config file that failed validation. The last good
+// config stays in effect until the file is fixed.
+func (w *Watcher) rejectConfig(err error) {
+	log.Errorf("config file %s rejected, keeping the last good config: %v", w.configPath, err)
+	events.Default().Emit(events.Event{
+		Type:     events.ConfigRejected,
+		Severity: events.SeverityWarning,
+		Message:  err.Error(),
+		Details:  map[string]any{"path": w.configPath},
+	})
+}
+
 // stopConfigReloadTimer stops any pending config reload timer
 func (w *Watcher) stopConfigReloadTimer() {
 	w.configReloadMu.Lock()
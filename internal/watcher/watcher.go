@@ -56,6 +56,8 @@ type Watcher struct {
 	watcher           *fsnotify.Watcher
 	lastAuthHashes    map[string]string
 	lastConfigHash    string
+	configFiles       map[string]bool // include: files watched besides configPath
+	configDirs        map[string]bool
 	authQueue         chan<- AuthUpdate
 	currentAuths      map[string]*provider.Auth
 	runtimeAuths      map[string]*provider.Auth
@@ -172,6 +174,7 @@ func (w *Watcher) Start(ctx context.Context) error {
 			return errAddConfig
 		}
 		log.Debugf("watching config file: %s", w.configPath)
+		w.watchConfigSet()
 	} else {
 		log.Infof("config file %s not found, running with defaults (use --init to create)", w.configPath)
 	}
@@ -263,6 +266,9 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	// Filter only relevant events: config file or auth-dir JSON files.
 	configOps := fsnotify.Write | fsnotify.Create | fsnotify.Rename
 	isConfigEvent := event.Name == w.configPath && event.Op&configOps != 0
+	if !isConfigEvent && event.Op&(configOps|fsnotify.Remove) != 0 {
+		isConfigEvent = w.isConfigFragment(event.Name)
+	}
 	authOps := fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename
 	isAuthJSON := strings.HasPrefix(event.Name, w.authDir) && strings.HasSuffix(event.Name, ".json") && event.Op&authOps != 0
 	if !isConfigEvent && !isAuthJSON {
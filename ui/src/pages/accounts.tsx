@@ -7,6 +7,7 @@ import { Dialog, DialogContent, DialogHeader, DialogTitle } from '@/components/u
 import { Progress } from '@/components/ui/progress'
 import { managementApi, type AuthFile } from '@/lib/api'
 import { toast } from '@/components/ui/toast'
+import { useAuthStore } from '@/stores/auth'
 import {
   Trash2,
   RefreshCw,
@@ -208,11 +209,12 @@ export function AccountsPage() {
     setIsTesting(true)
 
     try {
-      const response = await fetch('/v1/messages?skip-auth=true', {
+      const response = await fetch('/v1/management/test/messages', {
         method: 'POST',
-        headers: { 
+        headers: {
           'Content-Type': 'application/json',
           'anthropic-version': '2023-06-01',
+          'X-Management-Key': useAuthStore.getState().managementKey ?? '',
         },
         body: JSON.stringify({
           model,